@@ -0,0 +1,251 @@
+package evm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// What a contract says it answers to, in the words every other tool speaks.
+//
+// A wallet, an explorer or a library calls a contract by the first four bytes of the hash of a
+// canonical signature — the name and the types of what it takes, `add(uint256,uint256)` — and
+// reads what comes back through a JSON description of the same thing. Dispatching on the hash
+// of the bare name was a convention only Aurora's own command knew, so nothing else could
+// reach a contract it built.
+//
+// The language writes no types and no parameter list, so both are read off the scope: it takes
+// as many positions as it feeds, and every position is a word, which is a uint256. What it
+// answers is one word too, or one word per field when the scope promised a shape.
+
+// ABIParameter is one input or output of a function, as the ABI JSON writes it.
+type ABIParameter struct {
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	InternalType string         `json:"internalType,omitempty"`
+	Components   []ABIParameter `json:"components,omitempty"`
+}
+
+// ABIFunction is one scope a contract answers to, as the ABI JSON writes it — or its
+// constructor, which has no name and answers nothing.
+type ABIFunction struct {
+	Type            string         `json:"type"`
+	Name            string         `json:"name,omitempty"`
+	Inputs          []ABIParameter `json:"inputs"`
+	Outputs         []ABIParameter `json:"outputs,omitempty"`
+	StateMutability string         `json:"stateMutability"`
+}
+
+// Signature is the canonical signature the function is dispatched on.
+func (f ABIFunction) Signature() string {
+	return Signature(f.Name, len(f.Inputs))
+}
+
+// Selector is the four bytes a call to the function opens with.
+func (f ABIFunction) Selector() []byte {
+	return Selector(f.Signature())
+}
+
+// Signature writes the canonical signature of a scope taking a number of positions.
+func Signature(name string, positions int) string {
+	types := make([]string, positions)
+	for i := range types {
+		types[i] = "uint256"
+	}
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+// callableOnChain answers why a scope cannot be a function of the contract, or nil when it can.
+//
+// A name in Aurora may hold a dash, a question mark and more, and a function's name on chain
+// may not: the selector would still be worked out from it, but no wallet, ethers or cast will
+// write a call to get-balance(uint256), and an ABI listing it is one they refuse to read. It is
+// refused here rather than renamed, since a name the contract answers to that is not the one
+// written is a second name to know. A scope of a module is judged by the name its file gave
+// it; the module in front is the builder's.
+func callableOnChain(name string, origin ir.Origin) error {
+	_, symbol, _ := module.Split(name)
+	for at, c := range symbol {
+		letter := c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if letter || (at > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		where := ""
+		if origin.Known() {
+			where = fmt.Sprintf(" (at line %d, column %d)", origin.Line, origin.Column)
+		}
+		return fmt.Errorf("the scope %s cannot be called on chain%s: a function's name is letters, digits and underscores, and %q is not one — rename it, as %s",
+			symbol, where, string(c), strings.Map(underscored, symbol))
+	}
+	return nil
+}
+
+// underscored answers what a character that cannot be in a function's name could be instead.
+func underscored(c rune) rune {
+	if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		return c
+	}
+	return '_'
+}
+
+// Selector answers the four bytes a signature is known by: the first four of its hash, which
+// is what Solidity and everything that reads it computes.
+func Selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// ErrorSignature is what a revert with a reason is encoded as: the selector of Error(string),
+// then the text as a string is encoded in any call.
+const ErrorSignature = "Error(string)"
+
+// RevertReason encodes a reason the way Solidity's require does, so whatever shows a failed
+// call's reason shows an Aurora one too.
+func RevertReason(reason string) []byte {
+	padded := (len(reason) + 31) / 32 * 32
+	data := make([]byte, 4+32+32+padded)
+	copy(data, Selector(ErrorSignature))
+	data[4+31] = 0x20
+	binary.BigEndian.PutUint64(data[4+56:4+64], uint64(len(reason)))
+	copy(data[4+64:], reason)
+	return data
+}
+
+// ReasonOf reads the reason out of what a call reverted with, and says whether there was one.
+// A revert carries whatever the contract put there, and only Error(string) is read as text.
+func ReasonOf(data []byte) (string, bool) {
+	if len(data) < 4+64 || string(data[:4]) != string(Selector(ErrorSignature)) {
+		return "", false
+	}
+	length := binary.BigEndian.Uint64(data[4+56 : 4+64])
+	if uint64(len(data)-4-64) < length {
+		return "", false
+	}
+	return string(data[4+64 : 4+64+length]), true
+}
+
+// PositionsRead answers the highest position a body feeds, plus one.
+//
+// A nested deferred scope is stepped over: its feeds read what is applied to it, not what the
+// call applied here. It is the same answer emitter.ScopeReads gives from the tree, read from
+// the instructions because they are all the builder has.
+func PositionsRead(body []ir.Instruction) int {
+	highest := -1
+	for i := 0; i < len(body); i++ {
+		inst := body[i]
+		switch inst.GetOpCode() {
+		case ir.OpDefer:
+			i += int(byteutil.ToUint64(inst.GetRight().Bytes()))
+		case ir.OpGetFeed:
+			if nth := int(byteutil.ToUint64(inst.GetLeft().Bytes())); nth > highest {
+				highest = nth
+			}
+		}
+	}
+	return highest + 1
+}
+
+// ABI answers the functions a program's contract answers to, in the order the dispatcher
+// checks them. The promises are keyed by the name a scope is bound to in the instructions —
+// module.Qualify of a module's own name — and a scope that made none answers one word.
+//
+// Every function is a view: nothing a scope does writes to a chain, and a call that only
+// reads is what a wallet will make without asking for a signature. The one that reads
+// "callvalue" is payable instead, because a view is never sent any and it would only ever
+// read zero. The constructor is listed when the top of the program feeds, since that is what a
+// deployment has to be handed.
+//
+// Only the scopes of a module the dispatcher reaches are listed — the modules are the ones the
+// builder was handed, and none means every scope is.
+func ABI(insts []ir.Instruction, promises map[string]ast.Promise, modules []Module) []ABIFunction {
+	functions := make([]ABIFunction, 0)
+	if positions := PositionsRead(insts); positions > 0 {
+		functions = append(functions, ABIFunction{
+			Type:            "constructor",
+			Inputs:          words(positions),
+			StateMutability: "nonpayable",
+		})
+	}
+	for cursor := 0; cursor < len(insts); cursor++ {
+		name, body, end, ok := scopeAt(insts, cursor)
+		if !ok {
+			continue
+		}
+		if !dispatched(modules, cursor) {
+			cursor = end
+			continue
+		}
+		functions = append(functions, ABIFunction{
+			Type:            "function",
+			Name:            name,
+			Inputs:          words(PositionsRead(body)),
+			Outputs:         outputsOf(promises[name]),
+			StateMutability: mutabilityOf(body),
+		})
+		cursor = end
+	}
+	return functions
+}
+
+// mutabilityOf answers what a wallet is told a call of a body does to the chain. A body that
+// calls another contract may have it write, which is what "nonpayable" says; a static call
+// cannot, and reading the chain is still a view.
+func mutabilityOf(body []ir.Instruction) string {
+	mutability := "view"
+	for _, inst := range body {
+		switch inst.GetOpCode() {
+		case ir.OpCallValue:
+			return "payable"
+		case ir.OpContractCall:
+			mutability = "nonpayable"
+		}
+	}
+	return mutability
+}
+
+// words answers a uint256 for every position.
+func words(positions int) []ABIParameter {
+	inputs := make([]ABIParameter, positions)
+	for i := range inputs {
+		inputs[i] = ABIParameter{Name: "", Type: "uint256", InternalType: "uint256"}
+	}
+	return inputs
+}
+
+// outputsOf answers what a scope hands back: a tuple of one word per field when it promised a
+// shape, one word otherwise.
+func outputsOf(promise ast.Promise) []ABIParameter {
+	if promise.Shape == "" {
+		return []ABIParameter{{Name: "", Type: "uint256", InternalType: "uint256"}}
+	}
+	components := make([]ABIParameter, len(promise.Fields))
+	for i, field := range promise.Fields {
+		components[i] = ABIParameter{Name: field, Type: "uint256", InternalType: "uint256"}
+	}
+	return []ABIParameter{{
+		Name:         "",
+		Type:         "tuple",
+		InternalType: "struct " + promise.Shape,
+		Components:   components,
+	}}
+}
+
+// scopeAt reads a deferred scope bound to a name at the cursor: the name, the body as the
+// emitter wrote it, and where the binding is. The dispatcher and the ABI both read scopes
+// through it, so the two never list different ones.
+func scopeAt(insts []ir.Instruction, cursor int) (string, []ir.Instruction, int, bool) {
+	if cursor >= len(insts) || insts[cursor].GetOpCode() != ir.OpDefer {
+		return "", nil, cursor, false
+	}
+	// OpDefer layout: [OpDefer] [body of length N] [OpIdent]. Right operand = N (body length in instructions).
+	end := cursor + 1 + int(byteutil.ToUint64(insts[cursor].GetRight().Bytes()))
+	if end >= len(insts) || insts[end].GetOpCode() != ir.OpIdent {
+		return "", nil, cursor, false
+	}
+	return string(insts[end].GetLeft().Bytes()), insts[cursor+1 : end], end, true
+}
@@ -0,0 +1,229 @@
+package evm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// compile answers the instructions and the tree of a source, for what reads both.
+func compile(t *testing.T, source string) ([]ir.Instruction, ast.AST) {
+	t.Helper()
+
+	tokens, err := lexer.New().GetFilledTokens([]byte(source))
+	if err != nil {
+		t.Fatalf("lexer: %v", err)
+	}
+	tree, err := parser.New().Parse(parser.ParseInput{Filename: "main.ar", Tokens: tokens})
+	if err != nil {
+		t.Fatalf("parser: %v", err)
+	}
+	insts, err := emitter.New(emitter.NewEmitterOptions{}).Emit(tree)
+	if err != nil {
+		t.Fatalf("emitter: %v", err)
+	}
+	return insts, tree
+}
+
+// The selectors are the ones Solidity computes, which is the whole point: these two numbers
+// are what every wallet and library sends for these signatures.
+func TestSelectorIsSolidity(t *testing.T) {
+	cases := []struct {
+		signature string
+		want      string
+	}{
+		{signature: "add(uint256,uint256)", want: "771602f7"},
+		{signature: "transfer(address,uint256)", want: "a9059cbb"},
+	}
+	for _, tc := range cases {
+		if got := hex.EncodeToString(Selector(tc.signature)); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.signature, got, tc.want)
+		}
+	}
+}
+
+// A reason is encoded as Solidity's require encodes one, byte for byte, and reads back.
+func TestRevertReason(t *testing.T) {
+	// What `require(false, "nope")` reverts with in Solidity.
+	want := "08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000"
+	data := RevertReason("nope")
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if reason, ok := ReasonOf(data); !ok || reason != "nope" {
+		t.Errorf("read back %q, %v", reason, ok)
+	}
+	if _, ok := ReasonOf([]byte{0xde, 0xad}); ok {
+		t.Error("read a reason out of something that is not one")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	cases := []struct {
+		name      string
+		positions int
+		want      string
+	}{
+		{name: "total", positions: 0, want: "total()"},
+		{name: "neg", positions: 1, want: "neg(uint256)"},
+		{name: "add", positions: 2, want: "add(uint256,uint256)"},
+	}
+	for _, tc := range cases {
+		if got := Signature(tc.name, tc.positions); got != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+}
+
+// A scope takes as many positions as the highest it feeds, and a scope inside it feeds its
+// own.
+func TestABIReadsPositions(t *testing.T) {
+	insts, tree := compile(t, `ident add = defer { feed(0) + feed(1); };
+ident third = defer { feed(2); };
+ident outer = defer { ident inner = defer { feed(4); }; feed(0); };
+ident none = defer { 1; };
+`)
+
+	want := map[string]string{
+		"add":   "add(uint256,uint256)",
+		"third": "third(uint256,uint256,uint256)",
+		"outer": "outer(uint256)",
+		"none":  "none()",
+	}
+	functions := ABI(insts, promisesOf(tree), nil)
+	if len(functions) != len(want) {
+		t.Fatalf("got %d functions, want %d: %+v", len(functions), len(want), functions)
+	}
+	for _, fn := range functions {
+		if fn.Signature() != want[fn.Name] {
+			t.Errorf("%s: got %s, want %s", fn.Name, fn.Signature(), want[fn.Name])
+		}
+		if fn.Type != "function" || fn.StateMutability != "view" {
+			t.Errorf("%s: got a %s %s", fn.Name, fn.StateMutability, fn.Type)
+		}
+		if len(fn.Outputs) != 1 || fn.Outputs[0].Type != "uint256" {
+			t.Errorf("%s: outputs %+v, want one uint256", fn.Name, fn.Outputs)
+		}
+	}
+}
+
+// A scope that promised a shape answers a tuple of its fields, in the order they were declared.
+func TestABIAnswersPromisedShapes(t *testing.T) {
+	insts, tree := compile(t, `shape Square { width, height };
+ident new_square = defer { Square{feed(0), feed(1)}; } returns Square;
+`)
+
+	functions := ABI(insts, promisesOf(tree), nil)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, want 1", len(functions))
+	}
+	outputs := functions[0].Outputs
+	if len(outputs) != 1 || outputs[0].Type != "tuple" || outputs[0].InternalType != "struct Square" {
+		t.Fatalf("outputs %+v, want one tuple of Square", outputs)
+	}
+	components := outputs[0].Components
+	if len(components) != 2 || components[0].Name != "width" || components[1].Name != "height" {
+		t.Errorf("components %+v, want width and height", components)
+	}
+}
+
+// The dispatcher checks the selector of the signature, not of the bare name.
+func TestDispatcherSelectsOnTheSignature(t *testing.T) {
+	code := build(t, "ident add = defer { feed(0) + feed(1); };\n", byteutil.DefaultTapeSize)
+
+	signature := append([]byte{OpPush4}, Selector("add(uint256,uint256)")...)
+	if !bytes.Contains(code, signature) {
+		t.Errorf("the bytecode does not check for add(uint256,uint256)")
+	}
+	if bytes.Contains(code, append([]byte{OpPush4}, Selector("add")...)) {
+		t.Errorf("the bytecode still checks for the bare name")
+	}
+}
+
+// A name no wallet or ABI tool can call is refused when the contract is built, naming the scope
+// and what it could be called instead, rather than listed in an ABI nothing reads.
+func TestAScopeNamedWithADashIsRefused(t *testing.T) {
+	insts, _ := compile(t, "ident get-balance = defer { feed(0); };\n")
+
+	_, err := NewBuilder(insts, NewBuilderOptions{TapeSize: byteutil.DefaultTapeSize}).Build()
+	if err == nil {
+		t.Fatal("a scope named get-balance was built")
+	}
+	for _, want := range []string{"get-balance", "line 1", "get_balance"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func promisesOf(tree ast.AST) map[string]ast.Promise {
+	promises := make(map[string]ast.Promise, len(tree.Promises))
+	for _, promise := range tree.Promises {
+		promises[promise.Scope] = promise
+	}
+	return promises
+}
+
+// A program whose top feeds has a constructor that takes as many arguments, and it is listed
+// first, the way Solidity lists one.
+func TestABIListsTheConstructor(t *testing.T) {
+	insts, tree := compile(t, `ident rate = feed(0);
+ident limit = feed(1);
+ident scale = defer { feed(0) * rate; };
+`)
+
+	functions := ABI(insts, promisesOf(tree), nil)
+	if len(functions) != 2 {
+		t.Fatalf("got %d entries, want the constructor and scale", len(functions))
+	}
+	constructor := functions[0]
+	if constructor.Type != "constructor" || constructor.Name != "" || len(constructor.Inputs) != 2 || len(constructor.Outputs) != 0 {
+		t.Errorf("constructor = %+v, want one taking two words", constructor)
+	}
+	if functions[1].Signature() != "scale(uint256)" {
+		t.Errorf("got %s, want scale(uint256)", functions[1].Signature())
+	}
+}
+
+// A scope that reads what was sent with the call is payable: a view is never sent anything, and
+// a wallet following the ABI would only ever call it with zero.
+func TestABIListsWhatReadsTheValueAsPayable(t *testing.T) {
+	insts, tree := compile(t, `ident deposit = defer { callvalue; };
+ident who = defer { caller; };
+`)
+
+	functions := ABI(insts, promisesOf(tree), nil)
+	want := map[string]string{"deposit": "payable", "who": "view"}
+	for _, fn := range functions {
+		if fn.StateMutability != want[fn.Name] {
+			t.Errorf("%s is %s, want %s", fn.Name, fn.StateMutability, want[fn.Name])
+		}
+	}
+}
+
+// A call to another contract may write through it, so the scope that makes one is no view; a
+// static call cannot, and leaves it one.
+func TestABIListsWhatCallsAnotherContractAsNonpayable(t *testing.T) {
+	insts, tree := compile(t, `ident send = defer { call(feed(0), "take(uint256)", 1); };
+ident look = defer { staticcall(feed(0), "balance()"); };
+`)
+
+	functions := ABI(insts, promisesOf(tree), nil)
+	want := map[string]string{"send": "nonpayable", "look": "view"}
+	for _, fn := range functions {
+		if fn.StateMutability != want[fn.Name] {
+			t.Errorf("%s is %s, want %s", fn.Name, fn.StateMutability, want[fn.Name])
+		}
+	}
+}
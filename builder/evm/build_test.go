@@ -9,6 +9,7 @@ import (
 	"github.com/guiferpa/aurora/emitter"
 	"github.com/guiferpa/aurora/lexer"
 	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/ir"
 )
 
 // build compiles source all the way to EVM bytecode, which is what actually gets deployed.
@@ -35,17 +36,32 @@ func build(t *testing.T, source string, tapeSize int) []byte {
 	return bytecode
 }
 
-// Every contract opens with the instantiate block, which copies the runtime code out and
-// returns it — that is what the chain stores.
+// split builds a source and cuts the bytecode where the runtime begins: what comes before is
+// the constructor, and what comes after is what the chain keeps.
+func split(t *testing.T, source string, tapeSize int) ([]byte, []byte) {
+	t.Helper()
+
+	code := build(t, source, tapeSize)
+	insts, _ := compile(t, source)
+	rc, err := NewBuilder(insts, NewBuilderOptions{TapeSize: tapeSize}).PickRuntimeCode()
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	at := len(code) - GetRuntimeCodeLength(rc)
+	return code[:at], code[at:]
+}
+
+// A contract with nothing at the top opens with the instantiate block, which copies the
+// runtime code out and returns it — that is what the chain stores.
 func TestBuildStartsWithTheInstantiateBlock(t *testing.T) {
-	code := build(t, "ident a = 1;\n", byteutil.DefaultTapeSize)
+	code := build(t, "ident add = defer { feed(0) + feed(1); };\n", byteutil.DefaultTapeSize)
 
 	if len(code) <= INSTANTIATE_BLOCK_SIZE {
 		t.Fatalf("bytecode is %d bytes, too short to hold the instantiate block", len(code))
 	}
-	// PUSH2 <size> PUSH1 <this block> PUSH1 0x00 CODECOPY PUSH2 <size> PUSH1 0x00 RETURN
+	// PUSH2 <size> PUSH2 <this block> PUSH1 0x00 CODECOPY PUSH2 <size> PUSH1 0x00 RETURN
 	runtime := len(code) - INSTANTIATE_BLOCK_SIZE
-	want := []byte{OpPush2, byte(runtime >> 8), byte(runtime), OpPush1, INSTANTIATE_BLOCK_SIZE, OpPush1, 0x00, OpCodeCopy}
+	want := []byte{OpPush2, byte(runtime >> 8), byte(runtime), OpPush2, 0x00, INSTANTIATE_BLOCK_SIZE, OpPush1, 0x00, OpCodeCopy}
 	if !bytes.Equal(code[:len(want)], want) {
 		t.Errorf("instantiate block = %s, want %s",
 			byteutil.ToUpperHex(code[:len(want)]), byteutil.ToUpperHex(want))
@@ -69,11 +85,14 @@ func TestBuildAnnouncesTheRuntimeSize(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			code := build(t, tc.source, byteutil.DefaultTapeSize)
+			constructor, runtime := split(t, tc.source, byteutil.DefaultTapeSize)
+			block := constructor[len(constructor)-INSTANTIATE_BLOCK_SIZE:]
 			// Two bytes, big-endian, right after the PUSH2.
-			announced := int(code[1])<<8 | int(code[2])
-			if got := len(code) - INSTANTIATE_BLOCK_SIZE; announced != got {
-				t.Errorf("the block announces %d runtime bytes, %d follow", announced, got)
+			if announced := int(block[1])<<8 | int(block[2]); announced != len(runtime) {
+				t.Errorf("the block announces %d runtime bytes, %d follow", announced, len(runtime))
+			}
+			if from := int(block[4])<<8 | int(block[5]); from != len(constructor) {
+				t.Errorf("the block copies from %d, the runtime begins at %d", from, len(constructor))
 			}
 		})
 	}
@@ -118,16 +137,35 @@ ident sub = defer { feed(0) - feed(1); };
 	}
 }
 
-// Without a callable there is nothing to dispatch on, so the runtime is the root code.
+// Without a callable there is nothing to answer, so the runtime is empty: the top of the
+// program ran once, in the constructor, and that is all it does.
 func TestBuildWithoutCallables(t *testing.T) {
-	code := build(t, "ident a = 1 + 2;\n", byteutil.DefaultTapeSize)
-	runtime := code[INSTANTIATE_BLOCK_SIZE:]
+	constructor, runtime := split(t, "ident a = 1 + 2;\n", byteutil.DefaultTapeSize)
 
-	if runtime[0] == OpPush1 && len(runtime) > 2 && runtime[2] == OpCallDataLoad {
-		t.Error("no dispatcher should be emitted when there is no callable")
+	if len(runtime) != 0 {
+		t.Errorf("the runtime is %s, want nothing", byteutil.ToUpperHex(runtime))
 	}
-	if runtime[len(runtime)-1] != OpStop {
-		t.Errorf("runtime should end in STOP, got %#x", runtime[len(runtime)-1])
+	if !bytes.Contains(constructor, []byte{OpPush2, 0x00, 0x00, OpStorageStore}) {
+		t.Errorf("the constructor %s does not keep a in the first slot", byteutil.ToUpperHex(constructor))
+	}
+}
+
+// A name bound at the top is read by a scope from the storage the constructor wrote it to,
+// and a name a scope binds itself is still its own.
+func TestAScopeReadsWhatTheTopBound(t *testing.T) {
+	_, runtime := split(t, `ident rate = 3;
+ident scale = defer { feed(0) * rate; };
+ident shadow = defer { ident rate = 2; feed(0) * rate; };
+`, byteutil.DefaultTapeSize)
+
+	if !bytes.Contains(runtime, []byte{OpPush2, 0x00, 0x00, OpStorageLoad}) {
+		t.Errorf("the runtime %s never reads rate from storage", byteutil.ToUpperHex(runtime))
+	}
+	if bytes.Count(runtime, []byte{OpPush2, 0x00, 0x00, OpStorageLoad}) != 1 {
+		t.Errorf("the runtime %s reads storage more than once: shadow bound its own rate", byteutil.ToUpperHex(runtime))
+	}
+	if bytes.Contains(runtime, []byte{OpPush2, 0x00, 0x00, OpStorageStore}) {
+		t.Error("the runtime writes storage, and only the constructor binds a global")
 	}
 }
 
@@ -163,9 +201,10 @@ func TestGetRuntimeCodeLength(t *testing.T) {
 	}{
 		{name: "empty", code: &RuntimeCode{}, want: 0},
 		{
-			name: "root only",
-			code: &RuntimeCode{Root: bytes.NewBuffer([]byte{1, 2, 3})},
-			want: 3,
+			// The top of the program is the constructor's, and not a byte of it is kept.
+			name: "top of the program only",
+			code: &RuntimeCode{Init: []ir.Instruction{ir.NewInstruction([]byte("00"), ir.OpSave, ir.Const(1, 8), ir.Nothing())}},
+			want: 0,
 		},
 		{
 			name: "one dispatcher",
@@ -173,15 +212,15 @@ func TestGetRuntimeCodeLength(t *testing.T) {
 			want: DISPATCHER_BYTES_SIZE + NO_MATCH_DISPATCHER_SIZE + 2,
 		},
 		{
-			name: "dispatchers and root",
+			name: "dispatchers and the top of the program",
 			code: &RuntimeCode{
 				Dispatchers: []Dispatcher{
 					{Code: bytes.NewBuffer([]byte{1, 2})},
 					{Code: bytes.NewBuffer([]byte{3})},
 				},
-				Root: bytes.NewBuffer([]byte{4, 5, 6, 7}),
+				Init: []ir.Instruction{ir.NewInstruction([]byte("00"), ir.OpSave, ir.Const(1, 8), ir.Nothing())},
 			},
-			want: 2*DISPATCHER_BYTES_SIZE + NO_MATCH_DISPATCHER_SIZE + 3 + 4,
+			want: 2*DISPATCHER_BYTES_SIZE + NO_MATCH_DISPATCHER_SIZE + 3,
 		},
 	}
 
@@ -332,18 +371,6 @@ func TestWriteCodeEndsInStop(t *testing.T) {
 	}
 }
 
-func TestWriteBodyCodeWithoutRoot(t *testing.T) {
-	buf := bytes.NewBuffer(nil)
-	dispatchers := []Dispatcher{{Code: bytes.NewBuffer([]byte{1, 2, 3})}}
-
-	if _, err := WriteBodyCode(buf, dispatchers, nil); err != nil {
-		t.Fatalf("WriteBodyCode: %v", err)
-	}
-	if !bytes.Equal(buf.Bytes(), []byte{1, 2, 3}) {
-		t.Errorf("got %v, want the dispatcher body", buf.Bytes())
-	}
-}
-
 // The compiled name reaches the bytecode as a selector, so a change in how names are
 // hashed shows up here.
 func TestSelectorComesFromTheName(t *testing.T) {
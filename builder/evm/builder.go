@@ -8,8 +8,10 @@ package evm
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/wire/ir"
@@ -24,17 +26,20 @@ const (
 	DISPATCHER_BYTES_SIZE    = PUSH_ONE_SIZE + 1 + PUSH_ONE_SIZE + 1 + PUSH_FOUR_SIZE + 1 + PUSH_TWO_SIZE + 1
 	NO_MATCH_DISPATCHER_SIZE = 1
 	CALLDATA_SLOT_READABLE   = 32
-	MEMORY_SLOT_SIZE         = 32
+	// SELECTOR_SIZE is what a call opens with before its first argument.
+	SELECTOR_SIZE    = 4
+	MEMORY_SLOT_SIZE = 32
 	// PUSH_ONE_SIZE and PUSH_TWO_SIZE are the opcode and what it carries.
 	PUSH_ONE_SIZE = 2
 	PUSH_TWO_SIZE = 3
 	// PUSH_FOUR_SIZE is the opcode and the four bytes of a selector.
 	PUSH_FOUR_SIZE = 5
-	// INSTANTIATE_BLOCK_SIZE is what the constructor measures, and the runtime begins right
-	// after it — so the block carries this number inside itself, as the offset it copies
-	// from. It is added up here rather than written as a literal, because it was the same
-	// number in two places and a push changing size meant remembering both.
-	INSTANTIATE_BLOCK_SIZE = PUSH_TWO_SIZE + PUSH_ONE_SIZE + PUSH_ONE_SIZE + 1 + PUSH_TWO_SIZE + PUSH_ONE_SIZE + 1
+	// INSTANTIATE_BLOCK_SIZE is what the end of the constructor measures, and the runtime
+	// begins right after it — so the block carries this number inside itself, added to where
+	// it was written, as the offset it copies from. It is added up here rather than written as
+	// a literal, because it was the same number in two places and a push changing size meant
+	// remembering both.
+	INSTANTIATE_BLOCK_SIZE = PUSH_TWO_SIZE + PUSH_TWO_SIZE + PUSH_ONE_SIZE + 1 + PUSH_TWO_SIZE + PUSH_ONE_SIZE + 1
 	// MAX_CONTRACT_SIZE is what a chain will keep: 24,576 bytes, by EIP-170. A runtime past
 	// it is refused rather than written, because writing it produces a binary that deploys
 	// and is not the program.
@@ -42,26 +47,59 @@ const (
 )
 
 type Dispatcher struct {
-	Selector []byte
-	Offset   int
-	Length   int
-	Code     *bytes.Buffer
+	// Selector is the name the scope is bound to, and Signature what it is dispatched on:
+	// the name with a uint256 for every position it reads.
+	Selector  []byte
+	Signature string
+	Offset    int
+	Length    int
+	Code      *bytes.Buffer
 	// Body is what the code was written from, kept so it can be written again once where it
 	// lands is known.
 	Body []ir.Instruction
+	// At is the position of the instruction binding the scope, which is where its entry was
+	// written as far as a source map is concerned.
+	At int
 }
 
+// RuntimeCode is what a program becomes on a chain: the scopes it answers to, which is the
+// runtime, and the top of the program, which is not.
+//
+// The top of a program used to be written into the runtime after the dispatchers, where it
+// ran only when a call matched no scope — and then stopped before anything it bound could be
+// read. It is the constructor now: it runs once, at deployment, and what it binds is kept in
+// storage for the scopes to read, the way a name bound at the top of a file is there for every
+// call in the evaluator.
 type RuntimeCode struct {
-	Root        *bytes.Buffer
 	Dispatchers []Dispatcher
+	// Functions are the scopes the dispatched ones call, laid out after them.
+	Functions []Function
+	// Init is the top of the program, lowered, which the constructor runs.
+	Init []ir.Instruction
+	// Globals are the names Init binds, by the storage slot each is kept in.
+	Globals map[string]int
+}
+
+// A Module is one module's range of the instructions a builder is handed, as the loader laid
+// them out: every dependency before whoever uses it, and the entry last.
+type Module struct {
+	Name     string
+	From, To int
+	// Dispatched puts the module's scopes in the dispatcher, where the chain calls them. The
+	// scopes of a module that is not are still there for the others to call; they are simply
+	// not what the contract answers to.
+	Dispatched bool
 }
 
 type Builder struct {
-	tapeSize     int
-	cursor       int
-	insts        []ir.Instruction
-	operands     [][]byte
-	identManager *IdentManager
+	tapeSize int
+	optimize bool
+	cursor   int
+	insts    []ir.Instruction
+	operands [][]byte
+	modules  []Module
+	// functions are what the calls of the program reach, once they are known.
+	functions map[string]Function
 }
 
 func (b *Builder) GetInstruction() ir.Instruction {
@@ -74,48 +112,32 @@ func (b *Builder) GetInstruction() ir.Instruction {
 // the defer body (pointing at the OpIdent), and true. Otherwise returns (nil, cursor, false).
 // Does not mutate b.cursor.
 func (b *Builder) PickDeferAtCursor(cursor int, offset int) (d *Dispatcher, nextCursor int, ok bool) {
-	if cursor >= len(b.insts) {
-		return nil, cursor, false
-	}
-	inst := b.insts[cursor]
-	if inst.GetOpCode() != ir.OpDefer {
-		return nil, cursor, false
-	}
-
-	// OpDefer layout: [OpDefer] [body of length N] [OpIdent]. Right operand = N (body length in instructions).
-	bodylength := byteutil.ToUint64(inst.GetRight().Bytes())
-	end := cursor + 1 + int(bodylength)
-	if end > len(b.insts) {
+	name, body, end, ok := scopeAt(b.insts, cursor)
+	if !ok {
 		return nil, cursor, false
 	}
-	body := b.insts[cursor+1 : end]
-	body = Lowering(body, b.tapeSize)
+	// The signature is read before lowering, which reorders what the body feeds but never
+	// changes which positions it reads.
+	signature := Signature(name, PositionsRead(body))
+	body = b.lower(placedFrom(body, cursor+1))
 
 	// Written once to find out how long it is, and once more when where it lands is known —
 	// a jump inside it carries an address in the contract, and that address depends on how
 	// many scopes come before it, which is not known until they have all been found.
 	code := bytes.NewBuffer(make([]byte, 0))
-	if _, err := WriteCode(code, NewIdentManager(), body, b.tapeSize, 0); err != nil {
+	if _, err := WriteCode(code, NewCallingManager(make(map[string]int), b.functions), body, b.tapeSize, 0); err != nil {
 		return nil, cursor, false
 	}
 
-	// Defer must be assigned to an ident (e.g. "ident f = defer { ... }"); that OpIdent is the selector.
-	if end >= len(b.insts) {
-		return nil, cursor, false
-	}
-	selectorInst := b.insts[end]
-	if selectorInst.GetOpCode() != ir.OpIdent {
-		return nil, cursor, false
-	}
-	selector := selectorInst.GetLeft().Bytes()
-
 	// Prepend OpJumpDestiny so the EVM can jump to this block when the selector matches.
 	d = &Dispatcher{
-		Selector: selector,
-		Code:     bytes.NewBuffer(append([]byte{OpJumpDestiny}, code.Bytes()...)),
-		Offset:   offset,
-		Length:   code.Len(),
-		Body:     body,
+		Selector:  []byte(name),
+		Signature: signature,
+		Code:      bytes.NewBuffer(append([]byte{OpJumpDestiny}, code.Bytes()...)),
+		Offset:    offset,
+		Length:    code.Len(),
+		Body:      body,
+		At:        end,
 	}
 	return d, end, true
 }
@@ -123,49 +145,228 @@ func (b *Builder) PickDeferAtCursor(cursor int, offset int) (d *Dispatcher, next
 func (b *Builder) PickRuntimeCode() (*RuntimeCode, error) {
 	dispatchers := make([]Dispatcher, 0)
 	rootinsts := make([]ir.Instruction, 0)
+	init := make([]ir.Instruction, 0)
 	offset := 0
 
-	for b.cursor < len(b.insts) {
-		inst := b.GetInstruction()
-		if d, nextCursor, ok := b.PickDeferAtCursor(b.cursor, offset); ok {
-			dispatchers = append(dispatchers, *d)
-			offset += 1 + d.Length
-			// Skip the OpIdent that assigns the defer to a variable; it has no EVM meaning (selector is already in the dispatcher).
-			b.cursor = nextCursor + 1
-			continue
+	// What the calls reach is worked out before any scope is measured: a call is written as
+	// a jump to a function, and a scope that calls is as long as the jumps it makes.
+	functions := b.functionsOf()
+	b.functions = functionsByName(functions)
+	if err := b.refuseUnreached(functions); err != nil {
+		return nil, err
+	}
+
+	for _, each := range b.modules {
+		top := make([]ir.Instruction, 0)
+		for b.cursor = each.From; b.cursor < each.To; {
+			if _, _, end, ok := scopeAt(b.insts, b.cursor); ok && !each.Dispatched {
+				b.cursor = end + 1
+				continue
+			}
+			if d, nextCursor, ok := b.PickDeferAtCursor(b.cursor, offset); ok {
+				dispatchers = append(dispatchers, *d)
+				offset += 1 + d.Length
+				// Skip the OpIdent that assigns the defer to a variable; it has no EVM meaning (selector is already in the dispatcher).
+				b.cursor = nextCursor + 1
+				continue
+			}
+			top = append(top, placed{Instruction: b.GetInstruction(), position: b.cursor})
+			b.cursor++
 		}
-		rootinsts = append(rootinsts, inst)
-		b.cursor++
+		rootinsts = append(rootinsts, top...)
+		// Every module is lowered on its own. Each one's labels are counted from the same
+		// place, so one stream of all of them names two values with one label, and the
+		// lowering took one module's value for another's.
+		init = append(init, b.lower(top)...)
 	}
 
+	globals := GlobalsOf(rootinsts)
+
 	// Where each scope lands is known only now, since it depends on how many there are: the
 	// dispatcher block comes first and every entry of it is the same size. So they are
-	// written again, this time with the address they will have.
+	// written again, this time with the address they will have — and with the globals, which
+	// are only known once the whole top of the program has been read.
 	referenced := DISPATCHER_BYTES_SIZE*len(dispatchers) + NO_MATCH_DISPATCHER_SIZE
 	if len(dispatchers) == 0 {
 		referenced = 0
 	}
+
+	// The functions come after the scopes that call them, and where each begins is what the
+	// calls jump to — so they are measured, placed, and only then is anything written for
+	// good.
+	for at := range functions {
+		fn := &functions[at]
+		var measured counter
+		if err := writeInstructions(&measured, NewFunctionManager(globals, b.functions, *fn), fn.Body, b.tapeSize, 0); err != nil {
+			return nil, err
+		}
+		fn.Entry = referenced + offset
+		offset += 1 + int(measured)
+	}
+	b.functions = functionsByName(functions)
+
 	for at := range dispatchers {
 		d := &dispatchers[at]
 		code := bytes.NewBuffer(make([]byte, 0))
 		// One past the offset, because a scope opens with the JUMPDEST its dispatcher
 		// jumps to.
-		if _, err := WriteCode(code, b.identManager, d.Body, b.tapeSize, referenced+d.Offset+1); err != nil {
+		if _, err := WriteCode(code, NewCallingManager(globals, b.functions), d.Body, b.tapeSize, referenced+d.Offset+1); err != nil {
 			return nil, err
 		}
 		d.Code = bytes.NewBuffer(append([]byte{OpJumpDestiny}, code.Bytes()...))
 	}
-
-	if len(rootinsts) > 0 {
-		rootinsts = Lowering(rootinsts, b.tapeSize)
-		root := bytes.NewBuffer(make([]byte, 0))
-		if _, err := WriteCode(root, b.identManager, rootinsts, b.tapeSize, referenced+offset); err != nil {
+	for at := range functions {
+		fn := &functions[at]
+		code := bytes.NewBuffer([]byte{OpJumpDestiny})
+		if err := writeInstructions(code, NewFunctionManager(globals, b.functions, *fn), fn.Body, b.tapeSize, fn.Entry+1); err != nil {
 			return nil, err
 		}
-		return &RuntimeCode{Root: root, Dispatchers: dispatchers}, nil
+		fn.Code = code
 	}
 
-	return &RuntimeCode{Dispatchers: dispatchers}, nil
+	return &RuntimeCode{
+		Dispatchers: dispatchers,
+		Functions:   functions,
+		Init:        init,
+		Globals:     globals,
+	}, nil
+}
+
+// functionsOf answers the scopes the dispatched ones call, and the ones those call, each with
+// a frame of its own and lowered to be written as a function.
+//
+// Frames begin after the memory of the dispatched scopes — the most any of them binds, since
+// only one of them runs in a call — and follow one another in the order the calls reach them.
+// A scope that comes back round to itself is not one: see function.go.
+func (b *Builder) functionsOf() []Function {
+	scopes := scopesOf(b.insts)
+	named := byName(scopes)
+
+	memory := 0
+	next := make([]string, 0)
+	for _, each := range scopes {
+		if !dispatched(b.modules, each.at) {
+			continue
+		}
+		memory = max(memory, slotsOf(each.body)*MEMORY_SLOT_SIZE)
+		next = append(next, callsIn(each.body, named)...)
+	}
+
+	functions := make([]Function, 0)
+	seen := make(map[string]bool)
+	for len(next) > 0 {
+		name := next[0]
+		next = next[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if recursive(name, named) {
+			continue
+		}
+		each := named[name]
+		fn := Function{Name: name, Feeds: PositionsRead(each.body), Frame: memory, At: each.end}
+		memory += (1 + fn.Feeds + slotsOf(each.body)) * MEMORY_SLOT_SIZE
+		fn.Body = balance(b.lower(placedFrom(each.body, each.at+1)))
+		functions = append(functions, fn)
+		next = append(next, callsIn(each.body, named)...)
+	}
+	return functions
+}
+
+// refuseUnreached fails the build when code it is about to write makes a call the bytecode does
+// not: the top of the program, a dispatched scope or a function calling what no function is
+// written for. The evaluator makes every one of those calls, so a contract written without
+// them would answer something else than "aurora run" does, and nothing would say so but a
+// warning. Every such call is named, where it was made.
+//
+// A call in a scope nothing writes — a module's scope the file never reaches — is left alone:
+// it is not in the contract, so the contract cannot be wrong about it.
+func (b *Builder) refuseUnreached(functions []Function) error {
+	unreached := unreachedCalls(b.insts)
+	if len(unreached) == 0 {
+		return nil
+	}
+
+	written := make(map[int]bool)
+	for _, fn := range functions {
+		written[fn.At] = true
+	}
+	inside := make(map[int]scope)
+	for _, each := range scopesOf(b.insts) {
+		for at := each.at + 1; at < each.end; at++ {
+			inside[at] = each
+		}
+	}
+
+	positions := make([]int, 0, len(unreached))
+	for at := range unreached {
+		caller, ok := inside[at]
+		if ok && !dispatched(b.modules, caller.at) && !written[caller.end] {
+			continue
+		}
+		positions = append(positions, at)
+	}
+	slices.Sort(positions)
+
+	refused := make([]error, 0, len(positions))
+	for _, at := range positions {
+		where := ""
+		if origin := b.insts[at].GetOrigin(); origin.Known() {
+			where = fmt.Sprintf("line %d, column %d: ", origin.Line, origin.Column)
+		}
+		refused = append(refused, fmt.Errorf("%s%s", where, unreached[at]))
+	}
+	return errors.Join(refused...)
+}
+
+// functionsByName answers functions by the name a call reaches them by.
+func functionsByName(functions []Function) map[string]Function {
+	named := make(map[string]Function, len(functions))
+	for _, fn := range functions {
+		named[fn.Name] = fn
+	}
+	return named
+}
+
+// dispatched answers whether the instruction at a position belongs to a module whose scopes
+// the dispatcher reaches. With no modules said, the whole program is one, and it is.
+func dispatched(modules []Module, at int) bool {
+	if len(modules) == 0 {
+		return true
+	}
+	for _, each := range modules {
+		if at >= each.From && at < each.To {
+			return each.Dispatched
+		}
+	}
+	return false
+}
+
+// lower puts a piece of code in the order the stack needs, and makes it cheaper when the
+// builder was asked to.
+func (b *Builder) lower(insts []ir.Instruction) []ir.Instruction {
+	lowered := Lowering(insts, b.tapeSize)
+	if b.optimize {
+		return Optimize(lowered, b.tapeSize)
+	}
+	return lowered
+}
+
+// GlobalsOf answers the names the top of a program binds, each with a slot of storage of its
+// own, in the order they are bound.
+func GlobalsOf(insts []ir.Instruction) map[string]int {
+	globals := make(map[string]int)
+	for _, inst := range insts {
+		if inst.GetOpCode() != ir.OpIdent {
+			continue
+		}
+		name := string(inst.GetLeft().Bytes())
+		if _, bound := globals[name]; !bound {
+			globals[name] = len(globals)
+		}
+	}
+	return globals
 }
 
 func (b *Builder) WriteRuntimeBlock(bs io.Writer, rc *RuntimeCode) (int, error) {
@@ -173,7 +374,39 @@ func (b *Builder) WriteRuntimeBlock(bs io.Writer, rc *RuntimeCode) (int, error)
 		return 0, err
 	}
 
-	return WriteBodyCode(bs, rc.Dispatchers, rc.Root)
+	if _, err := WriteBodyCode(bs, rc.Dispatchers); err != nil {
+		return 0, err
+	}
+	for _, fn := range rc.Functions {
+		if _, err := bs.Write(fn.Code.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// WriteConstructor emits what runs at deployment: the top of the program, and then the block
+// that hands the chain the runtime.
+//
+// The arguments of the constructor are appended to the code being deployed, so where they
+// begin is where the whole of it ends — the top of the program, the block and the runtime.
+// The top is measured before that is known, which costs nothing: the address goes in a push of
+// a fixed size whatever it is.
+func (b *Builder) WriteConstructor(bs io.Writer, rc *RuntimeCode, runtimeSize int) (int, error) {
+	var measured counter
+	if _, err := WriteConstructorCode(&measured, NewConstructorManager(rc.Globals, Constructor{}), rc.Init, b.tapeSize); err != nil {
+		return 0, err
+	}
+	init := int(measured)
+
+	constructor := Constructor{ArgsAt: init + INSTANTIATE_BLOCK_SIZE + runtimeSize}
+	if _, err := WriteConstructorCode(bs, NewConstructorManager(rc.Globals, constructor), rc.Init, b.tapeSize); err != nil {
+		return 0, err
+	}
+	if _, err := WriteInstantiateBlock(bs, runtimeSize, init); err != nil {
+		return 0, err
+	}
+	return init + INSTANTIATE_BLOCK_SIZE, nil
 }
 
 // Build assembles the program into bytecode and returns it.
@@ -182,40 +415,115 @@ func (b *Builder) WriteRuntimeBlock(bs io.Writer, rc *RuntimeCode) (int, error)
 // back, and deciding where bytecode lands — a file, a deployment, a test — belongs to
 // whoever asked for it.
 func (b *Builder) Build() ([]byte, error) {
+	bytecode, _, err := b.BuildWithSourceMap()
+	return bytecode, err
+}
+
+// BuildWithSourceMap assembles the program and answers, beside the bytecode, where each range
+// of it was written.
+func (b *Builder) BuildWithSourceMap() ([]byte, SourceMap, error) {
 	rc, err := b.PickRuntimeCode()
 	if err != nil {
-		return nil, err
+		return nil, SourceMap{}, err
+	}
+	for _, d := range rc.Dispatchers {
+		if err := callableOnChain(string(d.Selector), b.insts[d.At].GetOrigin()); err != nil {
+			return nil, SourceMap{}, err
+		}
 	}
 
 	out := bytes.NewBuffer(make([]byte, 0))
 
 	runtimeSize := GetRuntimeCodeLength(rc)
 	if runtimeSize > MAX_CONTRACT_SIZE {
-		return nil, fmt.Errorf("the runtime is %d bytes and a chain keeps at most %d: this program cannot be deployed", runtimeSize, MAX_CONTRACT_SIZE)
+		return nil, SourceMap{}, fmt.Errorf("the runtime is %d bytes and a chain keeps at most %d: this program cannot be deployed", runtimeSize, MAX_CONTRACT_SIZE)
 	}
 
-	if _, err := WriteInstantiateBlock(out, runtimeSize); err != nil {
-		return nil, err
+	if _, err := b.WriteConstructor(out, rc, runtimeSize); err != nil {
+		return nil, SourceMap{}, err
 	}
 
 	if _, err := b.WriteRuntimeBlock(out, rc); err != nil {
-		return nil, err
+		return nil, SourceMap{}, err
 	}
 
-	return out.Bytes(), nil
+	sourceMap, err := b.MapSource(rc)
+	if err != nil {
+		return nil, SourceMap{}, err
+	}
+	return out.Bytes(), sourceMap, nil
+}
+
+// MapSource answers where the bytes written from a runtime code land and where each range of
+// them was written.
+//
+// It measures rather than watching the writer: every piece of code is already measured before
+// it is written, since a jump inside it needs to know where it lands, and measuring once more
+// is what keeps the writer one thing.
+func (b *Builder) MapSource(rc *RuntimeCode) (SourceMap, error) {
+	constructor, err := mappingsOf(NewConstructorManager(rc.Globals, Constructor{}), rc.Init, b.tapeSize, 0)
+	if err != nil {
+		return SourceMap{}, err
+	}
+
+	runtime := make(Mappings, 0)
+	referenced := 0
+	if len(rc.Dispatchers) > 0 {
+		referenced = DISPATCHER_BYTES_SIZE*len(rc.Dispatchers) + NO_MATCH_DISPATCHER_SIZE
+	}
+	for i, d := range rc.Dispatchers {
+		if origin := b.insts[d.At].GetOrigin(); origin.Known() {
+			runtime = append(runtime, Mapping{
+				Offset:   DISPATCHER_BYTES_SIZE * i,
+				Length:   DISPATCHER_BYTES_SIZE,
+				Position: d.At,
+				Line:     origin.Line,
+				Column:   origin.Column,
+			})
+		}
+	}
+	for _, d := range rc.Dispatchers {
+		// One past the offset, because a scope opens with the JUMPDEST its dispatcher
+		// jumps to.
+		body, err := mappingsOf(NewCallingManager(rc.Globals, functionsByName(rc.Functions)), d.Body, b.tapeSize, referenced+d.Offset+1)
+		if err != nil {
+			return SourceMap{}, err
+		}
+		runtime = append(runtime, body...)
+	}
+	for _, fn := range rc.Functions {
+		body, err := mappingsOf(NewFunctionManager(rc.Globals, functionsByName(rc.Functions), fn), fn.Body, b.tapeSize, fn.Entry+1)
+		if err != nil {
+			return SourceMap{}, err
+		}
+		runtime = append(runtime, body...)
+	}
+
+	return SourceMap{Constructor: constructor, Runtime: runtime}, nil
 }
 
 type NewBuilderOptions struct {
 	// TapeSize is the width in bytes of every value. Zero means the default (8).
 	TapeSize int
+	// Optimize makes the bytecode smaller and cheaper without changing what it answers, for
+	// "aurora build -O".
+	Optimize bool
+	// Modules are the ranges of the program, one a module. None means the whole program is
+	// one, and its scopes are dispatched.
+	Modules []Module
 }
 
 func NewBuilder(insts []ir.Instruction, options NewBuilderOptions) *Builder {
+	modules := options.Modules
+	if len(modules) == 0 {
+		modules = []Module{{From: 0, To: len(insts), Dispatched: true}}
+	}
 	return &Builder{
-		tapeSize:     byteutil.TapeSize(options.TapeSize),
-		operands:     make([][]byte, 0),
-		identManager: NewIdentManager(),
-		cursor:       0,
-		insts:        insts,
+		modules:  modules,
+		tapeSize: byteutil.TapeSize(options.TapeSize),
+		optimize: options.Optimize,
+		operands: make([][]byte, 0),
+		cursor:   0,
+		insts:    insts,
 	}
 }
@@ -80,6 +80,11 @@ false;
 	}
 }
 
+// constructor answers a manager for the top of a program that binds a in its first slot.
+func constructor() *IdentManager {
+	return NewConstructorManager(map[string]int{"a": 0}, Constructor{})
+}
+
 func TestPickRuntimeCode(t *testing.T) {
 	cases := []struct {
 		Name       string
@@ -96,9 +101,7 @@ func TestPickRuntimeCode(t *testing.T) {
 				WritePush(want, byteutil.FromUint64(4294967295), byteutil.DefaultTapeSize)
 				WriteAdd(want)
 				WriteMask(want, byteutil.DefaultTapeSize)
-				WriteReturn(want)
-				WriteIdent(want, NewIdentManager(), []byte("a"))
-				WriteStop(want)
+				WriteIdent(want, constructor(), []byte("a"))
 				if !bytes.Equal(got, want.Bytes()) {
 					return fmt.Errorf("expected: %v, got: %v", byteutil.ToUpperHex(want.Bytes()), byteutil.ToUpperHex(got))
 				}
@@ -112,9 +115,7 @@ func TestPickRuntimeCode(t *testing.T) {
 			func(got []byte) error {
 				want := bytes.NewBuffer(make([]byte, 0))
 				WritePush(want, byteutil.TrueTape(byteutil.DefaultTapeSize), byteutil.DefaultTapeSize)
-				WriteReturn(want)
-				WriteIdent(want, NewIdentManager(), []byte("a"))
-				WriteStop(want)
+				WriteIdent(want, constructor(), []byte("a"))
 				if !bytes.Equal(got, want.Bytes()) {
 					return fmt.Errorf("expected: %v, got: %v", byteutil.ToUpperHex(want.Bytes()), byteutil.ToUpperHex(got))
 				}
@@ -127,13 +128,11 @@ func TestPickRuntimeCode(t *testing.T) {
 			//nolint:errcheck
 			func(got []byte) error {
 				want := bytes.NewBuffer(make([]byte, 0))
-				WriteGetArg(want, byteutil.FromUint64(1), byteutil.DefaultTapeSize)
-				WriteGetArg(want, byteutil.FromUint64(0), byteutil.DefaultTapeSize)
+				WriteGetConstructorArg(want, byteutil.FromUint64(1), byteutil.DefaultTapeSize, 0)
+				WriteGetConstructorArg(want, byteutil.FromUint64(0), byteutil.DefaultTapeSize, 0)
 				WriteSubtract(want)
 				WriteMask(want, byteutil.DefaultTapeSize)
-				WriteReturn(want)
-				WriteIdent(want, NewIdentManager(), []byte("a"))
-				WriteStop(want)
+				WriteIdent(want, constructor(), []byte("a"))
 				if !bytes.Equal(got, want.Bytes()) {
 					return fmt.Errorf("expected: %v, got: %v", byteutil.ToUpperHex(want.Bytes()), byteutil.ToUpperHex(got))
 				}
@@ -166,7 +165,13 @@ func TestPickRuntimeCode(t *testing.T) {
 				t.Errorf("%v: %v", c.Name, err)
 				return
 			}
-			got := rc.Root.Bytes()
+			// The top of the program is the constructor's, and a block inside it answers where
+			// it stands rather than returning from the deployment.
+			root := bytes.NewBuffer(make([]byte, 0))
+			if _, err := WriteConstructorCode(root, NewConstructorManager(rc.Globals, Constructor{}), rc.Init, byteutil.DefaultTapeSize); err != nil {
+				t.Fatalf("%v: %v", c.Name, err)
+			}
+			got := root.Bytes()
 			if err := c.FnExpected(got); err != nil {
 				t.Errorf("%v: %v", c.Name, err)
 				return
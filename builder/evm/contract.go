@@ -0,0 +1,138 @@
+package evm
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// A call out of the contract, to another one.
+//
+// A call to a scope stays inside the contract and has a frame of its own, fixed when the
+// contract is written. A call to another contract leaves it: the calldata has to be laid out
+// in memory the way an ABI call is — the selector, then a word for every value — and handed
+// to CALL, and what comes back is read out of memory after it.
+//
+// Nothing is kept for it. The calldata is written where memory ends at that moment, which
+// MSIZE answers: nothing the contract has written lives there, and what the call leaves there
+// is dead as soon as the answer has been read. A frame or a name written there later is
+// written before it is read, as it always was. The answer is copied in right after the
+// calldata, where memory has never been written, so return data shorter than a word reads as
+// the rest of the word being zeros — which is what the evaluator reads, too.
+//
+// A call that fails fails this one, with the same return data: whatever the other contract
+// reverted with is what whoever called this one is told. One that answers more than a word
+// fails it too, with the reason the evaluator stops with: a value is one word, and the shape an
+// answer of several would land in is not written to the bytecode yet.
+
+// contractCalls are the instructions that call another contract.
+var contractCalls = map[byte]bool{
+	ir.OpContractCall: true,
+	ir.OpStaticCall:   true,
+}
+
+// WriteContractCall calls the contract at an address by a signature, with values, and leaves
+// the word it answered cut to a tape.
+//
+// The values are the address and then what is applied, in that order. Those already on the
+// stack are there in the same order, the last on top, so they are stored from the last one
+// back with where the calldata begins kept on top of them; one written down is pushed where it
+// is stored.
+func WriteContractCall(w io.Writer, values []ir.Operand, signature string, static bool, tapeSize int) (int, error) {
+	applied := values[1:]
+	length := 4 + len(applied)*MEMORY_SLOT_SIZE
+
+	// Where the calldata begins, and the selector at the front of it, in the four bytes a
+	// word stored there begins with.
+	if _, err := w.Write([]byte{OpMemorySize, OpPush4}); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(Selector(signature)); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpPush1, 0xe0, OpShiftLeft, OpDup2, OpMemoryStore}); err != nil {
+		return 0, err
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		if applied[i].Kind() == ir.KindImm {
+			if _, err := WritePush(w, applied[i].Bytes(), tapeSize); err != nil {
+				return 0, err
+			}
+		} else if _, err := w.Write([]byte{OpSwap1}); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{OpDup2}); err != nil {
+			return 0, err
+		}
+		if _, err := WritePush2(w, 4+i*MEMORY_SLOT_SIZE); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{OpAdd, OpMemoryStore}); err != nil {
+			return 0, err
+		}
+	}
+
+	if values[0].Kind() == ir.KindImm {
+		if _, err := WritePush(w, values[0].Bytes(), tapeSize); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{OpSwap1}); err != nil {
+			return 0, err
+		}
+	}
+
+	// The address is under where the calldata begins. CALL takes, from the top: the gas, the
+	// address, the value sent, where the calldata is and how long, and where the answer goes
+	// and how long. STATICCALL takes the same without the value.
+	if _, err := w.Write([]byte{OpPush1, MEMORY_SLOT_SIZE, OpDup2}); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, length); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpAdd}); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, length); err != nil {
+		return 0, err
+	}
+	call := []byte{OpDup4, OpPush1, 0x00, OpDup7, OpGas, OpCall}
+	if static {
+		call = []byte{OpDup4, OpDup6, OpGas, OpStaticCall}
+	}
+	if _, err := w.Write(call); err != nil {
+		return 0, err
+	}
+
+	// Whatever the call reverted with, this one reverts with.
+	bubble := []byte{
+		OpReturnDataSize, OpPush1, 0x00, OpPush1, 0x00, OpReturnDataCopy,
+		OpReturnDataSize, OpPush1, 0x00, OpRevert,
+	}
+	if _, err := writeUnless(w, bubble); err != nil {
+		return 0, err
+	}
+
+	// An answer of more than a word reverts: it answered within a word when its size is less
+	// than 33.
+	var long bytes.Buffer
+	if _, err := WriteRevert(&long, ir.AnswerOfManyWords); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpPush1, MEMORY_SLOT_SIZE + 1, OpReturnDataSize, OpLessThan}); err != nil {
+		return 0, err
+	}
+	if _, err := writeUnless(w, long.Bytes()); err != nil {
+		return 0, err
+	}
+
+	if _, err := WritePush2(w, length); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpAdd, OpMemoryLoad, OpSwap1, OpPop}); err != nil {
+		return 0, err
+	}
+	return WriteMask(w, tapeSize)
+}
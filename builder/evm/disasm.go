@@ -0,0 +1,265 @@
+package evm
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// Reading a binary back.
+//
+// The builder writes bytecode in a shape it always keeps: the top of the program, then the
+// block that hands the chain the runtime, then the runtime — one dispatcher entry per scope, a
+// STOP for a call that matched none, the bodies one after the other, and the functions they
+// call. Nothing records where
+// one part ends, but nothing has to: every part is written from a fixed pattern, so it can be
+// found again by that pattern. The same reading works on a runtime on its own, as a chain
+// answers it, which simply has no constructor in front.
+
+// An Op is one instruction of bytecode where it sits: its opcode and, for a push, what it
+// carries.
+type Op struct {
+	Offset int
+	Code   byte
+	Data   []byte
+}
+
+// Mnemonic is the name of the opcode.
+func (o Op) Mnemonic() string {
+	return ResolveOpCode(o.Code)
+}
+
+// Disassemble reads bytecode instruction by instruction. A push at the very end that carries
+// fewer bytes than it says keeps what there is, the way the EVM reads the missing ones as zero.
+func Disassemble(code []byte) []Op {
+	ops := make([]Op, 0, len(code))
+	for at := 0; at < len(code); {
+		op := Op{Offset: at, Code: code[at]}
+		at++
+		if op.Code >= OpPush1 && op.Code <= OpPush32 {
+			end := min(at+int(op.Code-OpPush1)+1, len(code))
+			op.Data = code[at:end]
+			at = end
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// What part of a binary a section is.
+const (
+	SectionConstructor = "constructor"
+	SectionDispatcher  = "dispatcher"
+	SectionScope       = "scope"
+	// SectionFunction is a scope as a call reaches it, which no dispatcher entry names.
+	SectionFunction = "function"
+	// SectionCode is runtime that follows none of the patterns the builder writes.
+	SectionCode = "code"
+	// SectionData is what comes after the runtime: the arguments a deployment appended.
+	SectionData = "data"
+)
+
+// A Section is one part of a binary.
+type Section struct {
+	Kind string
+	// At is where the section begins in the binary.
+	At int
+	// Ops are counted from the start of the code they run in: the binary for the
+	// constructor, the runtime for everything after it — which is what a jump names.
+	Ops []Op
+	// Data is what a data section holds, which is not code and is not read as any.
+	Data []byte
+	// Selector is what a scope's dispatcher entry checks for.
+	Selector []byte
+}
+
+// Sections splits bytecode into the parts the builder wrote it in.
+func Sections(code []byte) []Section {
+	from, size, end, ok := instantiateBlock(code)
+	if !ok {
+		return runtimeSections(code, 0)
+	}
+	sections := []Section{{Kind: SectionConstructor, Ops: Disassemble(code[:end])}}
+	sections = append(sections, runtimeSections(code[from:from+size], from)...)
+	if rest := code[from+size:]; len(rest) > 0 {
+		sections = append(sections, Section{Kind: SectionData, At: from + size, Data: rest})
+	}
+	return sections
+}
+
+// runtimeSections splits a runtime that begins at a point of the binary.
+func runtimeSections(runtime []byte, at int) []Section {
+	ops := Disassemble(runtime)
+	entries := dispatcherEntries(ops)
+	if len(entries) == 0 {
+		if len(ops) == 0 {
+			return nil
+		}
+		return []Section{{Kind: SectionCode, At: at, Ops: ops}}
+	}
+
+	// The dispatcher ends with the STOP a call that matched nothing lands on.
+	end := len(entries) * dispatcherOps
+	if end < len(ops) && ops[end].Code == OpStop {
+		end++
+	}
+	sections := []Section{{Kind: SectionDispatcher, At: at, Ops: ops[:end]}}
+
+	// A body runs from where its entry jumps to up to where the next one begins, and so does
+	// a function, from where a call jumps to: a call says where it goes as plainly as an entry
+	// of the dispatcher does.
+	starts := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		starts = append(starts, entry.target)
+	}
+	functions := callEntries(ops[end:])
+	starts = append(starts, functions...)
+	sort.Ints(starts)
+	sectionOf := func(from int) []Op {
+		to := len(runtime)
+		if next := sort.SearchInts(starts, from+1); next < len(starts) {
+			to = starts[next]
+		}
+		body := make([]Op, 0)
+		for _, op := range ops[end:] {
+			if op.Offset >= from && op.Offset < to {
+				body = append(body, op)
+			}
+		}
+		return body
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].target < entries[j].target })
+	for _, entry := range entries {
+		sections = append(sections, Section{Kind: SectionScope, At: at + entry.target, Ops: sectionOf(entry.target), Selector: entry.selector})
+	}
+	for _, entry := range functions {
+		sections = append(sections, Section{Kind: SectionFunction, At: at + entry, Ops: sectionOf(entry)})
+	}
+	return sections
+}
+
+// callEntries answers where the calls of a runtime jump to, once each and in order.
+func callEntries(ops []Op) []int {
+	seen := make(map[int]bool)
+	entries := make([]int, 0)
+	for i := range ops {
+		if entry, ok := callAt(ops, i); ok && !seen[entry] {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+	sort.Ints(entries)
+	return entries
+}
+
+// callAt answers where a call goes when the JUMP at an instruction is the one WriteCall writes:
+// where to come back to, stored in the frame, the address of the function, the jump, and the
+// JUMPDEST it comes back to right after.
+func callAt(ops []Op, jump int) (int, bool) {
+	if jump < 4 || jump+1 >= len(ops) || ops[jump].Code != OpJump {
+		return 0, false
+	}
+	back, frame, store, entry := ops[jump-4], ops[jump-3], ops[jump-2], ops[jump-1]
+	if !pushes(back, OpPush2) || !pushes(frame, OpPush2) || store.Code != OpMemoryStore || !pushes(entry, OpPush2) {
+		return 0, false
+	}
+	if ops[jump+1].Code != OpJumpDestiny || ops[jump+1].Offset != int(byteutil.ToUint64(back.Data)) {
+		return 0, false
+	}
+	return int(byteutil.ToUint64(entry.Data)), true
+}
+
+// returnAt answers whether the JUMP at an instruction is a function going back to whoever
+// called it, to the address its frame holds.
+func returnAt(ops []Op, jump int) bool {
+	return jump >= 2 && ops[jump].Code == OpJump && ops[jump-1].Code == OpMemoryLoad && pushes(ops[jump-2], OpPush2)
+}
+
+// instantiateBlock finds the block WriteInstantiateBlock writes: where the runtime it hands
+// over begins, how long it is, and where the block itself ends.
+func instantiateBlock(code []byte) (from int, size int, end int, ok bool) {
+	ops := Disassemble(code)
+	for i := 0; i+6 < len(ops); i++ {
+		block := ops[i : i+7]
+		if !pushes(block[0], OpPush2) || !pushes(block[1], OpPush2) || !pushesByte(block[2], 0x00) ||
+			block[3].Code != OpCodeCopy || !pushes(block[4], OpPush2) || !pushesByte(block[5], 0x00) ||
+			block[6].Code != OpReturn {
+			continue
+		}
+		size = int(byteutil.ToUint64(block[0].Data))
+		from = int(byteutil.ToUint64(block[1].Data))
+		end = block[6].Offset + 1
+		if from != end || !bytes.Equal(block[0].Data, block[4].Data) || from+size > len(code) {
+			continue
+		}
+		return from, size, end, true
+	}
+	return 0, 0, 0, false
+}
+
+// dispatcherOps is how many instructions one entry of the dispatcher is.
+const dispatcherOps = 8
+
+type dispatcherEntry struct {
+	selector []byte
+	target   int
+}
+
+// dispatcherEntries reads the entries WriteDispatcher writes from the top of the runtime, for
+// as long as they follow one another.
+func dispatcherEntries(ops []Op) []dispatcherEntry {
+	entries := make([]dispatcherEntry, 0)
+	for i := 0; i+dispatcherOps <= len(ops); i += dispatcherOps {
+		entry := ops[i : i+dispatcherOps]
+		if !pushesByte(entry[0], 0x00) || entry[1].Code != OpCallDataLoad ||
+			!pushesByte(entry[2], (CALLDATA_SLOT_READABLE-SELECTOR_SIZE)*BYTE_SIZE) || entry[3].Code != OpShiftRight ||
+			!pushes(entry[4], OpPush4) || entry[5].Code != OpEqual || !pushes(entry[6], OpPush2) ||
+			entry[7].Code != OpJumpIf {
+			break
+		}
+		entries = append(entries, dispatcherEntry{
+			selector: entry[4].Data,
+			target:   int(byteutil.ToUint64(entry[6].Data)),
+		})
+	}
+	return entries
+}
+
+func pushes(op Op, push byte) bool {
+	return op.Code == push && len(op.Data) == int(push-OpPush1)+1
+}
+
+func pushesByte(op Op, b byte) bool {
+	return pushes(op, OpPush1) && op.Data[0] == b
+}
+
+// A Binding is a scope of a program as the dispatcher knows it: the name, the signature its
+// selector is the hash of, and the position of the instruction that binds it — which carries
+// where in the source it was written.
+type Binding struct {
+	Name      string
+	Signature string
+	At        int
+}
+
+// Bindings answers the scopes of a program, read the way the dispatcher reads them: only the
+// ones of a module it reaches, and every one when no modules are said.
+func Bindings(insts []ir.Instruction, modules []Module) []Binding {
+	bindings := make([]Binding, 0)
+	for cursor := 0; cursor < len(insts); cursor++ {
+		name, body, end, ok := scopeAt(insts, cursor)
+		if !ok {
+			continue
+		}
+		if !dispatched(modules, cursor) {
+			cursor = end
+			continue
+		}
+		bindings = append(bindings, Binding{Name: name, Signature: Signature(name, PositionsRead(body)), At: end})
+		cursor = end
+	}
+	return bindings
+}
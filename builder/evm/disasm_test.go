@@ -0,0 +1,161 @@
+package evm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// A push carries what follows it, and a push cut off at the end of the code carries what there
+// is rather than reading past it.
+func TestDisassemble(t *testing.T) {
+	ops := Disassemble([]byte{OpPush2, 0x01, 0x02, OpAdd, OpPush4, 0xaa})
+
+	want := []Op{
+		{Offset: 0, Code: OpPush2, Data: []byte{0x01, 0x02}},
+		{Offset: 3, Code: OpAdd},
+		{Offset: 4, Code: OpPush4, Data: []byte{0xaa}},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("got %d ops, want %d: %+v", len(ops), len(want), ops)
+	}
+	for i := range want {
+		if ops[i].Offset != want[i].Offset || ops[i].Code != want[i].Code || !bytes.Equal(ops[i].Data, want[i].Data) {
+			t.Errorf("op %d = %+v, want %+v", i, ops[i], want[i])
+		}
+	}
+}
+
+func kinds(sections []Section) []string {
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		names[i] = section.Kind
+	}
+	return names
+}
+
+// A binary reads back as the parts it was written in: the constructor, the dispatcher, and one
+// body per scope, each found by the selector its entry checks — and the arguments a deployment
+// appended, which are not code.
+func TestSectionsOfABinary(t *testing.T) {
+	code := build(t, `ident rate = feed(0);
+ident add = defer { feed(0) + feed(1); };
+ident neg = defer { 0 - feed(0); };
+`, byteutil.DefaultTapeSize)
+	deployed := append(append([]byte{}, code...), bytes.Repeat([]byte{0x07}, 32)...)
+
+	sections := Sections(deployed)
+	want := []string{SectionConstructor, SectionDispatcher, SectionScope, SectionScope, SectionData}
+	if got := kinds(sections); len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, kind := range want {
+		if sections[i].Kind != kind {
+			t.Fatalf("got %v, want %v", kinds(sections), want)
+		}
+	}
+
+	if got := sections[2].Selector; !bytes.Equal(got, Selector("add(uint256,uint256)")) {
+		t.Errorf("the first body is checked for %x, want add", got)
+	}
+	if got := sections[3].Selector; !bytes.Equal(got, Selector("neg(uint256)")) {
+		t.Errorf("the second body is checked for %x, want neg", got)
+	}
+	for _, scope := range sections[2:4] {
+		if scope.Ops[0].Code != OpJumpDestiny {
+			t.Errorf("a body opens with %s, want the JUMPDEST its entry jumps to", scope.Ops[0].Mnemonic())
+		}
+	}
+	if data := sections[4]; data.At != len(code) || len(data.Data) != 32 {
+		t.Errorf("data at %d with %d bytes, want 32 at %d", data.At, len(data.Data), len(code))
+	}
+}
+
+// A runtime on its own — what a chain answers for a contract — reads the same, with nothing in
+// front of the dispatcher.
+func TestSectionsOfARuntime(t *testing.T) {
+	_, runtime := split(t, "ident add = defer { feed(0) + feed(1); };\n", byteutil.DefaultTapeSize)
+
+	sections := Sections(runtime)
+	if len(sections) != 2 || sections[0].Kind != SectionDispatcher || sections[1].Kind != SectionScope {
+		t.Fatalf("got %v, want the dispatcher and one scope", kinds(sections))
+	}
+	if sections[1].At != DISPATCHER_BYTES_SIZE+NO_MATCH_DISPATCHER_SIZE {
+		t.Errorf("the body is at %d, want right after the dispatcher", sections[1].At)
+	}
+}
+
+// A program with no scopes is all constructor: its runtime is empty, and nothing follows.
+func TestSectionsOfAProgramWithNoScopes(t *testing.T) {
+	sections := Sections(build(t, "ident a = 1;\n", byteutil.DefaultTapeSize))
+
+	if len(sections) != 1 || sections[0].Kind != SectionConstructor {
+		t.Errorf("got %v, want the constructor alone", kinds(sections))
+	}
+}
+
+// Bytecode the builder did not write is still listed, as code.
+func TestSectionsOfSomethingElse(t *testing.T) {
+	sections := Sections([]byte{OpPush1, 0x01, OpPush1, 0x02, OpAdd})
+
+	if len(sections) != 1 || sections[0].Kind != SectionCode || len(sections[0].Ops) != 3 {
+		t.Errorf("got %+v, want one section of three ops", sections)
+	}
+}
+
+// A binding points at the line the scope was written on, and carries the signature its
+// selector is the hash of.
+func TestBindings(t *testing.T) {
+	insts, _ := compile(t, `ident a = 1;
+
+ident add = defer { feed(0) + feed(1); };
+`)
+
+	bindings := Bindings(insts, nil)
+	if len(bindings) != 1 {
+		t.Fatalf("got %d bindings, want 1", len(bindings))
+	}
+	binding := bindings[0]
+	if binding.Name != "add" || binding.Signature != "add(uint256,uint256)" {
+		t.Errorf("got %s as %s, want add(uint256,uint256)", binding.Name, binding.Signature)
+	}
+	if line := insts[binding.At].GetOrigin().Line; line != 3 {
+		t.Errorf("bound on line %d, want 3", line)
+	}
+}
+
+// A module whose scopes are not dispatched is not what the contract answers to: its scopes are
+// written only as the functions the dispatched ones call, after every body, and one nobody calls
+// is not written at all.
+func TestSectionsOfAModuleReachedByCalls(t *testing.T) {
+	insts, _ := compile(t, `ident square = defer { feed(0) * feed(0); };
+ident unused = defer { 1; };
+ident f = defer { square(feed(0)) + 1; };
+`)
+	entry := scopesOf(insts)[2].at
+	modules := []Module{{Name: "geometry", From: 0, To: entry}, {Name: "main", From: entry, To: len(insts), Dispatched: true}}
+
+	code, err := NewBuilder(insts, NewBuilderOptions{TapeSize: byteutil.DefaultTapeSize, Modules: modules}).Build()
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+
+	want := []string{SectionConstructor, SectionDispatcher, SectionScope, SectionFunction}
+	got := kinds(Sections(code))
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if bindings := Bindings(insts, modules); len(bindings) != 1 || bindings[0].Name != "f" {
+		t.Errorf("the dispatcher answers to %+v, want f alone", bindings)
+	}
+	if functions := ABI(insts, nil, modules); len(functions) != 1 || functions[0].Name != "f" {
+		t.Errorf("the ABI lists %+v, want f alone", functions)
+	}
+}
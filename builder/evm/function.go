@@ -0,0 +1,259 @@
+package evm
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// A scope called from inside the contract.
+//
+// A scope the dispatcher reaches answers the chain: it ends in RETURN, and the call is over.
+// A scope another one calls has to answer the caller instead and let it carry on, which is a
+// function in the sense every machine without one means it — the caller leaves where to come
+// back to, jumps in, and the callee jumps back with its value on the stack.
+//
+// Where to come back to and what the callee is fed go to memory rather than the stack. The
+// stack can only be reached sixteen deep and its depth differs from one call site to the
+// next; memory has an address for each, fixed when the contract is written. So every function
+// has a frame of its own there — the address it returns to, then its feeds, then the names it
+// binds — laid out after the memory of the scopes the dispatcher reaches, one frame after the
+// other.
+//
+// One frame a function is one call of it at a time, which is every call that does not come
+// back round to itself. A scope that does, directly or through another, would need a frame a
+// call, and a stack of them: it is left out and said so, with everything else a call cannot
+// reach yet — a name that is not a scope bound at the top of a program, and a call at the top
+// itself, which runs in the constructor.
+
+// A Function is a scope as a call reaches it.
+type Function struct {
+	Name string
+	// Feeds is how many positions it reads, each a slot of its frame.
+	Feeds int
+	// Frame is where its memory begins: the address it returns to, then its feeds, then the
+	// names it binds.
+	Frame int
+	// Entry is the JUMPDEST a call jumps to, in the runtime.
+	Entry int
+	// Body is what the code was written from, lowered.
+	Body []ir.Instruction
+	Code *bytes.Buffer
+	// At is the position of the instruction binding the scope.
+	At int
+}
+
+// feedAt answers the address in memory of a position the function reads.
+func (f Function) feedAt(nth int) int {
+	return f.Frame + (1+nth)*MEMORY_SLOT_SIZE
+}
+
+// localsAt answers where the names the function binds begin.
+func (f Function) localsAt() int {
+	return f.feedAt(f.Feeds)
+}
+
+// scope is a scope bound at the top of a program, as a call graph knows it.
+type scope struct {
+	name string
+	body []ir.Instruction
+	// at is the OpDefer, end the OpIdent binding it.
+	at, end int
+}
+
+// scopesOf answers every scope bound at the top of a program, in every module, in the order
+// they are bound.
+func scopesOf(insts []ir.Instruction) []scope {
+	scopes := make([]scope, 0)
+	for cursor := 0; cursor < len(insts); cursor++ {
+		name, body, end, ok := scopeAt(insts, cursor)
+		if !ok {
+			continue
+		}
+		scopes = append(scopes, scope{name: name, body: body, at: cursor, end: end})
+		cursor = end
+	}
+	return scopes
+}
+
+// byName answers scopes by the name they are bound to.
+func byName(scopes []scope) map[string]scope {
+	named := make(map[string]scope, len(scopes))
+	for _, each := range scopes {
+		named[each.name] = each
+	}
+	return named
+}
+
+// callsIn answers the scopes a body calls, in the order it calls them: the names it calls that
+// are bound at the top and that it does not bind itself.
+func callsIn(body []ir.Instruction, scopes map[string]scope) []string {
+	bound := make(map[string]bool)
+	for _, inst := range body {
+		if inst.GetOpCode() == ir.OpIdent {
+			bound[string(inst.GetLeft().Bytes())] = true
+		}
+	}
+	calls := make([]string, 0)
+	for _, inst := range body {
+		if inst.GetOpCode() != ir.OpCall {
+			continue
+		}
+		name := string(inst.GetLeft().Bytes())
+		if _, ok := scopes[name]; ok && !bound[name] {
+			calls = append(calls, name)
+		}
+	}
+	return calls
+}
+
+// recursive answers whether a scope comes back round to itself through what it calls.
+func recursive(name string, scopes map[string]scope) bool {
+	seen := make(map[string]bool)
+	next := callsIn(scopes[name].body, scopes)
+	for len(next) > 0 {
+		callee := next[0]
+		next = next[1:]
+		if callee == name {
+			return true
+		}
+		if seen[callee] {
+			continue
+		}
+		seen[callee] = true
+		next = append(next, callsIn(scopes[callee].body, scopes)...)
+	}
+	return false
+}
+
+// slotsOf answers how many slots of memory the names a body binds take.
+func slotsOf(body []ir.Instruction) int {
+	names := make(map[string]bool)
+	for _, inst := range body {
+		if inst.GetOpCode() == ir.OpIdent {
+			names[string(inst.GetLeft().Bytes())] = true
+		}
+	}
+	return len(names)
+}
+
+// WriteCall calls a function: the values it is fed go to its frame, where to come back to goes
+// in front of them, and the jump in is followed by the JUMPDEST the jump back lands on — so
+// the value the function answers with is on the stack right after it, as any other
+// instruction's would be.
+//
+// The values are on the stack in the order the call takes them, the last on top, so they are
+// stored from the last one back. One past what the function reads is dropped, and a position it
+// reads that nobody applied is zero — the frame outlives the call, and what the last call left
+// there is not this one's.
+//
+// At is where the call is written in the runtime, which is what the address it comes back to is
+// counted from.
+func WriteCall(w io.Writer, fn Function, values []ir.Operand, tapeSize int, at int) (int, error) {
+	var stores bytes.Buffer
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i].Kind() == ir.KindImm {
+			if _, err := WritePush(&stores, values[i].Bytes(), tapeSize); err != nil {
+				return 0, err
+			}
+		}
+		if i >= fn.Feeds {
+			if _, err := stores.Write([]byte{OpPop}); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if _, err := WritePush2(&stores, fn.feedAt(i)); err != nil {
+			return 0, err
+		}
+		if _, err := stores.Write([]byte{OpMemoryStore}); err != nil {
+			return 0, err
+		}
+	}
+	for i := len(values); i < fn.Feeds; i++ {
+		if _, err := stores.Write([]byte{OpPush1, 0x00}); err != nil {
+			return 0, err
+		}
+		if _, err := WritePush2(&stores, fn.feedAt(i)); err != nil {
+			return 0, err
+		}
+		if _, err := stores.Write([]byte{OpMemoryStore}); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := w.Write(stores.Bytes()); err != nil {
+		return 0, err
+	}
+
+	back := at + stores.Len() + PUSH_TWO_SIZE + PUSH_TWO_SIZE + 1 + PUSH_TWO_SIZE + 1
+	if _, err := WritePush2(w, back); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, fn.Frame); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpMemoryStore}); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, fn.Entry); err != nil {
+		return 0, err
+	}
+	return w.Write([]byte{OpJump, OpJumpDestiny})
+}
+
+// WriteGetFeedOfFunction reads a position out of the frame of the function being written. The
+// caller stored a value already cut to the width, so there is nothing to mask.
+func WriteGetFeedOfFunction(w io.Writer, fn Function, left []byte) (int, error) {
+	if _, err := WritePush2(w, fn.feedAt(int(byteutil.ToUint64(left)))); err != nil {
+		return 0, err
+	}
+	return w.Write([]byte{OpMemoryLoad})
+}
+
+// WriteFunctionReturn jumps back to whoever called, leaving the value on the stack.
+func WriteFunctionReturn(w io.Writer, fn Function) (int, error) {
+	if _, err := WritePush2(w, fn.Frame); err != nil {
+		return 0, err
+	}
+	return w.Write([]byte{OpMemoryLoad, OpJump})
+}
+
+// balance leaves a function's stack as it found it, plus the value it answers with.
+//
+// A scope the dispatcher reaches never had to: RETURN ends the call with whatever is under
+// the answer. A function jumps back into its caller, which finds what the callee left behind
+// under its own values — so every value nobody takes is dropped where it was made, and so is
+// the value of an "if" nobody takes, in each arm that leaves one.
+func balance(insts []ir.Instruction) []ir.Instruction {
+	taken := labelsTaken(insts)
+	arms := armsOf(insts)
+	// What takes an "if" as a value, which an arm answering under it does not.
+	values := make(map[string]int)
+	for _, inst := range insts {
+		for _, operand := range consumes(inst) {
+			if inst.GetOpCode() == ir.OpReturn && bytes.Equal(operand.Bytes(), inst.GetLeft().Bytes()) {
+				continue
+			}
+			values[byteutil.ToHex(operand.Bytes())]++
+		}
+	}
+
+	out := make([]ir.Instruction, len(insts))
+	for at, inst := range insts {
+		op := inst.GetOpCode()
+		label := byteutil.ToHex(inst.GetLabel())
+		out[at] = inst
+		arm := op == ir.OpReturn && arms[byteutil.ToHex(inst.GetLeft().Bytes())]
+		if (produces(op) && taken[label] == 0) || (arm && values[byteutil.ToHex(inst.GetLeft().Bytes())] == 0) {
+			note, ok := inst.(tuned)
+			if !ok {
+				note = tuned{Instruction: inst}
+			}
+			note.popped = true
+			out[at] = note
+		}
+	}
+	return out
+}
@@ -0,0 +1,300 @@
+package evm
+
+import "github.com/guiferpa/aurora/byteutil"
+
+// What a call costs, read off the bytecode.
+//
+// Every opcode has a price, and most of it is known before anything runs: ADD is 3 whatever
+// it adds, a JUMPI is 10 whichever way it goes. What is not known is the part that depends on
+// the run — how far memory grows, how many words a copy moves, whether a slot was touched
+// before. So this answers the part that is known, and says so: it is a floor for those, an
+// exact figure for everything else, and the measured run beside it in "aurora build --gas" is
+// what covers the rest.
+//
+// It is counted per basic block — a straight run of instructions that is entered at its top
+// and left at its bottom — because that is the unit a jump cannot split. The cost of a scope
+// is then the cost of the most expensive way through its blocks that answers. A way that can
+// come back on itself has no most expensive way, and neither does a jump whose address is only
+// known at run time: both are said to be unbounded rather than given a number that is wrong.
+//
+// A call is the one jump that comes back. It is priced as what it jumps into — the most
+// expensive way through the function, calls and all — added to the block that makes it, which
+// then carries on at the JUMPDEST the function returns to.
+
+// StaticGas answers what an opcode costs before anything it depends on is known.
+//
+// Storage is priced at a first touch within the call, which is what a call that reads a
+// global pays, and EXP at an exponent the width of a tape, since that is as wide as one gets.
+// Memory expansion and the per-word part of a copy or a hash are left out: they are the run's
+// to say.
+func StaticGas(op byte, tapeSize int) int {
+	switch {
+	case op >= OpPush1 && op <= OpPush32,
+		op >= 0x80 && op <= 0x9f: // DUP1-DUP16, SWAP1-SWAP16
+		return 3
+	case op >= 0xa0 && op <= 0xa4: // LOG0-LOG4
+		return 375 * int(op-0xa0+1)
+	}
+
+	switch op {
+	case OpStop, OpReturn, OpRevert:
+		return 0
+	case OpJumpDestiny:
+		return 1
+	case OpAddress, OpOrigin, OpCaller, OpCallValue, OpCallDataSize, OpCodeSize, OpGasPrice,
+		OpReturnDataSize, OpCoinBase, OpTimestamp, OpNumber, OpPrevRandao, OpGasLimit,
+		OpChainId, OpBaseFee, OpBlobBaseFee, OpPop, OpProgramCounter, OpMemorySize, OpGas,
+		OpPush0:
+		return 2
+	case OpAdd, OpSub, OpLessThan, OpGreaterThan, OpSignedLessThan, OpSignedGreaterThan,
+		OpEqual, OpIsZero, OpAnd, OpOr, OpXor, OpNot, OpByte, OpShiftLeft, OpShiftRight,
+		OpShiftAritRight, OpCallDataLoad, OpCallDataCopy, OpCodeCopy, OpReturnDataCopy,
+		OpMemoryLoad, OpMemoryStore, OpMemoryStore8, OpMemoryCopy, OpBlobHash:
+		return 3
+	case OpMul, OpDiv, OpSignedDiv, OpMod, OpSignedMod, OpSignedExtend, OpSelfBalance:
+		return 5
+	case OpAddMod, OpMulMod, OpJump:
+		return 8
+	case OpExp:
+		return 10 + 50*byteutil.TapeSize(tapeSize)
+	case OpJumpIf:
+		return 10
+	case OpBlockHash:
+		return 20
+	case OpKECCAK256:
+		return 30
+	case OpTransientLoad, OpTransientStore:
+		return 100
+	case OpStorageLoad:
+		return 2100
+	case OpStorageStore:
+		// A cold slot set from zero: the most one write can cost.
+		return 22100
+	case OpBalance, OpExtCodeSize, OpExtCodeCopy, OpExtCodeHash, OpCall, OpStaticCall:
+		// An account touched for the first time. What a call spends on the other side is
+		// the other contract's, and only the run knows it.
+		return 2600
+	}
+	return 0
+}
+
+// A Block is one basic block of a scope: where it begins and ends in the runtime, and what it
+// costs to run through.
+type Block struct {
+	At, End int
+	Gas     int
+}
+
+// ScopeGas is what running one scope costs.
+type ScopeGas struct {
+	Selector []byte
+	// Dispatch is what the dispatcher spends reaching the body: every entry checked before
+	// this one, and this one.
+	Dispatch int
+	Blocks   []Block
+	// Body is the most expensive way through the blocks that answers, and means nothing
+	// when the scope is unbounded.
+	Body      int
+	Unbounded bool
+}
+
+// Gas answers the cost of a call of each scope of a binary, in the order the bodies are laid
+// out.
+func Gas(code []byte, tapeSize int) []ScopeGas {
+	sections := Sections(code)
+	functions := make(map[int][]Op)
+	for _, section := range sections {
+		if section.Kind == SectionFunction && len(section.Ops) > 0 {
+			functions[section.Ops[0].Offset] = section.Ops
+		}
+	}
+	called := calledGas(functions, tapeSize)
+
+	var entries []Op
+	scopes := make([]ScopeGas, 0)
+	for _, section := range sections {
+		switch section.Kind {
+		case SectionDispatcher:
+			entries = section.Ops
+		case SectionScope:
+			scope := ScopeGas{Selector: section.Selector, Dispatch: dispatchGas(entries, section.Selector, tapeSize)}
+			scope.Blocks, scope.Body, scope.Unbounded = blocksOf(section.Ops, tapeSize, called)
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// called prices a call by where it jumps to: what the function there costs, and whether it
+// has a most expensive way through at all.
+type called func(entry int) (gas int, bounded bool)
+
+// calledGas prices the functions of a runtime, each once. A function that is not there has no
+// price, and neither has one reached again while its own is being worked out — the builder
+// writes no such call, but bytes from elsewhere can.
+func calledGas(functions map[int][]Op, tapeSize int) called {
+	type priced struct {
+		gas     int
+		bounded bool
+	}
+	prices := make(map[int]priced)
+	var price called
+	price = func(entry int) (int, bool) {
+		if known, ok := prices[entry]; ok {
+			return known.gas, known.bounded
+		}
+		ops, ok := functions[entry]
+		if !ok {
+			return 0, false
+		}
+		prices[entry] = priced{}
+		_, gas, unbounded := blocksOf(ops, tapeSize, price)
+		prices[entry] = priced{gas: gas, bounded: !unbounded}
+		return gas, !unbounded
+	}
+	return price
+}
+
+// dispatchGas adds up the entries of the dispatcher up to the one that checks for the
+// selector. Every entry is the same instructions, so all but the last fall through at the
+// same price.
+func dispatchGas(ops []Op, selector []byte, tapeSize int) int {
+	gas := 0
+	for i := 0; i+dispatcherOps <= len(ops); i += dispatcherOps {
+		for _, op := range ops[i : i+dispatcherOps] {
+			gas += StaticGas(op.Code, tapeSize)
+		}
+		if string(ops[i+4].Data) == string(selector) {
+			break
+		}
+	}
+	return gas
+}
+
+// blocksOf splits a body into its basic blocks and answers the most expensive way through them
+// that does not end in a revert — a guard that fails costs less than the call it stops, and is
+// not what anybody is budgeting for.
+func blocksOf(ops []Op, tapeSize int, calls called) ([]Block, int, bool) {
+	if len(ops) == 0 {
+		return nil, 0, false
+	}
+
+	// A block begins where a jump can land and after anything that leaves.
+	type block struct {
+		Block
+		from, to int // the ops it holds
+		next     []int
+		reverts  bool
+	}
+	blocks := make([]*block, 0)
+	start := 0
+	for i, op := range ops {
+		if op.Code == OpJumpDestiny && i > start {
+			blocks = append(blocks, &block{from: start, to: i})
+			start = i
+		}
+		if leaves(op.Code) || op.Code == OpJumpIf {
+			blocks = append(blocks, &block{from: start, to: i + 1})
+			start = i + 1
+		}
+	}
+	if start < len(ops) {
+		blocks = append(blocks, &block{from: start, to: len(ops)})
+	}
+
+	end := ops[len(ops)-1].Offset + 1 + len(ops[len(ops)-1].Data)
+	at := make(map[int]int, len(blocks))
+	for i, b := range blocks {
+		b.At = ops[b.from].Offset
+		b.End = end
+		if b.to < len(ops) {
+			b.End = ops[b.to].Offset
+		}
+		for _, op := range ops[b.from:b.to] {
+			b.Gas += StaticGas(op.Code, tapeSize)
+		}
+		at[b.At] = i
+	}
+
+	unbounded := false
+	for i, b := range blocks {
+		last := b.to - 1
+		code := ops[last].Code
+		b.reverts = code == OpRevert
+		if entry, ok := callAt(ops, last); ok {
+			gas, bounded := calls(entry)
+			to, inside := at[ops[last+1].Offset]
+			if !bounded || !inside {
+				unbounded = true
+			} else {
+				b.Gas += gas
+				b.next = append(b.next, to)
+			}
+		} else if returnAt(ops, last) {
+			// Back to whoever called, which is where the rest of the way is counted.
+		} else if code == OpJump || code == OpJumpIf {
+			target, ok := jumpTarget(ops[b.from:b.to], len(ops[b.from:b.to])-1)
+			to, inside := at[target]
+			// Back to where it has been, or somewhere it cannot say: no way through has a
+			// most expensive one.
+			if !ok || !inside || to <= i {
+				unbounded = true
+			} else {
+				b.next = append(b.next, to)
+			}
+		}
+		if !leaves(code) && i+1 < len(blocks) {
+			b.next = append(b.next, i+1)
+		}
+	}
+
+	answered := make([]Block, len(blocks))
+	for i, b := range blocks {
+		answered[i] = b.Block
+	}
+	if unbounded {
+		return answered, 0, true
+	}
+
+	// Every jump goes forward, so the way through is worked out from the last block back.
+	const never = -1
+	cost := make([]int, len(blocks))
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b := blocks[i]
+		switch {
+		case b.reverts:
+			cost[i] = never
+		case len(b.next) == 0:
+			cost[i] = b.Gas
+		default:
+			cost[i] = never
+			for _, next := range b.next {
+				if cost[next] != never {
+					cost[i] = max(cost[i], b.Gas+cost[next])
+				}
+			}
+		}
+	}
+	return answered, max(cost[0], 0), false
+}
+
+// leaves answers whether control never goes on to the next instruction.
+func leaves(op byte) bool {
+	switch op {
+	case OpStop, OpReturn, OpRevert, OpJump, 0xfe, 0xff: // INVALID, SELFDESTRUCT
+		return true
+	}
+	return false
+}
+
+// jumpTarget answers where the jump at the end of a run of instructions goes, when it says so
+// itself: an address pushed right before it, or the distance a guard counts from its own PC.
+func jumpTarget(ops []Op, jump int) (int, bool) {
+	if jump >= 1 && pushes(ops[jump-1], OpPush2) {
+		return int(byteutil.ToUint64(ops[jump-1].Data)), true
+	}
+	if jump >= 3 && ops[jump-3].Code == OpProgramCounter && pushes(ops[jump-2], OpPush2) && ops[jump-1].Code == OpAdd {
+		return ops[jump-3].Offset + int(byteutil.ToUint64(ops[jump-2].Data)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// A scope with no jump in it costs every instruction in it.
+func TestGasOfAStraightScope(t *testing.T) {
+	code, _, _ := buildMapped(t, `ident add = defer { feed(0) + feed(1); };`)
+
+	scopes := Gas(code, byteutil.DefaultTapeSize)
+	if len(scopes) != 1 {
+		t.Fatalf("got %d scopes, want 1", len(scopes))
+	}
+	scope := scopes[0]
+	if scope.Unbounded {
+		t.Fatalf("a straight scope is %+v", scope)
+	}
+
+	want := 0
+	for _, section := range Sections(code) {
+		if section.Kind == SectionScope {
+			for _, op := range section.Ops {
+				want += StaticGas(op.Code, byteutil.DefaultTapeSize)
+			}
+		}
+	}
+	if scope.Body != want {
+		t.Errorf("the body costs %d, want %d", scope.Body, want)
+	}
+	if scope.Dispatch == 0 {
+		t.Error("reaching the body through the dispatcher cost nothing")
+	}
+}
+
+// A guard that fails is not the way a call answers, so its revert is not what the scope costs.
+func TestGasLeavesOutTheRevertOfAGuard(t *testing.T) {
+	code, _, _ := buildMapped(t, `ident half = defer { feed(0) / feed(1); };`)
+
+	scope := Gas(code, byteutil.DefaultTapeSize)[0]
+	if scope.Unbounded {
+		t.Fatalf("a division is unbounded: %+v", scope)
+	}
+	total := 0
+	for _, block := range scope.Blocks {
+		total += block.Gas
+	}
+	if len(scope.Blocks) < 3 || scope.Body >= total {
+		t.Errorf("the body costs %d of %d across %d blocks, want the revert left out", scope.Body, total, len(scope.Blocks))
+	}
+}
+
+// A later scope is reached past every entry before it, and pays for each.
+func TestGasOfDispatchGrowsWithTheEntriesBefore(t *testing.T) {
+	code, _, _ := buildMapped(t, `ident a = defer { 1; };
+ident b = defer { 2; };
+`)
+
+	scopes := Gas(code, byteutil.DefaultTapeSize)
+	if len(scopes) != 2 || scopes[1].Dispatch != 2*scopes[0].Dispatch {
+		t.Errorf("the dispatch costs %+v", scopes)
+	}
+}
+
+// A jump back to where it has been is a loop, and a loop has no most expensive way through.
+func TestGasOfALoopIsUnbounded(t *testing.T) {
+	ops := Disassemble([]byte{OpJumpDestiny, OpPush1, 0x01, OpPush2, 0x00, 0x00, OpJumpIf, OpStop})
+
+	_, _, unbounded := blocksOf(ops, byteutil.DefaultTapeSize, calledGas(nil, byteutil.DefaultTapeSize))
+	if !unbounded {
+		t.Error("a jump back to the top was given a cost")
+	}
+}
+
+// A call comes back, so a scope that makes one has a most expensive way through like any other
+// — and it is dearer than the scope it calls, which it runs the whole of.
+func TestGasOfACallIsWhatItJumpsInto(t *testing.T) {
+	code, _, _ := buildMapped(t, `ident square = defer { feed(0) * feed(0); };
+ident f = defer { square(feed(0)) + 1; };
+`)
+
+	scopes := Gas(code, byteutil.DefaultTapeSize)
+	if len(scopes) != 2 || scopes[1].Unbounded {
+		t.Fatalf("the scopes cost %+v", scopes)
+	}
+	if scopes[1].Body <= scopes[0].Body {
+		t.Errorf("the call costs %d and the scope it calls %d", scopes[1].Body, scopes[0].Body)
+	}
+}
@@ -9,14 +9,15 @@ func GetRuntimeCodeLength(rc *RuntimeCode) int {
 	for _, r := range rc.Dispatchers {
 		l += r.Code.Len()
 	}
-	if rc.Root != nil {
-		l += rc.Root.Len()
+	for _, fn := range rc.Functions {
+		l += fn.Code.Len()
 	}
 	return l
 }
 
 // GetCalldataArgsOffset returns the calldata byte offset for the Nth argument (0-based).
-// ABI layout: selector at 0, then each arg in a 32-byte slot: arg0 at 0x20, arg1 at 0x40, arg2 at 0x60, ...
+// ABI layout: the 4-byte selector at 0, then each arg in a 32-byte slot right after it: arg0
+// at 0x04, arg1 at 0x24, arg2 at 0x44, ... — the layout every Solidity caller writes.
 func GetCalldataArgsOffset(index uint64) byte {
-	return byte(CALLDATA_SLOT_READABLE * (index + 1))
+	return byte(SELECTOR_SIZE + CALLDATA_SLOT_READABLE*index)
 }
@@ -11,17 +11,17 @@ func TestGetCalldataArgsOffset(t *testing.T) {
 		{
 			"sample_get_calldata_args_index_from_bytes_1",
 			0,
-			0x20, // 32 bytes
+			0x04, // right after the selector
 		},
 		{
 			"sample_get_calldata_args_index_from_bytes_2",
 			1,
-			0x40, // 64 bytes
+			0x24, // 4 + 32 bytes
 		},
 		{
 			"sample_get_calldata_args_index_from_bytes_3",
 			2,
-			0x60, // 96 bytes (third slot)
+			0x44, // 4 + 64 bytes (third slot)
 		},
 	}
 
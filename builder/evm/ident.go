@@ -1,7 +1,30 @@
 package evm
 
+// IdentManager says where the names of the code being written live.
+//
+// A name bound inside a scope lives in memory, which lasts as long as the call. A name bound at
+// the top of the program is bound by the constructor, once, at deployment, and read by every
+// call after it — so it lives in storage, the one place that outlasts the constructor. Those
+// are the globals: every manager of a program shares them, and a scope reading a name it did
+// not bind itself reads the slot the constructor wrote.
 type IdentManager struct {
 	offsetIdents map[string]int
+	globals      map[string]int
+	// constructor is set while the top of the program is written: what it binds goes to
+	// storage, and what it feeds is read from the arguments appended to the code deployed.
+	constructor *Constructor
+	// functions are the scopes a call in this code reaches, by the name it calls them by.
+	functions map[string]Function
+	// function is set while a function is written: its feeds are read from its frame, its
+	// names are bound after them, and it answers by jumping back.
+	function *Function
+}
+
+// A Constructor is what writing the top of a program needs to know about where it runs.
+type Constructor struct {
+	// ArgsAt is where the constructor's arguments begin in the code being deployed: right
+	// after all of it, which is where a deployment appends them.
+	ArgsAt int
 }
 
 func (m *IdentManager) GetOffset(ident []byte) int {
@@ -16,6 +39,62 @@ func (m *IdentManager) GetLength() uint {
 	return uint(len(m.offsetIdents))
 }
 
+// Local answers the memory offset of a name this scope bound.
+func (m *IdentManager) Local(ident []byte) (int, bool) {
+	offset, ok := m.offsetIdents[string(ident)]
+	return offset, ok
+}
+
+// Global answers the storage slot of a name the top of the program binds.
+func (m *IdentManager) Global(ident []byte) (int, bool) {
+	slot, ok := m.globals[string(ident)]
+	return slot, ok
+}
+
 func NewIdentManager() *IdentManager {
-	return &IdentManager{offsetIdents: make(map[string]int)}
+	return &IdentManager{offsetIdents: make(map[string]int), globals: make(map[string]int)}
+}
+
+// NewScopeManager answers the manager of one deferred scope: memory of its own, and the
+// globals of the program it belongs to.
+func NewScopeManager(globals map[string]int) *IdentManager {
+	return &IdentManager{offsetIdents: make(map[string]int), globals: globals}
+}
+
+// NewCallingManager answers the manager of a scope that calls others: the functions are what
+// its calls reach.
+func NewCallingManager(globals map[string]int, functions map[string]Function) *IdentManager {
+	return &IdentManager{offsetIdents: make(map[string]int), globals: globals, functions: functions}
+}
+
+// NewFunctionManager answers the manager of a scope written as a function, whose memory is
+// its frame.
+func NewFunctionManager(globals map[string]int, functions map[string]Function, function Function) *IdentManager {
+	return &IdentManager{offsetIdents: make(map[string]int), globals: globals, functions: functions, function: &function}
+}
+
+// Function answers what a call to a name reaches, when it reaches anything.
+func (m *IdentManager) Function(name []byte) (Function, bool) {
+	fn, ok := m.functions[string(name)]
+	return fn, ok
+}
+
+// localsAt answers where the names this code binds begin in memory.
+func (m *IdentManager) localsAt() int {
+	if m.function != nil {
+		return m.function.localsAt()
+	}
+	return 0
+}
+
+// NewConstructorManager answers the manager of the top of the program, which binds the
+// globals.
+func NewConstructorManager(globals map[string]int, constructor Constructor) *IdentManager {
+	return &IdentManager{offsetIdents: make(map[string]int), globals: globals, constructor: &constructor}
+}
+
+// measuring answers a manager of the same kind with nothing bound yet, for measuring code
+// that is about to be written with this one.
+func (m *IdentManager) measuring() *IdentManager {
+	return &IdentManager{offsetIdents: make(map[string]int), globals: m.globals, constructor: m.constructor, functions: m.functions, function: m.function}
 }
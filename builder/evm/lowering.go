@@ -1,6 +1,8 @@
 package evm
 
 import (
+	"bytes"
+
 	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/wire/ir"
 )
@@ -76,12 +78,15 @@ func flipped(op byte) bool {
 
 // produces answers whether an instruction leaves its value on the stack, under its own label.
 //
+// A call does, the value the callee answers with, and so does a call to another contract.
+//
 // A binding does not: its value goes to memory and the stack comes out as it went in. That is
 // why it is not here, and why the one place a binding is read as a value — a scope whose last
 // expression is one — is handled where that is known.
 func produces(op byte) bool {
 	switch op {
-	case ir.OpSave, ir.OpGetFeed, ir.OpLoad,
+	case ir.OpSave, ir.OpGetFeed, ir.OpLoad, ir.OpCall, ir.OpContractCall, ir.OpStaticCall,
+		ir.OpCaller, ir.OpCallValue, ir.OpTimestamp, ir.OpBlockNumber, ir.OpChainID,
 		ir.OpAdd, ir.OpSubtract, ir.OpMultiply, ir.OpDivide, ir.OpExponential,
 		ir.OpEquals, ir.OpDiff, ir.OpBigger, ir.OpSmaller, ir.OpAnd, ir.OpOr:
 		return true
@@ -98,15 +103,6 @@ func Lowering(insts []ir.Instruction, tapeSize int) []ir.Instruction {
 	return ResolveOperandsOrder(insts, tapeSize)
 }
 
-// ResolveOperandsOrder emits every value right before whoever takes it.
-//
-// An instruction that leaves a value is held back under its label rather than emitted where it
-// was written: the code that produces it belongs next to the code that eats it. Holding it
-// back is safe because everything held back is pure — a constant, an argument, a read of
-// memory, or arithmetic over those — so moving it moves nothing observable.
-//
-// A value nobody takes has nowhere to be moved to, so it stays where it was written. That is
-// the last expression of a scope, which is the scope's answer.
 // divides answers whether control can leave an instruction for somewhere other than the next
 // one, or arrive at it from somewhere other than the one before.
 //
@@ -115,9 +111,24 @@ func Lowering(insts []ir.Instruction, tapeSize int) []ir.Instruction {
 // not: the value would be produced only when that side runs, and whoever takes it may be
 // reached the other way. It is the rule every compiler keeps, and it is why a scheduler works
 // inside a block and never through one.
+//
+// A guard is one too: the program can stop there. A division held back past it would fault
+// after the guard instead of before, and the call would revert with the other reason.
+//
+// A call to a scope is not one, though control does leave for the callee: it comes back to the
+// next instruction, always, so from here it is a value like a sum is. It is held like one —
+// which is what puts it on the right side of a subtraction — and it is as pure as one, since
+// nothing a scope does writes anything.
+//
+// A call to another contract is one, though it comes back as a call to a scope does: the other
+// contract may write, and whether it sees this call before or after another one is something
+// it can answer differently to. So it is made where it was written, and what was held before
+// it goes out ahead of it. The evaluator makes calls in the order they were written, and the
+// chain makes them in the same order.
 func divides(op byte) bool {
 	switch op {
-	case ir.OpIf, ir.OpJump, ir.OpReturn, ir.OpBeginScope, ir.OpDefer, ir.OpCall:
+	case ir.OpIf, ir.OpJump, ir.OpReturn, ir.OpBeginScope, ir.OpDefer, ir.OpRequire,
+		ir.OpContractCall, ir.OpStaticCall:
 		return true
 	default:
 		return false
@@ -129,7 +140,9 @@ func divides(op byte) bool {
 // An instruction that leaves a value is held back under its label rather than emitted where it
 // was written: the code that produces it belongs next to the code that eats it. Holding it
 // back is safe because everything held back is pure — a constant, an argument, a read of
-// memory, or arithmetic over those — so moving it moves nothing observable.
+// memory, or arithmetic over those — so moving it moves nothing observable. A division can
+// revert, but a revert undoes everything before it, so where on the run it happens is not
+// observable either.
 //
 // It is held back only as far as the next place control can go. Anything still waiting when
 // one of those is reached is emitted first, in the order it was written, because past that
@@ -137,6 +150,10 @@ func divides(op byte) bool {
 //
 // A value nobody takes has nowhere to be moved to, so it stays where it was written. That is
 // the last expression of a scope, which is the scope's answer.
+//
+// A value that went out early — ahead of a call to another contract, or as that call — is on
+// the stack in the order it was written. For an operation that reads its two the other way
+// round that is the wrong one on top, and it is left a note to turn them over.
 func ResolveOperandsOrder(insts []ir.Instruction, tapeSize int) []ir.Instruction {
 	taken := labelsTaken(insts)
 	pending := make(map[string][]ir.Instruction)
@@ -163,17 +180,24 @@ func ResolveOperandsOrder(insts []ir.Instruction, tapeSize int) []ir.Instruction
 		op := inst.GetOpCode()
 
 		sequence := make([]ir.Instruction, 0, 3)
+		arrived := make([]bool, 0, 2)
 		for _, operand := range consumes(inst) {
 			label := byteutil.ToHex(operand.Bytes())
 			// A label with nothing under it is a value from outside this scope, or one
 			// already taken. Either way there is nothing to emit for it here.
-			if produced, held := pending[label]; held {
+			produced, held := pending[label]
+			if held {
 				sequence = append(sequence, produced...)
 				delete(pending, label)
 			}
+			arrived = append(arrived, !held)
+		}
+		if flipped(op) && outOfOrder(out, consumes(inst), arrived) {
+			inst = tuned{Instruction: inst, swapped: true}
 		}
 		sequence = append(sequence, inst)
 
+		label := byteutil.ToHex(inst.GetLabel())
 		if divides(op) {
 			// What this takes was just put in front of it, so it goes out whole — and
 			// everything else waiting goes out ahead of it, where it is still on the
@@ -181,10 +205,14 @@ func ResolveOperandsOrder(insts []ir.Instruction, tapeSize int) []ir.Instruction
 			held := sequence
 			flush()
 			out = append(out, held...)
+			// A guard leaves nothing on the stack, like a binding, and answers with the
+			// neutral value when something asks for it.
+			if op == ir.OpRequire && taken[label] > 0 {
+				hold(label, []ir.Instruction{neutralOf(inst, tapeSize)})
+			}
 			continue
 		}
 
-		label := byteutil.ToHex(inst.GetLabel())
 		switch {
 		case produces(op) && taken[label] > 0:
 			hold(label, sequence)
@@ -196,8 +224,7 @@ func ResolveOperandsOrder(insts []ir.Instruction, tapeSize int) []ir.Instruction
 			// A scope whose last expression is a binding answers with the neutral value,
 			// which is what the evaluator answers. On the stack that has to be pushed: the
 			// binding itself left nothing there.
-			sequence = append(sequence, ir.NewInstruction(inst.GetLabel(), ir.OpSave, ir.ImmOf(byteutil.FalseTape(tapeSize), tapeSize), ir.Nothing()))
-			hold(label, sequence)
+			hold(label, append(sequence, neutralOf(inst, tapeSize)))
 		default:
 			out = append(out, sequence...)
 		}
@@ -206,6 +233,37 @@ func ResolveOperandsOrder(insts []ir.Instruction, tapeSize int) []ir.Instruction
 	return out
 }
 
+// outOfOrder answers whether the two values an operation takes reach the stack the wrong way
+// round. Arrived says which of them were on the stack already: those went out in the order they
+// are in out, and the ones held go out now, on top of them, in the order they are taken.
+func outOfOrder(out []ir.Instruction, taken []ir.Operand, arrived []bool) bool {
+	if len(taken) != 2 {
+		return false
+	}
+	switch {
+	case arrived[0] && arrived[1]:
+		return emittedAt(out, taken[0]) > emittedAt(out, taken[1])
+	default:
+		return !arrived[0] && arrived[1]
+	}
+}
+
+// emittedAt answers where the value under a label went out, or -1 when it did not here.
+func emittedAt(out []ir.Instruction, value ir.Operand) int {
+	for at := len(out) - 1; at >= 0; at-- {
+		if bytes.Equal(out[at].GetLabel(), value.Bytes()) {
+			return at
+		}
+	}
+	return -1
+}
+
+// neutralOf answers an instruction that pushes the neutral value under the label of one that
+// left nothing on the stack.
+func neutralOf(inst ir.Instruction, tapeSize int) ir.Instruction {
+	return ir.NewInstruction(inst.GetLabel(), ir.OpSave, ir.ImmOf(byteutil.FalseTape(tapeSize), tapeSize), ir.Nothing())
+}
+
 // labelsTaken counts how many instructions ask for each value.
 func labelsTaken(insts []ir.Instruction) map[string]int {
 	taken := make(map[string]int)
@@ -302,3 +302,30 @@ func TestNothingIsMovedAcrossABranch(t *testing.T) {
 		t.Errorf("what the branch tests is not in front of it:\n%s", ir.Format(lowered))
 	}
 }
+
+// A division written before a guard faults before it, on chain as off: it is not held back
+// past the guard, where the call would revert with the guard's reason instead.
+func TestNothingIsMovedAcrossAGuard(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpGetFeed, ir.Const(0, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("01"), ir.OpDivide, ir.Imm(1, 8), ir.RefTo([]byte("00"))),
+		ir.NewInstruction([]byte("02"), ir.OpGetFeed, ir.Const(1, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("03"), ir.OpRequire, ir.RefTo([]byte("02")), ir.TextOf("no")),
+		ir.NewInstruction([]byte("04"), ir.OpAdd, ir.RefTo([]byte("01")), ir.Imm(1, 8)),
+	}
+
+	lowered := ResolveOperandsOrder(insts, 8)
+
+	var divided, guarded int
+	for i, inst := range lowered {
+		switch inst.GetOpCode() {
+		case ir.OpDivide:
+			divided = i
+		case ir.OpRequire:
+			guarded = i
+		}
+	}
+	if divided > guarded {
+		t.Errorf("the division was moved past the guard:\n%s", ir.Format(lowered))
+	}
+}
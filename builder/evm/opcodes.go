@@ -121,14 +121,27 @@ const (
 )
 
 const (
-	OpReturn byte = 0xf3 // Halt execution returning output data from the last call
-	OpSwap1  byte = 0x90 // Swap 1st and 2nd stack items
+	OpCall       byte = 0xf1 // Message-call into an account
+	OpReturn     byte = 0xf3 // Halt execution returning output data from the last call
+	OpStaticCall byte = 0xfa // Static message-call into an account
+	OpRevert     byte = 0xfd // Halt execution reverting state changes but returning data and remaining gas
+	OpDup1       byte = 0x80 // Duplicate 1st stack item
+	OpDup2       byte = 0x81 // Duplicate 2nd stack item
+	OpDup3       byte = 0x82 // Duplicate 3rd stack item
+	OpDup4       byte = 0x83 // Duplicate 4th stack item
+	OpDup5       byte = 0x84 // Duplicate 5th stack item
+	OpDup6       byte = 0x85 // Duplicate 6th stack item
+	OpDup7       byte = 0x86 // Duplicate 7th stack item
+	OpSwap1      byte = 0x90 // Swap 1st and 2nd stack items
 )
 
 func ToOpByte(op uint32) []byte {
 	return byteutil.NoPadding(byteutil.FromUint32(op))
 }
 
+// ResolveOpCode names an opcode the way evm.codes and every other disassembler does, so a
+// listing can be read side by side with theirs. A few used to be spelled out — EQUAL,
+// SHIFTRIGHT, STORAGELOAD — which nothing else calls them.
 func ResolveOpCode(op byte) string {
 	switch op {
 	case OpStop:
@@ -156,15 +169,15 @@ func ResolveOpCode(op byte) string {
 	case OpSignedExtend:
 		return "SIGNEXTEND"
 	case OpLessThan:
-		return "LESSTHAN"
+		return "LT"
 	case OpGreaterThan:
-		return "GREATERTHAN"
+		return "GT"
 	case OpSignedLessThan:
 		return "SLT"
 	case OpSignedGreaterThan:
 		return "SGT"
 	case OpEqual:
-		return "EQUAL"
+		return "EQ"
 	case OpIsZero:
 		return "ISZERO"
 	case OpAnd:
@@ -178,11 +191,11 @@ func ResolveOpCode(op byte) string {
 	case OpByte:
 		return "BYTE"
 	case OpShiftLeft:
-		return "SHIFTLEFT"
+		return "SHL"
 	case OpShiftRight:
-		return "SHIFTRIGHT"
+		return "SHR"
 	case OpShiftAritRight:
-		return "SHIFTARITRIGHT"
+		return "SAR"
 	case OpKECCAK256:
 		return "KECCAK256"
 
@@ -217,7 +230,7 @@ func ResolveOpCode(op byte) string {
 	case OpReturnDataCopy:
 		return "RETURNDATACOPY"
 	case OpExtCodeHash:
-		return "EXTHEXHASH"
+		return "EXTCODEHASH"
 	case OpBlockHash:
 		return "BLOCKHASH"
 	case OpCoinBase:
@@ -249,15 +262,15 @@ func ResolveOpCode(op byte) string {
 	case OpMemoryStore8:
 		return "MSTORE8"
 	case OpStorageLoad:
-		return "STORAGELOAD"
+		return "SLOAD"
 	case OpStorageStore:
-		return "STORAGESTORE"
+		return "SSTORE"
 	case OpJump:
 		return "JUMP"
 	case OpJumpIf:
 		return "JUMPI"
 	case OpProgramCounter:
-		return "PROGRAMCOUNTER"
+		return "PC"
 	case OpMemorySize:
 		return "MSIZE"
 	case OpGas:
@@ -265,9 +278,9 @@ func ResolveOpCode(op byte) string {
 	case OpJumpDestiny:
 		return "JUMPDEST"
 	case OpTransientLoad:
-		return "TRANSIENTLOAD"
+		return "TLOAD"
 	case OpTransientStore:
-		return "TRANSIENTSTORE"
+		return "TSTORE"
 	case OpMemoryCopy:
 		return "MCOPY"
 	case OpPush0:
@@ -336,8 +349,28 @@ func ResolveOpCode(op byte) string {
 		return "PUSH31"
 	case OpPush32:
 		return "PUSH32"
+	case OpCall:
+		return "CALL"
 	case OpReturn:
 		return "RETURN"
+	case OpStaticCall:
+		return "STATICCALL"
+	case OpRevert:
+		return "REVERT"
+	case OpDup1:
+		return "DUP1"
+	case OpDup2:
+		return "DUP2"
+	case OpDup3:
+		return "DUP3"
+	case OpDup4:
+		return "DUP4"
+	case OpDup5:
+		return "DUP5"
+	case OpDup6:
+		return "DUP6"
+	case OpDup7:
+		return "DUP7"
 	case OpSwap1:
 		return "SWAP1"
 	}
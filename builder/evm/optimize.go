@@ -0,0 +1,293 @@
+package evm
+
+import (
+	"bytes"
+
+	"github.com/holiman/uint256"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// Making a lowered scope smaller and cheaper without changing what it answers.
+//
+// The writer is mechanical on purpose: every instruction becomes the same opcodes wherever it
+// is, which is what keeps the measurement of a scope and the writing of it the same thing. The
+// price is that it cannot see past one instruction — it masks a sum that the next instruction
+// masks again, pushes two numbers to add them when the program wrote both down, and reads a
+// name back out of memory right after storing it there.
+//
+// This is where that is seen, and it is a pass over the lowered stream rather than over the
+// bytes for the same reason the lowering is: the stream still says which value is which. What
+// it cannot say by changing the stream — leave this result unmasked, keep a copy of that one —
+// it says in a note the writer reads, so what is measured is still what is written.
+//
+// It is optional, for "aurora build -O". Every rewrite here is checked by the differential
+// harness, which calls the same scopes built both ways against the evaluator.
+
+// tuned is an instruction the optimizer left a note on for the writer.
+type tuned struct {
+	ir.Instruction
+	// unmasked leaves the result of arithmetic as wide as the EVM made it, because the only
+	// instruction that takes it is arithmetic that cuts its own result.
+	unmasked bool
+	// dup keeps a copy on the stack of the value a binding stores, for the read right after.
+	dup bool
+	// kept is that read, which has nothing left to write: the value is already there.
+	kept bool
+	// popped drops the value once it is made, for a function, which has to leave the stack
+	// as it found it. It is the builder's note rather than the optimizer's; see balance.
+	popped bool
+	// swapped turns the two values an operation takes round before it reads them, because
+	// they reached the stack in the order they were written rather than the one it reads
+	// them in. It is the lowering's note; see ResolveOperandsOrder.
+	swapped bool
+}
+
+// positionOf answers where an instruction was in what the builder was handed, under whatever
+// notes were left on it.
+func positionOf(inst ir.Instruction) (int, bool) {
+	switch each := inst.(type) {
+	case placed:
+		return each.position, true
+	case tuned:
+		return positionOf(each.Instruction)
+	}
+	return 0, false
+}
+
+// Optimize answers a lowered scope that answers the same and costs less.
+func Optimize(insts []ir.Instruction, tapeSize int) []ir.Instruction {
+	return tune(dropUnused(fold(insts, tapeSize)), tapeSize)
+}
+
+// fold works out what the program wrote down the whole of: arithmetic and comparisons over
+// numbers it already knows become the number, pushed once.
+//
+// An operand is known when it is written into the instruction, or when the instruction right
+// before pushed it as a literal — the lowering puts a value right before whoever takes it, so
+// a sum of literals arrives as two pushes and an add, and folding the add makes the next one
+// foldable too.
+//
+// A division by zero is left where it is: it fails where it runs, which is the point of it.
+//
+// Where the jumps land is worked out once, and again only when a fold drops instructions and
+// moves them: asking for every instruction made the pass grow with the square of the scope.
+func fold(insts []ir.Instruction, tapeSize int) []ir.Instruction {
+	out := append([]ir.Instruction{}, insts...)
+	landings := landingsOf(out)
+	for at := 0; at < len(out); at++ {
+		value, ok := constantOf(out, at, landings, tapeSize)
+		if !ok {
+			continue
+		}
+		from := at - len(consumes(out[at]))
+		inst := out[at]
+		out[from] = rebuilt(inst, ir.NewInstruction(inst.GetLabel(), ir.OpSave, ir.ImmOf(value, tapeSize), ir.Nothing()).At(inst.GetOrigin()))
+		if at > from {
+			for range at - from {
+				out = drop(out, from+1)
+			}
+			landings = landingsOf(out)
+		}
+		at = from
+	}
+	return out
+}
+
+// constantOf answers the value of an instruction when every operand of it is known. landings
+// are where the jumps of insts arrive.
+func constantOf(insts []ir.Instruction, at int, landings map[int]bool, tapeSize int) ([]byte, bool) {
+	inst := insts[at]
+	taken := consumes(inst)
+	from := at - len(taken)
+	if from < 0 {
+		return nil, false
+	}
+
+	// What is pushed right before, one literal for every value taken and nothing arriving in
+	// between: a jump landing there would bring a value of its own.
+	known := make(map[string][]byte, len(taken))
+	for i, operand := range taken {
+		producer := insts[from+i]
+		if producer.GetOpCode() != ir.OpSave || !bytes.Equal(producer.GetLabel(), operand.Bytes()) {
+			return nil, false
+		}
+		known[byteutil.ToHex(operand.Bytes())] = producer.GetLeft().Bytes()
+	}
+	for i := from + 1; i <= at; i++ {
+		if landings[i] {
+			return nil, false
+		}
+	}
+
+	values := make([]*uint256.Int, 0, 2)
+	for _, operand := range inst.GetOperands() {
+		switch operand.Kind() {
+		case ir.KindImm:
+			values = append(values, byteutil.ToUint256(operand.Bytes(), tapeSize))
+		case ir.KindRef:
+			values = append(values, byteutil.ToUint256(known[byteutil.ToHex(operand.Bytes())], tapeSize))
+		}
+	}
+	if len(values) != 2 {
+		return nil, false
+	}
+	return folded(inst.GetOpCode(), values[0], values[1], tapeSize)
+}
+
+// folded answers what the evaluator answers for an operation over two known values.
+//
+// And and or are not here. They are the one place the two backends reach the same answer by
+// different roads — a boolean on one side and bits on the other — and a folded one would
+// answer by neither.
+func folded(op byte, x, y *uint256.Int, tapeSize int) ([]byte, bool) {
+	condition := func(holds bool) ([]byte, bool) {
+		if holds {
+			return byteutil.TrueTape(tapeSize), true
+		}
+		return byteutil.FalseTape(tapeSize), true
+	}
+
+	v := new(uint256.Int)
+	switch op {
+	case ir.OpAdd:
+		v.Add(x, y)
+	case ir.OpSubtract:
+		v.Sub(x, y)
+	case ir.OpMultiply:
+		v.Mul(x, y)
+	case ir.OpDivide:
+		if y.IsZero() {
+			return nil, false
+		}
+		v.Div(x, y)
+	case ir.OpExponential:
+		v.Exp(x, y)
+	case ir.OpEquals:
+		return condition(x.Eq(y))
+	case ir.OpDiff:
+		return condition(!x.Eq(y))
+	case ir.OpBigger:
+		return condition(x.Gt(y))
+	case ir.OpSmaller:
+		return condition(x.Lt(y))
+	default:
+		return nil, false
+	}
+	return byteutil.FromUint256(v, tapeSize), true
+}
+
+// dropUnused leaves out a value nobody takes, which is a push the stack carries to the end of
+// the call for nothing. Only what reads and nothing else goes — a literal, a feed, a name, what
+// the call is — and never the last instruction, which is what a scope that ends in a value
+// answers with.
+func dropUnused(insts []ir.Instruction) []ir.Instruction {
+	taken := labelsTaken(insts)
+	for at := len(insts) - 2; at >= 0; at-- {
+		inst := insts[at]
+		switch inst.GetOpCode() {
+		case ir.OpSave, ir.OpGetFeed, ir.OpLoad,
+			ir.OpCaller, ir.OpCallValue, ir.OpTimestamp, ir.OpBlockNumber, ir.OpChainID:
+			if taken[byteutil.ToHex(inst.GetLabel())] == 0 {
+				insts = drop(insts, at)
+			}
+		}
+	}
+	return insts
+}
+
+// drop leaves one instruction out, and shortens every jump over it by one.
+//
+// The IR counts its jumps in instructions, so taking one away from between a jump and where it
+// lands moves the landing. A jump that lands on the one dropped lands on whatever comes after
+// it, which is where it would have gone next anyway: nothing dropped does anything.
+func drop(insts []ir.Instruction, k int) []ir.Instruction {
+	out := make([]ir.Instruction, 0, len(insts)-1)
+	for at, inst := range insts {
+		if at == k {
+			continue
+		}
+		if at < k {
+			switch inst.GetOpCode() {
+			case ir.OpIf:
+				if ahead := byteutil.ToUint64(inst.GetRight().Bytes()); at+1+int(ahead) > k {
+					inst = rebuilt(inst, ir.NewInstruction(inst.GetLabel(), ir.OpIf, inst.GetLeft(), ir.TargetAt(ahead-1)).At(inst.GetOrigin()))
+				}
+			case ir.OpJump:
+				if ahead := byteutil.ToUint64(inst.GetLeft().Bytes()); at+1+int(ahead) > k {
+					inst = rebuilt(inst, ir.NewInstruction(inst.GetLabel(), ir.OpJump, ir.TargetAt(ahead-1), inst.GetRight()).At(inst.GetOrigin()))
+				}
+			}
+		}
+		out = append(out, inst)
+	}
+	return out
+}
+
+// rebuilt answers an instruction written in place of another, where the other was.
+func rebuilt(was, inst ir.Instruction) ir.Instruction {
+	if position, ok := positionOf(was); ok {
+		return placed{Instruction: inst, position: position}
+	}
+	return inst
+}
+
+// tune leaves the writer the notes it cannot work out one instruction at a time.
+//
+// A sum, a difference or a product is cut to the width modulo 2^(8N), and 2^(8N) divides the
+// 2^256 the EVM wraps at — so when the only thing taking it is another of the three, which
+// cuts its own result, cutting the first one as well changes nothing. At the full width there
+// is no mask to leave out.
+//
+// A binding followed right away by a read of the same name stores the value and loads it
+// straight back; a copy kept on the stack is three gas where the read is a push and a load, or
+// a cold storage slot in the constructor. A read a jump lands on is left alone: whoever jumped
+// there did not leave the copy.
+func tune(insts []ir.Instruction, tapeSize int) []ir.Instruction {
+	landings := landingsOf(insts)
+	taken := labelsTaken(insts)
+	consumer := make(map[string]byte)
+	for _, inst := range insts {
+		for _, operand := range consumes(inst) {
+			consumer[byteutil.ToHex(operand.Bytes())] = inst.GetOpCode()
+		}
+	}
+
+	out := make([]ir.Instruction, len(insts))
+	for at, inst := range insts {
+		op := inst.GetOpCode()
+		label := byteutil.ToHex(inst.GetLabel())
+		note, noted := inst.(tuned)
+		if !noted {
+			note = tuned{Instruction: inst}
+		}
+
+		note.unmasked = byteutil.TapeSize(tapeSize) < byteutil.MaxTapeSize &&
+			wraps(op) && taken[label] == 1 && wraps(consumer[label])
+		note.dup = op == ir.OpIdent && at+1 < len(insts) && !landings[at+1] &&
+			insts[at+1].GetOpCode() == ir.OpLoad &&
+			bytes.Equal(insts[at+1].GetLeft().Bytes(), inst.GetLeft().Bytes())
+		if at > 0 {
+			previous, ok := out[at-1].(tuned)
+			note.kept = op == ir.OpLoad && ok && previous.dup
+		}
+
+		out[at] = inst
+		if noted || note.unmasked || note.dup || note.kept {
+			out[at] = note
+		}
+	}
+	return out
+}
+
+// wraps names the arithmetic whose result is the same whether it is cut before or after: the
+// three that a modulus goes through.
+func wraps(op byte) bool {
+	switch op {
+	case ir.OpAdd, ir.OpSubtract, ir.OpMultiply:
+		return true
+	default:
+		return false
+	}
+}
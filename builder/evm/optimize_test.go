@@ -0,0 +1,136 @@
+package evm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// 2 * 3 + 1 is written down whole, so it is pushed as the 7 it is: the product folds, and the
+// sum over it folds after.
+func TestOptimizeFoldsWhatWasWrittenDown(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpSave, ir.Imm(2, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("01"), ir.OpSave, ir.Imm(3, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("02"), ir.OpMultiply, ir.RefTo([]byte("00")), ir.RefTo([]byte("01"))),
+		ir.NewInstruction([]byte("03"), ir.OpAdd, ir.RefTo([]byte("02")), ir.Imm(1, 8)),
+		ir.NewInstruction([]byte("04"), ir.OpReturn, ir.RefTo([]byte("ff")), ir.RefTo([]byte("03"))),
+	}
+
+	optimized := Optimize(insts, 8)
+
+	if len(optimized) != 2 || optimized[0].GetOpCode() != ir.OpSave {
+		t.Fatalf("want a push and the return:\n%s", ir.Format(optimized))
+	}
+	if got := byteutil.ToUint64(optimized[0].GetLeft().Bytes()); got != 7 {
+		t.Errorf("folded to %d, want 7", got)
+	}
+	if got := string(optimized[0].GetLabel()); got != "03" {
+		t.Errorf("folded under %q, want the label of the sum, which the return names", got)
+	}
+}
+
+// A folded number wraps where the one worked out at run time does: at one byte, 200 + 100 is
+// 44.
+func TestOptimizeWrapsWhatItFolds(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpAdd, ir.Imm(200, 1), ir.Imm(100, 1)),
+	}
+
+	optimized := Optimize(insts, 1)
+
+	if got := optimized[0].GetLeft().Bytes(); !bytes.Equal(got, []byte{44}) {
+		t.Errorf("folded to %x, want 2c", got)
+	}
+}
+
+// A division by zero fails where it runs, so it is left there to run.
+func TestOptimizeLeavesADivisionByZero(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpDivide, ir.Imm(1, 8), ir.Imm(0, 8)),
+	}
+
+	if got := Optimize(insts, 8)[0].GetOpCode(); got != ir.OpDivide {
+		t.Errorf("the division became %s", ir.ResolveOpCode(got))
+	}
+}
+
+// A value nobody takes is not pushed, and the jump over it lands where it landed before.
+func TestOptimizeDropsAValueNobodyTakes(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpGetFeed, ir.Const(0, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("01"), ir.OpIf, ir.RefTo([]byte("00")), ir.TargetAt(3)),
+		ir.NewInstruction([]byte("02"), ir.OpSave, ir.Imm(1, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("03"), ir.OpSave, ir.Imm(42, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("04"), ir.OpReturn, ir.RefTo([]byte("01")), ir.RefTo([]byte("03"))),
+		ir.NewInstruction([]byte("05"), ir.OpGetFeed, ir.Const(1, 8), ir.Nothing()),
+	}
+
+	optimized := Optimize(insts, 8)
+
+	for _, inst := range optimized {
+		if string(inst.GetLabel()) == "02" {
+			t.Fatalf("the value nobody takes is still there:\n%s", ir.Format(optimized))
+		}
+	}
+	landings := landingsOf(optimized)
+	for at, inst := range optimized {
+		if landings[at] && string(inst.GetLabel()) != "05" {
+			t.Errorf("the branch lands on %q, want it where it landed before:\n%s", inst.GetLabel(), ir.Format(optimized))
+		}
+	}
+}
+
+// A sum taken only by a product is not cut: the product cuts its own result, and cutting the
+// sum as well changes nothing. The product is taken by a return, and is cut.
+func TestOptimizeLeavesTheMaskToWhoeverTakesTheSum(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpGetFeed, ir.Const(0, 1), ir.Nothing()),
+		ir.NewInstruction([]byte("01"), ir.OpGetFeed, ir.Const(1, 1), ir.Nothing()),
+		ir.NewInstruction([]byte("02"), ir.OpAdd, ir.RefTo([]byte("00")), ir.RefTo([]byte("01"))),
+		ir.NewInstruction([]byte("03"), ir.OpGetFeed, ir.Const(2, 1), ir.Nothing()),
+		ir.NewInstruction([]byte("04"), ir.OpMultiply, ir.RefTo([]byte("02")), ir.RefTo([]byte("03"))),
+		ir.NewInstruction([]byte("05"), ir.OpReturn, ir.RefTo([]byte("ff")), ir.RefTo([]byte("04"))),
+	}
+
+	masks := func(insts []ir.Instruction) int {
+		var code bytes.Buffer
+		if _, err := WriteCode(&code, NewIdentManager(), insts, 1, 0); err != nil {
+			t.Fatal(err)
+		}
+		return bytes.Count(code.Bytes(), []byte{OpPush1, 0xff, OpAnd})
+	}
+
+	if got, want := masks(Optimize(insts, 1)), masks(insts)-1; got != want {
+		t.Errorf("%d masks, want %d", got, want)
+	}
+	for _, inst := range Optimize(insts, 32) {
+		if _, ok := inst.(tuned); ok {
+			t.Errorf("at the full width there is no mask to leave out, and %s was tuned", ir.ResolveOpCode(inst.GetOpCode()))
+		}
+	}
+}
+
+// A name read right after it is bound is copied on the stack rather than read back.
+func TestOptimizeKeepsABoundValueOnTheStack(t *testing.T) {
+	insts := []ir.Instruction{
+		ir.NewInstruction([]byte("00"), ir.OpGetFeed, ir.Const(0, 8), ir.Nothing()),
+		ir.NewInstruction([]byte("01"), ir.OpIdent, ir.NameOf("a"), ir.RefTo([]byte("00"))),
+		ir.NewInstruction([]byte("02"), ir.OpLoad, ir.NameOf("a"), ir.Nothing()),
+		ir.NewInstruction([]byte("03"), ir.OpAdd, ir.RefTo([]byte("02")), ir.Imm(1, 8)),
+		ir.NewInstruction([]byte("04"), ir.OpReturn, ir.RefTo([]byte("ff")), ir.RefTo([]byte("03"))),
+	}
+
+	var code bytes.Buffer
+	if _, err := WriteCode(&code, NewIdentManager(), Optimize(insts, 8), 8, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(code.Bytes(), []byte{OpDup1}) {
+		t.Errorf("no copy was kept: %x", code.Bytes())
+	}
+	if bytes.Count(code.Bytes(), []byte{OpMemoryLoad}) != 0 {
+		t.Errorf("the name was read back: %x", code.Bytes())
+	}
+}
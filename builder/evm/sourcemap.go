@@ -0,0 +1,107 @@
+package evm
+
+import (
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// Where each byte of a binary was written.
+//
+// Every instruction knows the line and column it was compiled from, and the builder used to
+// let that go the moment it wrote the bytes — so a revert, a trace or a debugger on the EVM
+// side had an offset and nothing to point at. A source map keeps it: every range of bytes an
+// instruction became, with where the instruction was written.
+//
+// It is the builder's own JSON rather than Solidity's compressed format. That one counts a
+// place in bytes from the start of a file, which Aurora does not keep, and packs it into a
+// string nothing but a Solidity tool reads. This one says line and column, the way every
+// diagnostic of the language already does.
+//
+// The builder is handed one stream of instructions and knows no files, so a mapping says which
+// instruction it came from — its position in that stream — and the host, which holds the
+// ranges of every module, says which file that is.
+
+// A Mapping is a range of bytecode and where it was written.
+type Mapping struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+	// Position is the instruction the bytes were written from, in what the builder was handed.
+	Position int    `json:"-"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// Mappings are the ranges of one piece of code, in the order they were written.
+type Mappings []Mapping
+
+// At answers where the byte at an offset was written, when it was written from somewhere.
+func (m Mappings) At(offset int) (Mapping, bool) {
+	for _, mapping := range m {
+		if offset >= mapping.Offset && offset < mapping.Offset+mapping.Length {
+			return mapping, true
+		}
+	}
+	return Mapping{}, false
+}
+
+// A SourceMap is where the bytes of a binary were written. The constructor is counted from the
+// start of the binary and the runtime from its own start, which is what a chain runs and what
+// a trace of a call names.
+//
+// Bytes the builder wrote by itself — the instantiate block, the STOP a call that matched
+// nothing lands on, the JUMPDEST a jump lands on — have no mapping, since nobody wrote them. A
+// dispatcher entry maps to the line its scope was bound on.
+type SourceMap struct {
+	Constructor Mappings `json:"constructor"`
+	Runtime     Mappings `json:"runtime"`
+}
+
+// placed is an instruction that remembers where it was in what the builder was handed.
+//
+// Lowering moves the instructions of a scope around, so after it an instruction's index says
+// nothing; carrying the position along is how the bytes it becomes are traced back to it. An
+// instruction lowering made up itself is not placed, and maps to nothing.
+type placed struct {
+	ir.Instruction
+	position int
+}
+
+// placedFrom answers instructions that remember their position, the first of them at from.
+func placedFrom(insts []ir.Instruction, from int) []ir.Instruction {
+	out := make([]ir.Instruction, len(insts))
+	for i, inst := range insts {
+		out[i] = placed{Instruction: inst, position: from + i}
+	}
+	return out
+}
+
+// mappingsOf answers where the instructions of a piece of code land when it is written at a
+// base, measured the way writeInstructions writes them — with a manager of the same kind.
+func mappingsOf(im *IdentManager, insts []ir.Instruction, tapeSize int, base int) (Mappings, error) {
+	landings := landingsOf(insts)
+	positions, err := positionsOf(im.measuring(), insts, tapeSize, landings, armsOf(insts))
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make(Mappings, 0, len(insts))
+	for at, inst := range insts {
+		position, ok := positionOf(inst)
+		origin := inst.GetOrigin()
+		start := positions[at]
+		if landings[at] {
+			start++
+		}
+		if !ok || !origin.Known() || positions[at+1] == start {
+			continue
+		}
+		mappings = append(mappings, Mapping{
+			Offset:   base + start,
+			Length:   positions[at+1] - start,
+			Position: position,
+			Line:     origin.Line,
+			Column:   origin.Column,
+		})
+	}
+	return mappings, nil
+}
@@ -0,0 +1,111 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// buildMapped builds a source and answers the bytecode, its source map, and where the runtime
+// begins in it.
+func buildMapped(t *testing.T, source string) ([]byte, SourceMap, int) {
+	t.Helper()
+
+	insts, _ := compile(t, source)
+	code, sourceMap, err := NewBuilder(insts, NewBuilderOptions{TapeSize: byteutil.DefaultTapeSize}).BuildWithSourceMap()
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	_, runtime := split(t, source, byteutil.DefaultTapeSize)
+	return code, sourceMap, len(code) - len(runtime)
+}
+
+// The byte a division became points back at the line and column of the division — which is
+// what a revert on it has to say.
+func TestSourceMapPointsAnOpcodeAtItsLine(t *testing.T) {
+	code, sourceMap, at := buildMapped(t, `ident half = defer {
+  feed(0) / 2;
+};
+`)
+
+	var divided int
+	for _, section := range Sections(code) {
+		for _, op := range section.Ops {
+			if section.Kind == SectionScope && op.Code == OpDiv {
+				divided = op.Offset
+			}
+		}
+	}
+	if divided == 0 {
+		t.Fatalf("no DIV in the runtime at %d", at)
+	}
+
+	mapping, ok := sourceMap.Runtime.At(divided)
+	if !ok {
+		t.Fatalf("nothing maps the DIV at %#x: %+v", divided, sourceMap.Runtime)
+	}
+	if mapping.Line != 2 || mapping.Column != 11 {
+		t.Errorf("the DIV points at %d:%d, want 2:11", mapping.Line, mapping.Column)
+	}
+}
+
+// A dispatcher entry points at the line its scope was bound on, and the top of the program is
+// mapped in the constructor, counted from the start of the binary.
+func TestSourceMapOfTheDispatcherAndTheConstructor(t *testing.T) {
+	_, sourceMap, _ := buildMapped(t, `ident rate = feed(0);
+
+ident scale = defer { feed(0) * rate; };
+`)
+
+	entry, ok := sourceMap.Runtime.At(0)
+	if !ok || entry.Line != 3 || entry.Length != DISPATCHER_BYTES_SIZE {
+		t.Errorf("the entry maps to %+v, want all of it on line 3", entry)
+	}
+	if len(sourceMap.Constructor) == 0 {
+		t.Fatal("nothing of the constructor is mapped")
+	}
+	for _, mapping := range sourceMap.Constructor {
+		if mapping.Line != 1 {
+			t.Errorf("the constructor maps to line %d, want 1: %+v", mapping.Line, mapping)
+		}
+	}
+}
+
+// Every range is where the instruction it came from is, in the stream the builder was handed —
+// the position the host names a file by.
+func TestSourceMapKeepsThePositionOfTheInstruction(t *testing.T) {
+	source := `ident rate = feed(0);
+ident scale = defer { feed(0) * rate; };
+`
+	insts, _ := compile(t, source)
+	_, sourceMap, _ := buildMapped(t, source)
+
+	for _, mapping := range append(sourceMap.Constructor, sourceMap.Runtime...) {
+		origin := insts[mapping.Position].GetOrigin()
+		if origin.Line != mapping.Line || origin.Column != mapping.Column {
+			t.Errorf("%+v is at position %d, which is %d:%d", mapping, mapping.Position, origin.Line, origin.Column)
+		}
+	}
+}
+
+// Ranges follow one another and never overlap, so an offset is in one of them at most.
+func TestSourceMapRangesDoNotOverlap(t *testing.T) {
+	code, sourceMap, at := buildMapped(t, `ident pick = defer {
+  ident a = if feed(0) bigger 1 { 2; } else { 3; };
+  a * 2;
+};
+`)
+
+	for _, mappings := range []Mappings{sourceMap.Constructor, sourceMap.Runtime} {
+		end := 0
+		for _, mapping := range mappings {
+			if mapping.Offset < end {
+				t.Errorf("%+v begins before the range before it ends, at %d", mapping, end)
+			}
+			end = mapping.Offset + mapping.Length
+		}
+	}
+	if last := sourceMap.Runtime[len(sourceMap.Runtime)-1]; at+last.Offset+last.Length > len(code) {
+		t.Errorf("%+v runs past the end of the runtime", last)
+	}
+}
@@ -2,6 +2,7 @@ package evm
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/guiferpa/aurora/wire/diag"
 	"github.com/guiferpa/aurora/wire/ir"
@@ -19,26 +20,35 @@ import (
 // OpDefer and OpBeginScope write nothing of their own and are not gaps: the first becomes an
 // entry in the dispatcher, and the second opens a scope the builder lays out flat.
 var handled = map[byte]bool{
-	ir.OpAdd:         true,
-	ir.OpSubtract:    true,
-	ir.OpMultiply:    true,
-	ir.OpDivide:      true,
-	ir.OpSave:        true,
-	ir.OpIdent:       true,
-	ir.OpLoad:        true,
-	ir.OpGetFeed:     true,
-	ir.OpReturn:      true,
-	ir.OpDefer:       true,
-	ir.OpBeginScope:  true,
-	ir.OpIf:          true,
-	ir.OpJump:        true,
-	ir.OpEquals:      true,
-	ir.OpDiff:        true,
-	ir.OpBigger:      true,
-	ir.OpSmaller:     true,
-	ir.OpAnd:         true,
-	ir.OpOr:          true,
-	ir.OpExponential: true,
+	ir.OpAdd:          true,
+	ir.OpSubtract:     true,
+	ir.OpMultiply:     true,
+	ir.OpDivide:       true,
+	ir.OpSave:         true,
+	ir.OpIdent:        true,
+	ir.OpLoad:         true,
+	ir.OpGetFeed:      true,
+	ir.OpReturn:       true,
+	ir.OpDefer:        true,
+	ir.OpBeginScope:   true,
+	ir.OpIf:           true,
+	ir.OpJump:         true,
+	ir.OpEquals:       true,
+	ir.OpDiff:         true,
+	ir.OpBigger:       true,
+	ir.OpSmaller:      true,
+	ir.OpAnd:          true,
+	ir.OpOr:           true,
+	ir.OpExponential:  true,
+	ir.OpRequire:      true,
+	ir.OpCall:         true,
+	ir.OpCaller:       true,
+	ir.OpCallValue:    true,
+	ir.OpTimestamp:    true,
+	ir.OpBlockNumber:  true,
+	ir.OpChainID:      true,
+	ir.OpContractCall: true,
+	ir.OpStaticCall:   true,
 }
 
 // offChain is what is meant to be absent from a chain. Saying so is still worth a line: a
@@ -55,7 +65,6 @@ var offChain = map[byte]string{
 var pending = map[byte]string{
 	ir.OpIf:      "if",
 	ir.OpJump:    "if",
-	ir.OpCall:    "calling a scope",
 	ir.OpPreCall: "calling a scope",
 	ir.OpDiff:    "a comparison",
 	ir.OpEquals:  "a comparison",
@@ -83,17 +92,26 @@ var pending = map[byte]string{
 // place the program used it. That place comes from the instruction: the emitter knows where
 // every node was written and now says so, where before this named a feature and left the
 // person to find it.
+//
+// A call the bytecode does not make is said at every place it is made. The build refuses each
+// of them (see refuseUnreached), and an editor showing only the first would leave the person
+// to find the rest one build at a time.
 func Warnings(insts []ir.Instruction) []diag.Warning {
 	warnings := make([]diag.Warning, 0)
 	said := make(map[string]bool)
+	unreached := unreachedCalls(insts)
 
-	for _, inst := range insts {
+	for at, inst := range insts {
 		op := inst.GetOpCode()
-		if handled[op] {
+		message, call := unreached[at]
+		if handled[op] && !call {
 			continue
 		}
 
-		message, ok := offChain[op]
+		ok := call
+		if !ok {
+			message, ok = offChain[op]
+		}
 		if !ok {
 			name, pendingOp := pending[op]
 			if !pendingOp {
@@ -103,7 +121,7 @@ func Warnings(insts []ir.Instruction) []diag.Warning {
 				"%s does not reach the bytecode yet: a contract using it compiles and does nothing on chain", name)
 		}
 
-		if said[message] {
+		if said[message] && !call {
 			continue
 		}
 		said[message] = true
@@ -116,3 +134,35 @@ func Warnings(insts []ir.Instruction) []diag.Warning {
 
 	return warnings
 }
+
+// unreachedCalls answers the calls a program makes that the bytecode does not, by position, with
+// what to tell the person about each. See function.go for why each of these is not reached, and
+// refuseUnreached for what the build does about them.
+func unreachedCalls(insts []ir.Instruction) map[int]string {
+	scopes := scopesOf(insts)
+	named := byName(scopes)
+	inside := make(map[int]scope)
+	for _, each := range scopes {
+		for at := each.at + 1; at < each.end; at++ {
+			inside[at] = each
+		}
+	}
+
+	unreached := make(map[int]string)
+	for at, inst := range insts {
+		if inst.GetOpCode() != ir.OpCall {
+			continue
+		}
+		name := string(inst.GetLeft().Bytes())
+		caller, ok := inside[at]
+		switch {
+		case !ok:
+			unreached[at] = "calling a scope at the top of a program does not reach the bytecode yet: the constructor makes no calls, so the contract is not built"
+		case !slices.Contains(callsIn(caller.body, named), name):
+			unreached[at] = "calling a scope that is not bound at the top of a program does not reach the bytecode yet, so the contract is not built"
+		case recursive(name, named):
+			unreached[at] = "a scope that calls itself, directly or through another, does not reach the bytecode yet, so the contract is not built"
+		}
+	}
+	return unreached
+}
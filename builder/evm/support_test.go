@@ -1,6 +1,7 @@
 package evm
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -55,9 +56,10 @@ func TestWarningsNamesWhatDoesNotReachTheBytecode(t *testing.T) {
 			want:    []string{"shape does not reach the bytecode yet"},
 		},
 		{
-			name:    "calling a scope",
+			// A call inside a scope is a jump to a function now; the constructor makes none.
+			name:    "calling a scope at the top of a program",
 			opcodes: []byte{ir.OpCall},
-			want:    []string{"calling a scope does not reach the bytecode yet"},
+			want:    []string{"calling a scope at the top of a program does not reach the bytecode yet"},
 		},
 		{
 			// A log is not a gap: it is absent on purpose, and the wording says so.
@@ -206,3 +208,80 @@ func TestEveryPendingFeatureNamesItsPlace(t *testing.T) {
 		})
 	}
 }
+
+// A scope calling another one bound at the top is written as a call, and nothing is said about
+// it. What is said is what still does not reach: a scope that comes back round to itself, and a
+// call of a name that is not a scope at the top.
+func TestACallInsideAScopeIsNotAGap(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		says   string
+	}{
+		{name: "one scope calling another", source: "ident sq = defer { feed(0) * feed(0); };\nident f = defer { sq(feed(0)) + 1; };"},
+		{name: "a scope calling itself", source: "ident f = defer { f(feed(0)); };", says: "a scope that calls itself"},
+		{name: "two scopes calling each other", source: "ident f = defer { g(1); };\nident g = defer { f(1); };", says: "a scope that calls itself"},
+		{name: "a name bound inside the scope", source: "ident f = defer { ident g = defer { 1; }; g(); };", says: "not bound at the top"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, err := lexer.New().GetFilledTokens([]byte(tc.source))
+			if err != nil {
+				t.Fatalf("lexer: %v", err)
+			}
+			tree, err := parser.New().Parse(parser.ParseInput{Filename: "main.ar", Tokens: tokens})
+			if err != nil {
+				t.Fatalf("parser: %v", err)
+			}
+			insts, err := emitter.New(emitter.NewEmitterOptions{}).Emit(tree)
+			if err != nil {
+				t.Fatalf("emitter: %v", err)
+			}
+
+			warnings := Warnings(insts)
+			if tc.says == "" {
+				if len(warnings) != 0 {
+					t.Errorf("said %v about a call it writes", warnings)
+				}
+				return
+			}
+			for _, warning := range warnings {
+				if strings.Contains(warning.Message, tc.says) {
+					return
+				}
+			}
+			t.Errorf("said %v, want it to mention %q", warnings, tc.says)
+		})
+	}
+}
+
+// A call the bytecode does not make answers zero wherever it is made, so each of them is said
+// where it was written rather than once for the program.
+func TestEveryUnreachedCallIsSaidWhereItIsMade(t *testing.T) {
+	const source = `ident loop = defer { loop(feed(0)); };
+ident g = defer { loop(1) + feed(0); };`
+
+	tokens, err := lexer.New().GetFilledTokens([]byte(source))
+	if err != nil {
+		t.Fatalf("lexer: %v", err)
+	}
+	tree, err := parser.New().Parse(parser.ParseInput{Filename: "main.ar", Tokens: tokens})
+	if err != nil {
+		t.Fatalf("parser: %v", err)
+	}
+	insts, err := emitter.New(emitter.NewEmitterOptions{}).Emit(tree)
+	if err != nil {
+		t.Fatalf("emitter: %v", err)
+	}
+
+	lines := make([]int, 0, 2)
+	for _, warning := range Warnings(insts) {
+		if strings.Contains(warning.Message, "a scope that calls itself") {
+			lines = append(lines, warning.Line)
+		}
+	}
+	if !slices.Equal(lines, []int{1, 2}) {
+		t.Errorf("said it on lines %v, want both calls", lines)
+	}
+}
@@ -2,9 +2,9 @@ package evm
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/wire/ir"
 )
@@ -57,29 +57,104 @@ func WriteSubtract(w io.Writer) (int, error) {
 	return w.Write([]byte{OpSub})
 }
 
+// WriteDivide emits DIV behind a guard on the divisor.
+//
+// The EVM answers a division by zero with zero, and the evaluator stops with an error: the
+// same program answered a number on chain and failed everywhere else. The guard makes the
+// chain fail too, with the evaluator's words as the reason. DIV divides the top by the one
+// under it, so the divisor is second and DUP2 is what copies it for the guard to test.
 func WriteDivide(w io.Writer) (int, error) {
+	if _, err := w.Write([]byte{OpDup2}); err != nil {
+		return 0, err
+	}
+	if _, err := WriteGuard(w, ir.DivideByZero); err != nil {
+		return 0, err
+	}
 	return w.Write([]byte{OpDiv})
 }
 
+// WriteGuard takes the value on top of the stack and reverts with the reason when it is zero.
+//
+// The jump over the revert is counted from the guard itself — PC, plus how far the JUMPDEST is
+// — rather than to an address. A guard is written in the middle of an instruction, and an
+// address there would have to come out of the measurement of the whole scope; a distance comes
+// out of the guard alone, and is the same wherever the scope lands.
+func WriteGuard(w io.Writer, reason string) (int, error) {
+	var revert bytes.Buffer
+	if _, err := WriteRevert(&revert, reason); err != nil {
+		return 0, err
+	}
+	return writeUnless(w, revert.Bytes())
+}
+
+// writeUnless takes the value on top of the stack and runs the code only when it is zero,
+// jumping over it otherwise. The code is a way out — it never falls through to what follows.
+func writeUnless(w io.Writer, code []byte) (int, error) {
+	// PC, PUSH2 and its two bytes, ADD and JUMPI come before the code.
+	if _, err := w.Write([]byte{OpProgramCounter}); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, 6+len(code)); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpAdd, OpJumpIf}); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(code); err != nil {
+		return 0, err
+	}
+	return w.Write([]byte{OpJumpDestiny})
+}
+
+// WriteRevert stops the call with the reason encoded as Solidity encodes a failed require:
+// Error(string), which is what a wallet, a block explorer and every client library decode and
+// show. The encoding is written into memory a word at a time and reverted with; nothing after
+// it runs, so the memory it takes over is nobody else's any more.
+func WriteRevert(w io.Writer, reason string) (int, error) {
+	data := RevertReason(reason)
+	for at := 0; at < len(data); at += 32 {
+		word := make([]byte, 32)
+		copy(word, data[at:])
+		if _, err := w.Write(append([]byte{OpPush32}, word...)); err != nil {
+			return 0, err
+		}
+		if _, err := WritePush2(w, at); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{OpMemoryStore}); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := WritePush2(w, len(data)); err != nil {
+		return 0, err
+	}
+	return w.Write([]byte{OpPush1, 0x00, OpRevert})
+}
+
 // WriteSave puts a value on the EVM stack as a tape of the configured width. There is no
 // special case for booleans any more: they are tapes like everything else.
 func WriteSave(w io.Writer, left []byte, size int) (int, error) {
 	return WritePush(w, left, size)
 }
 
-type IdentOffsetMapper interface {
-	GetOffset(ident []byte) int
-	SetOffset(ident string, offset int)
-	GetLength() uint
-}
-
-// WriteIdent stores a value under a name, in a slot of memory of its own.
+// WriteIdent stores a value under a name, in a slot of memory of its own — or, at the top of
+// the program, in the slot of storage the name was given, where every call after the
+// constructor finds it.
 //
 // The address goes in two bytes. It used to go in one, and a slot is thirty-two wide, so the
 // ninth name in a contract was given the address of the first — 8 * 32 is 256, and one byte
 // holds none of it. Two names became one piece of memory, and each wrote over the other.
-func WriteIdent(w io.Writer, m IdentOffsetMapper, ident []byte) (int, error) {
-	offset := int(m.GetLength()) * MEMORY_SLOT_SIZE
+func WriteIdent(w io.Writer, m *IdentManager, ident []byte) (int, error) {
+	if slot, global := m.Global(ident); global && m.constructor != nil {
+		if _, err := WritePush2(w, slot); err != nil {
+			return 0, err
+		}
+		return w.Write([]byte{OpStorageStore})
+	}
+	offset := m.localsAt() + int(m.GetLength())*MEMORY_SLOT_SIZE
+	if local, bound := m.Local(ident); bound {
+		offset = local
+	}
 	if _, err := WritePush2(w, offset); err != nil {
 		return 0, err
 	}
@@ -90,7 +165,17 @@ func WriteIdent(w io.Writer, m IdentOffsetMapper, ident []byte) (int, error) {
 	return 0, nil
 }
 
-func WriteLoad(w io.Writer, m IdentOffsetMapper, left []byte) (int, error) {
+// WriteLoad reads a name back: from the memory of the scope that bound it, or from the storage
+// of the program when the top of it did.
+func WriteLoad(w io.Writer, m *IdentManager, left []byte) (int, error) {
+	if _, bound := m.Local(left); !bound {
+		if slot, global := m.Global(left); global {
+			if _, err := WritePush2(w, slot); err != nil {
+				return 0, err
+			}
+			return w.Write([]byte{OpStorageLoad})
+		}
+	}
 	if _, err := WritePush2(w, m.GetOffset(left)); err != nil {
 		return 0, err
 	}
@@ -126,6 +211,26 @@ func WriteGetArg(w io.Writer, left []byte, size int) (int, error) {
 	return WriteMask(w, size)
 }
 
+// WriteGetConstructorArg reads an argument of the constructor and cuts it to the tape width.
+//
+// A deployment has no calldata: what it is handed is appended to the code being deployed, so
+// the constructor copies the word out of its own code and reads it from memory. Past the end
+// of the code there is nothing to copy, and CODECOPY answers zeros — which is what reading past
+// what was applied answers everywhere else.
+func WriteGetConstructorArg(w io.Writer, left []byte, size int, argsAt int) (int, error) {
+	index := int(byteutil.ToUint64(left))
+	if _, err := w.Write([]byte{OpPush1, CALLDATA_SLOT_READABLE}); err != nil {
+		return 0, err
+	}
+	if _, err := WritePush2(w, argsAt+CALLDATA_SLOT_READABLE*index); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{OpPush1, 0x00, OpCodeCopy, OpPush1, 0x00, OpMemoryLoad}); err != nil {
+		return 0, err
+	}
+	return WriteMask(w, size)
+}
+
 func WriteStop(w io.Writer) (int, error) {
 	return w.Write([]byte{OpStop})
 }
@@ -139,22 +244,23 @@ func WritePush2(w io.Writer, n int) (int, error) {
 	return w.Write([]byte{OpPush2, byte(n >> 8), byte(n)})
 }
 
-// WriteInstantiateBlock emits the constructor: it copies the runtime out of the code being
-// deployed and hands it to the chain, which is what the chain then keeps.
+// WriteInstantiateBlock emits the end of the constructor: it copies the runtime out of the
+// code being deployed and hands it to the chain, which is what the chain then keeps.
 //
 // The size is pushed in two bytes. It used to be one, and a runtime past 255 bytes was
 // truncated by the conversion — a program with three deferred scopes reached that — so the
 // constructor asked for 96 bytes of a contract that had 352. It deployed, and what the chain
 // kept was cut off in the middle of an instruction.
 //
-// The offset it copies from is this block's own length, since the runtime begins right after
-// it. That number is derived rather than written down twice: the two used to be the same
-// literal in two places, and changing a push meant remembering both.
-func WriteInstantiateBlock(w io.Writer, runtimeSize int) (int, error) {
+// The runtime begins right after this block, and the block begins wherever the top of the
+// program ended, so the offset it copies from is where it was written plus its own length. Its
+// length is derived rather than written down twice: the two used to be the same literal in two
+// places, and changing a push meant remembering both.
+func WriteInstantiateBlock(w io.Writer, runtimeSize int, at int) (int, error) {
 	if _, err := WritePush2(w, runtimeSize); err != nil {
 		return 0, err
 	}
-	if _, err := w.Write([]byte{OpPush1, byte(INSTANTIATE_BLOCK_SIZE)}); err != nil {
+	if _, err := WritePush2(w, at+INSTANTIATE_BLOCK_SIZE); err != nil {
 		return 0, err
 	}
 	if _, err := w.Write([]byte{OpPush1, 0x00}); err != nil {
@@ -183,22 +289,21 @@ func WriteNoMatchDispatcher(w io.Writer) (int, error) {
 // runtime was jumped to at an address that had been truncated — a contract with twelve scopes
 // answered for the first and refused the third with "invalid jump destination". The body was
 // there; the dispatcher could not name it.
-func WriteDispatcher(bs io.Writer, id string, jumpTo int) (int, error) {
+func WriteDispatcher(bs io.Writer, signature string, jumpTo int) (int, error) {
 	if _, err := bs.Write([]byte{OpPush1, 0x00}); err != nil {
 		return 0, err
 	}
 	if _, err := bs.Write([]byte{OpCallDataLoad}); err != nil {
 		return 0, err
 	}
-	// Isolate the first 4 bytes of the keccak256 hash of the id
-	if _, err := bs.Write([]byte{OpPush1, byte((CALLDATA_SLOT_READABLE - 4) * BYTE_SIZE)}); err != nil {
+	// Isolate the first 4 bytes of the calldata, which is where a call names what it calls
+	if _, err := bs.Write([]byte{OpPush1, byte((CALLDATA_SLOT_READABLE - SELECTOR_SIZE) * BYTE_SIZE)}); err != nil {
 		return 0, err
 	}
 	if _, err := bs.Write([]byte{OpShiftRight}); err != nil {
 		return 0, err
 	}
-	selector := crypto.Keccak256([]byte(id))[:4]
-	if _, err := bs.Write(append([]byte{OpPush4}, selector...)); err != nil {
+	if _, err := bs.Write(append([]byte{OpPush4}, Selector(signature)...)); err != nil {
 		return 0, err
 	}
 	if _, err := bs.Write([]byte{OpEqual}); err != nil {
@@ -217,7 +322,7 @@ func WriteDispatchers(bs io.Writer, ds []Dispatcher) (int, error) {
 
 	for _, d := range ds {
 		jumpTo := referencedStart + d.Offset
-		if _, err := WriteDispatcher(bs, string(d.Selector), jumpTo); err != nil {
+		if _, err := WriteDispatcher(bs, d.Signature, jumpTo); err != nil {
 			return 0, err
 		}
 	}
@@ -233,17 +338,12 @@ func WriteDispatchers(bs io.Writer, ds []Dispatcher) (int, error) {
 	return dispatcherLen, nil
 }
 
-func WriteBodyCode(bs io.Writer, ds []Dispatcher, root *bytes.Buffer) (int, error) {
+func WriteBodyCode(bs io.Writer, ds []Dispatcher) (int, error) {
 	for _, d := range ds {
 		if _, err := bs.Write(d.Code.Bytes()); err != nil {
 			return 0, err
 		}
 	}
-	if root != nil {
-		if _, err := bs.Write(root.Bytes()); err != nil {
-			return 0, err
-		}
-	}
 	return 0, nil
 }
 
@@ -268,6 +368,15 @@ var comparisons = map[byte][]byte{
 	ir.OpAnd:     {OpIsZero, OpSwap1, OpIsZero, OpOr, OpIsZero},
 }
 
+// readings maps each context builtin to the EVM's own answer to it, which is the call's.
+var readings = map[byte]byte{
+	ir.OpCaller:      OpCaller,
+	ir.OpCallValue:   OpCallValue,
+	ir.OpTimestamp:   OpTimestamp,
+	ir.OpBlockNumber: OpNumber,
+	ir.OpChainID:     OpChainId,
+}
+
 // WriteImmediates puts on the stack the values an instruction carries inside itself.
 //
 // A Ref was already put there by whoever produced it, and the lowering saw to it that it
@@ -316,8 +425,14 @@ func (c *counter) Write(p []byte) (int, error) {
 // The names are registered into a manager of its own and thrown away, since what is measured
 // is how many bytes an instruction takes and every push is a fixed size now.
 func PositionsOf(insts []ir.Instruction, tapeSize int, landings map[int]bool, arms map[string]bool) ([]int, error) {
+	return positionsOf(NewIdentManager(), insts, tapeSize, landings, arms)
+}
+
+// positionsOf measures with a manager of the same kind as the one the code is written with:
+// the constructor writes a feed and a return differently from a scope, and a measurement of
+// the other kind would put its jumps somewhere else.
+func positionsOf(im *IdentManager, insts []ir.Instruction, tapeSize int, landings map[int]bool, arms map[string]bool) ([]int, error) {
 	positions := make([]int, len(insts)+1)
-	im := NewIdentManager()
 	for at, inst := range insts {
 		var measured counter
 		if landings[at] {
@@ -364,10 +479,17 @@ func armsOf(insts []ir.Instruction) map[string]bool {
 	return arms
 }
 
-// targetOf answers the byte an instruction jumps to, or zero for one that does not jump.
-func targetOf(inst ir.Instruction, at int, positions []int) int {
+// targetOf answers the byte an instruction jumps to, or zero for one that does not jump. A call
+// jumps into another scope and back, and what it needs is where it is written, which the way
+// back is counted from.
+func targetOf(inst ir.Instruction, at int, positions []int, landings map[int]bool) int {
 	var ahead int
 	switch inst.GetOpCode() {
+	case ir.OpCall:
+		if landings[at] {
+			return positions[at] + 1
+		}
+		return positions[at]
 	case ir.OpIf:
 		ahead = int(byteutil.ToUint64(inst.GetRight().Bytes()))
 	case ir.OpJump:
@@ -380,26 +502,41 @@ func targetOf(inst ir.Instruction, at int, positions []int) int {
 
 // WriteInstruction emits one instruction.
 //
-// The target is the byte a jump goes to, for the two instructions that jump. It is zero while
-// measuring, and measures the same either way, since every push is a fixed size.
+// The target is the byte a jump goes to, for the two instructions that jump, and where a call
+// is written, for the way back. It is zero while measuring, and measures the same either way,
+// since every push is a fixed size.
 //
 // Arms answers whether an OpReturn ends a branch rather than a scope. The two are the same
 // opcode: one says "the value of this scope" and the other "the value of this arm", and which
 // it is can be read — an arm names the OpIf it belongs to, and a scope names the OpBeginScope.
 func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeSize int, target int, arms map[string]bool) error {
 	op := inst.GetOpCode()
+	// What the optimizer noted about this one, when it was run and had something to say.
+	note, _ := inst.(tuned)
+	// A result left unmasked is cut as if the tape were as wide as a word, which writes
+	// nothing.
+	width := tapeSize
+	if note.unmasked {
+		width = byteutil.MaxTapeSize
+	}
 
-	if handled[op] && op != ir.OpSave {
+	if handled[op] && op != ir.OpSave && op != ir.OpCall && !contractCalls[op] {
 		if err := WriteImmediates(bs, inst, tapeSize); err != nil {
 			return err
 		}
 	}
 
+	if note.swapped {
+		if _, err := bs.Write([]byte{OpSwap1}); err != nil {
+			return err
+		}
+	}
+
 	if op == ir.OpAdd {
 		if _, err := WriteAdd(bs); err != nil {
 			return err
 		}
-		if _, err := WriteMask(bs, tapeSize); err != nil {
+		if _, err := WriteMask(bs, width); err != nil {
 			return err
 		}
 	}
@@ -408,7 +545,7 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 		if _, err := WriteMultiply(bs); err != nil {
 			return err
 		}
-		if _, err := WriteMask(bs, tapeSize); err != nil {
+		if _, err := WriteMask(bs, width); err != nil {
 			return err
 		}
 	}
@@ -417,7 +554,7 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 		if _, err := WriteSubtract(bs); err != nil {
 			return err
 		}
-		if _, err := WriteMask(bs, tapeSize); err != nil {
+		if _, err := WriteMask(bs, width); err != nil {
 			return err
 		}
 	}
@@ -428,10 +565,27 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 		}
 	}
 
-	if op == ir.OpReturn {
-		// The value of an arm is already on the stack, which is where whoever is under the
-		// branch finds it: there is nothing to write. Only a scope answers to the chain.
+	// The condition is on top, and the guard takes it: nothing is left for the lowering to
+	// account for unless something asks for the guard's value.
+	if op == ir.OpRequire {
+		if _, err := WriteGuard(bs, string(inst.GetRight().Bytes())); err != nil {
+			return err
+		}
+	}
+
+	if op == ir.OpReturn && im.function != nil {
+		// A function answers whoever called it, and the value is already where they look.
 		if !arms[byteutil.ToHex(inst.GetLeft().Bytes())] {
+			if _, err := WriteFunctionReturn(bs, *im.function); err != nil {
+				return err
+			}
+		}
+	} else if op == ir.OpReturn {
+		// The value of an arm is already on the stack, which is where whoever is under the
+		// branch finds it: there is nothing to write. Only a scope answers to the chain, and
+		// the constructor is not one — a RETURN there would hand the chain the value as the
+		// contract.
+		if !arms[byteutil.ToHex(inst.GetLeft().Bytes())] && im.constructor == nil {
 			if _, err := WriteReturn(bs); err != nil {
 				return err
 			}
@@ -445,19 +599,32 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 	}
 
 	if op == ir.OpIdent {
+		if note.dup {
+			if _, err := bs.Write([]byte{OpDup1}); err != nil {
+				return err
+			}
+		}
 		if _, err := WriteIdent(bs, im, inst.GetLeft().Bytes()); err != nil {
 			return err
 		}
 	}
 
-	if op == ir.OpLoad {
+	if op == ir.OpLoad && !note.kept {
 		if _, err := WriteLoad(bs, im, inst.GetLeft().Bytes()); err != nil {
 			return err
 		}
 	}
 
 	if op == ir.OpGetFeed {
-		if _, err := WriteGetArg(bs, inst.GetLeft().Bytes(), tapeSize); err != nil {
+		if im.function != nil {
+			if _, err := WriteGetFeedOfFunction(bs, *im.function, inst.GetLeft().Bytes()); err != nil {
+				return err
+			}
+		} else if im.constructor != nil {
+			if _, err := WriteGetConstructorArg(bs, inst.GetLeft().Bytes(), tapeSize, im.constructor.ArgsAt); err != nil {
+				return err
+			}
+		} else if _, err := WriteGetArg(bs, inst.GetLeft().Bytes(), tapeSize); err != nil {
 			return err
 		}
 	}
@@ -470,6 +637,17 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 		}
 	}
 
+	// What the call is, cut to the tape like an argument: an address is wider than most tapes,
+	// and the evaluator keeps the end of what its host answers.
+	if reading, ok := readings[op]; ok {
+		if _, err := bs.Write([]byte{reading}); err != nil {
+			return err
+		}
+		if _, err := WriteMask(bs, tapeSize); err != nil {
+			return err
+		}
+	}
+
 	if op == ir.OpExponential {
 		if _, err := bs.Write([]byte{OpExp}); err != nil {
 			return err
@@ -502,6 +680,31 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 		}
 	}
 
+	// A call nothing reaches is refused before anything is written (see unreachedCalls), so
+	// one here is the builder's mistake, and it is not written as though it were fine.
+	if op == ir.OpCall {
+		fn, ok := im.Function(inst.GetLeft().Bytes())
+		if !ok {
+			return fmt.Errorf("the call to %s reaches no function", inst.GetLeft().Bytes())
+		}
+		if _, err := WriteCall(bs, fn, valueOperands(inst), tapeSize, target); err != nil {
+			return err
+		}
+	}
+
+	if contractCalls[op] {
+		operands := inst.GetOperands()
+		if _, err := WriteContractCall(bs, valueOperands(inst), string(operands[1].Bytes()), op == ir.OpStaticCall, tapeSize); err != nil {
+			return err
+		}
+	}
+
+	if note.popped {
+		if _, err := bs.Write([]byte{OpPop}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -516,31 +719,47 @@ func WriteInstruction(bs io.Writer, im *IdentManager, inst ir.Instruction, tapeS
 // contract and not an offset into a scope. It is zero while a scope is being measured, and the
 // measurement does not depend on it.
 func WriteCode(bs io.Writer, im *IdentManager, insts []ir.Instruction, tapeSize int, base int) (int, error) {
+	if err := writeInstructions(bs, im, insts, tapeSize, base); err != nil {
+		return 0, err
+	}
+	return bs.Write([]byte{OpStop})
+}
+
+// WriteConstructorCode emits the top of the program, which runs once, at deployment, and then
+// carries on into the block that hands the chain the runtime — so it does not stop.
+func WriteConstructorCode(bs io.Writer, im *IdentManager, insts []ir.Instruction, tapeSize int) (int, error) {
+	var written counter
+	if err := writeInstructions(io.MultiWriter(bs, &written), im, insts, tapeSize, 0); err != nil {
+		return 0, err
+	}
+	return int(written), nil
+}
+
+func writeInstructions(bs io.Writer, im *IdentManager, insts []ir.Instruction, tapeSize int, base int) error {
 	landings := landingsOf(insts)
 	arms := armsOf(insts)
 
-	positions, err := PositionsOf(insts, tapeSize, landings, arms)
+	positions, err := positionsOf(im.measuring(), insts, tapeSize, landings, arms)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
 	for at, inst := range insts {
 		if landings[at] {
 			if _, err := bs.Write([]byte{OpJumpDestiny}); err != nil {
-				return 0, err
+				return err
 			}
 		}
-		if err := WriteInstruction(bs, im, inst, tapeSize, base+targetOf(inst, at, positions), arms); err != nil {
-			return 0, err
+		if err := WriteInstruction(bs, im, inst, tapeSize, base+targetOf(inst, at, positions, landings), arms); err != nil {
+			return err
 		}
 	}
 
 	// The way out of an "if" that ends a scope lands past the last instruction.
 	if landings[len(insts)] {
 		if _, err := bs.Write([]byte{OpJumpDestiny}); err != nil {
-			return 0, err
+			return err
 		}
 	}
-
-	return bs.Write([]byte{OpStop})
+	return nil
 }
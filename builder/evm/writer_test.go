@@ -13,13 +13,13 @@ import (
 // written once, in the constant, and read here rather than repeated.
 func TestWriteInstantiateBlock(t *testing.T) {
 	bs := bytes.NewBuffer(make([]byte, 0))
-	if _, err := WriteInstantiateBlock(bs, 8); err != nil {
+	if _, err := WriteInstantiateBlock(bs, 8, 0); err != nil {
 		t.Fatalf("writing the instantiate block: %v", err)
 	}
 
 	expected := []byte{
 		OpPush2, 0x00, 0x08,
-		OpPush1, INSTANTIATE_BLOCK_SIZE,
+		OpPush2, 0x00, INSTANTIATE_BLOCK_SIZE,
 		OpPush1, 0x00,
 		OpCodeCopy,
 		OpPush2, 0x00, 0x08,
@@ -34,12 +34,42 @@ func TestWriteInstantiateBlock(t *testing.T) {
 	}
 }
 
+// After the top of a program the runtime begins that much further on, and the block copies
+// from there.
+func TestWriteInstantiateBlockAfterTheTopOfTheProgram(t *testing.T) {
+	bs := bytes.NewBuffer(make([]byte, 0))
+	if _, err := WriteInstantiateBlock(bs, 8, 300); err != nil {
+		t.Fatalf("writing the instantiate block: %v", err)
+	}
+	from := 300 + INSTANTIATE_BLOCK_SIZE
+	if got, want := bs.Bytes()[3:6], []byte{OpPush2, byte(from >> 8), byte(from)}; !bytes.Equal(got, want) {
+		t.Errorf("it copies from %v, want %v", byteutil.ToUpperHex(got), byteutil.ToUpperHex(want))
+	}
+}
+
+// A constructor argument is appended to the code being deployed, so it is copied out of the
+// code rather than read from calldata a deployment does not have.
+func TestWriteGetConstructorArg(t *testing.T) {
+	bs := bytes.NewBuffer(make([]byte, 0))
+	if _, err := WriteGetConstructorArg(bs, byteutil.FromUint64(1), 1, 0x100); err != nil {
+		t.Fatalf("WriteGetConstructorArg: %v", err)
+	}
+	expected := []byte{
+		OpPush1, 0x20, OpPush2, 0x01, 0x20, OpPush1, 0x00, OpCodeCopy,
+		OpPush1, 0x00, OpMemoryLoad,
+		OpPush1, 0xff, OpAnd,
+	}
+	if got := bs.Bytes(); !bytes.Equal(got, expected) {
+		t.Errorf("got %v, want %v", byteutil.ToUpperHex(got), byteutil.ToUpperHex(expected))
+	}
+}
+
 // A runtime past what one byte holds used to be truncated by the conversion, so the
 // constructor asked for 96 bytes of a contract that had 352 and the chain kept the first 96.
 // Three deferred scopes reached that.
 func TestARuntimePastOneByteIsWrittenWhole(t *testing.T) {
 	bs := bytes.NewBuffer(make([]byte, 0))
-	if _, err := WriteInstantiateBlock(bs, 352); err != nil {
+	if _, err := WriteInstantiateBlock(bs, 352, 0); err != nil {
 		t.Fatalf("writing the instantiate block: %v", err)
 	}
 
@@ -94,13 +124,37 @@ func TestWriteDivide(t *testing.T) {
 		t.Errorf("Error writing divide: %v", err)
 		return
 	}
+	var guard bytes.Buffer
+	if _, err := WriteGuard(&guard, ir.DivideByZero); err != nil {
+		t.Fatal(err)
+	}
 	got := bs.Bytes()
-	expected := []byte{OpDiv}
+	expected := append(append([]byte{OpDup2}, guard.Bytes()...), OpDiv)
 	if !bytes.Equal(got, expected) {
 		t.Errorf("Divide: got: %v, expected: %v", byteutil.ToUpperHex(got), byteutil.ToUpperHex(expected))
 	}
 }
 
+// The guard's jump is counted from its own PC and lands on the JUMPDEST right after the revert,
+// so it reads the same wherever it is written.
+func TestWriteGuard(t *testing.T) {
+	var bs bytes.Buffer
+	if _, err := WriteGuard(&bs, "must not be zero"); err != nil {
+		t.Fatal(err)
+	}
+	ops := Disassemble(bs.Bytes())
+	if ops[0].Code != OpProgramCounter || ops[1].Code != OpPush2 || ops[2].Code != OpAdd || ops[3].Code != OpJumpIf {
+		t.Fatalf("the guard does not open with PC PUSH2 ADD JUMPI: %v", byteutil.ToUpperHex(bs.Bytes()))
+	}
+	last := ops[len(ops)-1]
+	if last.Code != OpJumpDestiny || last.Offset != int(byteutil.ToUint64(ops[1].Data)) {
+		t.Errorf("the guard jumps %d ahead, and its JUMPDEST is at %d", byteutil.ToUint64(ops[1].Data), last.Offset)
+	}
+	if ops[len(ops)-2].Code != OpRevert {
+		t.Errorf("the guard does not revert before its JUMPDEST: %v", byteutil.ToUpperHex(bs.Bytes()))
+	}
+}
+
 func TestWriteSave(t *testing.T) {
 	bs := bytes.NewBuffer(make([]byte, 0))
 	operand := []byte{1}
@@ -176,12 +230,82 @@ func TestWriteGetArg(t *testing.T) {
 		return
 	}
 	got := bs.Bytes()
-	expected := []byte{OpPush1, 0x20, OpCallDataLoad, OpPush8, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, OpAnd}
+	expected := []byte{OpPush1, 0x04, OpCallDataLoad, OpPush8, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, OpAnd}
 	if !bytes.Equal(got, expected) {
 		t.Errorf("GetArg: got: %v, expected: %v", byteutil.ToUpperHex(got), byteutil.ToUpperHex(expected))
 	}
 }
 
+// What the call is comes out of the EVM a word wide, and is cut to the tape like an argument.
+func TestWriteAReadingOfTheCall(t *testing.T) {
+	cases := []struct {
+		op   byte
+		want byte
+	}{
+		{ir.OpCaller, OpCaller},
+		{ir.OpCallValue, OpCallValue},
+		{ir.OpTimestamp, OpTimestamp},
+		{ir.OpBlockNumber, OpNumber},
+		{ir.OpChainID, OpChainId},
+	}
+	for _, tc := range cases {
+		t.Run(ir.ResolveOpCode(tc.op), func(t *testing.T) {
+			bs := bytes.NewBuffer(make([]byte, 0))
+			inst := ir.NewInstruction([]byte("00"), tc.op, ir.Nothing(), ir.Nothing())
+			if err := WriteInstruction(bs, NewIdentManager(), inst, 1, 0, nil); err != nil {
+				t.Fatalf("writing: %v", err)
+			}
+			if want := []byte{tc.want, OpPush1, 0xff, OpAnd}; !bytes.Equal(bs.Bytes(), want) {
+				t.Errorf("got %X, want %X", bs.Bytes(), want)
+			}
+		})
+	}
+}
+
+// The selector goes at the front of the calldata, the call is the one asked for, an answer past a
+// word reverts, and what is left afterwards is the word answered cut to the tape.
+func TestWriteContractCall(t *testing.T) {
+	cases := []struct {
+		name   string
+		static bool
+		want   byte
+	}{
+		{name: "call", static: false, want: OpCall},
+		{name: "staticcall", static: true, want: OpStaticCall},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bs bytes.Buffer
+			values := []ir.Operand{ir.RefTo([]byte("00")), ir.Imm(2, 1)}
+			if _, err := WriteContractCall(&bs, values, "take(uint256)", tc.static, 1); err != nil {
+				t.Fatalf("writing: %v", err)
+			}
+			ops := Disassemble(bs.Bytes())
+			if ops[0].Code != OpMemorySize || ops[1].Code != OpPush4 || !bytes.Equal(ops[1].Data, Selector("take(uint256)")) {
+				t.Fatalf("the calldata does not open with the selector: %v", byteutil.ToUpperHex(bs.Bytes()))
+			}
+			calls := 0
+			for _, op := range ops {
+				if op.Code == OpCall || op.Code == OpStaticCall {
+					calls++
+					if op.Code != tc.want {
+						t.Errorf("called with %s", ResolveOpCode(op.Code))
+					}
+				}
+			}
+			if calls != 1 {
+				t.Errorf("made %d calls, want one", calls)
+			}
+			if !bytes.Contains(bs.Bytes(), RevertReason(ir.AnswerOfManyWords)[:32]) {
+				t.Errorf("an answer of several words does not revert: %v", byteutil.ToUpperHex(bs.Bytes()))
+			}
+			if tail := bs.Bytes()[bs.Len()-3:]; !bytes.Equal(tail, []byte{OpPush1, 0xff, OpAnd}) {
+				t.Errorf("the answer is not cut to the tape: ends %X", tail)
+			}
+		})
+	}
+}
+
 func TestWriteReturn(t *testing.T) {
 	bs := bytes.NewBuffer(make([]byte, 0))
 	if _, err := WriteReturn(bs); err != nil {
@@ -200,7 +324,8 @@ func TestWriteReturn(t *testing.T) {
 
 // One entry of the dispatcher: read the selector out of the calldata, compare it with the one
 // this scope answers to, jump to the body when they match. The address goes in two bytes, so
-// a body past byte 255 of the runtime can be named.
+// a body past byte 255 of the runtime can be named. The selector is 0xf8a8fd6d, which is what
+// Solidity computes for test() — the one number that says any other tool can call it.
 func TestWriteDispatcher(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -214,12 +339,12 @@ func TestWriteDispatcher(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			bs := bytes.NewBuffer(make([]byte, 0))
-			if _, err := WriteDispatcher(bs, "test", tc.jumpTo); err != nil {
+			if _, err := WriteDispatcher(bs, "test()", tc.jumpTo); err != nil {
 				t.Fatalf("writing the dispatcher: %v", err)
 			}
 
 			expected := []byte{OpPush1, 0x00, OpCallDataLoad, OpPush1, 0xe0, OpShiftRight,
-				OpPush4, 0x9c, 0x22, 0xff, 0x5f, OpEqual}
+				OpPush4, 0xf8, 0xa8, 0xfd, 0x6d, OpEqual}
 			expected = append(expected, tc.want...)
 			expected = append(expected, OpJumpIf)
 
@@ -234,54 +359,20 @@ func TestWriteDispatcher(t *testing.T) {
 	}
 }
 
+// The bodies are written one after the other, in the order the dispatcher counts their
+// offsets in. Nothing follows them: the top of the program is the constructor's.
 func TestWriteBodyCode(t *testing.T) {
-	cases := []struct {
-		Name        string
-		Dispatchers []Dispatcher
-		Root        *bytes.Buffer
-		FnExpected  func() []byte
-	}{
-		{
-			"sample_body_code_1",
-			[]Dispatcher{
-				{
-					Selector: []byte("test"),
-					Code:     bytes.NewBuffer([]byte{1}),
-					Offset:   0,
-					Length:   1,
-				},
-			},
-			nil,
-			func() []byte {
-				return []byte{1}
-			},
-		},
-		{
-			"sample_body_code_2",
-			[]Dispatcher{
-				{
-					Selector: []byte("test"),
-					Code:     bytes.NewBuffer([]byte{1}),
-					Offset:   0,
-					Length:   1,
-				},
-			},
-			bytes.NewBuffer([]byte{2}),
-			func() []byte {
-				return []byte{1, 2}
-			},
-		},
+	dispatchers := []Dispatcher{
+		{Selector: []byte("test"), Code: bytes.NewBuffer([]byte{1}), Length: 1},
+		{Selector: []byte("other"), Code: bytes.NewBuffer([]byte{2, 3}), Offset: 1, Length: 2},
 	}
 
-	for _, c := range cases {
-		bs := bytes.NewBuffer(make([]byte, 0))
-		if _, err := WriteBodyCode(bs, c.Dispatchers, c.Root); err != nil {
-			t.Errorf("%v: %v", c.Name, err)
-			return
-		}
-		if got, expected := bs.Bytes(), c.FnExpected(); !bytes.Equal(got, expected) {
-			t.Errorf("EVM body code: name: %v, got: %v, expected: %v", c.Name, byteutil.ToUpperHex(got), byteutil.ToUpperHex(expected))
-		}
+	bs := bytes.NewBuffer(make([]byte, 0))
+	if _, err := WriteBodyCode(bs, dispatchers); err != nil {
+		t.Fatalf("WriteBodyCode: %v", err)
+	}
+	if got, want := bs.Bytes(), []byte{1, 2, 3}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", byteutil.ToUpperHex(got), byteutil.ToUpperHex(want))
 	}
 }
 
@@ -22,7 +22,21 @@ involved:
 
   aurora build                  the "main" profile
   aurora build dev              the "dev" profile
-  aurora build src/main.ar      that file`,
+  aurora build src/main.ar      that file
+
+With --gas, what calling each scope costs is reported after the build: the
+static cost read off the bytecode — the dispatcher reaching the scope and the
+most expensive way through its body, or "unbounded" when the body can loop —
+and the gas a call spent in an EVM run in memory, with every feed at 1.
+
+With -O, the bytecode is optimized: arithmetic over numbers the program wrote
+down is worked out at build time, a mask the next operation repeats is left
+out, a value nobody reads is not pushed, and a name read right after it is bound
+is kept on the stack instead of read back. What a call answers does not change;
+the build reports the bytes and the static gas that were saved.
+
+With --watch, the binary is built again every time the source or a module it
+reads changes.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runBuild,
 }
@@ -30,6 +44,9 @@ involved:
 func init() {
 	buildCmd.Flags().StringP("output", "o", "", "output path for compiled binary (default: binary from aurora.toml, or the file name without extension)")
 	buildCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+	buildCmd.Flags().Bool("gas", false, "report what calling each scope costs")
+	buildCmd.Flags().BoolP("optimize", "O", false, "optimize the bytecode and report what was saved")
+	addWatchFlag(buildCmd)
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
@@ -42,28 +59,72 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	output, err := cmd.Flags().GetString("output")
+	flag, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	tapeSize, err := cmd.Flags().GetInt("tape-size")
+	if err != nil {
+		return err
+	}
+	gas, err := cmd.Flags().GetBool("gas")
 	if err != nil {
 		return err
 	}
-	if output == "" {
-		// A profile carries its own output path; a loose file has none, so the binary
-		// lands next to where the command runs, named after the source.
-		output = target.Binary
-		if output == "" {
-			output = cli.DefaultBinaryPath(target.Source)
+	optimize, err := cmd.Flags().GetBool("optimize")
+	if err != nil {
+		return err
+	}
+
+	// The target is resolved again on every build: under --watch the manifest may have changed
+	// what it names, where the binary goes and how wide a value is.
+	session := func() (*cli.Session, cli.Target, error) {
+		target, err := cli.ResolveTarget(arg)
+		if err != nil {
+			return nil, target, err
 		}
+		return newBuildSession(cmd, target, tapeSize, buildOptions{gas: gas, optimize: optimize}), target, nil
 	}
 
-	tapeSize, err := cmd.Flags().GetInt("tape-size")
-	if err != nil {
+	return watch(cmd, target, func(changed []string) bool {
+		s, target, err := session()
+		return err != nil || s.Affected(target.Source, changed)
+	}, func(changed []string) error {
+		s, target, err := session()
+		if err != nil {
+			return err
+		}
+		_, err = s.Build(cmd.Context(), target.Source, outputOf(target, flag))
 		return err
+	})
+}
+
+// outputOf answers where a target's binary goes: the flag when one was given, then the
+// profile's own path, and for a loose file the file's name next to where the command runs.
+func outputOf(target cli.Target, flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if target.Binary != "" {
+		return target.Binary
 	}
+	return cli.DefaultBinaryPath(target.Source)
+}
+
+// buildOptions are what a build may be asked for beyond building.
+type buildOptions struct {
+	// gas says what calling each scope of what was built costs.
+	gas bool
+	// optimize writes smaller and cheaper bytecode, and says what it saved.
+	optimize bool
+}
 
+// newBuildSession puts the phases together for building a target. A build compiles and
+// writes bytecode; nothing evaluates, so no evaluator is made.
+func newBuildSession(cmd *cobra.Command, target cli.Target, tapeSize int, options buildOptions) *cli.Session {
 	size := cli.ResolveTapeSize(tapeSize, target.TapeSize)
 
-	// A build compiles and writes bytecode; nothing evaluates, so no evaluator is made.
-	_, err = cli.NewSession(cli.NewSessionOptions{
+	return cli.NewSession(cli.NewSessionOptions{
 		Lexer:    lexer.New(),
 		Parser:   parser.New(),
 		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
@@ -71,6 +132,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		TapeSize: size,
 		Stdout:   cmd.OutOrStdout(),
 		Warnings: os.Stderr,
-	}).Build(cmd.Context(), target.Source, output)
-	return err
+		Gas:      options.gas,
+		Optimize: options.optimize,
+	})
 }
@@ -5,19 +5,35 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/hosting/cli"
 )
 
 var callCmd = &cobra.Command{
-	Use:   "call <function> [profile]",
+	Use:   "call <function> [arg0 [arg1 ...]]",
 	Short: "Call program on a blockchain",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runCall,
+	Long: `Call a function of the program deployed under a profile, through its RPC.
+
+With --local, nothing is deployed and nothing is dialed: the profile's source is
+run by the evaluator and the function called on it, with the arguments checked
+the way the chain would check them. The profile may also be a .ar file. The
+chain flags say what call it is in, and --args what the constructor was deployed
+with, the way "aurora deploy --args" hands it:
+
+  aurora call --local area 3 4
+  aurora call --local --args 10,20 scale 3
+  aurora call --local -p examples/x.ar --call-value 100 pay
+  aurora call --local --contracts contracts.toml supply 0x5B38Da6a701c568545dCfcB03FcB875f56beddC4`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCall,
 }
 
 func init() {
 	callCmd.Flags().Bool("pretend", false, "pretend/simulate the call (dry run)")
 	callCmd.Flags().StringP("profile", "p", "main", "profile to call")
+	callCmd.Flags().Bool("local", false, "call the profile's source with the evaluator instead of a chain")
+	callCmd.Flags().StringSlice("args", nil, "with --local, arguments of the constructor, what the top of the program feeds (comma-separated)")
+	addChainFlags(callCmd)
 }
 
 func runCall(cmd *cobra.Command, args []string) error {
@@ -29,6 +45,13 @@ func runCall(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	local, err := cmd.Flags().GetBool("local")
+	if err != nil {
+		return err
+	}
+	if local {
+		return runCallLocal(cmd, profile, fn, args[1:])
+	}
 	env, err := cli.LoadEnviron(profile)
 	if err != nil {
 		return err
@@ -47,11 +70,48 @@ func runCall(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	// What the contract answers to was written beside the binary when it was built.
+	abi, err := cli.LoadABI(cli.ABIPath(env.AbsPath(env.Profile.Binary)))
+	if err != nil {
+		return err
+	}
 	return cli.Call(cmd.Context(), cli.CallInput{
 		Function:        fn,
+		ABI:             abi,
 		ContractAddress: d.ContractAddress,
 		RPC:             env.Profile.RPC,
 		Args:            args[1:],
 		Pretend:         pretend,
 	})
 }
+
+// runCallLocal calls the function off chain: the source the profile builds, run the way
+// "aurora run" runs it, in the call the chain flags describe.
+func runCallLocal(cmd *cobra.Command, profile, fn string, args []string) error {
+	target, err := cli.ResolveTarget(profile)
+	if err != nil {
+		return err
+	}
+	chain, err := chainContext(cmd)
+	if err != nil {
+		return err
+	}
+	constructorArgs, err := cmd.Flags().GetStringSlice("args")
+	if err != nil {
+		return err
+	}
+	contracts, err := chainContracts(cmd)
+	if err != nil {
+		return err
+	}
+	s, err := newRunSession(target, 0, constructorArgs, nil, chain, contracts)
+	if err != nil {
+		return err
+	}
+	result, err := s.CallLocal(cmd.Context(), target.Source, fn, args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Result: %s\n", byteutil.DecimalOf(result, cli.ResolveTapeSize(0, target.TapeSize)))
+	return nil
+}
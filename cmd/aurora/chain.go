@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/hosting/cli"
+)
+
+// addChainFlags gives a command the flags that say what call a program is running in. Run and
+// call --local share them: off chain there is no call, and these are what "caller",
+// "callvalue" and the rest answer instead.
+func addChainFlags(cmd *cobra.Command) {
+	cmd.Flags().String("caller", "", "the address caller answers (default zero)")
+	cmd.Flags().String("call-value", "", "the wei callvalue answers (default 0)")
+	cmd.Flags().String("timestamp", "", "the seconds timestamp answers (default 0)")
+	cmd.Flags().String("block-number", "", "the number blocknumber answers (default 0)")
+	cmd.Flags().String("chain-id", "", "the chain chainid answers (default 0)")
+	cmd.Flags().String("contracts", "", "a TOML file of what the contracts the program calls answer")
+}
+
+// chainContext reads the chain flags. What was not given answers zero, which is also what an
+// evaluator with no context answers.
+func chainContext(cmd *cobra.Command) (evaluator.Context, error) {
+	flags := cmd.Flags()
+	read := func(name string) string {
+		value, _ := flags.GetString(name)
+		return value
+	}
+	context, err := cli.NewChainContext(cli.ChainContextOptions{
+		Caller:      read("caller"),
+		CallValue:   read("call-value"),
+		Timestamp:   read("timestamp"),
+		BlockNumber: read("block-number"),
+		ChainID:     read("chain-id"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return context, nil
+}
+
+// chainContracts reads the file --contracts names, every time it is asked: under --watch the
+// file may have changed between runs. Nothing named is nil, which a call out of the program
+// stops at, as it always has.
+func chainContracts(cmd *cobra.Command) (evaluator.Contracts, error) {
+	path, err := cmd.Flags().GetString("contracts")
+	if err != nil || path == "" {
+		return nil, err
+	}
+	registry, err := cli.LoadContracts(path)
+	if err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
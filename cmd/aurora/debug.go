@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/hosting/debug"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/shared/printer"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug [profile | file.ar] [args...]",
+	Short: "Run a program one step at a time",
+	Long: `Run a program one step at a time.
+
+The program stops on its first line and waits for a command:
+
+  break 12           stop on line 12 of the file that was run
+  break geometry:3   stop on line 3 of the module imported as geometry
+  continue           go on until a breakpoint
+  step / next        the next line, into a call or over it
+  stepi              one instruction
+  finish             until the call the program is in answers
+  env                the environ chain: names, deferred scopes, feed arguments
+  print n            what n holds, as bytes, a decimal and text
+
+"help" lists every command. An empty line runs the last one again. The target
+and the arguments are read the way "aurora run" reads them.`,
+	RunE: runDebug,
+}
+
+func init() {
+	debugCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+}
+
+func runDebug(cmd *cobra.Command, args []string) error {
+	var arg string
+	var programArgs []string
+	if len(args) > 0 {
+		arg, programArgs = args[0], args[1:]
+	}
+	target, err := cli.ResolveTarget(arg)
+	if err != nil {
+		return err
+	}
+	tapeSize, err := cmd.Flags().GetInt("tape-size")
+	if err != nil {
+		return err
+	}
+
+	size := cli.ResolveTapeSize(tapeSize, target.TapeSize)
+	session := cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(size, target.SourceRoot),
+		TapeSize: size,
+		Stdout:   os.Stdout,
+		Warnings: os.Stderr,
+	})
+	program, err := session.Compile(target.Source)
+	if err != nil {
+		return err
+	}
+
+	// The debugger is the evaluator's monitor, and reads what the evaluator holds: each is
+	// handed the other.
+	debugger := debug.New(debug.Options{In: os.Stdin, Out: os.Stdout, TapeSize: size})
+	out := os.Stdout
+	ev := evaluator.New(evaluator.NewEvaluatorOptions{
+		PrintBytes:   printer.Bytes(out, size),
+		PrintChars:   printer.Chars(out, size),
+		PrintDecimal: printer.Decimal(out, size),
+		Args:         cli.ParseArgs(programArgs),
+		TapeSize:     size,
+		Monitor:      debugger,
+	})
+	return debugger.Run(ev, program)
+}
@@ -26,6 +26,7 @@ func init() {
 	deployCmd.Flags().IntVar(&deployMinTipGwei, "min-tip", 0, "minimum priority fee in Gwei (overrides default when RPC suggests too low)")
 	deployCmd.Flags().IntVar(&deployMinMaxFeeGwei, "min-max-fee", 0, "minimum max fee per gas in Gwei (overrides default when RPC suggests too low)")
 	deployCmd.Flags().StringP("profile", "p", "main", "profile to deploy")
+	deployCmd.Flags().StringSlice("args", nil, "arguments of the constructor, what the top of the program feeds (comma-separated)")
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
@@ -43,8 +44,19 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	if env.Profile.Privkey == "" {
 		return fmt.Errorf("profile %s: privkey is required for deploy", profile)
 	}
+	constructorArgs, err := cmd.Flags().GetStringSlice("args")
+	if err != nil {
+		return err
+	}
+	binary := env.AbsPath(env.Profile.Binary)
+	abi, err := cli.LoadABI(cli.ABIPath(binary))
+	if err != nil {
+		return err
+	}
 	address, deployTxHash, deployedAt, err := cli.Deploy(cmd.Context(), cli.DeployInput{
-		BinaryPath:    env.AbsPath(env.Profile.Binary),
+		BinaryPath:    binary,
+		Args:          constructorArgs,
+		ABI:           abi,
 		RPC:           env.Profile.RPC,
 		Privkey:       env.Profile.Privkey,
 		MinTipGwei:    deployMinTipGwei,
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/hosting/cli"
+)
+
+var disasmCmd = &cobra.Command{
+	Use:   "disasm [binary]",
+	Short: "List the EVM instructions of a binary",
+	Long: `List the EVM instructions of a binary.
+
+Every instruction is shown with its offset and what it pushes, split into the
+constructor, the dispatcher and the body of each scope. The binary may be raw
+bytes or hex after 0x — a runtime fetched from a chain reads the same, with no
+constructor in front.
+
+Selectors are named from the ABI beside the binary, and every instruction is
+noted with the line it was written on from the source map beside it. Given
+the source, every body is also headed by the file and line its scope was
+bound on. With no argument, the "main" profile's binary is listed, with its
+source:
+
+  aurora disasm                       the "main" profile
+  aurora disasm bin/main              that binary, named from bin/main.abi.json
+  aurora disasm bin/main -s main.ar   named from the source, with lines`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDisasm,
+}
+
+func init() {
+	disasmCmd.Flags().StringP("source", "s", "", "the .ar file the binary was built from")
+	disasmCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+}
+
+func runDisasm(cmd *cobra.Command, args []string) error {
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		return err
+	}
+	tapeSize, err := cmd.Flags().GetInt("tape-size")
+	if err != nil {
+		return err
+	}
+
+	var binary string
+	if len(args) > 0 {
+		binary = args[0]
+	} else {
+		target, err := cli.ResolveTarget("")
+		if err != nil {
+			return err
+		}
+		binary = outputOf(target, "")
+		if source == "" {
+			source = target.Source
+		}
+	}
+
+	bytecode, err := os.ReadFile(binary)
+	if err != nil {
+		return err
+	}
+
+	// The source says more than the ABI, so it wins when there is one. With neither, the
+	// listing is still written — it only cannot name what it lists.
+	var symbols cli.Symbols
+	switch {
+	case source != "":
+		target, err := cli.ResolveTarget(source)
+		if err != nil {
+			return err
+		}
+		if symbols, err = newBuildSession(cmd, target, tapeSize, buildOptions{}).Symbols(target.Source); err != nil {
+			return err
+		}
+	default:
+		abi, err := cli.LoadABI(cli.ABIPath(binary))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		symbols = cli.SymbolsOfABI(abi)
+	}
+
+	in := cli.DisasmInput{Bytecode: bytecode, Symbols: symbols}
+	sourceMap, err := cli.LoadSourceMap(cli.SourceMapPath(binary))
+	switch {
+	case err == nil:
+		in.SourceMap = &sourceMap
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	return cli.Disasm(cmd.OutOrStdout(), in)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/shared/manifest"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [--check] [paths...]",
+	Short: "Rewrite source files in the canonical layout",
+	Long: `Rewrite source files in the canonical layout.
+
+Each path is a file, or a directory whose .ar files are all formatted, down to
+the leaves. With no path, the directory the command is run in. The name of every
+file that changed is written.
+
+Only the layout changes: the spaces inside a line, how far in a line starts,
+runs of blank lines, and the lines of a block — its { ends the line that opens
+it, a block over more than one line holds a statement per line, and its } starts
+a line of its own. Comments stay where they were written, and so do the other
+line breaks. A file that does not parse is left alone, and the command fails.
+
+With --check nothing is written: the files not in the layout are named, and
+the command fails when there is one, which is what a CI job wants.`,
+	RunE: runFmt,
+}
+
+func init() {
+	fmtCmd.Flags().Bool("check", false, "name the files not in the layout, and fail when there is one, without writing")
+}
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	check, err := cmd.Flags().GetBool("check")
+	if err != nil {
+		return err
+	}
+	files, err := cli.SourceFiles(args)
+	if err != nil {
+		return err
+	}
+
+	var failed []error
+	unformatted := 0
+	for _, file := range files {
+		session, err := newFileSession(file)
+		if err != nil {
+			return err
+		}
+		formatted, err := session.Format(file)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if !formatted.Changed() {
+			continue
+		}
+		unformatted++
+		if !check {
+			if err := os.WriteFile(file, formatted.Result, 0o644); err != nil {
+				failed = append(failed, err)
+				continue
+			}
+		}
+		_, _ = fmt.Fprintln(os.Stdout, file)
+	}
+
+	if check && unformatted > 0 {
+		failed = append(failed, fmt.Errorf("%d of %d files are not formatted; aurora fmt rewrites them", unformatted, len(files)))
+	}
+	return errors.Join(failed...)
+}
+
+// newFileSession puts the phases together for reading one file: a file is parsed with what it
+// imports, and the project it belongs to says where that is found and how wide a value is.
+//
+// The source root is found from the project rather than from where the command was run.
+// Running a program names one file, from the root of its project; formatting and linting name
+// every file under a directory, and the directory is usually not that root.
+func newFileSession(file string) (*cli.Session, error) {
+	project, err := cli.ProjectTapeSize(file)
+	if err != nil {
+		return nil, err
+	}
+	size := cli.ResolveTapeSize(0, project)
+	sourceRoot := cli.ProjectSourceRoot(file)
+	if dir, err := filepath.Abs(filepath.Dir(file)); err == nil {
+		if root, err := manifest.FindProjectRootFrom(dir); err == nil {
+			sourceRoot = manifest.AbsPath(root, sourceRoot)
+		}
+	}
+	return cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(size, sourceRoot),
+		TapeSize: size,
+	}), nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [profile | file.ar]",
+	Short: "Show what each phase of the compiler made of a program",
+	Long: `Show what each phase of the compiler made of a program.
+
+Every module the program reads is shown, in the order they load: the tokens the
+lexer read, the tree the parser built, and the instructions the emitter wrote,
+at the positions they hold in the whole program. Nothing runs.
+
+With no flag all three are shown; each flag picks one:
+
+  aurora inspect x.ar --ir          the instructions only
+  aurora inspect x.ar --ast --json  the tree, as JSON`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInspect,
+}
+
+func init() {
+	inspectCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+	inspectCmd.Flags().Bool("tokens", false, "show the tokens")
+	inspectCmd.Flags().Bool("ast", false, "show the tree")
+	inspectCmd.Flags().Bool("ir", false, "show the instructions")
+	inspectCmd.Flags().Bool("json", false, "write JSON instead of text")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	var arg string
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	target, err := cli.ResolveTarget(arg)
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	tapeSize, err := flags.GetInt("tape-size")
+	if err != nil {
+		return err
+	}
+	var opts cli.InspectOptions
+	for name, into := range map[string]*bool{"tokens": &opts.Tokens, "ast": &opts.AST, "ir": &opts.IR} {
+		if *into, err = flags.GetBool(name); err != nil {
+			return err
+		}
+	}
+	asJSON, err := flags.GetBool("json")
+	if err != nil {
+		return err
+	}
+
+	size := cli.ResolveTapeSize(tapeSize, target.TapeSize)
+	session := cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(size, target.SourceRoot),
+		TapeSize: size,
+	})
+
+	inspection, err := session.Inspect(target.Source, opts)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(inspection)
+	}
+	return cli.WriteInspection(os.Stdout, inspection)
+}
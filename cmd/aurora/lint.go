@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/linter"
+	"github.com/guiferpa/aurora/shared/manifest"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [--rules] [paths...]",
+	Short: "Report what is legal in a program and probably not meant",
+	Long: `Report what is legal in a program and probably not meant: a name nothing
+reads, an import nothing reaches through, a condition that only goes one way.
+
+Each path is a file, or a directory whose .ar files are all read, down to the
+leaves. With no path, the directory the command is run in. Files are read
+together with everything they import, so a name a module declares is used when
+any file reads it: lint a project whole.
+
+Every rule has a name and a severity, one of off, info, warning or error. The
+[lint] table of aurora.toml changes a rule's severity by its name:
+
+  [lint]
+  shadowed-name = "warning"
+  dead-code = "error"
+
+A comment silences a rule on one line, or in the whole file:
+
+  ident unused = 1; #- lint:ignore unused-ident
+  #- lint:ignore unused-ident, shadowed-name the next line holding code
+  #- lint:ignore-file dead-code
+
+The command fails when it finds a warning or an error, or a file does not parse.
+--rules lists the rules, and the severity each one has in this project.`,
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().Bool("rules", false, "list the rules and their severities instead of linting")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	list, err := cmd.Flags().GetBool("rules")
+	if err != nil {
+		return err
+	}
+	severities, err := lintSeverities()
+	if err != nil {
+		return err
+	}
+	l, err := linter.New(linter.Options{Severities: severities})
+	if err != nil {
+		return err
+	}
+	if list {
+		return writeRules(l)
+	}
+
+	paths, err := cli.SourceFiles(args)
+	if err != nil {
+		return err
+	}
+	var failed []error
+	files := make([]linter.File, 0)
+	for _, path := range paths {
+		session, err := newFileSession(path)
+		if err != nil {
+			return err
+		}
+		read, err := session.Lintable(path)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		files = append(files, read...)
+	}
+
+	findings := l.Lint(files)
+	cli.ReportFindings(os.Stdout, findings)
+
+	failing := 0
+	for _, finding := range findings {
+		if finding.Severity >= linter.Warning {
+			failing++
+		}
+	}
+	if failing > 0 {
+		failed = append(failed, fmt.Errorf("%d findings to fix, or to silence with #- lint:ignore", failing))
+	}
+	return errors.Join(failed...)
+}
+
+// lintSeverities is the [lint] table of the project the command is run in, if it is run in one.
+func lintSeverities() (map[string]string, error) {
+	root, err := manifest.FindProjectRoot()
+	if err != nil {
+		return nil, nil // no project: every rule keeps its own
+	}
+	m, err := manifest.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Lint) > 0 {
+		// Where a misspelled rule came from, for when the linter refuses it.
+		if _, err := linter.New(linter.Options{Severities: m.Lint}); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(root, manifest.Filename), err)
+		}
+	}
+	return m.Lint, nil
+}
+
+func writeRules(l *linter.Linter) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, rule := range l.Rules() {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Summary)
+	}
+	return w.Flush()
+}
@@ -17,6 +17,7 @@ import (
 
 func init() {
 	replCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8)")
+	replCmd.Flags().String("load", "", "run a file into the session before the first prompt")
 }
 
 var replCmd = &cobra.Command{
@@ -30,27 +31,45 @@ var replCmd = &cobra.Command{
 		if err := byteutil.ValidateTapeSize(tapeSize); err != nil {
 			return err
 		}
+		load, err := cmd.Flags().GetString("load")
+		if err != nil {
+			return err
+		}
 		size := byteutil.TapeSize(tapeSize)
 		out := os.Stdout
 
-		repl.NewSession(repl.NewSessionOptions{
-			Lexer:   lexer.New(),
-			Parser:  parser.New(),
-			Emitter: emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
-			// One evaluator lasts the session: a name bound on one line is there on the next.
-			Evaluator: evaluator.New(evaluator.NewEvaluatorOptions{
-				PrintBytes:   printer.Bytes(out, size),
-				PrintChars:   printer.Chars(out, size),
-				PrintDecimal: printer.Decimal(out, size),
-				TapeSize:     size,
-			}),
+		session := repl.NewSession(repl.NewSessionOptions{
+			Lexer:  lexer.New(),
+			Parser: parser.New(),
+			// Everything that depends on the width is built here, and built again when :tape
+			// changes it. One evaluator lasts until then: a name bound on one line is there on
+			// the next.
+			Build: func(size int) repl.Phases {
+				return repl.Phases{
+					Emitter: emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+					Evaluator: evaluator.New(evaluator.NewEvaluatorOptions{
+						PrintBytes:   printer.Bytes(out, size),
+						PrintChars:   printer.Chars(out, size),
+						PrintDecimal: printer.Decimal(out, size),
+						TapeSize:     size,
+					}),
+					// A use line resolves from where the session was started, which is the
+					// same answer the other commands give: the project you are standing in.
+					Resolver: newResolver(size, cli.ProjectSourceRoot("")),
+				}
+			},
 			In:       os.Stdin,
 			Out:      out,
 			TapeSize: size,
-			// A use line resolves from where the session was started, which is the same
-			// answer the other commands give: the project you are standing in.
-			Resolver: newResolver(size, cli.ProjectSourceRoot("")),
-		}).Start()
+		})
+		// A file saved from a session carries on from where it stopped. One that does not run
+		// is said before anything is typed, rather than leaving half of it bound.
+		if load != "" {
+			if err := session.Replay(load); err != nil {
+				return err
+			}
+		}
+		session.Start()
 		return nil
 	},
 }
@@ -26,12 +26,64 @@ involved:
   aurora run dev              the "dev" profile
   aurora run examples/x.ar    that file
 
-Anything after the target is passed to the program and read with feed(n).`,
+Anything after the target is passed to the program and read with feed(n).
+
+With --watch, the program runs again every time it or a module it reads
+changes, on a cleared screen.
+
+With --trace, every instruction that runs is written to stderr: where it was
+written, what its operands were worth and what it answered. --trace-module,
+--trace-op and --trace-line narrow it down, and --trace-limit bounds it:
+
+  aurora run --trace --trace-op call,return examples/x.ar
+  aurora run --trace --trace-module geometry --trace-line 3
+
+Off chain there is no call for caller, callvalue, timestamp, blocknumber and
+chainid to read, so they answer zero unless the flags say otherwise:
+
+  aurora run --caller 0x5B38Da6a701c568545dCfcB03FcB875f56beddC4 --call-value 100
+
+Nor is there another contract to call, so what each one answers is read from the
+file --contracts names, a table per address:
+
+  aurora run --contracts contracts.toml`,
 	RunE: runRun,
 }
 
 func init() {
 	runCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+	runCmd.Flags().Bool("trace", false, "write every instruction that runs to stderr")
+	runCmd.Flags().Int("trace-limit", cli.DefaultTraceLimit, "most steps a trace writes")
+	runCmd.Flags().StringSlice("trace-module", nil, "trace only these modules, by import name or file path")
+	runCmd.Flags().StringSlice("trace-op", nil, "trace only these instructions (e.g. add, OpCall)")
+	runCmd.Flags().IntSlice("trace-line", nil, "trace only these source lines")
+	addChainFlags(runCmd)
+	addWatchFlag(runCmd)
+}
+
+// traceOptions reads the --trace flags, and answers nil when the run is not traced.
+func traceOptions(cmd *cobra.Command) (*cli.TraceOptions, error) {
+	flags := cmd.Flags()
+	if on, err := flags.GetBool("trace"); err != nil || !on {
+		return nil, err
+	}
+	limit, err := flags.GetInt("trace-limit")
+	if err != nil {
+		return nil, err
+	}
+	modules, err := flags.GetStringSlice("trace-module")
+	if err != nil {
+		return nil, err
+	}
+	opcodes, err := flags.GetStringSlice("trace-op")
+	if err != nil {
+		return nil, err
+	}
+	lines, err := flags.GetIntSlice("trace-line")
+	if err != nil {
+		return nil, err
+	}
+	return &cli.TraceOptions{Limit: limit, Modules: modules, OpCodes: opcodes, Lines: lines}, nil
 }
 
 func runRun(cmd *cobra.Command, args []string) error {
@@ -49,10 +101,60 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	tracing, err := traceOptions(cmd)
+	if err != nil {
+		return err
+	}
+	chain, err := chainContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The target is resolved again on every run: under --watch the manifest may have changed
+	// what it names and how wide a value is in it.
+	session := func() (*cli.Session, cli.Target, error) {
+		target, err := cli.ResolveTarget(arg)
+		if err != nil {
+			return nil, target, err
+		}
+		contracts, err := chainContracts(cmd)
+		if err != nil {
+			return nil, target, err
+		}
+		s, err := newRunSession(target, tapeSize, programArgs, tracing, chain, contracts)
+		return s, target, err
+	}
 
+	return watch(cmd, target, func(changed []string) bool {
+		s, target, err := session()
+		return err != nil || s.Affected(target.Source, changed)
+	}, func(changed []string) error {
+		s, target, err := session()
+		if err != nil {
+			return err
+		}
+		return s.Run(cmd.Context(), target.Source)
+	})
+}
+
+// newRunSession puts the phases together for running a target, traced when tracing says how,
+// in the call chain says it is in, with contracts answering for the contracts it calls.
+func newRunSession(target cli.Target, tapeSize int, programArgs []string, tracing *cli.TraceOptions, chain evaluator.Context, contracts evaluator.Contracts) (*cli.Session, error) {
 	size := cli.ResolveTapeSize(tapeSize, target.TapeSize)
 	out := os.Stdout
 
+	// A nil *cli.Trace is not a nil evaluator.Tracer, so the port is only filled when there
+	// is a trace to fill it with.
+	var trace *cli.Trace
+	var tracer evaluator.Tracer
+	if tracing != nil {
+		var err error
+		if trace, err = cli.NewTrace(os.Stderr, *tracing); err != nil {
+			return nil, err
+		}
+		tracer = trace
+	}
+
 	return cli.NewSession(cli.NewSessionOptions{
 		Lexer:    lexer.New(),
 		Parser:   parser.New(),
@@ -65,10 +167,14 @@ func runRun(cmd *cobra.Command, args []string) error {
 				PrintDecimal: printer.Decimal(out, size),
 				Args:         cli.ParseArgs(programArgs),
 				TapeSize:     size,
+				Tracer:       tracer,
+				Context:      chain,
+				Contracts:    contracts,
 			})
 		},
 		TapeSize: size,
 		Stdout:   out,
 		Warnings: os.Stderr,
-	}).Run(cmd.Context(), target.Source)
+		Trace:    trace,
+	}), nil
 }
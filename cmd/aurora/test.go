@@ -31,13 +31,30 @@ file alone:
 
   aurora test                      the "main" profile
   aurora test dev                  the "dev" profile
-  aurora test src/greeting.test.ar that file`,
+  aurora test src/greeting.test.ar that file
+
+With --cover, every line the tests ran is counted, across the test files and
+the modules they name. A summary follows the report, and an LCOV file is
+written for editors and CI to read.
+
+With --gas, what calling each scope of the profile's source costs follows the
+report, as "aurora build --gas" says it — or of the test file, and the modules
+it names, when one was given. Nothing is written to disk.
+
+With --watch, the tests run again every time something changes — only the test
+files whose modules were touched, or all of them when aurora.toml was. The LCOV
+file written after each run still covers every test file: the ones that did not
+run again count what they reached the last time they ran.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runTest,
 }
 
 func init() {
 	testCmd.Flags().IntP("tape-size", "t", 0, "bytes per value (1-32, default 8; overrides tape_size from aurora.toml)")
+	testCmd.Flags().Bool("cover", false, "count the lines the tests ran, and write them as LCOV")
+	testCmd.Flags().String("cover-profile", "lcov.info", "where --cover writes its LCOV file")
+	testCmd.Flags().Bool("gas", false, "report what calling each scope costs")
+	addWatchFlag(testCmd)
 }
 
 // firstOf answers the file the project is found from, and nothing when there are none to run
@@ -58,15 +75,86 @@ func runTest(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	cover, err := cmd.Flags().GetBool("cover")
+	if err != nil {
+		return err
+	}
+	profile, err := cmd.Flags().GetString("cover-profile")
+	if err != nil {
+		return err
+	}
+	gas, err := cmd.Flags().GetBool("gas")
+	if err != nil {
+		return err
+	}
 
 	// Which files run, and how wide a value is in them, is settled before the phases are
 	// built: a test file named directly belongs to a project, and the project decides the width.
-	files, size, err := cli.TestFiles(target, tapeSize)
+	// Under --watch it is settled again on every run, since a test file may have appeared.
+	session := func() (*cli.Session, []string, error) {
+		files, size, err := cli.TestFiles(target, tapeSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newTestSession(files, size, cover), files, nil
+	}
+
+	// What is watched is the project the tests belong to, found the way running it is found —
+	// a test file named directly is a source file like any other.
+	watched, err := cli.ResolveTarget(target)
 	if err != nil {
 		return err
 	}
 
-	report, err := cli.NewSession(cli.NewSessionOptions{
+	// What every test file ran the last time it ran: a watched run that re-ran only some of
+	// them still reports, and writes a profile, covering all of them.
+	history := cli.NewCoverageHistory()
+
+	return watch(cmd, watched, func(changed []string) bool {
+		s, files, err := session()
+		return err != nil || len(s.AffectedTests(files, changed)) > 0
+	}, func(changed []string) error {
+		s, files, err := session()
+		if err != nil {
+			return err
+		}
+		// On the first run, and on a change to the manifest, this is every file.
+		affected := s.AffectedTests(files, changed)
+
+		report, err := s.TestWatched(cmd.Context(), affected, files, history)
+		if err != nil {
+			return err
+		}
+		if report.Coverage != nil {
+			if err := writeCoverProfile(profile, report.Coverage); err != nil {
+				return err
+			}
+		}
+		if gas {
+			// Resolved again, like the tests: the manifest may have changed what it names.
+			watched, err := cli.ResolveTarget(target)
+			if err != nil {
+				return err
+			}
+			if _, err := newBuildSession(cmd, watched, tapeSize, buildOptions{}).Gas(watched.Source); err != nil {
+				return err
+			}
+		}
+		if !report.OK() {
+			// The report has already been written; this is only what the exit code carries,
+			// so that a script or a CI job can tell what happened.
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d assertions failed", report.Failed, report.Passed+report.Failed)
+			}
+			return fmt.Errorf("some test files could not run")
+		}
+		return nil
+	})
+}
+
+// newTestSession puts the phases together for running test files at a width.
+func newTestSession(files []string, size int, cover bool) *cli.Session {
+	return cli.NewSession(cli.NewSessionOptions{
 		Lexer:   lexer.New(),
 		Parser:  parser.New(),
 		Emitter: emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
@@ -82,21 +170,24 @@ func runTest(cmd *cobra.Command, args []string) error {
 				PrintDecimal: printer.Decimal(io.Discard, size),
 				TapeSize:     size,
 				Asserts:      true,
+				Cover:        cover,
 			})
 		},
 		TapeSize: size,
 		Stdout:   os.Stdout,
-	}).Test(cmd.Context(), files)
+	})
+}
+
+// writeCoverProfile writes what the tests ran to path, as LCOV. It is written whether or not
+// the tests passed: which lines a failing run reached is exactly what someone wants to look at.
+func writeCoverProfile(path string, coverage *cli.Coverage) error {
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	if !report.OK() {
-		// The report has already been written; this is only what the exit code carries, so
-		// that a script or a CI job can tell what happened.
-		if report.Failed > 0 {
-			return fmt.Errorf("%d of %d assertions failed", report.Failed, report.Passed+report.Failed)
-		}
-		return fmt.Errorf("some test files could not run")
+	if err := cli.WriteLCOV(f, coverage); err != nil {
+		_ = f.Close()
+		return err
 	}
-	return nil
+	return f.Close()
 }
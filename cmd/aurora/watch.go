@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guiferpa/aurora/hosting/cli"
+)
+
+// addWatchFlag gives a command "--watch". Run, test and build share it, and what it means is
+// the same for the three: do this once, then again every time the project changes.
+func addWatchFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "run again every time the source, its modules or aurora.toml change")
+}
+
+// watch runs once, or — under --watch — runs once and then every time a change affects it,
+// until Ctrl+C.
+//
+// Ctrl+C ends the watch rather than the process, so the command returns like any other and
+// the exit code is decided where it always is.
+func watch(cmd *cobra.Command, target cli.Target, affects func(changed []string) bool, run func(changed []string) error) error {
+	on, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
+	}
+	if !on {
+		return run(nil)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	return cli.Watch(ctx, cli.WatchOptions{
+		Paths:   cli.WatchedPaths(target),
+		Screen:  os.Stdout,
+		Affects: affects,
+	}, run)
+}
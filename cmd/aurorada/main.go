@@ -0,0 +1,75 @@
+// Command aurorada is the Aurora debug adapter: what an editor runs to debug a program, the
+// way it runs aurorals to read one. It speaks the Debug Adapter Protocol over stdin and
+// stdout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/hosting/dap"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/version"
+)
+
+func main() {
+	logPath := flag.String("log", "", "write adapter logs to this file (default: stderr)")
+	showVersion := flag.Bool("version", false, "show version and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.VERSION)
+		return
+	}
+
+	// stdin and stdout carry the protocol, so logs go where the language server's do.
+	var out io.Writer = os.Stderr
+	if *logPath != "" {
+		file, err := os.Create(*logPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aurorada: cannot open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+
+	logger := log.New(out, "[aurorada] ", log.Ldate|log.Ltime|log.Lshortfile)
+	logger.Printf("Adapter started (version %s)", version.VERSION)
+
+	adapter := dap.New(dap.Options{Compile: compile, Logger: logger})
+	if err := adapter.Serve(os.Stdin, os.Stdout); err != nil {
+		logger.Println(err)
+	}
+
+	logger.Println("Adapter stopped")
+}
+
+// compile reads a launch's program the way "aurora debug" reads its target, from the directory
+// the launch names. What compiling says about the program — warnings, mostly — goes to stderr:
+// stdout is the protocol's.
+func compile(arg, dir string) (loader.Program, int, error) {
+	target, err := cli.ResolveTargetFrom(dir, arg)
+	if err != nil {
+		return loader.Program{}, 0, err
+	}
+	size := cli.ResolveTapeSize(0, target.TapeSize)
+	session := cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(size, target.SourceRoot),
+		TapeSize: size,
+		Stdout:   os.Stderr,
+		Warnings: os.Stderr,
+	})
+	program, err := session.Compile(target.Source)
+	return program, size, err
+}
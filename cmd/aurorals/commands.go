@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/hosting/lsp/state"
+	"github.com/guiferpa/aurora/hosting/lsp/textdoc"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/shared/printer"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// What the code lenses run.
+//
+// A command runs in the server, on an evaluator of its own, against what the editor shows:
+// every file is read through the buffers, so what runs is what is on screen, saved or not.
+// It is compiled the way "aurora run" and "aurora test" compile — the same session, built
+// here rather than in cmd/aurora — and what happened goes back twice: as the answer to the
+// request, for a client that does something with it, and as a message, for the person who
+// clicked.
+
+// A RunResult is what running a scope answers with: everything the program printed, the
+// call's answer last, and what stopped it if something did.
+type RunResult struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// A TestResult is what running a test file answers with.
+type TestResult struct {
+	Passed  int             `json:"passed"`
+	Failed  int             `json:"failed"`
+	Results []AssertOutcome `json:"results"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type AssertOutcome struct {
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// executeCommand carries out a command a lens named.
+func (sv server) executeCommand(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseExecuteCommandRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	args := make([]string, 0, len(req.Params.Arguments))
+	for _, raw := range req.Params.Arguments {
+		var arg string
+		if err := json.Unmarshal(raw, &arg); err != nil {
+			// A value typed as a number arrives as one, and is written the same.
+			arg = string(raw)
+		}
+		args = append(args, arg)
+	}
+
+	switch req.Params.Command {
+	case textdoc.RunScope:
+		if len(args) < 2 {
+			return lsp.NewFailedResponse(req.ID, "running a scope needs the file and its name")
+		}
+		result := runScope(s, args[0], args[1], args[2:])
+		return lsp.Messages{textdoc.NewExecuteCommandResponse(req.ID, result), result.message(args[1])}
+	case textdoc.RunTests:
+		if len(args) < 1 {
+			return lsp.NewFailedResponse(req.ID, "running tests needs the file")
+		}
+		only := ""
+		if len(args) > 1 {
+			only = args[1]
+		}
+		result := runTests(s, args[0], only)
+		return lsp.Messages{textdoc.NewExecuteCommandResponse(req.ID, result), result.message(filepath.Base(args[0]))}
+	}
+	return lsp.NewFailedResponse(req.ID, "unknown command: "+req.Params.Command)
+}
+
+// runScope runs the file a scope is in, and then calls the scope with the values given.
+//
+// The call is written at the bottom of the file rather than made some other way, because that
+// is all calling a scope from outside is: the file runs as it would, its modules before it,
+// and one more line asks for the answer. Whatever the file prints on the way is part of the
+// output — it is what running it does.
+func runScope(s *state.State, filename, name string, values []string) RunResult {
+	for _, value := range values {
+		if !isValue(value) {
+			return RunResult{Error: fmt.Sprintf("%q is not a value: a number, or true or false", value)}
+		}
+	}
+	call := fmt.Sprintf("\nprintd %s(%s);\n", name, strings.Join(values, ", "))
+
+	read := readThroughBuffers(s)
+	entry, err := filepath.Abs(filename)
+	if err != nil {
+		entry = filename
+	}
+	withCall := func(path string) ([]byte, error) {
+		source, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		if absolute, err := filepath.Abs(path); err == nil && absolute == entry {
+			return append(source, call...), nil
+		}
+		return source, nil
+	}
+
+	out := bytes.NewBuffer(nil)
+	size := tapeSizeFor(filename)
+	session := cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(withCall, size, sourceRootFor(filename)),
+		NewEvaluator: func() *evaluator.Evaluator {
+			return evaluator.New(evaluator.NewEvaluatorOptions{
+				PrintBytes:   printer.Bytes(out, size),
+				PrintChars:   printer.Chars(out, size),
+				PrintDecimal: printer.Decimal(out, size),
+				TapeSize:     size,
+			})
+		},
+		TapeSize: size,
+	})
+
+	result := RunResult{}
+	if err := session.Run(context.Background(), entry); err != nil {
+		result.Error = err.Error()
+	}
+	result.Output = out.String()
+	return result
+}
+
+// isValue says whether what a client handed in is one value: written into a line of source,
+// anything else would be more source.
+func isValue(value string) bool {
+	tokens, err := lexer.New().GetFilledTokens([]byte(value))
+	if err != nil || len(tokens) != 2 {
+		return false
+	}
+	switch tokens[0].GetTag().Id {
+	case token.NUMBER, token.TRUE, token.FALSE:
+		return true
+	}
+	return false
+}
+
+// runTests runs a test file as "aurora test" runs one, and answers every assertion — or, from
+// a lens above one, the assertions written with that message.
+func runTests(s *state.State, filename, only string) TestResult {
+	size := tapeSizeFor(filename)
+	session := cli.NewSession(cli.NewSessionOptions{
+		Lexer:    lexer.New(),
+		Parser:   parser.New(),
+		Emitter:  emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+		Resolver: newResolver(readThroughBuffers(s), size, sourceRootFor(filename)),
+		NewEvaluator: func() *evaluator.Evaluator {
+			return evaluator.New(evaluator.NewEvaluatorOptions{
+				PrintBytes:   printer.Bytes(io.Discard, size),
+				PrintChars:   printer.Chars(io.Discard, size),
+				PrintDecimal: printer.Decimal(io.Discard, size),
+				TapeSize:     size,
+				Asserts:      true,
+			})
+		},
+		TapeSize: size,
+	})
+
+	result := TestResult{Results: make([]AssertOutcome, 0)}
+	report, err := session.Test(context.Background(), []string{filename})
+	if err == nil && len(report.Files) == 1 {
+		err = report.Files[0].Err
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// A failed assertion's message is the one it was written with, said as a failure.
+	for _, each := range report.Files[0].Results {
+		if only != "" && each.Message != only && each.Message != "assertion failed: "+only {
+			continue
+		}
+		result.Results = append(result.Results, AssertOutcome{Passed: each.Passed, Message: each.Message})
+		if each.Passed {
+			result.Passed++
+		} else {
+			result.Failed++
+		}
+	}
+	return result
+}
+
+// message is what the person who ran a scope is told: the call's answer, which is the last
+// line printed, or what went wrong.
+func (r RunResult) message(name string) lsp.ShowMessageNotification {
+	if r.Error != "" {
+		return lsp.NewShowMessageNotification(lsp.MessageError, name+": "+r.Error)
+	}
+	lines := strings.Split(strings.TrimRight(r.Output, "\n"), "\n")
+	return lsp.NewShowMessageNotification(lsp.MessageInfo, name+" answered "+lines[len(lines)-1])
+}
+
+// message is what the person who ran a test is told: how many held, and the first that did
+// not.
+func (r TestResult) message(file string) lsp.ShowMessageNotification {
+	if r.Error != "" {
+		return lsp.NewShowMessageNotification(lsp.MessageError, file+": "+r.Error)
+	}
+	summary := fmt.Sprintf("%s: %d passed, %d failed", file, r.Passed, r.Failed)
+	for _, each := range r.Results {
+		if !each.Passed {
+			return lsp.NewShowMessageNotification(lsp.MessageError, summary+" — "+each.Message)
+		}
+	}
+	if r.Passed == 0 {
+		return lsp.NewShowMessageNotification(lsp.MessageWarning, file+": no assertions ran")
+	}
+	return lsp.NewShowMessageNotification(lsp.MessageInfo, summary)
+}
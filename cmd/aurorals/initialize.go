@@ -21,5 +21,10 @@ func InitializeHandler(l *log.Logger, s *state.State, contents []byte) any {
 	// Snippets are only offered to a client that expands them; to anyone else the
 	// placeholders are literal text.
 	s.SetSnippetSupport(req.SnippetSupport())
+	roots := make([]string, 0)
+	for _, root := range req.Roots() {
+		roots = append(roots, string(root))
+	}
+	s.SetRoots(roots)
 	return initialize.NewResponse(req.ID)
 }
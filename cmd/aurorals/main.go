@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/guiferpa/aurora/builder/evm"
 	"github.com/guiferpa/aurora/emitter"
 	"github.com/guiferpa/aurora/hosting/lsp"
 	"github.com/guiferpa/aurora/hosting/lsp/state"
@@ -56,6 +57,8 @@ func main() {
 		Parser:  parser.New(),
 		Emit:    emitter.New(emitter.NewEmitterOptions{}).EmitProgram,
 		Resolve: resolveModules(documents),
+		Locate:  locateProject(documents),
+		Target:  evm.Warnings,
 	})}
 
 	lsp.Listen(logger, os.Stdin, os.Stdout, documents, sv.handlers())
@@ -84,5 +87,14 @@ func (sv server) handlers() map[lsp.Method]lsp.MethodHandler {
 		"textDocument/prepareRename":       sv.prepareRename,
 		"textDocument/rename":              sv.rename,
 		"textDocument/semanticTokens/full": sv.semanticTokens,
+		"textDocument/formatting":          sv.formatting,
+		"textDocument/rangeFormatting":     sv.rangeFormatting,
+		"textDocument/codeAction":          sv.codeAction,
+		"textDocument/signatureHelp":       sv.signatureHelp,
+		"textDocument/inlayHint":           sv.inlayHint,
+		"textDocument/documentSymbol":      sv.documentSymbol,
+		"workspace/symbol":                 sv.workspaceSymbol,
+		"textDocument/codeLens":            sv.codeLens,
+		"workspace/executeCommand":         sv.executeCommand,
 	}
 }
@@ -26,35 +26,41 @@ import (
 // resolveModules answers with the modules a document imports, for a tree the server already
 // parsed. It is the port textdoc is handed: everything about the world is on this side of it.
 func resolveModules(s *state.State) textdoc.Resolve {
+	return func(doc textdoc.Document, uses []ast.UseDeclaration) ([]module.Module, error) {
+		return newResolver(readThroughBuffers(s), doc.TapeSize, sourceRootFor(doc.Filename)).DependenciesOf(doc.Filename, uses)
+	}
+}
+
+// newResolver puts a resolver together the way the command line does, reading through read:
+// the editor's buffers, for everything the server resolves or runs.
+func newResolver(read resolver.Read, tapeSize int, sourceRoot string) *resolver.Resolver {
 	lx := lexer.New()
 	ps := parser.New()
 
-	return func(doc textdoc.Document, uses []ast.UseDeclaration) ([]module.Module, error) {
-		return resolver.New(resolver.Options{
-			SourceRoot: sourceRootFor(doc.Filename),
-			Read:       readThroughBuffers(s),
-			Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
-				tokens, err := lx.GetFilledTokens(source)
-				if err != nil {
-					return ast.AST{}, err
-				}
-				return ps.Parse(parser.ParseInput{
-					Filename: filename,
-					Tokens:   tokens,
-					TapeSize: doc.TapeSize,
-					Module:   string(id),
-					Imports:  imports,
-				})
-			},
-			Header: func(source []byte) ([]ast.UseDeclaration, error) {
-				tokens, err := lx.GetFilledTokens(source)
-				if err != nil {
-					return nil, err
-				}
-				return parser.ScanUses(tokens), nil
-			},
-		}).DependenciesOf(doc.Filename, uses)
-	}
+	return resolver.New(resolver.Options{
+		SourceRoot: sourceRoot,
+		Read:       read,
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return ps.Parse(parser.ParseInput{
+				Filename: filename,
+				Tokens:   tokens,
+				TapeSize: tapeSize,
+				Module:   string(id),
+				Imports:  imports,
+			})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
 }
 
 // readThroughBuffers answers with what the editor is showing, and falls back to the disk.
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/hosting/lsp/state"
+	"github.com/guiferpa/aurora/hosting/lsp/textdoc"
+	"github.com/guiferpa/aurora/resolver"
 	"github.com/guiferpa/aurora/shared/manifest"
 )
 
@@ -114,3 +121,94 @@ func sourceRootFor(filename string) string {
 	}
 	return filepath.Join(dir, manifest.DefaultSourceRoot)
 }
+
+// locateProject answers what surrounds a document for the quick fixes that reach past it: the
+// manifest, read through the editor's buffers like any file, and every module under the source
+// root.
+func locateProject(s *state.State) textdoc.Locate {
+	read := readThroughBuffers(s)
+
+	return func(doc textdoc.Document) textdoc.Project {
+		project := textdoc.Project{Modules: modulesUnder(sourceRootFor(doc.Filename))}
+		if found, ok := settingsFor(doc.Filename); ok && found.manifest != "" {
+			if source, err := read(found.manifest); err == nil {
+				project.Manifest, project.ManifestSource = found.manifest, string(source)
+			}
+		}
+		return project
+	}
+}
+
+// modulesUnder is every module a source root holds, by the specifier a use line names it
+// with. A test file is not a module: nothing imports one.
+func modulesUnder(root string) []string {
+	modules := make([]string, 0)
+	_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, resolver.Extension) || strings.HasSuffix(path, ".test"+resolver.Extension) {
+			return nil
+		}
+		relative, err := filepath.Rel(root, path)
+		if err == nil {
+			modules = append(modules, strings.TrimSuffix(filepath.ToSlash(relative), resolver.Extension))
+		}
+		return nil
+	})
+	return modules
+}
+
+// A projectFile is one file a workspace search reads: where it is, the URI it is answered
+// under, and the module a use line names it by — empty for a file outside any source root.
+type projectFile struct {
+	path   string
+	uri    lsp.URI
+	module string
+}
+
+// projectFiles answers every module under the source root of each folder the editor opened
+// and of each document it has open, and then the open documents no source root holds: an
+// entry file usually sits next to src/ rather than in it, and it is the one most likely to be
+// searched for. A file is answered once however many ways it is reached.
+func projectFiles(s *state.State) []projectFile {
+	open := make(map[string]lsp.URI)
+	sourceRoots := make([]string, 0)
+	for _, root := range s.Roots() {
+		sourceRoots = append(sourceRoots, sourceRootFor(filepath.Join(textdoc.PathFromURI(lsp.URI(root)), manifest.Filename)))
+	}
+	for uri := range s.Documents() {
+		path := textdoc.PathFromURI(lsp.URI(uri))
+		if absolute, err := filepath.Abs(path); err == nil {
+			open[absolute] = lsp.URI(uri)
+		}
+		sourceRoots = append(sourceRoots, sourceRootFor(path))
+	}
+
+	files := make([]projectFile, 0)
+	seen := make(map[string]bool)
+	add := func(path, module string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		uri, isOpen := open[path]
+		if !isOpen {
+			uri = uriOf(path)
+		}
+		files = append(files, projectFile{path: path, uri: uri, module: module})
+	}
+
+	slices.Sort(sourceRoots)
+	for _, root := range slices.Compact(sourceRoots) {
+		for _, specifier := range modulesUnder(root) {
+			add(filepath.Join(root, filepath.FromSlash(specifier)+resolver.Extension), specifier)
+		}
+	}
+	paths := make([]string, 0, len(open))
+	for path := range open {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		add(path, "")
+	}
+	return files
+}
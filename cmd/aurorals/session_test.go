@@ -419,3 +419,225 @@ func TestSessionRenameIsRefusedWithAReason(t *testing.T) {
 		t.Errorf("said %v, want it to say why", failure["message"])
 	}
 }
+
+// A quick fix that reaches past the document, through the server wired the way main wires it:
+// the literal does not fit the project's width, and the edit is to the project's manifest,
+// under the URI its path makes.
+func TestSessionCodeActionWidensTheProjectTape(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := "[project]\n  name = \"narrow\"\n  tape_size = 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "aurora.toml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "src", "main.ar"))
+	replies := session(t, func(documents *state.State) textdoc.NewSessionOptions {
+		return textdoc.NewSessionOptions{
+			Lexer:   lexer.New(),
+			Parser:  parser.New(),
+			Resolve: resolveModules(documents),
+			Locate:  locateProject(documents),
+		}
+	},
+		didOpen(uri, "printd 300;\n"),
+		request(11, "textDocument/codeAction", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range":        map[string]any{"start": map[string]any{"line": 0, "character": 8}, "end": map[string]any{"line": 0, "character": 8}},
+			"context":      map[string]any{"diagnostics": []any{}},
+		}),
+		exitMessage,
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected diagnostics plus the code actions, got %d: %v", len(replies), replies)
+	}
+	actions := replies[1]["result"].([]any)
+	if len(actions) != 1 {
+		t.Fatalf("offered %v, want one fix", actions)
+	}
+	action := actions[0].(map[string]any)
+	if action["title"] != "Set tape_size to 2 in aurora.toml" || action["kind"] != "quickfix" {
+		t.Errorf("offered %v", action)
+	}
+	if preferred, _ := action["isPreferred"].(bool); preferred {
+		t.Error("widening every value of the project is offered as preferred, which an editor may apply unasked")
+	}
+	changes := action["edit"].(map[string]any)["changes"].(map[string]any)
+	edits, touched := changes["file://"+filepath.ToSlash(filepath.Join(dir, "aurora.toml"))]
+	if !touched || len(changes) != 1 {
+		t.Fatalf("changed %v, want the manifest alone", changes)
+	}
+	if got := edits.([]any)[0].(map[string]any)["newText"]; got != " 2" {
+		t.Errorf("writes %q, want the new width", got)
+	}
+}
+
+// A client asking for refactorings alone is answered with none, rather than with fixes it did
+// not ask for.
+func TestSessionCodeActionHonoursTheKindsAskedFor(t *testing.T) {
+	uri := "file:///tmp/main.ar"
+	replies := runSession(t,
+		didOpen(uri, "shape point { x };\n"),
+		request(12, "textDocument/codeAction", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range":        map[string]any{"start": map[string]any{"line": 0, "character": 7}, "end": map[string]any{"line": 0, "character": 7}},
+			"context":      map[string]any{"diagnostics": []any{}, "only": []string{"refactor"}},
+		}),
+		exitMessage,
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected diagnostics plus the code actions, got %d: %v", len(replies), replies)
+	}
+	if actions := replies[1]["result"].([]any); len(actions) != 0 {
+		t.Errorf("offered %v to a client that asked for refactorings", actions)
+	}
+}
+
+// Signature help while a call is being written, which is a document that does not parse: the
+// reply names the positions the scope reads and which one the cursor is on.
+func TestSessionSignatureHelpWhileTyping(t *testing.T) {
+	uri := "file:///tmp/main.ar"
+	replies := runSession(t,
+		didOpen(uri, "ident area = defer { feed(0) * feed(1); };\nprintd area(2, "),
+		request(13, "textDocument/signatureHelp", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": 1, "character": 15},
+		}),
+		exitMessage,
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected diagnostics plus the signature, got %d: %v", len(replies), replies)
+	}
+	result := replies[1]["result"].(map[string]any)
+	signature := result["signatures"].([]any)[0].(map[string]any)
+	if signature["label"] != "area(feed(0), feed(1))" {
+		t.Errorf("label is %v", signature["label"])
+	}
+	if result["activeParameter"] != float64(1) {
+		t.Errorf("the cursor is on %v, want the second value", result["activeParameter"])
+	}
+}
+
+// A search across the project reads every module under the source root of the folder the
+// editor opened, including the ones nobody has open, and answers each by its file's URI.
+func TestSessionWorkspaceSymbolReachesEveryModule(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src", "geometry"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range map[string]string{
+		"aurora.toml":            "[project]\n  name = \"search\"\n\n[profiles]\n  [profiles.main]\n    source = \"src/main.ar\"\n    binary = \"bin/main\"\n",
+		"src/geometry/shapes.ar": "shape Square { w, h };\nident new_square = defer { Square{feed(0), feed(1)}; };\n",
+		"src/main.ar":            "use geometry/shapes as g;\nprintd 1;\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replies := runSessionInProject(t, dir,
+		request(1, "initialize", map[string]any{"rootUri": "file://" + filepath.ToSlash(dir)}),
+		request(2, "workspace/symbol", map[string]any{"query": "square"}),
+		exitMessage,
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected initialize plus the symbols, got %d: %v", len(replies), replies)
+	}
+	found := replies[1]["result"].([]any)
+	names := make([]string, 0)
+	for _, symbol := range found {
+		symbol := symbol.(map[string]any)
+		names = append(names, fmt.Sprintf("%v in %v", symbol["name"], symbol["containerName"]))
+		want := "file://" + filepath.ToSlash(filepath.Join(dir, "src", "geometry", "shapes.ar"))
+		if uri := symbol["location"].(map[string]any)["uri"]; uri != want {
+			t.Errorf("%v points at %v, want %s", symbol["name"], uri, want)
+		}
+	}
+	if strings.Join(names, ", ") != "Square in geometry/shapes, new_square in geometry/shapes" {
+		t.Errorf("found %v", names)
+	}
+}
+
+// The outline of an open document, over the wire.
+func TestSessionDocumentSymbol(t *testing.T) {
+	uri := "file:///tmp/main.ar"
+	replies := runSession(t,
+		didOpen(uri, "ident a = 1;\nshape P { x };\n"),
+		request(4, "textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": uri}}),
+		exitMessage,
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("expected diagnostics plus the outline, got %d: %v", len(replies), replies)
+	}
+	symbols := replies[1]["result"].([]any)
+	if len(symbols) != 2 || symbols[1].(map[string]any)["name"] != "P" || len(symbols[1].(map[string]any)["children"].([]any)) != 1 {
+		t.Errorf("outline is %v", symbols)
+	}
+}
+
+// A lens runs a scope in the server: the file runs, the call is made with what the client
+// handed in, and the answer comes back both as the reply and as a message for the person.
+func TestSessionExecuteCommandRunsAScope(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.ar")
+	if err := os.WriteFile(path, []byte("ident area = defer { feed(0) * feed(1); };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := runSessionInProject(t, dir,
+		request(5, "workspace/executeCommand", map[string]any{"command": "aurora.runScope", "arguments": []any{path, "area", "6", 7}}),
+		request(6, "workspace/executeCommand", map[string]any{"command": "aurora.runScope", "arguments": []any{path, "area", "1); printd 2"}}),
+		exitMessage,
+	)
+
+	if len(replies) != 4 {
+		t.Fatalf("expected a reply and a message for each command, got %d: %v", len(replies), replies)
+	}
+	if output := replies[0]["result"].(map[string]any)["output"]; output != "42\n" {
+		t.Errorf("output is %q", output)
+	}
+	if told := replies[1]["params"].(map[string]any)["message"]; told != "area answered 42" {
+		t.Errorf("told %q", told)
+	}
+	if failure := replies[2]["result"].(map[string]any)["error"]; failure == nil || !strings.Contains(failure.(string), "is not a value") {
+		t.Errorf("source passed in as a value was not refused: %v", replies[2])
+	}
+}
+
+// A lens above one assertion runs the file and answers for that assertion alone.
+func TestSessionExecuteCommandRunsTests(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.test.ar")
+	source := "ident a = 2;\nassert(a equals 2, \"a is two\");\nassert(a equals 3, \"a is three\");\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := runSessionInProject(t, dir,
+		request(7, "workspace/executeCommand", map[string]any{"command": "aurora.runTests", "arguments": []any{path}}),
+		request(8, "workspace/executeCommand", map[string]any{"command": "aurora.runTests", "arguments": []any{path, "a is two"}}),
+		exitMessage,
+	)
+
+	if len(replies) != 4 {
+		t.Fatalf("expected a reply and a message for each command, got %d: %v", len(replies), replies)
+	}
+	all := replies[0]["result"].(map[string]any)
+	if all["passed"] != float64(1) || all["failed"] != float64(1) {
+		t.Errorf("the file answered %v", all)
+	}
+	if told := replies[1]["params"].(map[string]any); told["type"] != float64(lsp.MessageError) || !strings.Contains(told["message"].(string), "assertion failed: a is three") {
+		t.Errorf("told %v", told)
+	}
+	one := replies[2]["result"].(map[string]any)
+	if one["passed"] != float64(1) || one["failed"] != float64(0) {
+		t.Errorf("the one assertion answered %v", one)
+	}
+}
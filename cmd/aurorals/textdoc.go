@@ -183,3 +183,142 @@ func (sv server) semanticTokens(l *log.Logger, s *state.State, contents []byte)
 
 	return textdoc.NewSemanticTokensResponse(req.ID, data)
 }
+
+// formatting answers the edit that puts a document in the layout "aurora fmt" writes.
+func (sv server) formatting(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseFormattingRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	edits := sv.textdoc.FormattingFor(document(uri, s.GetDocument(string(uri))))
+	return textdoc.NewFormattingResponse(req.ID, edits)
+}
+
+// rangeFormatting answers the edit that puts the lines of a selection in the same layout.
+func (sv server) rangeFormatting(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseRangeFormattingRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	edits := sv.textdoc.RangeFormattingFor(document(uri, s.GetDocument(string(uri))), req.Params.Range)
+	return textdoc.NewFormattingResponse(req.ID, edits)
+}
+
+// codeAction answers the quick fixes for the diagnostics under a range, each edit under the URI
+// of the file it changes — the open document under the one the client sent, and anything else,
+// aurora.toml, under the one its path makes.
+func (sv server) codeAction(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseCodeActionRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	actions := make([]textdoc.CodeAction, 0)
+	if !req.Params.Context.Wants(textdoc.QuickFix) {
+		return textdoc.NewCodeActionResponse(req.ID, actions)
+	}
+
+	uri := req.Params.TextDocument.URI
+	doc := document(uri, s.GetDocument(string(uri)))
+	for _, fix := range sv.textdoc.FixesFor(doc, req.Params.Range) {
+		changes := make(map[lsp.URI][]textdoc.TextEdit, len(fix.Edits))
+		for filename, edits := range fix.Edits {
+			at := uri
+			if filename != doc.Filename {
+				at = uriOf(filename)
+			}
+			changes[at] = edits
+		}
+		actions = append(actions, textdoc.CodeAction{
+			Title:       fix.Title,
+			Kind:        textdoc.QuickFix,
+			Diagnostics: []textdoc.Diagnostic{fix.Diagnostic},
+			IsPreferred: fix.Preferred,
+			Edit:        &textdoc.WorkspaceEdit{Changes: changes},
+		})
+	}
+	return textdoc.NewCodeActionResponse(req.ID, actions)
+}
+
+// signatureHelp answers what the call under the cursor reads, or null outside of one.
+func (sv server) signatureHelp(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseSignatureHelpRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	help, ok := sv.textdoc.SignatureFor(document(uri, s.GetDocument(string(uri))), req.Params.Position)
+	if !ok {
+		return lsp.NewNullResponse(&req.ID)
+	}
+	return textdoc.NewSignatureHelpResponse(req.ID, help)
+}
+
+func (sv server) inlayHint(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseInlayHintRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	hints := sv.textdoc.InlayHintsFor(document(uri, s.GetDocument(string(uri))), req.Params.Range)
+	return textdoc.NewInlayHintResponse(req.ID, hints)
+}
+
+// documentSymbol answers the outline of a document.
+func (sv server) documentSymbol(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseDocumentSymbolRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	symbols := sv.textdoc.DocumentSymbolsFor(document(uri, s.GetDocument(string(uri))))
+	return textdoc.NewDocumentSymbolResponse(req.ID, symbols)
+}
+
+// workspaceSymbol answers the symbols a search matches in every module of the projects the
+// editor is working in, and in the documents it has open that belong to none of them. A file
+// open in the editor is read from its buffer and named by the URI the client sent.
+func (sv server) workspaceSymbol(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseWorkspaceSymbolRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	read := readThroughBuffers(s)
+	found := make([]textdoc.SymbolInformation, 0)
+	for _, file := range projectFiles(s) {
+		source, err := read(file.path)
+		if err != nil {
+			continue
+		}
+		doc := textdoc.Document{Filename: file.path, Source: string(source), TapeSize: tapeSizeFor(file.path)}
+		found = append(found, sv.textdoc.WorkspaceSymbolsIn(doc, file.uri, file.module, req.Params.Query)...)
+	}
+	return textdoc.NewWorkspaceSymbolResponse(req.ID, found)
+}
+
+// codeLens answers the lenses of a document.
+func (sv server) codeLens(l *log.Logger, s *state.State, contents []byte) any {
+	req, err := textdoc.ParseCodeLensRequest(contents)
+	if err != nil {
+		l.Println(err)
+		return nil
+	}
+
+	uri := req.Params.TextDocument.URI
+	return textdoc.NewCodeLensResponse(req.ID, sv.textdoc.CodeLensesFor(document(uri, s.GetDocument(string(uri)))))
+}
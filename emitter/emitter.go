@@ -103,8 +103,14 @@ func EmitInstruction(tc *int, insts *[]ir.Instruction, expr ast.Node, tapeSize i
 		return emitPrintStatement(tc, insts, n, tapeSize)
 	case ast.AssertStatement:
 		return emitAssertStatement(tc, insts, n, tapeSize)
+	case ast.RequireStatement:
+		return emitRequireStatement(tc, insts, n, tapeSize)
 	case ast.FeedExpression:
 		return emitFeedExpression(tc, insts, n, tapeSize)
+	case ast.ContextExpression:
+		return emitContextExpression(tc, insts, n)
+	case ast.ContractCall:
+		return emitContractCall(tc, insts, n, tapeSize)
 	case ast.BinaryExpression:
 		return emitBinaryExpression(tc, insts, n, tapeSize)
 	case ast.NumberLiteral:
@@ -431,6 +437,17 @@ func emitAssertStatement(tc *int, insts *[]ir.Instruction, n ast.AssertStatement
 
 }
 
+// emitRequireStatement guards on a condition the way an assertion checks one. The two are
+// emitted alike and told apart by their opcode, since what a failure does is up to whoever
+// runs the instruction.
+func emitRequireStatement(tc *int, insts *[]ir.Instruction, n ast.RequireStatement, tapeSize int) ir.Label {
+	cond := operandFor(tc, insts, n.Condition, tapeSize)
+	l := GenerateLabel(tc)
+	*insts = append(*insts, ir.NewInstruction(l, ir.OpRequire, cond, ir.TextOf(n.Message)).At(originOf(n.Token)))
+	return l
+
+}
+
 // emitFeedExpression reads the nth value applied to this scope.
 func emitFeedExpression(tc *int, insts *[]ir.Instruction, n ast.FeedExpression, tapeSize int) ir.Label {
 	l := GenerateLabel(tc)
@@ -439,6 +456,41 @@ func emitFeedExpression(tc *int, insts *[]ir.Instruction, n ast.FeedExpression,
 
 }
 
+// contextOps is the instruction each context builtin is.
+var contextOps = map[ast.ContextReading]byte{
+	ast.Caller:      ir.OpCaller,
+	ast.CallValue:   ir.OpCallValue,
+	ast.Timestamp:   ir.OpTimestamp,
+	ast.BlockNumber: ir.OpBlockNumber,
+	ast.ChainID:     ir.OpChainID,
+}
+
+// emitContextExpression reads something about the call the program is running in.
+func emitContextExpression(tc *int, insts *[]ir.Instruction, n ast.ContextExpression) ir.Label {
+	l := GenerateLabel(tc)
+	*insts = append(*insts, ir.NewInstruction(l, contextOps[n.Reading], ir.Nothing(), ir.Nothing()).At(originOf(n.Token)))
+	return l
+}
+
+// emitContractCall calls another contract. The address comes first and the signature after
+// it, then the values in the order they were written — the order of a call to a scope, with
+// what it reaches and what it is called by in front.
+func emitContractCall(tc *int, insts *[]ir.Instruction, n ast.ContractCall, tapeSize int) ir.Label {
+	operands := make([]ir.Operand, 0, len(n.Values)+2)
+	operands = append(operands, operandFor(tc, insts, n.Address, tapeSize), ir.TextOf(n.Signature))
+	for _, value := range n.Values {
+		operands = append(operands, operandFor(tc, insts, value, tapeSize))
+	}
+
+	op := ir.OpContractCall
+	if n.Static {
+		op = ir.OpStaticCall
+	}
+	l := GenerateLabel(tc)
+	*insts = append(*insts, ir.NewInstructionOver(l, op, operands...).At(originOf(n.Token)))
+	return l
+}
+
 // emitBinaryExpression does arithmetic on two values.
 func emitBinaryExpression(tc *int, insts *[]ir.Instruction, n ast.BinaryExpression, tapeSize int) ir.Label {
 	ll := operandFor(tc, insts, n.Left, tapeSize)
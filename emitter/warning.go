@@ -89,7 +89,7 @@ func checkAsserts(nodes []ast.Node) []diag.Warning {
 // answering with one of two scopes — and which body a call reaches is a runtime question,
 // which is not one this can answer.
 func checkAppliedValues(nodes []ast.Node) []diag.Warning {
-	reads := scopeReads(nodes)
+	reads := ScopeReads(nodes)
 
 	warnings := make([]diag.Warning, 0)
 	var walk func(scope []ast.Node)
@@ -113,9 +113,12 @@ func checkAppliedValues(nodes []ast.Node) []diag.Warning {
 // the scope is fine, and the call is what has to change.
 func appliedValuesWarning(call ast.CalleeLiteral, positions int) diag.Warning {
 	applied := len(call.Params)
-	warning := diag.Warning{Message: fmt.Sprintf(
-		"%s reads %d positions and %d were applied: feed(%d) answers with a tape of zeros",
-		call.Id.Value, positions, applied, applied)}
+	warning := diag.Warning{
+		Message: fmt.Sprintf("%s reads %d positions and %d were applied: feed(%d) answers with a tape of zeros",
+			call.Id.Value, positions, applied, applied),
+		Code:  diag.ShortCall,
+		Facts: map[string]int{diag.Reads: positions, diag.Applied: applied},
+	}
 	if call.Id.Token != nil {
 		warning.Line = call.Id.Token.GetLine()
 		warning.Column = call.Id.Token.GetColumn()
@@ -123,9 +126,13 @@ func appliedValuesWarning(call ast.CalleeLiteral, positions int) diag.Warning {
 	return warning
 }
 
-// scopeReads answers how many positions each name reads, for the names that answer that
+// ScopeReads answers how many positions each name reads, for the names that answer that
 // unambiguously. A name bound more than once is dropped rather than guessed at.
-func scopeReads(nodes []ast.Node) map[string]int {
+//
+// It is exported for the language server, which says the same thing at a call site while it
+// is being written: which positions the scope it reaches reads. One answer, read from one
+// place, is what keeps the editor and the warning from disagreeing.
+func ScopeReads(nodes []ast.Node) map[string]int {
 	reads := make(map[string]int)
 	bound := make(map[string]bool)
 
@@ -204,6 +211,8 @@ func childScopesOf(node ast.Node) []ast.Node {
 		return []ast.Node{n.Param}
 	case ast.AssertStatement:
 		return []ast.Node{n.Condition}
+	case ast.RequireStatement:
+		return []ast.Node{n.Condition}
 	case ast.ShapeLiteral:
 		// A field can hold a deferred scope, so the values of a shape are walked like any
 		// other place a scope can hide.
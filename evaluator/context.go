@@ -0,0 +1,36 @@
+package evaluator
+
+import (
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// A Context answers what a program asks about the call it is running in: who made it, with how
+// much value, and in which block of which chain.
+//
+// None of it is the program's. On chain the chain says, and the builder reads it straight out
+// of the EVM; off chain there is no call and no block, so whoever runs the program says instead
+// — which is what lets the same program answer the same thing in both places, given the same
+// answers. The evaluator asks and keeps what comes back, the way it does a Printer.
+//
+// Each answer is a number, big-endian, as wide as the host likes: the evaluator cuts it to a
+// tape the way the chain's is cut, keeping the last bytes. An address is twenty bytes, so at
+// any width under that a program reads the end of it, on chain and off alike.
+type Context interface {
+	Caller() []byte
+	CallValue() []byte
+	Timestamp() []byte
+	BlockNumber() []byte
+	ChainID() []byte
+}
+
+// EvaluateContext leaves what the host answered under the label, as a tape. With no host to
+// ask, every answer is the neutral value: a call nobody made, with nothing, in no block.
+func (e *Evaluator) EvaluateContext(label []byte, read func(Context) []byte) error {
+	value := byteutil.FalseTape(e.tapeSize)
+	if e.context != nil {
+		value = byteutil.PaddingTape(read(e.context), e.tapeSize)
+	}
+	e.environ.SetTemp(byteutil.ToHex(label), value)
+	e.IncrementCursor()
+	return nil
+}
@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// Contracts answers for the contracts a program calls.
+//
+// On chain a call out of the program reaches code somebody else deployed, and the chain runs
+// it. Off chain there is no other code to run, so whoever runs the program says what each
+// contract answers — the way a Context says who made the call.
+//
+// A call is handed over the way the chain would see it: the address as twenty bytes, the
+// signature as it was written, and every value as a word. What comes back is return data, one
+// word at most, which the evaluator cuts to a tape the way a call's values are cut on the way
+// in. A contract that fails answers an error, and the program stops with it, where on chain the
+// call reverts.
+type Contracts interface {
+	Call(address []byte, signature string, values [][]byte, static bool) ([]byte, error)
+}
+
+// An Answer is what a contract registered with a Registry does with a call: given the values
+// it was applied, the return data.
+type Answer func(values [][]byte) ([]byte, error)
+
+// A Registry is Contracts for a test: every contract a program calls is registered with what
+// it answers, signature by signature, and nothing else answers at all.
+//
+// It is the evaluator's because every host needs the same one. A call nobody registered is an
+// error rather than zeros, since zeros are a plausible answer, and a test that forgot a
+// contract would pass on them.
+type Registry struct {
+	contracts map[string]map[string]Answer
+}
+
+// NewRegistry answers a registry with nothing registered.
+func NewRegistry() *Registry {
+	return &Registry{contracts: make(map[string]map[string]Answer)}
+}
+
+// Answer registers what the contract at the address answers when called by the signature.
+// It answers the registry, so a test can register one after another.
+func (r *Registry) Answer(address []byte, signature string, answer Answer) *Registry {
+	key := addressKey(address)
+	if r.contracts[key] == nil {
+		r.contracts[key] = make(map[string]Answer)
+	}
+	r.contracts[key][signature] = answer
+	return r
+}
+
+// Returns answers an Answer that ignores what it was applied and returns the words given, a
+// word each — which is what most contracts in a test do.
+func Returns(words ...[]byte) Answer {
+	return func([][]byte) ([]byte, error) {
+		data := make([]byte, 0, len(words)*byteutil.MaxTapeSize)
+		for _, word := range words {
+			data = append(data, byteutil.PaddingTape(word, byteutil.MaxTapeSize)...)
+		}
+		return data, nil
+	}
+}
+
+// Call answers with what was registered. A static call is answered the same way: what a
+// registered contract does is a function of its values, so it has nothing to write.
+func (r *Registry) Call(address []byte, signature string, values [][]byte, _ bool) ([]byte, error) {
+	key := addressKey(address)
+	answers, ok := r.contracts[key]
+	if !ok {
+		return nil, fmt.Errorf("no contract answers at 0x%s", key)
+	}
+	answer, ok := answers[signature]
+	if !ok {
+		return nil, fmt.Errorf("the contract at 0x%s does not answer %s", key, signature)
+	}
+	return answer(values)
+}
+
+// addressKey is an address as a registry keeps it: the twenty bytes an address is, whatever
+// width it came in.
+func addressKey(address []byte) string {
+	return byteutil.ToHex(byteutil.PaddingTape(address, 20))
+}
+
+// EvaluateContractCallOver calls another contract through the host: the address, then the
+// signature, then the values, in the order the IR carries them.
+//
+// The answer is the word the contract returned, cut to a tape. A value on chain is one word on
+// the stack, and an answer of several words would need a shape to land in, which the bytecode
+// does not write yet — so an answer past a word is refused, with the words the chain reverts
+// with, rather than read for a part of it. Return data that stops short of a word is read as
+// though the rest were zeros, which is what the chain reads past the end of it.
+func (e *Evaluator) EvaluateContractCallOver(label []byte, operands []ir.Operand, static bool) error {
+	if e.contracts == nil {
+		return fmt.Errorf("call to %s: nothing answers for other contracts here", operands[1].Bytes())
+	}
+	address := byteutil.PaddingTape(e.value(operands[0]), byteutil.MaxTapeSize)
+	values := make([][]byte, 0, len(operands)-2)
+	for _, operand := range operands[2:] {
+		values = append(values, byteutil.PaddingTape(e.value(operand), byteutil.MaxTapeSize))
+	}
+
+	data, err := e.contracts.Call(address[12:], string(operands[1].Bytes()), values, static)
+	if err != nil {
+		return err
+	}
+	if len(data) > byteutil.MaxTapeSize {
+		return errors.New(ir.AnswerOfManyWords)
+	}
+	word := make([]byte, byteutil.MaxTapeSize)
+	copy(word, data)
+	e.environ.SetTemp(byteutil.ToHex(label), byteutil.PaddingTape(word, e.tapeSize))
+	e.IncrementCursor()
+	return nil
+}
@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// callee is the address every call below is made to, on a tape too short to hold one whole:
+// the evaluator widens it the way the chain does, so the registry still knows it.
+var callee = byteutil.FromUint64(0xc0ffee)
+
+// seen keeps what the contract it stands for was handed, so a test can ask.
+type seen struct {
+	address   []byte
+	signature string
+	values    [][]byte
+	static    bool
+	answers   []byte
+}
+
+func (s *seen) Call(address []byte, signature string, values [][]byte, static bool) ([]byte, error) {
+	s.address, s.signature, s.values, s.static = address, signature, values, static
+	return s.answers, nil
+}
+
+// contractCall is a call to the callee by the signature, with the values under "00" and "01".
+func contractCall(opcode byte, signature string) ir.Instruction {
+	return ir.NewInstructionOver([]byte("02"), opcode,
+		ir.ImmOf(callee, tapeSize), ir.TextOf(signature), ir.RefTo([]byte("00")), ir.RefTo([]byte("01")))
+}
+
+// Both opcodes reach the host, and the host is handed what the chain would be: the address as
+// twenty bytes, the signature, the values as words — and whether the call may write.
+func TestExecuteInstructionDispatchesTheCallsToOtherContracts(t *testing.T) {
+	cases := []struct {
+		name   string
+		opcode byte
+		static bool
+	}{
+		{name: "call", opcode: ir.OpContractCall, static: false},
+		{name: "staticcall", opcode: ir.OpStaticCall, static: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			contract := &seen{answers: word(42)}
+			e := New(NewEvaluatorOptions{Contracts: contract})
+			operands(5, 7)(e)
+
+			if err := e.ExecuteInstruction(contractCall(tc.opcode, "add(uint256,uint256)")); err != nil {
+				t.Fatalf("executing: %v", err)
+			}
+
+			if want := byteutil.PaddingTape(callee, 20); !bytes.Equal(contract.address, want) {
+				t.Errorf("called %x, want %x", contract.address, want)
+			}
+			if contract.signature != "add(uint256,uint256)" {
+				t.Errorf("called by %q", contract.signature)
+			}
+			if len(contract.values) != 2 || !bytes.Equal(contract.values[0], word(5)) || !bytes.Equal(contract.values[1], word(7)) {
+				t.Errorf("applied %x, want the words of 5 and 7", contract.values)
+			}
+			if contract.static != tc.static {
+				t.Errorf("static was %v, want %v", contract.static, tc.static)
+			}
+			if got := e.environ.GetTemp(byteutil.ToHex([]byte("02"))); !bytes.Equal(got, byteutil.FromUint64(42)) {
+				t.Errorf("answered %v, want 42 on a tape", got)
+			}
+		})
+	}
+}
+
+// A word a contract answers is cut to a tape, as the chain reads it; and one that answers less
+// than a word is read as though the rest were zeros.
+func TestWhatAContractAnswersIsCutToATape(t *testing.T) {
+	cases := []struct {
+		name    string
+		answers []byte
+		want    []byte
+	}{
+		{name: "a word", answers: word(0x0102), want: byteutil.FromUint64(0x0102)},
+		{name: "part of a word", answers: word(0x07ff)[:31], want: byteutil.FromUint64(0x0700)},
+		{name: "nothing", answers: nil, want: byteutil.FalseTape(tapeSize)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New(NewEvaluatorOptions{Contracts: &seen{answers: tc.answers}})
+			operands(5, 7)(e)
+
+			if err := e.ExecuteInstruction(contractCall(ir.OpContractCall, "pair(uint256,uint256)")); err != nil {
+				t.Fatalf("executing: %v", err)
+			}
+			if got := e.environ.GetTemp(byteutil.ToHex([]byte("02"))); !bytes.Equal(got, tc.want) {
+				t.Errorf("answered %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// An answer of several words has no shape on chain to land in, so it is refused in the words the
+// chain reverts with rather than read for its first.
+func TestAnAnswerOfSeveralWordsIsRefused(t *testing.T) {
+	e := New(NewEvaluatorOptions{Contracts: &seen{answers: append(word(1), word(2)...)}})
+	operands(5, 7)(e)
+
+	err := e.ExecuteInstruction(contractCall(ir.OpContractCall, "pair(uint256,uint256)"))
+	if err == nil || err.Error() != ir.AnswerOfManyWords {
+		t.Errorf("got %v, want %q", err, ir.AnswerOfManyWords)
+	}
+}
+
+// Unlike a reading of the call, a call to another contract has no neutral answer, so a host
+// that wires nothing to answer it makes it an error rather than a zero.
+func TestACallToAContractWithNothingToAnswerIsAnError(t *testing.T) {
+	e := New(NewEvaluatorOptions{})
+	operands(5, 7)(e)
+
+	err := e.ExecuteInstruction(contractCall(ir.OpContractCall, "add(uint256,uint256)"))
+	if err == nil || !strings.Contains(err.Error(), "add(uint256,uint256)") {
+		t.Errorf("got %v, want an error naming the call", err)
+	}
+}
+
+// A registry answers what was registered, by address and signature, and nothing else; what a
+// registered contract fails with is what the call fails with.
+func TestARegistryAnswersOnlyWhatWasRegistered(t *testing.T) {
+	registry := NewRegistry().
+		Answer(callee, "answer()", Returns(byteutil.FromUint64(42))).
+		Answer(callee, "fail()", func([][]byte) ([]byte, error) { return nil, errors.New("not today") })
+
+	// Registered at a narrow address and called at twenty bytes: the same contract.
+	got, err := registry.Call(byteutil.PaddingTape(callee, 20), "answer()", nil, true)
+	if err != nil {
+		t.Fatalf("calling: %v", err)
+	}
+	if !bytes.Equal(got, word(42)) {
+		t.Errorf("answered %x, want the word of 42", got)
+	}
+
+	cases := []struct {
+		name      string
+		address   []byte
+		signature string
+		want      string
+	}{
+		{name: "an address nobody registered", address: byteutil.FromUint64(1), signature: "answer()", want: "no contract answers at 0x"},
+		{name: "a signature it does not answer", address: callee, signature: "other()", want: "does not answer other()"},
+		{name: "a contract that fails", address: callee, signature: "fail()", want: "not today"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := registry.Call(tc.address, tc.signature, nil, false); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want an error saying %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// compile turns source into the instructions an evaluator runs.
+func compile(t testing.TB, source string) []ir.Instruction {
+	t.Helper()
+
+	tokens, err := lexer.New().GetFilledTokens([]byte(source))
+	if err != nil {
+		t.Fatalf("lexer: %v", err)
+	}
+	tree, err := parser.New().Parse(parser.ParseInput{Filename: "main.ar", Tokens: tokens})
+	if err != nil {
+		t.Fatalf("parser: %v", err)
+	}
+	insts, err := emitter.New(emitter.NewEmitterOptions{}).Emit(tree)
+	if err != nil {
+		t.Fatalf("emitter: %v", err)
+	}
+	return insts
+}
+
+// A run nobody asked to count keeps nothing, which is what lets coverage cost nothing when it
+// is off.
+func TestCoverageIsNilUnlessAskedFor(t *testing.T) {
+	ev := New(NewEvaluatorOptions{})
+	if _, err := ev.Evaluate(compile(t, "ident a = 1 + 2;")); err != nil {
+		t.Fatalf("evaluating: %v", err)
+	}
+	if got := ev.Coverage(); got != nil {
+		t.Errorf("counted %v without being asked", got)
+	}
+}
+
+// Every instruction that ran is counted, as many times as it ran, and one that never ran is
+// not there at all — the arm of an if that was not taken, here.
+func TestCoverageCountsWhatRan(t *testing.T) {
+	insts := compile(t, `ident twice = defer { feed(0) * 2; };
+twice(1);
+twice(2);
+if 1 bigger 2 { 99; } else { 0; };
+`)
+	ev := New(NewEvaluatorOptions{Cover: true})
+	if _, err := ev.Evaluate(insts); err != nil {
+		t.Fatalf("evaluating: %v", err)
+	}
+	hits := ev.Coverage()
+
+	// last answers the number an OpSave writes down, which is how the two arms are told apart.
+	last := func(inst ir.Instruction) byte {
+		written := inst.GetLeft().Bytes()
+		return written[len(written)-1]
+	}
+	for at, inst := range insts {
+		ran := hits[uint64(at)]
+		switch {
+		case inst.GetOpCode() == ir.OpMultiply && ran != 2:
+			t.Errorf("the body of a scope called twice ran %d times", ran)
+		case inst.GetOpCode() == ir.OpSave && inst.GetOrigin().Line == 4 && last(inst) == 99 && ran != 0:
+			t.Errorf("the arm that was not taken ran %d times", ran)
+		case inst.GetOpCode() == ir.OpSave && inst.GetOrigin().Line == 4 && last(inst) == 0 && ran != 1:
+			t.Errorf("the arm that was taken ran %d times", ran)
+		}
+	}
+}
@@ -338,6 +338,68 @@ func TestExecuteInstructionDispatchesThePrints(t *testing.T) {
 	}
 }
 
+// chain answers each reading of the call with a word of its own, so a reading dispatched to
+// its neighbour shows.
+type chain struct{}
+
+func (chain) Caller() []byte      { return word(0xca11) }
+func (chain) CallValue() []byte   { return word(0x0100) }
+func (chain) Timestamp() []byte   { return word(0x7157) }
+func (chain) BlockNumber() []byte { return word(0x0b10) }
+func (chain) ChainID() []byte     { return word(0x0001) }
+
+// word is a number as the chain answers it: thirty-two bytes, the number at the end.
+func word(n uint64) []byte {
+	return append(make([]byte, 24), byteutil.FromUint64(n)...)
+}
+
+// The readings of the call take no operands, so the opcode alone decides which one the host is
+// asked; and the host answers in words, which the tape keeps the end of.
+func TestExecuteInstructionDispatchesTheReadingsOfTheCall(t *testing.T) {
+	cases := []struct {
+		name   string
+		opcode byte
+		want   uint64
+	}{
+		{name: "caller", opcode: ir.OpCaller, want: 0xca11},
+		{name: "callvalue", opcode: ir.OpCallValue, want: 0x0100},
+		{name: "timestamp", opcode: ir.OpTimestamp, want: 0x7157},
+		{name: "blocknumber", opcode: ir.OpBlockNumber, want: 0x0b10},
+		{name: "chainid", opcode: ir.OpChainID, want: 0x0001},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New(NewEvaluatorOptions{Context: chain{}})
+
+			inst := ir.NewInstruction([]byte("02"), tc.opcode, ir.Nothing(), ir.Nothing())
+			if err := e.ExecuteInstruction(inst); err != nil {
+				t.Fatalf("executing: %v", err)
+			}
+
+			got := e.environ.GetTemp(byteutil.ToHex([]byte("02")))
+			if !bytes.Equal(got, byteutil.FromUint64(tc.want)) {
+				t.Errorf("read %v, want %d on a tape", got, tc.want)
+			}
+		})
+	}
+}
+
+// With no host to say what call it is, a program is in a call nobody made: every reading is
+// zero, not an error, so a program that reads one still runs under "aurora run" with no flags.
+func TestAReadingOfTheCallWithNoContextIsZero(t *testing.T) {
+	e := New(NewEvaluatorOptions{})
+
+	inst := ir.NewInstruction([]byte("02"), ir.OpCaller, ir.Nothing(), ir.Nothing())
+	if err := e.ExecuteInstruction(inst); err != nil {
+		t.Fatalf("executing: %v", err)
+	}
+
+	if got := e.environ.GetTemp(byteutil.ToHex([]byte("02"))); !bytes.Equal(got, byteutil.FalseTape(tapeSize)) {
+		t.Errorf("read %v, want zero", got)
+	}
+}
+
 // A print is an expression, so it answers with a value like everything else in Aurora — the
 // one the printer gives back. Answering with nothing would make "printd x + 1" mean nothing.
 func TestAPrintAnswersWithWhatThePrinterGaveBack(t *testing.T) {
@@ -424,6 +486,11 @@ func TestExecuteInstructionCarriesAnErrorBack(t *testing.T) {
 			inst: ir.NewInstruction([]byte("02"), ir.OpDivide, ir.RefTo([]byte("00")), ir.RefTo([]byte("01"))),
 			want: "integer divide by zero",
 		},
+		{
+			name: "require of a false condition",
+			inst: ir.NewInstruction([]byte("02"), ir.OpRequire, ir.RefTo([]byte("01")), ir.TextOf("must not be zero")),
+			want: "must not be zero",
+		},
 	}
 
 	for _, tc := range cases {
@@ -468,6 +535,14 @@ var coveredElsewhere = map[byte]bool{
 	ir.OpPrintDecimal: true,
 	ir.OpAssert:       true,
 	ir.OpCall:         true,
+	ir.OpRequire:      true,
+	ir.OpCaller:       true,
+	ir.OpCallValue:    true,
+	ir.OpTimestamp:    true,
+	ir.OpBlockNumber:  true,
+	ir.OpChainID:      true,
+	ir.OpContractCall: true,
+	ir.OpStaticCall:   true,
 	// Declared and never emitted; the step-over test is what covers it.
 	ir.OpPreCall: true,
 }
@@ -480,7 +555,7 @@ func TestEveryOpcodeIsDispatchedByACase(t *testing.T) {
 		tested[tc.opcode] = true
 	}
 
-	for op := ir.OpMultiply; op <= ir.OpField; op++ {
+	for op := ir.OpMultiply; op <= ir.OpStaticCall; op++ {
 		if tested[op] || coveredElsewhere[op] {
 			continue
 		}
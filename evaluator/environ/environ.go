@@ -16,6 +16,9 @@ type Environ struct {
 	// to the program and not to a scope, and every module environ is handed the same map so
 	// that a body running anywhere can reach it.
 	modules map[string]*Environ
+	// call says a call opened this environ, to hold what it was fed. It is where one frame of
+	// a running program's stack begins, which nothing else on the chain says.
+	call bool
 }
 
 func (e *Environ) Ahead(next *Environ) *Environ {
@@ -27,6 +30,11 @@ func (e *Environ) GetPrevious() *Environ {
 	return e.prev
 }
 
+// Called says whether a call opened this environ.
+func (e *Environ) Called() bool {
+	return e.call
+}
+
 func (e *Environ) SetTemp(key string, value []byte) {
 	e.temps[key] = value
 }
@@ -77,6 +85,17 @@ func (e *Environ) GetLocalIdent(key string) []byte {
 	return e.idents[key]
 }
 
+// Idents answers the names bound here, by key, without walking outwards. It is what an
+// inspector lists; a program only ever looks a name up.
+func (e *Environ) Idents() map[string][]byte {
+	return e.idents
+}
+
+// Defers answers the deferred scopes created here, by key, without walking outwards.
+func (e *Environ) Defers() map[string][]byte {
+	return e.defers
+}
+
 // DefersLength returns the number of defers in this environ (used to build the next incremental key).
 func (e *Environ) DefersLength() int {
 	return len(e.defers)
@@ -183,6 +202,8 @@ type NewEnvironOptions struct {
 	Args     []byte
 	Prev     *Environ
 	TapeSize int
+	// Call marks the environ a call opens.
+	Call bool
 }
 
 func NewEnviron(opts NewEnvironOptions) *Environ {
@@ -201,6 +222,7 @@ func NewEnviron(opts NewEnvironOptions) *Environ {
 		idents: idents,
 		defers: make(map[string][]byte),
 		temps:  make(map[string][]byte),
+		call:   opts.Call,
 		prev:   opts.Prev,
 	}
 }
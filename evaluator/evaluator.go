@@ -78,6 +78,19 @@ type Evaluator struct {
 	printDecimal  Printer
 	environ       *environ.Environ
 	tapeSize      int
+	// covered counts how many times each instruction ran, by its position in the stream. It
+	// is nil unless coverage was asked for, and nil is what keeps a plain run from paying.
+	covered map[uint64]int
+	// tracer is told about every instruction that runs, and depth is how many calls deep the
+	// running one is, which is what it is told alongside.
+	tracer Tracer
+	depth  int
+	// monitor is asked before every instruction runs.
+	monitor Monitor
+	// context answers what the program asks about the call it is running in.
+	context Context
+	// contracts answers the calls the program makes to other contracts, when something does.
+	contracts Contracts
 }
 
 // TapeSize is the width, in bytes, of every value this evaluator handles.
@@ -108,6 +121,17 @@ func (e *Evaluator) GetAssertErrors() []error {
 	return errs
 }
 
+// Coverage answers how many times each instruction ran, by its position in the stream, or
+// nil when coverage was not asked for.
+//
+// It is counted by position rather than by origin. An origin is a line and a column, and it
+// does not say which file: a program of several modules is one stream, and the same line
+// number is somewhere in every one of them. A position says which range it sits in, so the
+// host that holds the ranges knows the file, and the instruction under it knows the line.
+func (e *Evaluator) Coverage() map[uint64]int {
+	return e.covered
+}
+
 // value answers what an operand is worth.
 //
 // A Ref names a value another instruction left behind, so it is looked up. An Imm is the
@@ -166,7 +190,7 @@ func (e *Evaluator) EvaluateMultiply(label []byte, left, right ir.Operand) error
 func (e *Evaluator) EvaluateDivide(label []byte, left, right ir.Operand) error {
 	x, y := e.operands(left, right)
 	if y.IsZero() {
-		return fmt.Errorf("integer divide by zero")
+		return errors.New(ir.DivideByZero)
 	}
 	e.setValue(label, new(uint256.Int).Div(x, y))
 	e.IncrementCursor()
@@ -546,16 +570,24 @@ func (e *Evaluator) EvaluateCallOver(label []byte, operands []ir.Operand) error
 	for at, operand := range operands[1:] {
 		args[uint64(at)] = e.value(operand)
 	}
-	next := environ.NewEnviron(environ.NewEnvironOptions{})
+	// The scope answers under a label of its own module, and every module counts its labels
+	// from the same place: a value of the caller's waiting under the same one is put back.
+	held, holding := e.environ.GetTemps()[returnKey]
+	next := environ.NewEnviron(environ.NewEnvironOptions{Call: true})
 	next.SetArguments(args)
 	e.environ = e.environ.Ahead(next)
 	savedCursor, savedEnd := e.cursor, e.end
+	e.depth++
 	_, err := e.ExecuteInstructions(from+1, to)
+	e.depth--
 	e.cursor, e.end = savedCursor, savedEnd
 	if err != nil {
 		return err
 	}
 	retval := e.environ.GetTemp(returnKey)
+	if holding {
+		e.environ.SetTemp(returnKey, held)
+	}
 	e.environ.SetTemp(byteutil.ToHex(label), retval)
 	e.IncrementCursor()
 	return nil
@@ -589,6 +621,18 @@ func (e *Evaluator) EvaluateAssert(label []byte, left, right ir.Operand) error {
 	return nil
 }
 
+// EvaluateRequire stops the program with the message when the condition does not hold. It
+// checks under every runner, unlike an assertion: a guard is part of what the program does,
+// and the same program on a chain reverts there with the message as its reason.
+func (e *Evaluator) EvaluateRequire(label []byte, left, right ir.Operand) error {
+	if !byteutil.ToBoolean(e.value(left)) {
+		return errors.New(string(right.Bytes()))
+	}
+	e.environ.SetTemp(byteutil.ToHex(label), byteutil.FalseTape(e.tapeSize))
+	e.IncrementCursor()
+	return nil
+}
+
 func (e *Evaluator) CanReadInstructions() bool {
 	return e.cursor < e.end
 }
@@ -691,7 +735,25 @@ func init() {
 		ir.OpTail:  (*Evaluator).EvaluateTail,
 
 		// Assertions
-		ir.OpAssert: (*Evaluator).EvaluateAssert,
+		ir.OpAssert:  (*Evaluator).EvaluateAssert,
+		ir.OpRequire: (*Evaluator).EvaluateRequire,
+
+		// The call the program is running in
+		ir.OpCaller: func(e *Evaluator, label []byte, _, _ ir.Operand) error {
+			return e.EvaluateContext(label, Context.Caller)
+		},
+		ir.OpCallValue: func(e *Evaluator, label []byte, _, _ ir.Operand) error {
+			return e.EvaluateContext(label, Context.CallValue)
+		},
+		ir.OpTimestamp: func(e *Evaluator, label []byte, _, _ ir.Operand) error {
+			return e.EvaluateContext(label, Context.Timestamp)
+		},
+		ir.OpBlockNumber: func(e *Evaluator, label []byte, _, _ ir.Operand) error {
+			return e.EvaluateContext(label, Context.BlockNumber)
+		},
+		ir.OpChainID: func(e *Evaluator, label []byte, _, _ ir.Operand) error {
+			return e.EvaluateContext(label, Context.ChainID)
+		},
 	}
 }
 
@@ -711,6 +773,12 @@ func init() {
 		ir.OpJoin: (*Evaluator).EvaluateJoinOver,
 		ir.OpPull: (*Evaluator).EvaluatePullOver,
 		ir.OpCall: (*Evaluator).EvaluateCallOver,
+		ir.OpContractCall: func(e *Evaluator, label []byte, operands []ir.Operand) error {
+			return e.EvaluateContractCallOver(label, operands, false)
+		},
+		ir.OpStaticCall: func(e *Evaluator, label []byte, operands []ir.Operand) error {
+			return e.EvaluateContractCallOver(label, operands, true)
+		},
 	}
 }
 
@@ -721,6 +789,22 @@ func init() {
 // declared and never emitted, and an instruction the evaluator does not know is exactly what
 // a half-wired new opcode looks like — a running program does not stop for it.
 func (e *Evaluator) ExecuteInstruction(inst ir.Instruction) error {
+	if e.monitor != nil {
+		if err := e.monitor.Before(e.cursor, inst, e.depth); err != nil {
+			return err
+		}
+	}
+	if e.covered != nil {
+		e.covered[e.cursor]++
+	}
+	if e.tracer != nil {
+		return e.traced(inst)
+	}
+	return e.execute(inst)
+}
+
+// execute is ExecuteInstruction's dispatch, without anything counted or told.
+func (e *Evaluator) execute(inst ir.Instruction) error {
 	if over, ok := runOperations[inst.GetOpCode()]; ok {
 		return over(e, inst.GetLabel(), inst.GetOperands())
 	}
@@ -782,6 +866,17 @@ func (e *Evaluator) EvaluateModule(insts []ir.Instruction, from, to uint64, id s
 	return e.EvaluateRange(insts, from, to)
 }
 
+// EvaluateCall runs a range of the entry the way a call from outside runs: what it feeds is
+// what the call was handed, ABI words narrowed to tapes, not what the program was started
+// with. Those are the constructor's, and they are the top's again once the call is over.
+func (e *Evaluator) EvaluateCall(insts []ir.Instruction, from, to uint64, args []byte) (eval.Returns, error) {
+	top := e.environ.GetArguments()
+	e.environ.SetArguments(environ.NewEnviron(environ.NewEnvironOptions{Args: args, TapeSize: e.tapeSize}).GetArguments())
+	defer e.environ.SetArguments(top)
+
+	return e.EvaluateRange(insts, from, to)
+}
+
 type NewEvaluatorOptions struct {
 	// A Printer per reading of a tape. What each one does with the value, and what it
 	// answers with, is the host's business: the evaluator only asks and keeps the answer.
@@ -793,10 +888,34 @@ type NewEvaluatorOptions struct {
 	TapeSize int
 	// Asserts turns assertions on. Only "aurora test" does.
 	Asserts bool
+	// Cover counts every instruction that runs, for "aurora test --cover". Off, nothing is
+	// counted and nothing is kept.
+	Cover bool
+	// Tracer is told about every instruction that runs. Nil, which is what every run but a
+	// traced one leaves it, costs nothing.
+	Tracer Tracer
+	// Monitor is asked before every instruction runs, and may hold it there: "aurora debug"
+	// is one. Nil, like a nil Tracer, costs nothing.
+	Monitor Monitor
+	// Context answers "caller", "callvalue" and the rest, which off chain only the host can.
+	// Nil answers the neutral value for every one of them.
+	Context Context
+	// Contracts answers for the contracts a program calls. Unlike Context there is no neutral
+	// answer to fall back on, so with none a call to another contract is an error.
+	Contracts Contracts
 }
 
 func New(options NewEvaluatorOptions) *Evaluator {
+	var covered map[uint64]int
+	if options.Cover {
+		covered = make(map[uint64]int)
+	}
 	return &Evaluator{
+		covered:       covered,
+		tracer:        options.Tracer,
+		monitor:       options.Monitor,
+		context:       options.Context,
+		contracts:     options.Contracts,
 		cursor:        0,
 		end:           0,
 		insts:         make([]ir.Instruction, 0),
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"cmp"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/evaluator/environ"
+)
+
+// What a program is standing in, for whoever is looking at it from outside: a REPL asked what
+// it has bound, a debugger stopped on a line. Nothing here changes a thing — it reads the
+// environ chain and answers with copies of it, named the way the program wrote them.
+
+// A Frame is one environ of the chain: what was bound in it, the scopes deferred in it, and
+// what feed answers with while it is open.
+type Frame struct {
+	// Bindings are sorted by name, so two looks at the same frame read the same.
+	Bindings []Binding
+	// Scopes are in the order they were deferred, which is the index a value calling one holds.
+	Scopes []Scope
+	// Arguments are by position: feed(0) is the first.
+	Arguments [][]byte
+	// Call says a call opened the frame: the frames from one call to the next are the
+	// environs of one level of the stack.
+	Call bool
+}
+
+// A Binding is a name and the value it holds.
+type Binding struct {
+	Name  string
+	Value []byte
+}
+
+// A Scope is a deferred body: its index in the environ that deferred it, and where its
+// instructions sit in the stream.
+type Scope struct {
+	Index uint64
+	From  uint64
+	To    uint64
+}
+
+// Frames answers the chain from the innermost environ out to the one every program starts in.
+func (e *Evaluator) Frames() []Frame {
+	frames := make([]Frame, 0)
+	for curr := e.environ; curr != nil; curr = curr.GetPrevious() {
+		frames = append(frames, frameOf(curr))
+	}
+	return frames
+}
+
+// ModuleFrame answers the environ a module binds its names in, and false when no module of
+// that name has run.
+func (e *Evaluator) ModuleFrame(id string) (Frame, bool) {
+	home := e.environ.Module(id)
+	if home == nil {
+		return Frame{}, false
+	}
+	return frameOf(home), true
+}
+
+// Lookup answers what a name holds, found the way a program running here would find it.
+func (e *Evaluator) Lookup(name string) ([]byte, bool) {
+	value, _ := e.resolve([]byte(name))
+	return value, value != nil
+}
+
+func frameOf(env *environ.Environ) Frame {
+	frame := Frame{Call: env.Called()}
+
+	for key, value := range env.Idents() {
+		name, err := hex.DecodeString(key)
+		if err != nil {
+			continue
+		}
+		frame.Bindings = append(frame.Bindings, Binding{Name: string(name), Value: value})
+	}
+	slices.SortFunc(frame.Bindings, func(a, b Binding) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for key, blob := range env.Defers() {
+		index, err := hex.DecodeString(key)
+		if err != nil {
+			continue
+		}
+		from, to, _, ok := decodeDeferBlob(blob)
+		if !ok {
+			continue
+		}
+		frame.Scopes = append(frame.Scopes, Scope{Index: byteutil.ToUint64(index), From: from, To: to})
+	}
+	slices.SortFunc(frame.Scopes, func(a, b Scope) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	args := env.GetArguments()
+	for at := uint64(0); at < uint64(len(args)); at++ {
+		frame.Arguments = append(frame.Arguments, args[at])
+	}
+	return frame
+}
@@ -127,6 +127,22 @@ func TestAModuleCallsAnother(t *testing.T) {
 	}
 }
 
+// A scope of another module answers under a label counted in its own file, which can be the
+// label of a value the caller is still holding: here the read of a, waiting for the sum while
+// the second call runs.
+func TestACallDoesNotTakeTheCallersValueItsLabelMatches(t *testing.T) {
+	printed, err := runProgram(t,
+		file{"g", "ident k = 3 + 4;\nident square = defer { feed(0) * feed(0); };"},
+		file{"", "use g as x;\nident f = defer { ident a = x.square(x.square(feed(0))); a + x.square(2); };\nprintd f(3);"},
+	)
+	if err != nil {
+		t.Fatalf("running: %v", err)
+	}
+	if len(printed) != 1 || printed[0] != 85 {
+		t.Errorf("printed %v, want [85] — 4 would mean the last call took the place of a", printed)
+	}
+}
+
 // And what a module cannot do: read a name from whoever called it. A scope sees the chain of
 // its caller, which is how a deferred scope has always worked, but the names it asks for are
 // its own module's — so the entry's n is not what it finds, and there is nothing else to find.
@@ -0,0 +1,17 @@
+package evaluator
+
+import "github.com/guiferpa/aurora/wire/ir"
+
+// A Monitor is asked before every instruction runs, and can keep it from running.
+//
+// It is the Tracer's other half. A tracer is told what happened and has no say in it; a
+// monitor is told what is about to happen, and the instruction waits for its answer — which is
+// what a debugger is: something that holds a program still on a line while a person looks at
+// it. The evaluator stays one loop running one instruction at a time, calls included, and the
+// monitor is where that loop is paused; nothing about a call had to be taken apart for it.
+//
+// An error stops the program where it stands, the way a failing instruction would, and is what
+// the run answers with.
+type Monitor interface {
+	Before(position uint64, inst ir.Instruction, depth int) error
+}
@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// stopAt is a monitor that lets every instruction run until it reaches an opcode.
+type stopAt struct {
+	op     byte
+	before []uint64
+}
+
+func (m *stopAt) Before(position uint64, inst ir.Instruction, _ int) error {
+	if inst.GetOpCode() == m.op {
+		return errStopped
+	}
+	m.before = append(m.before, position)
+	return nil
+}
+
+var errStopped = errors.New("stopped")
+
+// A monitor is asked before each instruction, and what it answers with is what the run answers
+// with: the instruction it refused never ran.
+func TestMonitorIsAskedBeforeEachInstruction(t *testing.T) {
+	insts := compile(t, "ident a = 1 + 2;\nident b = a * 3;")
+	monitor := &stopAt{op: ir.OpMultiply}
+	ev := New(NewEvaluatorOptions{Monitor: monitor, Cover: true})
+
+	if _, err := ev.Evaluate(insts); !errors.Is(err, errStopped) {
+		t.Fatalf("the run answered %v, want what the monitor said", err)
+	}
+	for at, position := range monitor.before {
+		if position != uint64(at) {
+			t.Fatalf("asked about position %d in turn %d", position, at)
+		}
+	}
+	for position := range ev.Coverage() {
+		if insts[position].GetOpCode() == ir.OpMultiply {
+			t.Error("the instruction the monitor refused ran")
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package evaluator
+
+import (
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// A Tracer is told about every instruction the evaluator runs, the way a Printer is told about
+// every value a program prints: the evaluator hands over what happened and does not ask what
+// becomes of it. Whether it is written, counted or thrown away is the host's business.
+//
+// It is optional, and being optional is most of its design. An evaluator with no tracer pays
+// for one comparison per instruction and nothing else — no operand is read twice, no step is
+// built — which is what lets every run keep the port open.
+type Tracer interface {
+	Trace(step Step)
+}
+
+// A Step is one instruction that ran.
+type Step struct {
+	// Position is where the instruction sits in the stream, which is what a coverage count
+	// and a module's range are counted in: the host that holds the ranges knows the file.
+	Position uint64
+	Label    []byte
+	OpCode   byte
+	Origin   ir.Origin
+	Operands []ir.Operand
+	// Values are what the operands were worth when the instruction began, one per operand: a
+	// Ref's value as the instruction before left it, an Imm's as it is written, and nil for an
+	// operand that is not a value — a name, a target, a text.
+	Values [][]byte
+	// Result is what the instruction left under its label, or nil when it leaves nothing.
+	Result []byte
+	// Depth is how many calls deep the instruction ran, zero at the top of a program.
+	Depth int
+	// Err is why the instruction failed, when it did. It is the last step of the run.
+	Err error
+}
+
+// traced runs an instruction and tells the tracer about it.
+//
+// The operands are read before it runs, because running it consumes them: a temp is read
+// once, and reading is removing. They are read from the map rather than with GetTemp for the
+// same reason — looking must not change what the instruction finds.
+//
+// A call is told after its body, once it has an answer, so the steps of a body come before the
+// call that ran them; Depth is what says they belong to it.
+func (e *Evaluator) traced(inst ir.Instruction) error {
+	step := Step{
+		Position: e.cursor,
+		Label:    inst.GetLabel(),
+		OpCode:   inst.GetOpCode(),
+		Origin:   inst.GetOrigin(),
+		Operands: inst.GetOperands(),
+		Depth:    e.depth,
+	}
+	temps := e.environ.GetTemps()
+	step.Values = make([][]byte, len(step.Operands))
+	for at, operand := range step.Operands {
+		switch operand.Kind() {
+		case ir.KindImm:
+			step.Values[at] = operand.Bytes()
+		case ir.KindRef:
+			step.Values[at] = temps[byteutil.ToHex(operand.Bytes())]
+		}
+	}
+
+	step.Err = e.execute(inst)
+	if step.Err == nil {
+		step.Result = e.environ.GetTemps()[byteutil.ToHex(step.Label)]
+	}
+	e.tracer.Trace(step)
+	return step.Err
+}
@@ -0,0 +1,152 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// steps keeps every step it is told about.
+type steps []Step
+
+func (s *steps) Trace(step Step) { *s = append(*s, step) }
+
+// Every instruction that ran is told once, in the order it ran, with what its operands were
+// worth and what it left behind.
+func TestTracerIsToldEveryStep(t *testing.T) {
+	insts := compile(t, "ident a = 1 + 2;")
+	traced := &steps{}
+	ev := New(NewEvaluatorOptions{Tracer: traced})
+	if _, err := ev.Evaluate(insts); err != nil {
+		t.Fatalf("evaluating: %v", err)
+	}
+
+	if len(*traced) != len(insts) {
+		t.Fatalf("told %d steps, ran %d instructions", len(*traced), len(insts))
+	}
+	var add *Step
+	for at, step := range *traced {
+		if step.Position != uint64(at) {
+			t.Errorf("step %d is at position %d", at, step.Position)
+		}
+		if step.OpCode == ir.OpAdd {
+			add = &(*traced)[at]
+		}
+	}
+	if add == nil {
+		t.Fatal("no OpAdd was traced")
+	}
+	if got := byteutil.ToUint64(add.Values[0]); got != 1 {
+		t.Errorf("left operand was %d, want 1", got)
+	}
+	if got := byteutil.ToUint64(add.Values[1]); got != 2 {
+		t.Errorf("right operand was %d, want 2", got)
+	}
+	if got := byteutil.ToUint64(add.Result); got != 3 {
+		t.Errorf("result was %d, want 3", got)
+	}
+	if !add.Origin.Known() {
+		t.Error("the add does not say where it was written")
+	}
+}
+
+// A Ref's value is read for the tracer without being consumed: the instruction that reads it
+// still finds it, so a traced program answers what an untraced one does.
+func TestTracingDoesNotConsumeWhatItReads(t *testing.T) {
+	source := "ident a = (1 + 2) * 4; a;"
+	plain := New(NewEvaluatorOptions{})
+	want, err := plain.Evaluate(compile(t, source))
+	if err != nil {
+		t.Fatalf("evaluating: %v", err)
+	}
+	ev := New(NewEvaluatorOptions{Tracer: &steps{}})
+	got, err := ev.Evaluate(compile(t, source))
+	if err != nil {
+		t.Fatalf("evaluating traced: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("traced run left %d temps, plain run %d", len(got), len(want))
+	}
+	for key, value := range want {
+		if byteutil.ToUint64(got[key]) != byteutil.ToUint64(value) {
+			t.Errorf("temp %s is %v traced, %v plain", key, got[key], value)
+		}
+	}
+}
+
+// The body of a call is one level deeper than the call, and the call is told after its body,
+// once it has an answer.
+func TestTracerIsToldHowDeepACallRan(t *testing.T) {
+	traced := &steps{}
+	ev := New(NewEvaluatorOptions{Tracer: traced})
+	if _, err := ev.Evaluate(compile(t, "ident twice = defer { feed(0) * 2; };\ntwice(3);")); err != nil {
+		t.Fatalf("evaluating: %v", err)
+	}
+
+	multiplied, called := -1, -1
+	for at, step := range *traced {
+		switch step.OpCode {
+		case ir.OpMultiply:
+			multiplied = at
+			if step.Depth != 1 {
+				t.Errorf("the body ran at depth %d, want 1", step.Depth)
+			}
+		case ir.OpCall:
+			called = at
+			if step.Depth != 0 {
+				t.Errorf("the call ran at depth %d, want 0", step.Depth)
+			}
+			if got := byteutil.ToUint64(step.Result); got != 6 {
+				t.Errorf("the call answered %d, want 6", got)
+			}
+		}
+	}
+	if multiplied < 0 || called < 0 {
+		t.Fatalf("missing steps: multiply at %d, call at %d", multiplied, called)
+	}
+	if multiplied > called {
+		t.Errorf("the call was told before its body")
+	}
+}
+
+// The step that fails is told, with why, and it is the last one.
+func TestTracerIsToldTheStepThatFailed(t *testing.T) {
+	traced := &steps{}
+	ev := New(NewEvaluatorOptions{Tracer: traced})
+	if _, err := ev.Evaluate(compile(t, "1 / 0; 2;")); err == nil {
+		t.Fatal("dividing by zero did not fail")
+	}
+	last := (*traced)[len(*traced)-1]
+	if last.OpCode != ir.OpDivide || last.Err == nil {
+		t.Fatalf("the last step was %s with %v, want the failing OpDivide", ir.ResolveOpCode(last.OpCode), last.Err)
+	}
+	if last.Result != nil {
+		t.Errorf("a failed step carried a result: %v", last.Result)
+	}
+}
+
+func benchmarkEvaluate(b *testing.B, tracer Tracer) {
+	insts := compile(b, `ident fib = defer {
+  ident n = feed(0);
+  if n smaller 2 { n; } else { fib(n - 1) + fib(n - 2); };
+};
+fib(10);
+`)
+	b.ResetTimer()
+	for b.Loop() {
+		ev := New(NewEvaluatorOptions{Tracer: tracer})
+		if _, err := ev.Evaluate(insts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// What a run with no tracer costs, to hold against what it cost before the port was there.
+func BenchmarkEvaluateUntraced(b *testing.B) { benchmarkEvaluate(b, nil) }
+
+func BenchmarkEvaluateTraced(b *testing.B) { benchmarkEvaluate(b, discard{}) }
+
+type discard struct{}
+
+func (discard) Trace(Step) {}
@@ -0,0 +1,459 @@
+// Package formatter prints an Aurora source back in the one layout every file shares.
+//
+// It prints from the tokens rather than from the tree. The tree is what a program means, and a
+// great deal of what was written is gone from it by then: a module's names are qualified, a
+// number is its value and no longer its spelling, text is its bytes, and a comment was never
+// in it at all. The tokens are everything that was written, comments included, so printing
+// them is the one way to change only the layout — and a formatter that changed anything else
+// would be a compiler.
+//
+// The tree is still asked one thing: which braces build a shape. `Point{1, 2}` and
+// `if flag { … }` are the same tokens, and only the parse knows that Point was declared as a
+// shape and flag was not. Asking it is also why a file that does not parse is not formatted:
+// the layout of something that is not a program yet is nobody's to decide.
+//
+// Where a line breaks is decided by the structure, not by the writer, wherever a block is
+// involved: a block's `{` ends the line that opens it, a block written over more than one line
+// holds one statement per line, and its `}` starts a line of its own at the depth of the line
+// that opened it, with an `else` following on the same line. A block written on one line stays
+// on one line. Everything else — an expression broken over lines, the arms of a branch, the
+// blank lines between statements — keeps the writer's breaks; what is decided there is
+// everything inside a line and where each line starts.
+package formatter
+
+import (
+	"strings"
+
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// indent is one level, which every example has always used.
+const indent = "  "
+
+// Format answers a source in the canonical layout. The tokens are every token of it, the
+// lexer's GetTokens and not GetFilledTokens — the breaks and the comments are what is kept —
+// and the tree is what the same source parsed into.
+func Format(tokens []token.Token, tree ast.AST) []byte {
+	lines := layout(tokens, tree)
+	b := &strings.Builder{}
+	for _, each := range lines {
+		b.WriteString(each.text)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// FormatLines answers lines from to to of a source, 1-based and inclusive, in the canonical
+// layout: how far in they start is worked out from the whole of it, since a line says nothing
+// about how deep it is. A blank line the layout drops is not in the answer, which has no line
+// break at its end.
+//
+// The layout moves a brace onto the line before it, so a selection may have to grow to take in
+// the lines it moved from or to; the lines the answer replaces come back with it, and a caller
+// replaces those and not only the ones it asked for.
+//
+// It is what formatting a selection is: the lines around it are left as they were written.
+func FormatLines(tokens []token.Token, tree ast.AST, from, to int) (string, int, int) {
+	lines := layout(tokens, tree)
+	for grown := true; grown; {
+		grown = false
+		for _, each := range lines {
+			if each.last < from || each.first > to {
+				continue
+			}
+			if each.first < from || each.last > to {
+				from, to, grown = min(from, each.first), max(to, each.last), true
+			}
+		}
+	}
+
+	kept := make([]string, 0, max(to-from+1, 0))
+	for _, each := range lines {
+		if each.first >= from && each.last <= to {
+			kept = append(kept, each.text)
+		}
+	}
+	return strings.Join(kept, "\n"), from, to
+}
+
+// A line is one line of the output, and the lines of the source it was printed from.
+type line struct {
+	first, last int
+	text        string
+}
+
+// An opener is a bracket that has not been closed yet, and the line it was opened on.
+type opener struct {
+	line int
+	// literal says the brace builds a shape, and its values sit tight inside it.
+	literal bool
+}
+
+// printer is one pass over a source: the brackets open where it has got to, and what it knows
+// from the tree.
+type printer struct {
+	// literals are the cursors of the braces that build a shape, and signs of the minuses
+	// that are a sign rather than a subtraction.
+	literals map[int]bool
+	signs    map[int]bool
+	open     []opener
+	// last is the last token that was not a comment, on whichever line it was: whether the
+	// next line goes on from it.
+	last token.Token
+}
+
+func layout(tokens []token.Token, tree ast.AST) []line {
+	literals := literalBraces(tokens, tree)
+	p := &printer{literals: literals, signs: signs(tokens)}
+	written := rows(restructure(pieces(tokens), literals))
+	out := make([]line, 0, len(written))
+	for at, row := range written {
+		if len(row) == 0 {
+			// A blank line is the last line of the source before the one it comes before.
+			before := written[at+1][0].line - 1
+			out = append(out, line{first: before, last: before, text: ""})
+			continue
+		}
+		tks := make([]token.Token, len(row))
+		for i, each := range row {
+			tks[i] = each.tk
+		}
+		out = append(out, line{first: row[0].line, last: row[len(row)-1].line, text: p.line(at, tks)})
+	}
+	return out
+}
+
+// A piece is a token that is printed, the line of the source it was written on, and how many
+// line breaks came before it: none when it goes on the line of the piece before it, and more
+// than one when there are blank lines between them.
+type piece struct {
+	tk     token.Token
+	line   int
+	breaks int
+}
+
+// pieces answers the tokens of a source that are printed, without the spaces and breaks
+// between them — what those were is kept on the piece after them.
+func pieces(tokens []token.Token) []piece {
+	found := make([]piece, 0, len(tokens))
+	number, breaks := 1, 0
+	for _, tk := range tokens {
+		switch tk.GetTag().Id {
+		case token.EOF:
+			return found
+		case token.BREAK_LINE:
+			// "\r\n" is two breaks to the lexer and one line to whoever wrote it.
+			if string(tk.GetMatch()) == "\n" {
+				number++
+				breaks++
+			}
+		case token.WHITESPACE:
+		default:
+			found = append(found, piece{tk: tk, line: number, breaks: breaks})
+			breaks = 0
+		}
+	}
+	return found
+}
+
+// restructure decides the breaks a block's structure fixes, over the ones that were written:
+//
+//   - a block's `{` goes on the line that opens it, and an `else` on the line of the `}`
+//     before it — a semicolon, too, sits against whatever it ends;
+//   - a block written over more than one line starts a line after its `{`, after every
+//     statement, and at its `}`, with no blank line at either end of it;
+//   - the top of a file is such a block, with no braces.
+//
+// Nothing is ever joined onto a comment: a comment ends the line it is on.
+func restructure(all []piece, literals map[int]bool) []piece {
+	closing := matches(all)
+
+	// join puts a piece on the line of the one before it, when it can go there.
+	join := func(at int) {
+		if at > 0 && all[at-1].tk.GetTag().Id == token.COMMENT_LINE {
+			all[at].breaks = max(all[at].breaks, 1)
+			return
+		}
+		all[at].breaks = 0
+	}
+	// starts puts a piece at the start of a line, unless it is a comment written at the end of
+	// the line before: that one stays where it was said.
+	starts := func(at int, blank bool) {
+		if at >= len(all) {
+			return
+		}
+		if all[at].tk.GetTag().Id == token.COMMENT_LINE && all[at].breaks == 0 {
+			return
+		}
+		all[at].breaks = max(all[at].breaks, 1)
+		if !blank {
+			all[at].breaks = 1
+		}
+	}
+	// statements starts a line after every statement between from and to, at their depth.
+	statements := func(from, to int) {
+		depth := 0
+		for at := from; at < to; at++ {
+			switch all[at].tk.GetTag().Id {
+			case token.O_CUR_BRK, token.O_PAREN, token.O_BRK:
+				depth++
+			case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+				depth--
+			case token.SEMICOLON:
+				if depth == 0 && at+1 < to {
+					starts(at+1, true)
+				}
+			}
+		}
+	}
+
+	// The joins come first: whether a block was written over more than one line is asked of
+	// it once its own braces, and those of the blocks inside it, are where they go.
+	for at, each := range all {
+		switch each.tk.GetTag().Id {
+		case token.SEMICOLON:
+			join(at)
+		case token.ELSE:
+			if at > 0 && all[at-1].tk.GetTag().Id == token.C_CUR_BRK {
+				join(at)
+			}
+		case token.O_CUR_BRK:
+			if end, ok := closing[at]; ok && !literals[each.tk.GetCursor()] {
+				join(at)
+				if end == at+1 {
+					join(end) // an empty block is `{}`, however it was written
+				}
+			}
+		}
+	}
+
+	statements(0, len(all))
+	for at, each := range all {
+		end, ok := closing[at]
+		if !ok || each.tk.GetTag().Id != token.O_CUR_BRK || literals[each.tk.GetCursor()] || end == at+1 {
+			continue
+		}
+		multiline := false
+		for inside := at + 1; inside <= end; inside++ {
+			multiline = multiline || all[inside].breaks > 0
+		}
+		if multiline {
+			starts(at+1, false)
+			statements(at+1, end)
+			starts(end, false)
+		}
+	}
+	return all
+}
+
+// matches answers where each bracket of a source is closed, by where it is opened.
+func matches(all []piece) map[int]int {
+	closing := make(map[int]int)
+	open := make([]int, 0)
+	for at, each := range all {
+		switch each.tk.GetTag().Id {
+		case token.O_CUR_BRK, token.O_PAREN, token.O_BRK:
+			open = append(open, at)
+		case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+			if len(open) > 0 {
+				closing[open[len(open)-1]] = at
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return closing
+}
+
+// rows answers the lines the pieces print on, with an empty one for a run of blank lines — one,
+// however many there were, and none at the top of a file.
+func rows(all []piece) [][]piece {
+	lines := make([][]piece, 0)
+	for _, each := range all {
+		if len(lines) == 0 || each.breaks > 0 {
+			if each.breaks > 1 && len(lines) > 0 {
+				lines = append(lines, nil)
+			}
+			lines = append(lines, nil)
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], each)
+	}
+	return lines
+}
+
+// line prints one line: how deep it is, then its tokens with the spaces the layout puts
+// between them.
+func (p *printer) line(number int, written []token.Token) string {
+	b := &strings.Builder{}
+	b.WriteString(strings.Repeat(indent, p.depth(written)))
+	for at, tk := range written {
+		if at > 0 && p.spaced(written[at-1], tk) {
+			b.WriteString(" ")
+		}
+		b.WriteString(text(tk))
+		p.advance(number, tk)
+	}
+	return b.String()
+}
+
+// depth answers how many levels in a line starts. A line is one level deeper than each line
+// that opened a bracket still open, however many that line opened — `f(defer {` is one level,
+// not two — and a line that starts by closing brackets is as deep as whatever they close.
+//
+// A line going on from one that did not end its statement is a level deeper again, so an
+// expression broken over lines reads as one.
+func (p *printer) depth(written []token.Token) int {
+	closing := 0
+	for _, tk := range written {
+		if !closes(tk) {
+			break
+		}
+		closing++
+	}
+	open := p.open[:max(len(p.open)-closing, 0)]
+
+	depth := 0
+	for at, each := range open {
+		if at == 0 || each.line != open[at-1].line {
+			depth++
+		}
+	}
+	if closing == 0 && p.continues() {
+		depth++
+	}
+	return depth
+}
+
+// continues says whether the line being printed goes on from the one before it.
+func (p *printer) continues() bool {
+	if p.last == nil {
+		return false
+	}
+	switch p.last.GetTag().Id {
+	case token.SEMICOLON, token.COMMA, token.O_CUR_BRK, token.C_CUR_BRK, token.O_PAREN, token.O_BRK:
+		return false
+	}
+	return true
+}
+
+// advance keeps track of the brackets a token opens and closes.
+func (p *printer) advance(number int, tk token.Token) {
+	switch tk.GetTag().Id {
+	case token.COMMENT_LINE:
+		return
+	case token.O_CUR_BRK, token.O_PAREN, token.O_BRK:
+		p.open = append(p.open, opener{line: number, literal: p.literals[tk.GetCursor()]})
+	case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+		if len(p.open) > 0 {
+			p.open = p.open[:len(p.open)-1]
+		}
+	}
+	p.last = tk
+}
+
+// spaced says whether a space goes between two tokens of a line.
+func (p *printer) spaced(before, after token.Token) bool {
+	switch after.GetTag().Id {
+	case token.COMMENT_LINE:
+		return true
+	case token.SEMICOLON, token.COMMA, token.COLON, token.DOT, token.C_PAREN, token.C_BRK:
+		return false
+	case token.O_PAREN:
+		// What is applied sits against what it is applied to: a call, feed, assert and require.
+		switch before.GetTag().Id {
+		case token.ID, token.FEED, token.ASSERT, token.REQUIRE:
+			return false
+		}
+	case token.O_CUR_BRK:
+		return !p.literals[after.GetCursor()]
+	case token.C_CUR_BRK:
+		// Written when the brace is reached, so the one it closes is still open.
+		if before.GetTag().Id == token.O_CUR_BRK {
+			return false
+		}
+		return len(p.open) == 0 || !p.open[len(p.open)-1].literal
+	}
+
+	switch before.GetTag().Id {
+	case token.O_PAREN, token.O_BRK, token.DOT:
+		return false
+	case token.O_CUR_BRK:
+		return !p.literals[before.GetCursor()]
+	case token.SUB:
+		// A minus with nothing before it to take from is a sign, and a sign sits against
+		// what it signs: `-5`, `10 + -5`.
+		return !p.signs[before.GetCursor()]
+	}
+	return true
+}
+
+// text is what a token is printed as. A comment loses the spaces it ended with, and nothing
+// else: what it says is the writer's.
+func text(tk token.Token) string {
+	if tk.GetTag().Id == token.COMMENT_LINE {
+		return strings.TrimRight(string(tk.GetMatch()), " \t")
+	}
+	return string(tk.GetMatch())
+}
+
+func closes(tk token.Token) bool {
+	switch tk.GetTag().Id {
+	case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+		return true
+	}
+	return false
+}
+
+// literalBraces answers the cursors of the braces that build a shape: the one after the name
+// of every shape literal in the tree.
+func literalBraces(tokens []token.Token, tree ast.AST) map[int]bool {
+	names := make(map[int]bool)
+	ast.Walk(tree, func(node ast.Node) bool {
+		if literal, ok := node.(ast.ShapeLiteral); ok && literal.Token != nil {
+			names[literal.Token.GetCursor()] = true
+		}
+		return true
+	})
+
+	braces := make(map[int]bool)
+	named := false
+	for _, tk := range tokens {
+		switch tk.GetTag().Id {
+		case token.WHITESPACE, token.BREAK_LINE, token.COMMENT_LINE:
+			continue
+		case token.O_CUR_BRK:
+			if named {
+				braces[tk.GetCursor()] = true
+			}
+		}
+		named = names[tk.GetCursor()]
+	}
+	return braces
+}
+
+// signs answers the cursors of the minuses that are a sign: the ones with nothing before them
+// that could end a value, so nothing to take from.
+func signs(tokens []token.Token) map[int]bool {
+	found := make(map[int]bool)
+	var before token.Token
+	for _, tk := range tokens {
+		switch tk.GetTag().Id {
+		case token.WHITESPACE, token.BREAK_LINE, token.COMMENT_LINE:
+			continue
+		case token.SUB:
+			if before == nil || !endsValue(before) {
+				found[tk.GetCursor()] = true
+			}
+		}
+		before = tk
+	}
+	return found
+}
+
+func endsValue(tk token.Token) bool {
+	switch tk.GetTag().Id {
+	case token.ID, token.NUMBER, token.STRING, token.TRUE, token.FALSE, token.C_PAREN, token.C_BRK, token.C_CUR_BRK:
+		return true
+	}
+	return false
+}
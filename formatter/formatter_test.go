@@ -0,0 +1,306 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/resolver"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// formatted parses a source on its own and answers it formatted.
+func formatted(t *testing.T, source string) string {
+	t.Helper()
+	tokens, tree := parsed(t, source)
+	return string(Format(tokens, tree))
+}
+
+func parsed(t *testing.T, source string) ([]token.Token, ast.AST) {
+	t.Helper()
+	lx := lexer.New()
+	tokens, err := lx.GetTokens([]byte(source))
+	if err != nil {
+		t.Fatalf("lexing: %v", err)
+	}
+	filled, _ := lx.GetFilledTokens([]byte(source))
+	tree, err := parser.New().Parse(parser.ParseInput{Filename: "x.ar", Tokens: filled})
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	return tokens, tree
+}
+
+func TestSpacesEveryLineTheSameWay(t *testing.T) {
+	cases := []struct{ name, source, want string }{
+		{"operators", "ident   x=1+2*3;\n", "ident x = 1 + 2 * 3;\n"},
+		{"calls and feed", "ident f = defer { feed ( 0 ) + feed(1) ; };\nprintd f ( 1 , 2 );\n", "ident f = defer { feed(0) + feed(1); };\nprintd f(1, 2);\n"},
+		{"parentheses after a keyword", "printb(2 + 3)*4;\n", "printb (2 + 3) * 4;\n"},
+		{"tapes", "printb [ 1,2 , 3 ];\nprintb [ ];\n", "printb [1, 2, 3];\nprintb [];\n"},
+		{"signs", "printd - 5;\nprintd 10+ - 5;\nprintd 10 -5;\n", "printd -5;\nprintd 10 + -5;\nprintd 10 - 5;\n"},
+		{"shapes", "shape Point {x,y};\nident p = Point { 1 , 2 };\nprintd p . x;\n", "shape Point { x, y };\nident p = Point{1, 2};\nprintd p.x;\n"},
+		{"an if on a name is not a shape", "ident flag = true;\nprintd if flag{1;}else{2;};\n", "ident flag = true;\nprintd if flag { 1; } else { 2; };\n"},
+		{"an empty block", "ident nothing = defer {  };\n", "ident nothing = defer {};\n"},
+		{"a branch", "ident c = 1;\nprintd branch {\nc equals 1 : 10 ,\n0;\n};\n", "ident c = 1;\nprintd branch {\n  c equals 1: 10,\n  0;\n};\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := formatted(t, c.source); got != c.want {
+				t.Errorf("formatted\n%s\nwant\n%s", got, c.want)
+			}
+		})
+	}
+}
+
+// A line starts one level in for every line that opened a bracket still open, and one more
+// when it goes on from a line that did not end what it was saying.
+func TestIndentsByWhatIsOpen(t *testing.T) {
+	source := `ident area = defer {
+ident w = feed(0);
+    ident h = feed(1);
+if w bigger h {
+w * h;
+} else {
+      h * w;
+};
+};
+printd area(3,
+4);
+ident long = 1 +
+2;
+`
+	want := `ident area = defer {
+  ident w = feed(0);
+  ident h = feed(1);
+  if w bigger h {
+    w * h;
+  } else {
+    h * w;
+  };
+};
+printd area(3,
+  4);
+ident long = 1 +
+  2;
+`
+	if got := formatted(t, source); got != want {
+		t.Errorf("formatted\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Comments are what the writer said, and they stay where they were said: on a line of their
+// own at the depth of the code around them, or after code, one space away.
+func TestKeepsComments(t *testing.T) {
+	source := `#- A scope.
+ident f = defer {
+      #- inside it
+  feed(0);    #- the first value   
+};
+
+
+#- After two blank lines, one.
+printd f(1);#- tight
+`
+	want := `#- A scope.
+ident f = defer {
+  #- inside it
+  feed(0); #- the first value
+};
+
+#- After two blank lines, one.
+printd f(1); #- tight
+`
+	if got := formatted(t, source); got != want {
+		t.Errorf("formatted\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTrimsTheEdgesOfAFile(t *testing.T) {
+	if got := formatted(t, "\n\n\nprintd 1;\n\n\n"); got != "printd 1;\n" {
+		t.Errorf("formatted %q", got)
+	}
+	if got := formatted(t, "printd 1;"); got != "printd 1;\n" {
+		t.Errorf("formatted %q, want a line break at the end", got)
+	}
+	if got := formatted(t, "printd 1;\r\nprintd 2;\r\n"); got != "printd 1;\nprintd 2;\n" {
+		t.Errorf("formatted %q, want one break per line", got)
+	}
+	if got := formatted(t, ""); got != "" {
+		t.Errorf("formatted %q, want nothing", got)
+	}
+}
+
+// A selection is its own lines, as deep as the whole file puts them.
+func TestFormatsLines(t *testing.T) {
+	tokens, tree := parsed(t, "ident f = defer {\nident a=1;\n\n\n   a+1;\n};\nprintd f();\n")
+	if got, from, to := FormatLines(tokens, tree, 2, 5); got != "  ident a = 1;\n\n  a + 1;" || from != 2 || to != 5 {
+		t.Errorf("lines 2 to 5 are\n%q, as lines %d to %d", got, from, to)
+	}
+	if got, _, _ := FormatLines(tokens, tree, 7, 7); got != "printd f();" {
+		t.Errorf("line 7 is %q", got)
+	}
+}
+
+// A selection takes in the lines a brace moves between: the brace written under the line
+// that opens its block is printed on that line, so both are replaced.
+func TestFormatsLinesAcrossAMovedBrace(t *testing.T) {
+	tokens, tree := parsed(t, "ident f = defer\n{\nfeed(0);\n};\n")
+	got, from, to := FormatLines(tokens, tree, 1, 1)
+	if got != "ident f = defer {" || from != 1 || to != 2 {
+		t.Errorf("line 1 is %q, as lines %d to %d; want the brace under it taken in", got, from, to)
+	}
+}
+
+// The structure decides where a block breaks, so two writers' brace styles are the same file
+// once formatted: the brace ends the line that opens the block, a block over more than one
+// line holds a statement per line, and its closing brace starts a line of its own.
+func TestBraceStylesFormatTheSame(t *testing.T) {
+	want := `ident pick = defer {
+  if feed(0) bigger 2 {
+    99;
+  } else {
+    0;
+  };
+};
+printd pick(3);
+`
+	styles := map[string]string{
+		"allman": `ident pick = defer
+{
+  if feed(0) bigger 2
+  {
+    99;
+  }
+  else
+  {
+    0;
+  };
+};
+printd pick(3);
+`,
+		"crammed": `ident pick = defer { if feed(0) bigger 2
+{
+99;}
+else
+{ 0;
+}; };
+printd pick(3);
+`,
+		"canonical": want,
+	}
+	for name, source := range styles {
+		t.Run(name, func(t *testing.T) {
+			if got := formatted(t, source); got != want {
+				t.Errorf("formatted\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// The case that started it: a block in the Allman style was indented as a continuation of the
+// line above it, and its statements were left where they sat.
+func TestAnAllmanIfIsPutInTheLayout(t *testing.T) {
+	got := formatted(t, "if 1 bigger 2\n{\n99;}\nelse\n{ 0;\n};\n")
+	want := "if 1 bigger 2 {\n  99;\n} else {\n  0;\n};\n"
+	if got != want {
+		t.Errorf("formatted\n%s\nwant\n%s", got, want)
+	}
+}
+
+// A block written on one line stays on one line, and every statement of a file starts a line.
+func TestALineBlockStaysAndStatementsSplit(t *testing.T) {
+	got := formatted(t, "ident b = if true { 1; } else { 2; }; printb b; #- the first\nident e = defer {\n\n};\n")
+	want := "ident b = if true { 1; } else { 2; };\nprintb b; #- the first\nident e = defer {};\n"
+	if got != want {
+		t.Errorf("formatted\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Every example is already in the layout, formatting twice is formatting once, and nothing
+// but the layout changes: the tokens that mean something are the same ones, in order.
+func TestExamplesAreFormatted(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "examples", "*.ar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := filepath.Glob(filepath.Join("..", "examples", "project", "src", "*.ar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := exampleResolver(filepath.Join("..", "examples", "project", "src"))
+	for _, file := range slices.Concat(files, project) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modules, err := rs.Resolve(file)
+			if err != nil {
+				t.Fatalf("resolving: %v", err)
+			}
+			tree := modules[len(modules)-1].Tree
+			tokens, err := lexer.New().GetTokens(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			once := Format(tokens, tree)
+			if string(once) != string(source) {
+				t.Errorf("not in the layout; formatted it reads\n%s", once)
+			}
+			again, _ := lexer.New().GetTokens(once)
+			if twice := Format(again, tree); string(twice) != string(once) {
+				t.Errorf("formatting twice changed it:\n%s", twice)
+			}
+			if !slices.EqualFunc(meaningful(t, source), meaningful(t, once), sameToken) {
+				t.Error("formatting changed a token")
+			}
+		})
+	}
+}
+
+func exampleResolver(root string) *resolver.Resolver {
+	lx, ps := lexer.New(), parser.New()
+	return resolver.New(resolver.Options{
+		SourceRoot: root,
+		Read:       os.ReadFile,
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return ps.Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
+}
+
+// meaningful answers the tokens a parse reads, and the comments, which the layout must not
+// touch either.
+func meaningful(t *testing.T, source []byte) []token.Token {
+	t.Helper()
+	tokens, err := lexer.New().GetTokens(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return slices.DeleteFunc(tokens, func(tk token.Token) bool {
+		id := tk.GetTag().Id
+		return id == token.WHITESPACE || id == token.BREAK_LINE || id == token.EOF
+	})
+}
+
+func sameToken(a, b token.Token) bool {
+	return a.GetTag().Id == b.GetTag().Id && strings.TrimRight(string(a.GetMatch()), " ") == strings.TrimRight(string(b.GetMatch()), " ")
+}
@@ -2,24 +2,34 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
 
 	"github.com/guiferpa/aurora/builder/evm"
 	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/loader"
 )
 
 // BuildReport is what a build produced.
 type BuildReport struct {
 	Source       string // the file that was compiled
 	Binary       string // where the bytecode landed
+	ABI          string // where what it answers to landed, beside it
+	SourceMap    string // where the map from its bytes to the source landed, beside it
 	Instructions int    // how many instructions the emitter produced
 	Bytes        int    // how large the bytecode is
 	TapeSize     int    // width in bytes of every value in it
+	// Optimized says the build ran the optimizer, and the two after it what that saved against
+	// the same program built without it: bytes of bytecode, and static gas over every scope.
+	Optimized  bool
+	BytesSaved int
+	GasSaved   int
 }
 
 // Build compiles the source and writes its bytecode to outputPath.
@@ -29,9 +39,11 @@ type BuildReport struct {
 // the binary went — the path may come from a profile rather than from the command line.
 func (s *Session) Build(ctx context.Context, source, outputPath string) (BuildReport, error) {
 	report := BuildReport{
-		Source:   source,
-		Binary:   outputPath,
-		TapeSize: byteutil.TapeSize(s.tapeSize),
+		Source:    source,
+		Binary:    outputPath,
+		ABI:       ABIPath(outputPath),
+		SourceMap: SourceMapPath(outputPath),
+		TapeSize:  byteutil.TapeSize(s.tapeSize),
 	}
 
 	if err := byteutil.ValidateTapeSize(s.tapeSize); err != nil {
@@ -52,25 +64,101 @@ func (s *Session) Build(ctx context.Context, source, outputPath string) (BuildRe
 
 	// Assembling and writing are two things, and the builder only does the first: it
 	// hands the bytecode back, and where it lands is decided here.
-	bytecode, err := evm.NewBuilder(program.Instructions, evm.NewBuilderOptions{
+	//
+	// The contract answers to the scopes of the file built, and to those alone: a module's
+	// scopes are there for the file to call, which is what it used it for.
+	modules := modulesOf(program, false)
+	bytecode, sourceMap, err := evm.NewBuilder(program.Instructions, evm.NewBuilderOptions{
 		TapeSize: s.tapeSize,
-	}).Build()
+		Optimize: s.optimize,
+		Modules:  modules,
+	}).BuildWithSourceMap()
 	if err != nil {
 		return report, err
 	}
 	report.Bytes = len(bytecode)
 
+	// What the optimizer saved is only a number against something: the same program built
+	// without it, which is cheap next to everything else a build does.
+	if s.optimize {
+		plain, err := evm.NewBuilder(program.Instructions, evm.NewBuilderOptions{TapeSize: s.tapeSize, Modules: modules}).Build()
+		if err != nil {
+			return report, err
+		}
+		report.Optimized = true
+		report.BytesSaved = len(plain) - len(bytecode)
+		report.GasSaved = staticGas(plain, s.tapeSize) - staticGas(bytecode, s.tapeSize)
+	}
+
+	// The ABI goes beside the binary because it is how anything else calls it: a wallet or a
+	// library reads the signatures from it, and so does "aurora call".
+	functions := evm.ABI(program.Instructions, program.Promises(), modules)
+	abi, err := json.MarshalIndent(functions, "", "  ")
+	if err != nil {
+		return report, err
+	}
+
+	// The builder knows where each range was written by position in the program; which file
+	// that is, only the program knows.
+	mapped, err := json.MarshalIndent(filesOf(program, sourceMap, filepath.Dir(report.SourceMap)), "", "  ")
+	if err != nil {
+		return report, err
+	}
+
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
 		return report, err
 	}
 	if err := os.WriteFile(outputPath, bytecode, 0o644); err != nil {
 		return report, err
 	}
+	if err := os.WriteFile(report.ABI, append(abi, '\n'), 0o644); err != nil {
+		return report, err
+	}
+	if err := os.WriteFile(report.SourceMap, append(mapped, '\n'), 0o644); err != nil {
+		return report, err
+	}
 
 	writeBuildReport(s.stdout, report)
+	if s.gas {
+		scopes, err := MeasureGas(bytecode, functions, s.tapeSize)
+		if err != nil {
+			return report, err
+		}
+		WriteGasReport(s.stdout, scopes)
+	}
 	return report, nil
 }
 
+// Gas compiles the source and says what calling each of its scopes costs, without writing a
+// binary anywhere: "aurora test --gas" asks it of what it tests.
+//
+// Every module's scopes are dispatched here, not only the file's: a test file is where a
+// project's scopes are called from, and the cost asked for is theirs.
+func (s *Session) Gas(source string) ([]ScopeGas, error) {
+	if err := byteutil.ValidateTapeSize(s.tapeSize); err != nil {
+		return nil, err
+	}
+	program, err := s.compile(source)
+	if err != nil {
+		return nil, err
+	}
+	modules := modulesOf(program, true)
+	bytecode, err := evm.NewBuilder(program.Instructions, evm.NewBuilderOptions{
+		TapeSize: s.tapeSize,
+		Optimize: s.optimize,
+		Modules:  modules,
+	}).Build()
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := MeasureGas(bytecode, evm.ABI(program.Instructions, program.Promises(), modules), s.tapeSize)
+	if err != nil {
+		return nil, err
+	}
+	WriteGasReport(s.stdout, scopes)
+	return scopes, nil
+}
+
 // writeBuildReport says where the binary went and what it is made of: the shape of the
 // program above the line, its size on disk below it.
 func writeBuildReport(w io.Writer, report BuildReport) {
@@ -87,6 +175,64 @@ func writeBuildReport(w io.Writer, report BuildReport) {
 		plural(report.Bytes, "byte"),
 		report.TapeSize,
 	)))
+	_, _ = fmt.Fprintf(w, "   %s\n", dim("ABI: "+displayPath(report.ABI)))
+	_, _ = fmt.Fprintf(w, "   %s\n", dim("source map: "+displayPath(report.SourceMap)))
+	if report.Optimized {
+		_, _ = fmt.Fprintf(w, "   %s\n", dim(fmt.Sprintf("optimized: %s and %d gas saved",
+			plural(report.BytesSaved, "byte"), report.GasSaved)))
+	}
+}
+
+// modulesOf answers the ranges of a program as the builder takes them. The entry is the last
+// range, and its scopes are dispatched; every other module's are too when every is asked for.
+func modulesOf(program loader.Program, every bool) []evm.Module {
+	modules := make([]evm.Module, 0, len(program.Ranges))
+	for at, each := range program.Ranges {
+		modules = append(modules, evm.Module{
+			Name:       string(each.Module),
+			From:       int(each.From),
+			To:         int(each.To),
+			Dispatched: every || at == len(program.Ranges)-1,
+		})
+	}
+	return modules
+}
+
+// staticGas adds up the static cost of calling every scope of a binary once. A scope that can
+// loop has no such cost, and is left out on both sides of a comparison alike.
+func staticGas(bytecode []byte, tapeSize int) int {
+	gas := 0
+	for _, scope := range evm.Gas(bytecode, tapeSize) {
+		if !scope.Unbounded {
+			gas += scope.Dispatch + scope.Body
+		}
+	}
+	return gas
+}
+
+// SourceMapPath answers where the source map of a binary goes: beside it, named after it, the
+// way its ABI is.
+func SourceMapPath(binary string) string {
+	return strings.TrimSuffix(binary, filepath.Ext(binary)) + ".map.json"
+}
+
+// filesOf names the file every range of a source map was written in, relative to where the map
+// is — so a project moved somewhere else still reads its own map.
+func filesOf(program loader.Program, sourceMap evm.SourceMap, dir string) evm.SourceMap {
+	name := func(mappings evm.Mappings) evm.Mappings {
+		named := make(evm.Mappings, len(mappings))
+		for i, mapping := range mappings {
+			if each, ok := program.RangeOf(uint64(mapping.Position)); ok {
+				mapping.File = each.Filename
+				if relative, err := filepath.Rel(dir, each.Filename); err == nil {
+					mapping.File = filepath.ToSlash(relative)
+				}
+			}
+			named[i] = mapping
+		}
+		return named
+	}
+	return evm.SourceMap{Constructor: name(sourceMap.Constructor), Runtime: name(sourceMap.Runtime)}
 }
 
 func plural(count int, noun string) string {
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -118,6 +119,41 @@ func TestBuildReportsTheTapeSizeItUsed(t *testing.T) {
 	}
 }
 
+// The ABI lands beside the binary, naming every scope by the signature it is dispatched on.
+func TestBuildWritesTheABIBesideTheBinary(t *testing.T) {
+	dir := t.TempDir()
+	entry := filepath.Join(dir, "main.ar")
+	source := "shape Pair { left, right };\n" +
+		"ident add = defer { feed(0) + feed(1); };\n" +
+		"ident pair = defer { Pair{feed(0), 2}; } returns Pair;\n"
+	if err := os.WriteFile(entry, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.bin")
+	report, err := newSession(t, sessionOpts{}).Build(t.Context(), entry, out)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if want := filepath.Join(dir, "out.abi.json"); report.ABI != want {
+		t.Errorf("the ABI went to %s, want %s", report.ABI, want)
+	}
+
+	functions, err := LoadABI(report.ABI)
+	if err != nil {
+		t.Fatalf("LoadABI: %v", err)
+	}
+	signatures := make([]string, 0, len(functions))
+	for _, fn := range functions {
+		signatures = append(signatures, fn.Signature())
+	}
+	if got := strings.Join(signatures, " "); got != "add(uint256,uint256) pair(uint256)" {
+		t.Errorf("signatures = %s", got)
+	}
+	if outputs := functions[1].Outputs; len(outputs) != 1 || len(outputs[0].Components) != 2 {
+		t.Errorf("pair answers %+v, want a tuple of two", outputs)
+	}
+}
+
 // Nowhere to write is not a reason to fail, and it is how every existing caller builds.
 func TestBuildWithoutAReportStillBuilds(t *testing.T) {
 	dir := t.TempDir()
@@ -202,3 +238,72 @@ func TestBuildSaysNothingAboutWhatItCarries(t *testing.T) {
 		t.Errorf("the build said %q about a program it carries whole", got)
 	}
 }
+
+// The source map lands beside the binary, and a scope a module binds — in the runtime because
+// the file calls it — is mapped to the module's file, named from where the map is, so the
+// project reads its map wherever it is moved.
+func TestBuildWritesTheSourceMapBesideTheBinary(t *testing.T) {
+	projectOf(t, map[string]string{
+		"src/a/b.ar":  "ident square = defer {\n  feed(0) * feed(0);\n};",
+		"src/main.ar": "use a/b as x;\nident twice = defer { x.square(feed(0)) + feed(0); };",
+	})
+
+	report, err := newSession(t, sessionOpts{}).Build(t.Context(), filepath.FromSlash("src/main.ar"), filepath.FromSlash("bin/main"))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if want := filepath.FromSlash("bin/main.map.json"); report.SourceMap != want {
+		t.Errorf("the source map went to %s, want %s", report.SourceMap, want)
+	}
+
+	contents, err := os.ReadFile(report.SourceMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"file": "../src/a/b.ar"`, `"file": "../src/main.ar"`} {
+		if !strings.Contains(string(contents), want) {
+			t.Errorf("the map does not say %s:\n%s", want, contents)
+		}
+	}
+
+	sourceMap, err := LoadSourceMap(report.SourceMap)
+	if err != nil {
+		t.Fatalf("LoadSourceMap: %v", err)
+	}
+	lines := make(map[string]bool)
+	for _, mapping := range sourceMap.Runtime {
+		lines[fmt.Sprintf("%s:%d", filepath.ToSlash(mapping.File), mapping.Line)] = true
+	}
+	for _, want := range []string{"src/a/b.ar:2", "src/main.ar:2"} {
+		if !lines[want] {
+			t.Errorf("nothing of the runtime maps to %s: %v", want, lines)
+		}
+	}
+}
+
+// A build with -O says what it saved against the same program built without it, and what it
+// wrote is that much smaller.
+func TestBuildReportsWhatOptimizingSaved(t *testing.T) {
+	dir := t.TempDir()
+	source := writeAt(t, dir, "main.ar", "ident f = defer { ident a = 2 * 3 + feed(0); a * 2; };\n")
+
+	plain, err := newSession(t, sessionOpts{tapeSize: 1}).Build(t.Context(), source, filepath.Join(dir, "plain"))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	stdout := &strings.Builder{}
+	optimized, err := newSession(t, sessionOpts{tapeSize: 1, stdout: stdout, optimize: true}).Build(t.Context(), source, filepath.Join(dir, "optimized"))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if !optimized.Optimized || optimized.BytesSaved <= 0 || optimized.GasSaved <= 0 {
+		t.Errorf("report = %+v, want something saved", optimized)
+	}
+	if got := plain.Bytes - optimized.Bytes; got != optimized.BytesSaved {
+		t.Errorf("%d bytes smaller, and the report says %d", got, optimized.BytesSaved)
+	}
+	if want := fmt.Sprintf("optimized: %d bytes and %d gas saved", optimized.BytesSaved, optimized.GasSaved); !strings.Contains(stdout.String(), want) {
+		t.Errorf("the report does not say %q:\n%s", want, stdout.String())
+	}
+}
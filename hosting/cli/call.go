@@ -2,34 +2,92 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/guiferpa/aurora/builder/evm"
 	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/parser"
 )
 
 // CallInput is the input for the Call handler.
 type CallInput struct {
-	Function        string // function name (selector = Keccak256(function))
+	Function        string // function name, looked up in the ABI for its signature
 	ContractAddress string
 	RPC             string
 	Args            []string // optional arguments (decimal or 0x-prefixed hex), ABI-encoded as uint256 each
 	Pretend         bool
+	// ABI is what the contract answers to, as "aurora build" wrote it next to the binary.
+	ABI []evm.ABIFunction
 }
 
-func EncodeSelector(selector string) []byte {
-	return byteutil.Padding32Bytes(crypto.Keccak256([]byte(selector)))
+// EncodeSelector answers the four bytes a call to a canonical signature opens with.
+func EncodeSelector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// ABIPath answers where the ABI of a binary is written: beside it, under the same name.
+func ABIPath(binary string) string {
+	return strings.TrimSuffix(binary, filepath.Ext(binary)) + ".abi.json"
+}
+
+// LoadABI reads the ABI "aurora build" wrote.
+func LoadABI(path string) ([]evm.ABIFunction, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the ABI (run 'aurora build' first): %w", err)
+	}
+	var functions []evm.ABIFunction
+	if err := json.Unmarshal(contents, &functions); err != nil {
+		return nil, fmt.Errorf("reading the ABI %s: %w", path, err)
+	}
+	return functions, nil
+}
+
+// EncodeCall writes the calldata of a call through the ABI: the selector of the function's
+// signature, and a word for every argument.
+//
+// The name is looked up rather than hashed as it is, because what a contract dispatches on is
+// the signature, and the signature says how many arguments there are — a call with the wrong
+// count would reach nothing, or worse, read zeros where an argument was meant to be.
+func EncodeCall(functions []evm.ABIFunction, name string, args []string) ([]byte, error) {
+	for _, fn := range functions {
+		if fn.Type != "function" || fn.Name != name {
+			continue
+		}
+		if len(args) != len(fn.Inputs) {
+			return nil, fmt.Errorf("%s takes %d arguments, %d were given", fn.Signature(), len(fn.Inputs), len(args))
+		}
+		return append(EncodeSelector(fn.Signature()), ParseArgs(args)...), nil
+	}
+	names := make([]string, 0, len(functions))
+	for _, fn := range functions {
+		if fn.Type == "function" {
+			names = append(names, fn.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("the contract answers to nothing, so it has no %s", name)
+	}
+	return nil, fmt.Errorf("the contract has no %s (it has %s)", name, strings.Join(names, ", "))
 }
 
 // Call performs an eth_call and prints the result.
 func Call(ctx context.Context, in CallInput) error {
-	selector := EncodeSelector(in.Function)
-	args := ParseArgs(in.Args)
+	data, err := EncodeCall(in.ABI, in.Function, in.Args)
+	if err != nil {
+		return err
+	}
+	selector, args := data[:4], data[4:]
 	contract := common.HexToAddress(in.ContractAddress)
-	data := append(selector, args...)
 
 	if in.Pretend {
 		fmt.Printf("Contract:   0x%x (%d bytes)\n", contract, len(contract.Bytes()))
@@ -57,3 +115,57 @@ func Call(ctx context.Context, in CallInput) error {
 	fmt.Printf("Result: %v\n", result)
 	return nil
 }
+
+// CallLocal calls a scope of the source the way "aurora call" calls it on chain, with the
+// evaluator answering instead of a network: the program runs, its modules before it, and then
+// the scope is applied args. Those are the call's alone — the top runs as the constructor, fed
+// what the evaluator was handed, as "aurora deploy --args" feeds it. Each is applied as a feed
+// of the call, so it arrives the way a call's does — a word narrowed to a tape, not a literal
+// checked against one.
+//
+// Only what the contract answers to can be called, checked against the ABI a build writes, so
+// a call that would reach nothing on chain is refused here with the same words.
+func (s *Session) CallLocal(ctx context.Context, source, function string, args []string) ([]byte, error) {
+	program, err := s.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := EncodeCall(evm.ABI(program.Instructions, nil, modulesOf(program, false)), function, args); err != nil {
+		return nil, err
+	}
+
+	feeds := make([]string, 0, len(args))
+	for i := range args {
+		feeds = append(feeds, fmt.Sprintf("feed(%d)", i))
+	}
+	tokens, err := s.lexer.GetFilledTokens(fmt.Appendf(nil, "%s(%s);", function, strings.Join(feeds, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.parser.Parse(parser.ParseInput{Filename: source, Tokens: tokens, TapeSize: s.tapeSize})
+	if err != nil {
+		return nil, err
+	}
+	call, err := s.emitter.Emit(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	ev, err := s.evaluator()
+	if err != nil {
+		return nil, err
+	}
+	for _, each := range program.Ranges {
+		if _, err := ev.EvaluateModule(program.Instructions, each.From, each.To, string(each.Module)); err != nil {
+			return nil, err
+		}
+	}
+	// The call is one more range at the end of the stream, run where the entry's names are:
+	// a scope's body is found by where it is in the stream, so the stream is not cut.
+	insts := slices.Concat(program.Instructions, call)
+	temps, err := ev.EvaluateCall(insts, uint64(len(program.Instructions)), uint64(len(insts)), ParseArgs(args))
+	if err != nil {
+		return nil, err
+	}
+	return temps[byteutil.ToHex(call[len(call)-1].GetLabel())], nil
+}
@@ -3,9 +3,12 @@ package cli
 import (
 	"bytes"
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/guiferpa/aurora/builder/evm"
 	"github.com/guiferpa/aurora/byteutil"
 )
 
@@ -13,6 +16,7 @@ func TestCallFailsWhenRPCUnreachable(t *testing.T) {
 	ctx := context.Background()
 	err := Call(ctx, CallInput{
 		Function:        "foo",
+		ABI:             []evm.ABIFunction{{Type: "function", Name: "foo"}},
 		ContractAddress: "0x0000000000000000000000000000000000000000",
 		RPC:             "http://invalid.invalid:99999",
 	})
@@ -26,6 +30,7 @@ func TestCallFailsWhenContractAddressInvalid(t *testing.T) {
 	ctx := context.Background()
 	err := Call(ctx, CallInput{
 		Function:        "foo",
+		ABI:             []evm.ABIFunction{{Type: "function", Name: "foo"}},
 		ContractAddress: "not-a-valid-address",
 		RPC:             "http://127.0.0.1:99999",
 	})
@@ -47,22 +52,80 @@ func TestCallFailsWhenFunctionInvalid(t *testing.T) {
 	}
 }
 
+// The selector is Solidity's: the first four bytes of the hash of the signature.
 func TestEncodeSelector(t *testing.T) {
-	cases := []struct {
-		Function   string
-		ExpectedFn func() []byte
-	}{
-		{
-			Function: "fn",
-			ExpectedFn: func() []byte {
-				return byteutil.Padding32Bytes(crypto.Keccak256([]byte("fn")))
-			},
-		},
-	}
-	for _, c := range cases {
-		got := EncodeSelector(c.Function)
-		if expected := c.ExpectedFn(); !bytes.Equal(got, expected) {
-			t.Errorf("Unexpected selector: got %v (%d), expected: %v (%d)", byteutil.ToHexBloom(got), len(got), byteutil.ToHexBloom(expected), len(expected))
-		}
+	if got := EncodeSelector("add(uint256,uint256)"); !bytes.Equal(got, []byte{0x77, 0x16, 0x02, 0xf7}) {
+		t.Errorf("got %s, want 771602F7", byteutil.ToUpperHex(got))
+	}
+}
+
+func TestEncodeCallGoesThroughTheABI(t *testing.T) {
+	functions := []evm.ABIFunction{
+		{Type: "function", Name: "add", Inputs: make([]evm.ABIParameter, 2)},
+		{Type: "function", Name: "total"},
+	}
+
+	data, err := EncodeCall(functions, "add", []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("EncodeCall: %v", err)
+	}
+	want := append(EncodeSelector("add(uint256,uint256)"), ParseArgs([]string{"1", "2"})...)
+	if !bytes.Equal(data, want) {
+		t.Errorf("got %s, want %s", byteutil.ToUpperHex(data), byteutil.ToUpperHex(want))
+	}
+
+	if _, err := EncodeCall(functions, "add", []string{"1"}); err == nil || !strings.Contains(err.Error(), "takes 2") {
+		t.Errorf("a short call: got %v, want a refusal naming the count", err)
+	}
+	if _, err := EncodeCall(functions, "sub", nil); err == nil || !strings.Contains(err.Error(), "add, total") {
+		t.Errorf("an unknown name: got %v, want a refusal naming what there is", err)
+	}
+}
+
+// What "aurora call --local" does: the scope is applied the arguments and answers in the call
+// the context describes, and a scope the ABI does not list is refused in the ABI's words.
+func TestCallLocalAppliesAScopeInTheCall(t *testing.T) {
+	entry := filepath.Join(t.TempDir(), "main.ar")
+	source := "ident paid = defer { callvalue + feed(0); };\n"
+	if err := os.WriteFile(entry, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := NewChainContext(ChainContextOptions{CallValue: "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"5"}
+	s := newSession(t, sessionOpts{tapeSize: 8, chain: chain})
+
+	got, err := s.CallLocal(context.Background(), entry, "paid", args)
+	if err != nil {
+		t.Fatalf("CallLocal: %v", err)
+	}
+	if !bytes.Equal(got, byteutil.FromUint64(12)) {
+		t.Errorf("answered %v, want 12", got)
+	}
+
+	if _, err := s.CallLocal(context.Background(), entry, "unpaid", nil); err == nil || !strings.Contains(err.Error(), "has no unpaid") {
+		t.Errorf("an unknown scope: got %v, want a refusal", err)
+	}
+}
+
+// The top of a program is its constructor, so it is fed what the contract was deployed with and
+// the scope what it is called with — the call's arguments reaching the top would have it read
+// as deployed with them.
+func TestCallLocalFeedsTheConstructorAndTheCallApart(t *testing.T) {
+	entry := filepath.Join(t.TempDir(), "main.ar")
+	source := "ident rate = feed(0);\nident scale = defer { feed(0) * rate; };\n"
+	if err := os.WriteFile(entry, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newSession(t, sessionOpts{tapeSize: 8, args: []string{"10"}})
+
+	got, err := s.CallLocal(context.Background(), entry, "scale", []string{"3"})
+	if err != nil {
+		t.Fatalf("CallLocal: %v", err)
+	}
+	if !bytes.Equal(got, byteutil.FromUint64(30)) {
+		t.Errorf("answered %v, want 30: the rate deployed with times the 3 called with", got)
 	}
 }
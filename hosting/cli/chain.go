@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// What a program running off chain is told about the call it is in.
+//
+// On chain a contract reads who called it, with how much, and in which block, and none of it is
+// written in the program. Off chain there is no call to read it from, so "aurora run" and
+// "aurora call --local" are told it through flags and tell the evaluator. What nobody said is
+// zero, which is what an evaluator with no context answers anyway — so a program that never
+// asks runs exactly as it did.
+
+// ChainContextOptions is what the call was said to be, as it was written.
+type ChainContextOptions struct {
+	// Caller is an address: forty hex digits, after 0x or not.
+	Caller string
+	// CallValue is in wei. It, and every other number, is decimal or hex after 0x.
+	CallValue   string
+	Timestamp   string
+	BlockNumber string
+	ChainID     string
+}
+
+// A ChainContext answers what a program asks about its call with what it was told. Each answer
+// is a word, the way the EVM answers it, and the evaluator cuts it to a tape.
+type ChainContext struct {
+	caller, callValue, timestamp, blockNumber, chainID []byte
+}
+
+func (c ChainContext) Caller() []byte      { return c.caller }
+func (c ChainContext) CallValue() []byte   { return c.callValue }
+func (c ChainContext) Timestamp() []byte   { return c.timestamp }
+func (c ChainContext) BlockNumber() []byte { return c.blockNumber }
+func (c ChainContext) ChainID() []byte     { return c.chainID }
+
+// NewChainContext reads what the call was said to be, and refuses what a chain could not have
+// said: an address of the wrong length, or a number that is not one or does not fit a word.
+func NewChainContext(opts ChainContextOptions) (ChainContext, error) {
+	var c ChainContext
+	if opts.Caller != "" {
+		if !common.IsHexAddress(opts.Caller) {
+			return ChainContext{}, fmt.Errorf("caller %q is not an address: it is forty hex digits", opts.Caller)
+		}
+		c.caller = common.LeftPadBytes(common.HexToAddress(opts.Caller).Bytes(), 32)
+	}
+
+	numbers := []struct {
+		name  string
+		text  string
+		value *[]byte
+	}{
+		{"call value", opts.CallValue, &c.callValue},
+		{"timestamp", opts.Timestamp, &c.timestamp},
+		{"block number", opts.BlockNumber, &c.blockNumber},
+		{"chain id", opts.ChainID, &c.chainID},
+	}
+	for _, each := range numbers {
+		if each.text == "" {
+			continue
+		}
+		n := parseNumber(each.text)
+		if n == nil || n.Sign() < 0 || n.BitLen() > 256 {
+			return ChainContext{}, fmt.Errorf("%s %q is not a number a word holds", each.name, each.text)
+		}
+		*each.value = common.LeftPadBytes(n.Bytes(), 32)
+	}
+	return c, nil
+}
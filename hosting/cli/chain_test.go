@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// Every answer is a word, the way the chain answers it, whatever base it was written in.
+func TestNewChainContextReadsWords(t *testing.T) {
+	c, err := NewChainContext(ChainContextOptions{
+		Caller:      "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		CallValue:   "100",
+		Timestamp:   "0x10",
+		BlockNumber: "7",
+	})
+	if err != nil {
+		t.Fatalf("NewChainContext: %v", err)
+	}
+
+	caller := c.Caller()
+	if len(caller) != 32 || caller[12] != 0x5b || caller[31] != 0xc4 {
+		t.Errorf("caller = %s, want the address at the end of a word", byteutil.ToUpperHex(caller))
+	}
+	for name, got := range map[string][]byte{"call value": c.CallValue(), "timestamp": c.Timestamp(), "block number": c.BlockNumber()} {
+		if len(got) != 32 {
+			t.Errorf("%s is %d bytes, want a word", name, len(got))
+		}
+	}
+	if c.CallValue()[31] != 100 || c.Timestamp()[31] != 16 || c.BlockNumber()[31] != 7 {
+		t.Errorf("read %v, %v and %v, want 100, 16 and 7", c.CallValue(), c.Timestamp(), c.BlockNumber())
+	}
+	// Nobody said which chain, so it answers nothing and the evaluator reads zero.
+	if c.ChainID() != nil {
+		t.Errorf("chain id = %v, want nothing", c.ChainID())
+	}
+}
+
+func TestNewChainContextRefusesWhatAChainCouldNotSay(t *testing.T) {
+	cases := []struct {
+		name string
+		opts ChainContextOptions
+		want string
+	}{
+		{name: "a short address", opts: ChainContextOptions{Caller: "0x12"}, want: "is not an address"},
+		{name: "a word too wide", opts: ChainContextOptions{CallValue: "0x1" + strings.Repeat("0", 64)}, want: "call value"},
+		{name: "a negative number", opts: ChainContextOptions{Timestamp: "-1"}, want: "timestamp"},
+		{name: "not a number", opts: ChainContextOptions{ChainID: "mainnet"}, want: "chain id"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewChainContext(tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want a refusal naming %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -114,7 +114,7 @@ func TestAssertBelongsToATestFile(t *testing.T) {
 // since it crosses the loader, the session and the writer to get there.
 func TestAShortCallIsReportedWithWhereToLook(t *testing.T) {
 	dir := t.TempDir()
-	source := writeFile(t, dir, "main.ar", "ident sum = defer { feed(0) + feed(1); };\nprintb sum(5);\n")
+	source := writeFile(t, dir, "main.ar", "ident sum = defer { feed(0) + feed(1); };\nident f = defer { sum(5); };\n")
 	warnings := &strings.Builder{}
 
 	if _, err := newSession(t, sessionOpts{warnings: warnings}).
@@ -123,7 +123,7 @@ func TestAShortCallIsReportedWithWhereToLook(t *testing.T) {
 	}
 
 	got := warnings.String()
-	for _, want := range []string{"main.ar:2:8:", "sum reads 2 positions", "feed(1)"} {
+	for _, want := range []string{"main.ar:2:19:", "sum reads 2 positions", "feed(1)"} {
 		if !strings.Contains(got, want) {
 			t.Errorf("the compiler said %q, want it to mention %q", got, want)
 		}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/guiferpa/aurora/evaluator"
+)
+
+// What the contracts a program calls answer, when it runs off chain.
+//
+// A Go test hands the evaluator a registry it built; "aurora run" and "aurora call --local" read
+// one from a file named by --contracts. The file is TOML, a table per address, and in it what
+// each signature answers:
+//
+//	["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"]
+//	"totalSupply()" = 1000
+//	"balanceOf(address)" = "0x2a"
+//
+// Every answer is one word, since a word is what a call answers on chain, and it answers the
+// same whatever it is applied. A contract or a signature left out is not answered at all, so a
+// call to it stops the program, as it would with no file.
+
+// LoadContracts reads the answers of a contracts file into a registry, and refuses what a chain
+// could not answer: an address of the wrong length, or a number that is not one or does not fit
+// a word.
+func LoadContracts(path string) (*evaluator.Registry, error) {
+	var file map[string]map[string]any
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return nil, fmt.Errorf("reading the contracts %s: %w", path, err)
+	}
+
+	registry := evaluator.NewRegistry()
+	for _, address := range sortedKeys(file) {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("%s: %q is not an address: it is forty hex digits", path, address)
+		}
+		answers := file[address]
+		for _, signature := range sortedKeys(answers) {
+			word, err := answerWord(answers[signature])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s at %s %w", path, signature, address, err)
+			}
+			registry.Answer(common.HexToAddress(address).Bytes(), signature, evaluator.Returns(word))
+		}
+	}
+	return registry, nil
+}
+
+// answerWord reads what a signature answers, written as a TOML integer or as a string in the
+// bases an argument is written in.
+func answerWord(value any) ([]byte, error) {
+	var text string
+	switch v := value.(type) {
+	case int64:
+		text = fmt.Sprint(v)
+	case string:
+		text = v
+	default:
+		return nil, fmt.Errorf("answers %v, which is not a number", value)
+	}
+	n := parseNumber(text)
+	if n == nil || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("answers %q, which is not a number a word holds", text)
+	}
+	return common.LeftPadBytes(n.Bytes(), 32), nil
+}
+
+// sortedKeys answers the keys of a table in order, so the first refusal of a file is the same
+// one every time it is read.
+func sortedKeys[V any](table map[string]V) []string {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/byteutil"
+)
+
+// writeContracts writes a contracts file and answers where it is.
+func writeContracts(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "contracts.toml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// What "aurora call --local --contracts" does: a call out of the program is answered by the
+// file, in either base, and a signature it leaves out is not answered at all.
+func TestLoadContractsAnswersTheCallsItNames(t *testing.T) {
+	const token = "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"
+	registry, err := LoadContracts(writeContracts(t, `["`+token+`"]
+"totalSupply()" = 1000
+"decimals()" = "0x12"
+`))
+	if err != nil {
+		t.Fatalf("LoadContracts: %v", err)
+	}
+
+	entry := filepath.Join(t.TempDir(), "main.ar")
+	source := "ident supply = defer { staticcall(feed(0), \"totalSupply()\") + staticcall(feed(0), \"decimals()\"); };\n" +
+		"ident owner = defer { staticcall(feed(0), \"owner()\"); };\n"
+	if err := os.WriteFile(entry, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newSession(t, sessionOpts{tapeSize: 32, contracts: registry})
+
+	got, err := s.CallLocal(t.Context(), entry, "supply", []string{token})
+	if err != nil {
+		t.Fatalf("CallLocal: %v", err)
+	}
+	if !bytes.Equal(got, byteutil.PaddingTape([]byte{0x03, 0xfa}, 32)) {
+		t.Errorf("answered %x, want 1018", got)
+	}
+
+	if _, err := s.CallLocal(t.Context(), entry, "owner", []string{token}); err == nil || !strings.Contains(err.Error(), "does not answer owner()") {
+		t.Errorf("a signature the file leaves out: got %v, want a refusal", err)
+	}
+}
+
+// A file is refused for what a chain could not answer, naming the file and what in it.
+func TestLoadContractsRefusesWhatAChainCouldNotAnswer(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{name: "a short address", contents: "[\"0x12\"]\n\"f()\" = 1\n", want: `"0x12" is not an address`},
+		{name: "a negative number", contents: "[\"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4\"]\n\"f()\" = -1\n", want: "f() at"},
+		{name: "not a number", contents: "[\"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4\"]\n\"f()\" = \"many\"\n", want: `answers "many"`},
+		{name: "a fraction", contents: "[\"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4\"]\n\"f()\" = 1.5\n", want: "not a number"},
+		{name: "not TOML", contents: "[", want: "reading the contracts"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeContracts(t, tc.contents)
+			_, err := LoadContracts(path)
+			if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), path) {
+				t.Errorf("got %v, want a refusal naming %q and the file", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/guiferpa/aurora/loader"
+)
+
+// Coverage is which lines of which files ran under "aurora test --cover", and how many times.
+//
+// A line is counted when an instruction written on it ran. A line that compiled to something
+// and never ran is kept at zero, which is the whole point: a line nobody compiled anything
+// from is not a line that could have been missed, so it is not in the table at all.
+type Coverage struct {
+	files map[string]map[int]int
+}
+
+// FileCoverage is one file's lines, by number, and how many times each of them ran.
+type FileCoverage struct {
+	Path  string
+	Lines map[int]int
+}
+
+// Total answers how many lines of the file could have run.
+func (f FileCoverage) Total() int {
+	return len(f.Lines)
+}
+
+// Covered answers how many of them did.
+func (f FileCoverage) Covered() int {
+	covered := 0
+	for _, hits := range f.Lines {
+		if hits > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// Percent answers how much of the file ran. A file with nothing to run is whole, since there
+// was nothing for a test to miss.
+func (f FileCoverage) Percent() float64 {
+	return percent(f.Covered(), f.Total())
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// NewCoverage answers an empty table, ready to be added to one program at a time.
+func NewCoverage() *Coverage {
+	return &Coverage{files: make(map[string]map[int]int)}
+}
+
+// Add folds what one program ran into the table.
+//
+// Hits are counted by position in the program's stream, which is what the evaluator knows; the
+// range a position sits in says which file it came from, and the instruction there says which
+// line. Test files are left out: a test running is not what coverage measures, and every line
+// of one runs by definition.
+func (c *Coverage) Add(program loader.Program, hits map[uint64]int) {
+	for _, each := range program.Ranges {
+		if strings.HasSuffix(each.Filename, TestExtension) {
+			continue
+		}
+		lines, ok := c.files[each.Filename]
+		if !ok {
+			lines = make(map[int]int)
+			c.files[each.Filename] = lines
+		}
+		// A line is as many instructions as it compiled to, and it ran as many times as the
+		// busiest of them: "a + b" is three instructions and one line that ran once.
+		ran := make(map[int]int)
+		for at := each.From; at < each.To; at++ {
+			origin := program.Instructions[at].GetOrigin()
+			if !origin.Known() {
+				continue
+			}
+			ran[origin.Line] = max(ran[origin.Line], hits[at])
+		}
+		for line, times := range ran {
+			lines[line] += times
+		}
+	}
+}
+
+// Merge folds another table into this one, line by line.
+func (c *Coverage) Merge(other *Coverage) {
+	if other == nil {
+		return
+	}
+	for path, ran := range other.files {
+		lines, ok := c.files[path]
+		if !ok {
+			lines = make(map[int]int)
+			c.files[path] = lines
+		}
+		for line, times := range ran {
+			lines[line] += times
+		}
+	}
+}
+
+// CoverageHistory is what each test file ran the last time it ran.
+//
+// Under --watch only the tests a change affected run again, and the table they make is only
+// theirs. A test that was not run again still reached what it reached before — nothing it
+// reads has changed, or it would have been run — so the profile written after every run is
+// the latest table of each test file, folded together.
+type CoverageHistory struct {
+	files map[string]*Coverage
+}
+
+// NewCoverageHistory answers a history with no run in it yet.
+func NewCoverageHistory() *CoverageHistory {
+	return &CoverageHistory{files: make(map[string]*Coverage)}
+}
+
+// Record keeps what the test files of a report ran, in place of what they ran before, and
+// answers the coverage of every file in files. A test file that is no longer among them has
+// gone away, and what it ran goes with it.
+func (h *CoverageHistory) Record(report TestReport, files []string) *Coverage {
+	for _, file := range report.Files {
+		h.files[file.Path] = file.Coverage
+	}
+	for path := range h.files {
+		if !slices.Contains(files, path) {
+			delete(h.files, path)
+		}
+	}
+
+	coverage := NewCoverage()
+	for _, path := range files {
+		coverage.Merge(h.files[path])
+	}
+	return coverage
+}
+
+// Files answers every file in the table, sorted by path so two runs read the same.
+func (c *Coverage) Files() []FileCoverage {
+	paths := make([]string, 0, len(c.files))
+	for path := range c.files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	files := make([]FileCoverage, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, FileCoverage{Path: path, Lines: c.files[path]})
+	}
+	return files
+}
+
+// sortedLines answers a file's line numbers in order.
+func sortedLines(lines map[int]int) []int {
+	numbers := make([]int, 0, len(lines))
+	for line := range lines {
+		numbers = append(numbers, line)
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+// WriteLCOV writes the table in the LCOV tracefile format, which is what editors and CI
+// services read coverage from: a record per file, a DA line per line that could have run, and
+// the two totals at the end of each record.
+func WriteLCOV(w io.Writer, coverage *Coverage) error {
+	for _, file := range coverage.Files() {
+		if _, err := fmt.Fprintf(w, "SF:%s\n", file.Path); err != nil {
+			return err
+		}
+		for _, line := range sortedLines(file.Lines) {
+			if _, err := fmt.Fprintf(w, "DA:%d,%d\n", line, file.Lines[line]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "LF:%d\nLH:%d\nend_of_record\n", file.Total(), file.Covered()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCoverage is the summary under a test report: every file, how much of it ran, and the
+// total across all of them.
+func writeCoverage(w io.Writer, coverage *Coverage) {
+	if w == nil || coverage == nil {
+		return
+	}
+
+	dim := color.New(color.Faint).SprintFunc()
+
+	files := coverage.Files()
+	if len(files) == 0 {
+		_, _ = fmt.Fprintf(w, "\n%s\n", dim("coverage: no source files ran under test"))
+		return
+	}
+
+	_, _ = fmt.Fprintln(w)
+	total, covered := 0, 0
+	for _, file := range files {
+		total += file.Total()
+		covered += file.Covered()
+		_, _ = fmt.Fprintf(w, "%6.1f%%  %s  %s\n", file.Percent(), displayPath(file.Path),
+			dim(fmt.Sprintf("%d/%d lines", file.Covered(), file.Total())))
+	}
+	_, _ = fmt.Fprintf(w, "coverage: %.1f%% of %s\n", percent(covered, total), plural(total, "line"))
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Coverage is counted across every test file and the modules they name, by the file a line
+// was written in — not by the test that happened to reach it.
+func TestCoverageFollowsTheModuleALineWasWrittenIn(t *testing.T) {
+	dir := project(t)
+	writeAt(t, dir, "src/main.ar", "printd 1;\n")
+	// The body nobody calls sits on a line of its own: the line binding it runs when the
+	// module loads, and is rightly counted.
+	writeAt(t, dir, "src/geometry.ar", `ident area = defer { feed(0) * feed(1); };
+ident perimeter = defer {
+  2 * (feed(0) + feed(1));
+};
+`)
+	writeAt(t, dir, "src/area.test.ar", `use geometry as g;
+assert(g.area(2, 3) equals 6, "area");
+`)
+
+	report, err := tested(t, "", sessionOpts{stdout: io.Discard, cover: true})
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if report.Coverage == nil {
+		t.Fatal("a run that counted came back with no coverage")
+	}
+
+	files := report.Coverage.Files()
+	if len(files) != 1 {
+		t.Fatalf("covered %d files, want only the module: %+v", len(files), files)
+	}
+	geometry := files[0]
+	if !strings.HasSuffix(geometry.Path, "geometry.ar") {
+		t.Fatalf("covered %s, want the module", geometry.Path)
+	}
+	if geometry.Lines[1] == 0 {
+		t.Error("the scope the test called is not counted as run")
+	}
+	if hits, known := geometry.Lines[3]; !known || hits != 0 {
+		t.Errorf("the scope nobody called is at %d (known: %v), want a line that could have run and did not", hits, known)
+	}
+}
+
+// Without --cover there is nothing to report, and nothing is said about it.
+func TestNoCoverageUnlessAskedFor(t *testing.T) {
+	dir := project(t)
+	writeAt(t, dir, "src/main.ar", "printd 1;\n")
+	writeAt(t, dir, "src/main.test.ar", `assert(1 equals 1, "holds");`)
+
+	var out bytes.Buffer
+	report, err := tested(t, "", sessionOpts{stdout: &out})
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if report.Coverage != nil {
+		t.Errorf("coverage was kept without being asked for")
+	}
+	if strings.Contains(out.String(), "coverage") {
+		t.Errorf("the report talks about coverage nobody asked for:\n%s", out.String())
+	}
+}
+
+// The LCOV file is what an editor and a CI service read, so its shape is the contract: a
+// record per file, a DA line per line, and the two totals.
+func TestWriteLCOV(t *testing.T) {
+	coverage := NewCoverage()
+	coverage.files["src/a.ar"] = map[int]int{3: 0, 1: 2}
+
+	var out bytes.Buffer
+	if err := WriteLCOV(&out, coverage); err != nil {
+		t.Fatalf("WriteLCOV: %v", err)
+	}
+
+	want := "SF:src/a.ar\nDA:1,2\nDA:3,0\nLF:2\nLH:1\nend_of_record\n"
+	if got := out.String(); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Under --watch only the tests a change affected run again. What the others reached is kept
+// from their last run, so the summary and the profile after a narrow run still cover every
+// test.
+func TestCoverageHistoryKeepsWhatTheOtherTestsRan(t *testing.T) {
+	dir := project(t)
+	writeAt(t, dir, "src/main.ar", "printd 1;\n")
+	geometry := writeAt(t, dir, "src/geometry.ar", "ident area = defer { feed(0) * feed(1); };\n")
+	writeAt(t, dir, "src/label.ar", "ident name = defer { feed(0); };\n")
+	area := writeAt(t, dir, "src/area.test.ar", "use geometry as g;\nassert(g.area(2, 3) equals 6, \"area\");\n")
+	writeAt(t, dir, "src/name.test.ar", "use label as l;\nassert(l.name(1) equals 1, \"name\");\n")
+
+	files, size, err := TestFiles("", 0)
+	if err != nil {
+		t.Fatalf("TestFiles: %v", err)
+	}
+	var out bytes.Buffer
+	s := newSession(t, sessionOpts{tapeSize: size, stdout: &out, asserts: true, cover: true})
+	history := NewCoverageHistory()
+
+	first, err := s.Test(t.Context(), files)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if got := len(history.Record(first, files).Files()); got != 2 {
+		t.Fatalf("the first run covered %d modules, want both", got)
+	}
+
+	// Only the test naming geometry runs again; label is still covered, once.
+	affected := s.AffectedTests(files, []string{geometry})
+	if !slices.Equal(affected, []string{area}) {
+		t.Fatalf("a change to geometry affects %v, want only the test naming it", affected)
+	}
+	out.Reset()
+	again, err := s.TestWatched(t.Context(), affected, files, history)
+	if err != nil {
+		t.Fatalf("TestWatched: %v", err)
+	}
+	covered := again.Coverage.Files()
+	if len(covered) != 2 {
+		t.Fatalf("a narrow run covered %d modules, want both: %+v", len(covered), covered)
+	}
+	for _, file := range covered {
+		if file.Lines[1] != 1 {
+			t.Errorf("%s line 1 ran %d times, want once — neither lost nor counted twice", file.Path, file.Lines[1])
+		}
+	}
+
+	// What the terminal says is the same table the profile is written from.
+	if !strings.Contains(out.String(), "coverage: 100.0% of 2 lines") {
+		t.Errorf("the summary does not cover both modules:\n%s", out.String())
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/guiferpa/aurora/builder/evm"
 )
 
 const (
@@ -32,6 +34,30 @@ type DeployInput struct {
 	Privkey       string // private key in hex (no 0x prefix)
 	MinTipGwei    int    // min priority fee in Gwei (0 = use default)
 	MinMaxFeeGwei int    // min max fee per gas in Gwei (0 = use default)
+	// Args are what the constructor feeds, appended to the bytecode as a uint256 each.
+	Args []string
+	// ABI is what "aurora build" wrote beside the binary, read for how many arguments the
+	// constructor takes.
+	ABI []evm.ABIFunction
+}
+
+// EncodeDeploy appends the constructor's arguments to the bytecode, which is where a
+// deployment carries them and where the constructor reads them from.
+//
+// The count is checked against the ABI for the reason a call's is: a constructor handed too
+// few reads zeros where a value was meant to be, and that is only noticed once the contract is
+// on a chain and cannot be deployed again at the same address.
+func EncodeDeploy(bytecode []byte, functions []evm.ABIFunction, args []string) ([]byte, error) {
+	takes := 0
+	for _, fn := range functions {
+		if fn.Type == "constructor" {
+			takes = len(fn.Inputs)
+		}
+	}
+	if len(args) != takes {
+		return nil, fmt.Errorf("the constructor takes %d arguments, %d were given", takes, len(args))
+	}
+	return append(append(make([]byte, 0, len(bytecode)+32*len(args)), bytecode...), ParseArgs(args)...), nil
 }
 
 // decodeBytecode reads raw bytes from the file. If content starts with "0x" or "0X", it is decoded as hex; otherwise it is used as raw bytecode.
@@ -67,6 +93,10 @@ func Deploy(ctx context.Context, in DeployInput) (address string, deployTxHash s
 	if len(bs) < MIN_BYTECODE_LEN {
 		return "", "", time.Time{}, fmt.Errorf("bytecode too short (%d bytes); need at least %d", len(bs), MIN_BYTECODE_LEN)
 	}
+	bs, err = EncodeDeploy(bs, in.ABI, in.Args)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
 
 	privateKey, err := crypto.HexToECDSA(strings.TrimSpace(in.Privkey))
 	if err != nil {
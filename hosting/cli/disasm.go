@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/guiferpa/aurora/builder/evm"
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// What a binary says, for "aurora disasm".
+//
+// Bytes alone say what runs and not why. A selector is four bytes of a hash, and a body is a
+// run of pushes and stores with nothing on it to say which scope it was — so a listing is only
+// as readable as what it knows about the program. The ABI beside a binary knows the names;
+// the source knows the names and the lines they were written on.
+
+// A Symbol is what a listing knows about one part of a binary: what it is called and where it
+// was written. A part nothing is known about has none.
+type Symbol struct {
+	Name     string
+	Filename string
+	Line     int
+}
+
+func (s Symbol) String() string {
+	where := ""
+	if s.Filename != "" {
+		where = displayPath(s.Filename)
+		if s.Line > 0 {
+			where = fmt.Sprintf("%s:%d", where, s.Line)
+		}
+	}
+	switch {
+	case s.Name == "":
+		return where
+	case where == "":
+		return s.Name
+	}
+	return s.Name + " — " + where
+}
+
+// Symbols is what a listing knows about a binary beyond its bytes.
+type Symbols struct {
+	Constructor Symbol
+	// Scopes are keyed by the selector, in hex, since that is all the bytecode has.
+	Scopes map[string]Symbol
+}
+
+// SymbolsOfABI answers what an ABI says: the signature of every selector, and nothing about
+// where any of it was written.
+func SymbolsOfABI(functions []evm.ABIFunction) Symbols {
+	symbols := Symbols{Scopes: make(map[string]Symbol)}
+	for _, fn := range functions {
+		if fn.Type != "function" {
+			continue
+		}
+		symbols.Scopes[hex.EncodeToString(fn.Selector())] = Symbol{Name: fn.Signature()}
+	}
+	return symbols
+}
+
+// Symbols compiles the source and answers what it says about the binary it builds: the
+// signature of every scope, and the file and line it was bound on. The constructor is the top
+// of the file named, from the first line of it that runs.
+func (s *Session) Symbols(source string) (Symbols, error) {
+	program, err := s.compile(source)
+	if err != nil {
+		return Symbols{}, err
+	}
+
+	symbols := Symbols{Scopes: make(map[string]Symbol)}
+	filenameAt := func(at int) string {
+		if each, ok := program.RangeOf(uint64(at)); ok {
+			return each.Filename
+		}
+		return source
+	}
+	for _, binding := range evm.Bindings(program.Instructions, modulesOf(program, false)) {
+		symbols.Scopes[hex.EncodeToString(evm.Selector(binding.Signature))] = Symbol{
+			Name:     binding.Signature,
+			Filename: filenameAt(binding.At),
+			Line:     program.Instructions[binding.At].GetOrigin().Line,
+		}
+	}
+
+	symbols.Constructor = Symbol{Filename: source}
+	if len(program.Ranges) > 0 {
+		entry := program.Ranges[len(program.Ranges)-1]
+		symbols.Constructor = Symbol{Filename: entry.Filename, Line: firstLineOfTheTop(program.Instructions[entry.From:entry.To])}
+	}
+	return symbols, nil
+}
+
+// firstLineOfTheTop answers the first line of a module the constructor runs: the first that is
+// not a deferred scope, which the runtime holds instead.
+func firstLineOfTheTop(insts []ir.Instruction) int {
+	for i := 0; i < len(insts); i++ {
+		if insts[i].GetOpCode() == ir.OpDefer {
+			// The body, and the binding after it.
+			i += 1 + int(byteutil.ToUint64(insts[i].GetRight().Bytes()))
+			continue
+		}
+		if origin := insts[i].GetOrigin(); origin.Known() {
+			return origin.Line
+		}
+	}
+	return 0
+}
+
+// DisasmInput is what a listing is written from.
+type DisasmInput struct {
+	// Bytecode is what the binary holds: raw bytes, or hex after 0x.
+	Bytecode []byte
+	Symbols  Symbols
+	// SourceMap, when there is one, puts the line each instruction was written on beside it.
+	SourceMap *evm.SourceMap
+}
+
+// Disasm writes the listing of a binary: every instruction with its offset and what it pushes,
+// split into the parts the builder wrote, each headed by what is known about it.
+//
+// Offsets are counted the way the code they are in counts them: from the start of the binary
+// in the constructor, from the start of the runtime after it — so a jump reads as the offset
+// it lands on.
+func Disasm(w io.Writer, in DisasmInput) error {
+	code, err := decodeBytecode(in.Bytecode)
+	if err != nil {
+		return err
+	}
+
+	bold := color.New(color.Bold).SprintFunc()
+	dim := color.New(color.Faint).SprintFunc()
+
+	for i, section := range evm.Sections(code) {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\n", bold(section.Kind), dim(headingOf(section, in.Symbols)))
+
+		if section.Kind == evm.SectionData {
+			for at := 0; at < len(section.Data); at += 32 {
+				_, _ = fmt.Fprintf(w, "  %04x  %x\n", section.At+at, section.Data[at:min(at+32, len(section.Data))])
+			}
+			continue
+		}
+		mappings := mappingsOf(in.SourceMap, section.Kind)
+		for _, op := range section.Ops {
+			line := fmt.Sprintf("  %04x  %s", op.Offset, op.Mnemonic())
+			if op.Data != nil {
+				line += " 0x" + hex.EncodeToString(op.Data)
+			}
+			notes := make([]string, 0, 2)
+			if op.Code == evm.OpPush4 && section.Kind == evm.SectionDispatcher {
+				if symbol, ok := in.Symbols.Scopes[hex.EncodeToString(op.Data)]; ok {
+					notes = append(notes, symbol.Name)
+				}
+			}
+			// A range is noted where it begins; the instructions after it are the same line.
+			if mapping, ok := mappings.At(op.Offset); ok && mapping.Offset == op.Offset {
+				notes = append(notes, fmt.Sprintf("%s:%d:%d", displayPath(mapping.File), mapping.Line, mapping.Column))
+			}
+			if len(notes) > 0 {
+				line = fmt.Sprintf("%-28s %s", line, dim("; "+strings.Join(notes, " ")))
+			}
+			_, _ = fmt.Fprintln(w, line)
+		}
+	}
+	return nil
+}
+
+// mappingsOf answers the mappings of the code a section is in.
+func mappingsOf(sourceMap *evm.SourceMap, kind string) evm.Mappings {
+	switch {
+	case sourceMap == nil || kind == evm.SectionData:
+		return nil
+	case kind == evm.SectionConstructor:
+		return sourceMap.Constructor
+	}
+	return sourceMap.Runtime
+}
+
+// LoadSourceMap reads the source map "aurora build" wrote. Its files are written relative to
+// where the map is, and are read back as paths from where the command runs.
+func LoadSourceMap(path string) (evm.SourceMap, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return evm.SourceMap{}, fmt.Errorf("reading the source map (run 'aurora build' first): %w", err)
+	}
+	var sourceMap evm.SourceMap
+	if err := json.Unmarshal(contents, &sourceMap); err != nil {
+		return evm.SourceMap{}, fmt.Errorf("reading the source map %s: %w", path, err)
+	}
+	for _, mappings := range []evm.Mappings{sourceMap.Constructor, sourceMap.Runtime} {
+		for i := range mappings {
+			if mappings[i].File != "" && !filepath.IsAbs(mappings[i].File) {
+				mappings[i].File = filepath.Join(filepath.Dir(path), filepath.FromSlash(mappings[i].File))
+			}
+		}
+	}
+	return sourceMap, nil
+}
+
+// headingOf says what a section is and where it sits in the binary.
+func headingOf(section evm.Section, symbols Symbols) string {
+	size := len(section.Data)
+	for _, op := range section.Ops {
+		size += 1 + len(op.Data)
+	}
+	where := fmt.Sprintf("(%s at %#04x)", plural(size, "byte"), section.At)
+
+	switch section.Kind {
+	case evm.SectionConstructor:
+		if top := symbols.Constructor.String(); top != "" {
+			return "the top of " + top + " " + where
+		}
+	case evm.SectionScope:
+		selector := hex.EncodeToString(section.Selector)
+		if symbol, ok := symbols.Scopes[selector]; ok {
+			return symbol.String() + " " + where
+		}
+		return "0x" + selector + " " + where
+	case evm.SectionData:
+		return "what a deployment appended " + where
+	}
+	return where
+}
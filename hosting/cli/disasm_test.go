@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const disasmAR = `ident rate = feed(0);
+
+ident scale = defer { feed(0) * rate; };
+`
+
+// builtForDisasm builds the program and answers where its source and binary are.
+func builtForDisasm(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.ar")
+	if err := os.WriteFile(source, []byte(disasmAR), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "bin", "main")
+	if _, err := newSession(t, sessionOpts{}).Build(context.Background(), source, binary); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	return source, binary
+}
+
+// The ABI beside a binary names every selector the dispatcher checks and every body.
+func TestDisasmNamesSelectorsFromTheABI(t *testing.T) {
+	_, binary := builtForDisasm(t)
+	bytecode, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abi, err := LoadABI(ABIPath(binary))
+	if err != nil {
+		t.Fatalf("LoadABI: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Disasm(&out, DisasmInput{Bytecode: bytecode, Symbols: SymbolsOfABI(abi)}); err != nil {
+		t.Fatalf("Disasm: %v", err)
+	}
+	listing := out.String()
+	for _, want := range []string{"constructor", "dispatcher", "; scale(uint256)", "scope scale(uint256)", "SSTORE", "SLOAD"} {
+		if !strings.Contains(listing, want) {
+			t.Errorf("the listing does not say %q:\n%s", want, listing)
+		}
+	}
+}
+
+// The source says where each scope was bound, and where the top the constructor runs begins.
+func TestDisasmAnnotatesWithTheSource(t *testing.T) {
+	source, binary := builtForDisasm(t)
+	bytecode, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols, err := newSession(t, sessionOpts{}).Symbols(source)
+	if err != nil {
+		t.Fatalf("Symbols: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Disasm(&out, DisasmInput{Bytecode: bytecode, Symbols: symbols}); err != nil {
+		t.Fatalf("Disasm: %v", err)
+	}
+	listing := out.String()
+	for _, want := range []string{"constructor the top of " + displayPath(source) + ":1", "scope scale(uint256) — " + displayPath(source) + ":3"} {
+		if !strings.Contains(listing, want) {
+			t.Errorf("the listing does not say %q:\n%s", want, listing)
+		}
+	}
+}
+
+// A binary written as hex lists the same as the bytes, and knowing nothing about it still
+// lists it — a body is then headed by its selector.
+func TestDisasmReadsHex(t *testing.T) {
+	_, binary := builtForDisasm(t)
+	bytecode, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw, hexed bytes.Buffer
+	if err := Disasm(&raw, DisasmInput{Bytecode: bytecode}); err != nil {
+		t.Fatalf("Disasm: %v", err)
+	}
+	if err := Disasm(&hexed, DisasmInput{Bytecode: []byte("0x" + hex.EncodeToString(bytecode) + "\n")}); err != nil {
+		t.Fatalf("Disasm: %v", err)
+	}
+	if raw.String() != hexed.String() {
+		t.Errorf("hex listed differently:\n%s\nwant:\n%s", hexed.String(), raw.String())
+	}
+	if !strings.Contains(raw.String(), "scope 0x2bec1547") {
+		t.Errorf("an unnamed body is not headed by its selector:\n%s", raw.String())
+	}
+}
+
+// With the source map beside it, an instruction is noted with the line it was written on.
+func TestDisasmNotesTheLineOfAnInstruction(t *testing.T) {
+	source, binary := builtForDisasm(t)
+	bytecode, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceMap, err := LoadSourceMap(SourceMapPath(binary))
+	if err != nil {
+		t.Fatalf("LoadSourceMap: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Disasm(&out, DisasmInput{Bytecode: bytecode, SourceMap: &sourceMap}); err != nil {
+		t.Fatalf("Disasm: %v", err)
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.Contains(line, "  MUL") {
+			if want := "; " + displayPath(source) + ":3:"; !strings.Contains(line, want) {
+				t.Errorf("the MUL is noted %q, want %s", line, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no MUL in the listing:\n%s", out.String())
+}
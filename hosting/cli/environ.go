@@ -18,6 +18,20 @@ func LoadEnviron(profileName string) (*Environ, error) {
 	if err != nil {
 		return nil, err
 	}
+	return loadEnvironAt(root, profileName)
+}
+
+// LoadEnvironFrom is LoadEnviron with the project looked for from dir rather than the working
+// directory: a host that serves more than one project cannot move the process to each of them.
+func LoadEnvironFrom(dir, profileName string) (*Environ, error) {
+	root, err := manifest.FindProjectRootFrom(dir)
+	if err != nil {
+		return nil, err
+	}
+	return loadEnvironAt(root, profileName)
+}
+
+func loadEnvironAt(root, profileName string) (*Environ, error) {
 	m, err := manifest.Load(root)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -8,7 +9,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/holiman/uint256"
+
+	"github.com/guiferpa/aurora/builder/evm"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/wire/ir"
 )
 
 // Aurora exists to let a call be simulated off the chain, which only means something if the
@@ -26,56 +35,162 @@ import (
 func onChain(t *testing.T, source, function string, args []string, tapeSize int) []byte {
 	t.Helper()
 
+	return onChainWith(t, source, tapeSize, nil, func(functions []evm.ABIFunction) []byte {
+		// Through the ABI the build wrote, as "aurora call" encodes, so what is proven here is
+		// the path someone actually takes rather than one built for the test.
+		calldata, err := EncodeCall(functions, function, args)
+		if err != nil {
+			t.Fatalf("encoding the call: %v", err)
+		}
+		return calldata
+	})
+}
+
+// onChainWith is onChain with the calldata written by the test: a caller that is not "aurora
+// call" can send whatever it likes, and what the contract does with that is a question too.
+// The constructor is handed its arguments the way "aurora deploy --args" hands them.
+func onChainWith(t *testing.T, source string, tapeSize int, constructor []string, calldata func([]evm.ABIFunction) []byte) []byte {
+	t.Helper()
+
+	returned, err := callOnChain(t, source, sessionOpts{tapeSize: tapeSize}, constructor, calldata)
+	if err != nil {
+		t.Fatalf("calling: %v", err)
+	}
+	return returned
+}
+
+// callOnChain is onChainWith without judging the call: a call that reverts answers the error
+// and whatever it reverted with. The build is made with the options given, which is how the
+// same call is made of a binary built with -O.
+func callOnChain(t *testing.T, source string, build sessionOpts, constructor []string, calldata func([]evm.ABIFunction) []byte) ([]byte, error) {
+	t.Helper()
+
+	return callOnChainAt(t, writeAt(t, t.TempDir(), "contract.ar", source), build, constructor, calldata)
+}
+
+// callOnChainAt is callOnChain of a file already written, beside whatever modules it uses.
+func callOnChainAt(t *testing.T, path string, build sessionOpts, constructor []string, calldata func([]evm.ABIFunction) []byte) ([]byte, error) {
+	t.Helper()
+
+	cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
+	address, functions := deployAt(t, path, build, constructor, cfg)
+
+	if build.chain != nil {
+		inCall(cfg, build.chain)
+	}
+	returned, _, err := runtime.Call(address, calldata(functions), cfg)
+	return returned, err
+}
+
+// deployAt builds a file and deploys it into the chain the config holds, answering where it
+// landed and what it answers to. A chain that already holds a contract keeps it, which is how
+// one contract is put beside another for it to call.
+func deployAt(t *testing.T, path string, build sessionOpts, constructor []string, cfg *runtime.Config) (common.Address, []evm.ABIFunction) {
+	t.Helper()
+
 	// Through the command, and then read back from where it landed: what is installed below
 	// is the binary a user gets, not one assembled for the test.
-	dir := t.TempDir()
-	path := writeAt(t, dir, "contract.ar", source)
-	binary := filepath.Join(dir, "contract.bin")
+	binary := strings.TrimSuffix(path, filepath.Ext(path)) + ".bin"
 
-	if _, err := newSession(t, sessionOpts{tapeSize: tapeSize}).Build(t.Context(), path, binary); err != nil {
+	if _, err := newSession(t, build).Build(t.Context(), path, binary); err != nil {
 		t.Fatalf("building: %v", err)
 	}
 	bytecode, err := os.ReadFile(binary)
 	if err != nil {
 		t.Fatalf("reading the binary: %v", err)
 	}
+	functions, err := LoadABI(ABIPath(binary))
+	if err != nil {
+		t.Fatalf("reading the ABI: %v", err)
+	}
 
-	cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
-	_, address, _, err := runtime.Create(bytecode, cfg)
+	deployed, err := EncodeDeploy(bytecode, functions, constructor)
+	if err != nil {
+		t.Fatalf("encoding the deployment: %v", err)
+	}
+
+	_, address, _, err := runtime.Create(deployed, cfg)
 	if err != nil {
 		t.Fatalf("deploying: %v", err)
 	}
+	return address, functions
+}
 
-	// The same two encoders "aurora call" uses, so what is proven here is the path someone
-	// actually takes rather than one built for the test.
-	calldata := append(EncodeSelector(function), ParseArgs(args)...)
+// inCall makes the call the one the context describes, so the chain is asked in the call the
+// evaluator was told about. Only what the context says is changed: what it leaves out the
+// evaluator reads as zero, and the chain as whatever the runtime made of it, which a test that
+// asks for it has to say.
+func inCall(cfg *runtime.Config, chain evaluator.Context) {
+	if caller := chain.Caller(); caller != nil {
+		cfg.Origin = common.BytesToAddress(caller)
+	}
+	if value := chain.CallValue(); value != nil {
+		cfg.Value = new(big.Int).SetBytes(value)
+		// Value is moved out of the caller's balance, so the caller has to have it.
+		cfg.State.AddBalance(cfg.Origin, uint256.MustFromBig(cfg.Value), tracing.BalanceChangeUnspecified)
+	}
+	if timestamp := chain.Timestamp(); timestamp != nil {
+		cfg.Time = new(big.Int).SetBytes(timestamp).Uint64()
+	}
+	if number := chain.BlockNumber(); number != nil {
+		cfg.BlockNumber = new(big.Int).SetBytes(number)
+	}
+	if id := chain.ChainID(); id != nil {
+		// The runtime's chain config is shared by every EVM it makes, so the call gets a copy.
+		config := *cfg.ChainConfig
+		config.ChainID = new(big.Int).SetBytes(id)
+		cfg.ChainConfig = &config
+	}
+}
 
-	returned, _, err := runtime.Call(address, calldata, cfg)
-	if err != nil {
-		t.Fatalf("calling %s: %v", function, err)
+// revertsOnChain calls a scope that is expected to revert, and answers the reason it reverted
+// with.
+func revertsOnChain(t *testing.T, source, function string, args []string, tapeSize int) string {
+	t.Helper()
+
+	returned, err := callOnChain(t, source, sessionOpts{tapeSize: tapeSize}, nil, func(functions []evm.ABIFunction) []byte {
+		calldata, err := EncodeCall(functions, function, args)
+		if err != nil {
+			t.Fatalf("encoding the call: %v", err)
+		}
+		return calldata
+	})
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		t.Fatalf("the call did not revert: answered %x, %v", returned, err)
 	}
-	return returned
+	reason, ok := evm.ReasonOf(returned)
+	if !ok {
+		t.Fatalf("the call reverted with no reason: %x", returned)
+	}
+	return reason
 }
 
 // offChain answers what the evaluator makes of the same call, with the arguments arriving the
 // same way they arrive on chain: encoded by ParseArgs and narrowed to a tape on the way in.
 //
-// The call still has to be written into the source, because there is no way to ask the
-// evaluator for one scope by name — the closest thing, "aurora call", only speaks to a
-// network. That is the gap this harness makes visible. What it must not do is write the
-// arguments in as literals: a literal is checked against the tape when it is compiled, so
+// The call is written into the source and printed, the way a user would write it, which keeps
+// the probe honest about what "aurora run" prints rather than what CallLocal hands back. What
+// it must not do is write the arguments in as literals: a literal is checked against the tape when it is compiled, so
 // "add(300, 0)" on a one-byte tape is refused at compile time while the same 300 arriving as
 // calldata is simply narrowed.
 func offChain(t *testing.T, source, function string, args []string, tapeSize int) string {
 	t.Helper()
 
+	return offChainIn(t, t.TempDir(), source, function, args, tapeSize)
+}
+
+// offChainIn is offChain with the program written into a directory, beside whatever modules it
+// uses.
+func offChainIn(t *testing.T, dir, source, function string, args []string, tapeSize int) string {
+	t.Helper()
+
 	feeds := make([]string, 0, len(args))
 	for i := range args {
 		feeds = append(feeds, fmt.Sprintf("feed(%d)", i))
 	}
 	probe := fmt.Sprintf("printd %s(%s);", function, strings.Join(feeds, ", "))
 
-	path := writeAt(t, t.TempDir(), "program.ar", source+"\n"+probe+"\n")
+	path := writeAt(t, dir, "program.ar", source+"\n"+probe+"\n")
 	out := &strings.Builder{}
 	session := newSession(t, sessionOpts{tapeSize: tapeSize, stdout: out, args: args})
 	if err := session.Run(t.Context(), path); err != nil {
@@ -84,15 +199,64 @@ func offChain(t *testing.T, source, function string, args []string, tapeSize int
 	return strings.TrimSpace(out.String())
 }
 
+// failsOffChain answers the error the evaluator stops the same call with.
+func failsOffChain(t *testing.T, source, function string, args []string, tapeSize int) string {
+	t.Helper()
+
+	feeds := make([]string, 0, len(args))
+	for i := range args {
+		feeds = append(feeds, fmt.Sprintf("feed(%d)", i))
+	}
+	probe := fmt.Sprintf("printd %s(%s);", function, strings.Join(feeds, ", "))
+
+	path := writeAt(t, t.TempDir(), "program.ar", source+"\n"+probe+"\n")
+	session := newSession(t, sessionOpts{tapeSize: tapeSize, stdout: &strings.Builder{}, args: args})
+	err := session.Run(t.Context(), path)
+	if err == nil {
+		t.Fatal("the evaluator did not fail")
+	}
+	return err.Error()
+}
+
 // agree runs the same call through both backends and reports when they answer differently.
+//
+// The chain is asked twice, of the binary built as it is and of the one built with -O: the
+// optimizer is only allowed to change what a call costs, and every program the harness agrees
+// on is a program it is held to.
 func agree(t *testing.T, source, function string, args []string, tapeSize int) {
 	t.Helper()
 
-	returned := onChain(t, source, function, args, tapeSize)
-	want := offChain(t, source, function, args, tapeSize)
+	agreeBeside(t, nil, source, function, args, tapeSize)
+}
 
-	if got := decimalOf(returned); got != want {
-		t.Errorf("the chain answered %s and the evaluator %s", got, want)
+// agreeBeside is agree of a program that uses modules, written beside it by file name.
+func agreeBeside(t *testing.T, modules map[string]string, source, function string, args []string, tapeSize int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if len(modules) > 0 {
+		// A module is found under the src of the project somebody is standing in.
+		dir = filepath.Join(project(t), "src")
+	}
+	for name, content := range modules {
+		writeAt(t, dir, name, content)
+	}
+	want := offChainIn(t, dir, source, function, args, tapeSize)
+	path := writeAt(t, dir, "contract.ar", source)
+	for _, build := range []sessionOpts{{tapeSize: tapeSize}, {tapeSize: tapeSize, optimize: true}} {
+		returned, err := callOnChainAt(t, path, build, nil, func(functions []evm.ABIFunction) []byte {
+			calldata, err := EncodeCall(functions, function, args)
+			if err != nil {
+				t.Fatalf("encoding the call: %v", err)
+			}
+			return calldata
+		})
+		if err != nil {
+			t.Fatalf("calling (optimized: %t): %v", build.optimize, err)
+		}
+		if got := decimalOf(returned); got != want {
+			t.Errorf("the chain answered %s and the evaluator %s (optimized: %t)", got, want, build.optimize)
+		}
 	}
 }
 
@@ -136,11 +300,15 @@ ident multiply = defer { feed(0) * feed(1); };`
 }
 
 // A name the contract does not have reaches the no-match STOP, which answers with nothing —
-// it does not run the first scope it finds.
+// it does not run the first scope it finds. "aurora call" refuses the name before it leaves,
+// so the calldata is written here the way any other caller could write it.
 func TestAnUnknownNameAnswersWithNothing(t *testing.T) {
 	const source = `ident add = defer { feed(0) + feed(1); };`
 
-	if returned := onChain(t, source, "subtract", []string{"1", "2"}, 0); len(returned) != 0 {
+	returned := onChainWith(t, source, 0, nil, func([]evm.ABIFunction) []byte {
+		return append(EncodeSelector("subtract(uint256,uint256)"), ParseArgs([]string{"1", "2"})...)
+	})
+	if len(returned) != 0 {
 		t.Errorf("a name that is not there answered %v", returned)
 	}
 }
@@ -213,10 +381,19 @@ func TestArithmeticWrapsAtTheTapeWidthOnBothSides(t *testing.T) {
 //
 // Nothing in the IR says which of the two is right. OpGetFeed carries an index and nothing
 // else, so the meaning lives in whichever consumer implemented it first.
+//
+// "aurora call" no longer sends a short call — the signature says how many arguments there
+// are — but a caller that is not it can, so the calldata is written here.
 func TestReadingPastTheValuesAppliedAnswersTheSameOnChainAndOff(t *testing.T) {
 	const source = `ident sum = defer { feed(0) + feed(1); };`
 
-	agree(t, source, "sum", []string{"5"}, 0)
+	returned := onChainWith(t, source, 0, nil, func([]evm.ABIFunction) []byte {
+		return append(EncodeSelector("sum(uint256,uint256)"), ParseArgs([]string{"5"})...)
+	})
+	want := offChain(t, source, "sum", []string{"5"}, 0)
+	if got := decimalOf(returned); got != want {
+		t.Errorf("the chain answered %s and the evaluator %s", got, want)
+	}
 }
 
 // A name bound inside a scope used to compile to an MSTORE with nothing under it: the lowering
@@ -227,15 +404,16 @@ func TestALocalInsideAScopeAnswersTheSameOnChainAndOff(t *testing.T) {
 	cases := []struct {
 		name   string
 		source string
+		args   []string
 	}{
-		{name: "read once", source: `ident sum = defer { ident x = feed(0); x + feed(1); };`},
-		{name: "read twice", source: `ident sum = defer { ident x = feed(0); x + x; };`},
-		{name: "two of them, and one reads the other", source: `ident sum = defer { ident x = feed(0); ident y = x + feed(1); y + x; };`},
+		{name: "read once", source: `ident sum = defer { ident x = feed(0); x + feed(1); };`, args: []string{"3", "4"}},
+		{name: "read twice", source: `ident sum = defer { ident x = feed(0); x + x; };`, args: []string{"3"}},
+		{name: "two of them, and one reads the other", source: `ident sum = defer { ident x = feed(0); ident y = x + feed(1); y + x; };`, args: []string{"3", "4"}},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			agree(t, tc.source, "sum", []string{"3", "4"}, 0)
+			agree(t, tc.source, "sum", tc.args, 0)
 		})
 	}
 }
@@ -256,7 +434,7 @@ func TestAValueWrittenDownAnswersTheSameOnChainAndOff(t *testing.T) {
 		{name: "written down on the left of a subtraction", source: `ident f = defer { 10 - feed(0); };`, args: []string{"4"}},
 		{name: "written down on the right of a division", source: `ident f = defer { feed(0) / 2; };`, args: []string{"9"}},
 		{name: "written down on the left of a division", source: `ident f = defer { 100 / feed(0); };`, args: []string{"4"}},
-		{name: "both written down", source: `ident f = defer { 7 - 3; };`, args: []string{"0"}},
+		{name: "both written down", source: `ident f = defer { 7 - 3; };`},
 		{name: "written down in a sum", source: `ident f = defer { feed(0) + 5; };`, args: []string{"6"}},
 		{name: "bound to a name", source: `ident f = defer { ident x = 3; feed(0) - x; };`, args: []string{"10"}},
 	}
@@ -398,3 +576,512 @@ func TestComparisonsFollowTheTapeWidth(t *testing.T) {
 		})
 	}
 }
+
+// The top of a program runs once, at deployment, and a scope reads what it bound on every
+// call after. Off the chain the same top runs before the call, fed the same values — which is
+// all a constructor is.
+func TestTheTopOfTheProgramIsTheConstructor(t *testing.T) {
+	cases := []struct {
+		name        string
+		source      string
+		constructor []string
+	}{
+		{
+			name:        "a value written down",
+			source:      "ident offset = 2;\nident scale = defer { feed(0) + offset; };",
+			constructor: nil,
+		},
+		{
+			name:        "a value the deployment hands in",
+			source:      "ident rate = feed(0);\nident offset = 2;\nident scale = defer { feed(0) * rate + offset; };",
+			constructor: []string{"3"},
+		},
+		{
+			// A branch at the top jumps inside the constructor, whose addresses are counted
+			// from the start of the code deployed rather than from the runtime.
+			name:        "a branch at the top",
+			source:      "ident rate = if feed(1) equals 0 { feed(0); } else { 100; };\nident scale = defer { feed(0) * rate; };",
+			constructor: []string{"4", "0"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			returned := onChainWith(t, tc.source, 0, tc.constructor, func(functions []evm.ABIFunction) []byte {
+				calldata, err := EncodeCall(functions, "scale", []string{"7"})
+				if err != nil {
+					t.Fatalf("encoding the call: %v", err)
+				}
+				return calldata
+			})
+
+			path := writeAt(t, t.TempDir(), "program.ar", tc.source+"\nprintd scale(7);\n")
+			out := &strings.Builder{}
+			if err := newSession(t, sessionOpts{stdout: out, args: tc.constructor}).Run(t.Context(), path); err != nil {
+				t.Fatalf("running: %v", err)
+			}
+
+			if got, want := decimalOf(returned), strings.TrimSpace(out.String()); got != want {
+				t.Errorf("the chain answered %s and the evaluator %s", got, want)
+			}
+		})
+	}
+}
+
+// A deployment handed a different number of values than the top of the program feeds is
+// refused before it is sent.
+func TestADeploymentIsHandedWhatTheConstructorTakes(t *testing.T) {
+	functions := []evm.ABIFunction{{Type: "constructor", Inputs: make([]evm.ABIParameter, 2)}}
+
+	deployed, err := EncodeDeploy([]byte{0x00}, functions, []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("EncodeDeploy: %v", err)
+	}
+	if len(deployed) != 1+2*32 {
+		t.Errorf("deployed %d bytes, want the code and two words", len(deployed))
+	}
+	if _, err := EncodeDeploy([]byte{0x00}, functions, []string{"1"}); err == nil {
+		t.Error("a deployment short of an argument was not refused")
+	}
+	if _, err := EncodeDeploy([]byte{0x00}, nil, []string{"1"}); err == nil {
+		t.Error("an argument for a constructor that takes none was not refused")
+	}
+}
+
+// A division by zero fails on both sides, with the same words: the evaluator stops with them and
+// the chain reverts with them as the reason, where it used to answer zero.
+func TestADivisionByZeroFailsTheSameOnChainAndOff(t *testing.T) {
+	source := `ident ratio = defer { feed(0) / feed(1); };`
+
+	agree(t, source, "ratio", []string{"12", "4"}, 8)
+
+	reason := revertsOnChain(t, source, "ratio", []string{"12", "0"}, 8)
+	failure := failsOffChain(t, source, "ratio", []string{"12", "0"}, 8)
+	if !strings.Contains(failure, reason) {
+		t.Errorf("the chain reverted with %q and the evaluator failed with %q", reason, failure)
+	}
+}
+
+// A guard that does not hold stops both sides with its message, and one that holds lets the
+// call answer as if it were not there.
+func TestAGuardFailsTheSameOnChainAndOff(t *testing.T) {
+	source := `ident withdraw = defer {
+  require(feed(0) bigger feed(1), "not enough to withdraw");
+  feed(0) - feed(1);
+};`
+
+	agree(t, source, "withdraw", []string{"10", "3"}, 8)
+
+	reason := revertsOnChain(t, source, "withdraw", []string{"3", "10"}, 8)
+	if reason != "not enough to withdraw" {
+		t.Errorf("the chain reverted with %q", reason)
+	}
+	if failure := failsOffChain(t, source, "withdraw", []string{"3", "10"}, 8); !strings.Contains(failure, reason) {
+		t.Errorf("the chain reverted with %q and the evaluator failed with %q", reason, failure)
+	}
+}
+
+// What -O rewrites, each on its own, at the widths where it matters: a mask is only left out
+// below a word, and a folded number has to wrap where the one worked out at run time wraps.
+// agree builds every case both ways, so what is checked is that the optimized binary answers
+// what the evaluator answers and the plain one does.
+func TestAnOptimizedBuildAnswersTheSameOnChainAndOff(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		args   []string
+	}{
+		{name: "numbers written down", source: `ident f = defer { 2 * 3 + 1; };`},
+		{name: "numbers written down that leave the width", source: `ident f = defer { 200 + 100 - 1; };`},
+		{name: "a comparison of numbers written down", source: `ident f = defer { if 3 bigger 2 { feed(0); } else { 7; }; };`, args: []string{"9"}},
+		{name: "a power of numbers written down", source: `ident f = defer { 3 ^ 7; };`},
+		{name: "a number and a feed", source: `ident f = defer { (2 + 3) * feed(0); };`, args: []string{"60"}},
+		{name: "arithmetic over arithmetic", source: `ident f = defer { (feed(0) + feed(1)) * feed(2) - feed(3); };`, args: []string{"200", "100", "3", "5"}},
+		{name: "a division after a sum that left the width", source: `ident f = defer { (feed(0) + feed(1)) / feed(2); };`, args: []string{"255", "1", "2"}},
+		{name: "a name read right after it is bound", source: `ident f = defer { ident a = feed(0) + 1; a * 2; };`, args: []string{"100"}},
+		{name: "a name bound from a branch", source: `ident f = defer { ident a = if feed(0) { 2 + 3; } else { 3; }; a * 2; };`, args: []string{"1"}},
+		{name: "a value nobody reads", source: `ident f = defer { 1; feed(0); feed(1) + 1; };`, args: []string{"4", "5"}},
+		{name: "a value nobody reads, in an arm", source: `ident f = defer { if feed(0) { 1; 42; } else { 7; }; };`, args: []string{"1"}},
+		{name: "a global worked out at deployment", source: "ident base = 2 * 3 + 1;\nident f = defer { base + feed(0); };", args: []string{"5"}},
+	}
+
+	for _, size := range []int{1, 8, 32} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("%s at %d", tc.name, size), func(t *testing.T) {
+				agree(t, tc.source, "f", tc.args, size)
+			})
+		}
+	}
+}
+
+// A scope calls another, and a scope of a module it uses, and the call comes back with the
+// answer. Until now a call wrote nothing on chain at all; a module's scopes were dispatched as
+// though the contract answered to them, and none of them could be reached from the file.
+func TestAScopeCallingAnotherAnswersTheSameOnChainAndOff(t *testing.T) {
+	geometry := map[string]string{"geometry.ar": `ident k = 3 + 4;
+ident square = defer { feed(0) * feed(0); };
+ident area = defer { square(feed(0)) + feed(1); };
+ident shifted = defer { k + feed(0); };`}
+
+	cases := []struct {
+		name     string
+		modules  map[string]string
+		source   string
+		function string
+		args     []string
+		tapeSize int
+	}{
+		{
+			name:     "a scope of the same file",
+			source:   `ident twice = defer { feed(0) * 2; }; ident f = defer { twice(feed(0)) + 1; };`,
+			function: "f", args: []string{"20"},
+		},
+		{
+			// The value the call answers with is taken from the right of a subtraction, which
+			// is the side the lowering has to get right.
+			name:     "the answer on the right of a subtraction",
+			source:   `ident twice = defer { feed(0) * 2; }; ident f = defer { 100 - twice(feed(0)); };`,
+			function: "f", args: []string{"20"},
+		},
+		{
+			name:     "the answer on the left of a subtraction",
+			source:   `ident twice = defer { feed(0) * 2; }; ident f = defer { twice(feed(0)) - feed(1); };`,
+			function: "f", args: []string{"20", "5"},
+		},
+		{
+			name:     "a scope of a module, which calls one of its own",
+			modules:  geometry,
+			source:   `use geometry as g; ident f = defer { g.area(feed(0), 1); };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			// A global of the module lives in a slot of its own, apart from the file's name.
+			name:     "a scope of a module reading what the module bound",
+			modules:  geometry,
+			source:   `use geometry as g; ident k = 1; ident f = defer { g.shifted(feed(0)) + k; };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			name:     "the same scope called twice, and a call inside an argument",
+			modules:  geometry,
+			source:   `use geometry as g; ident f = defer { ident a = g.square(g.square(feed(0))); a + g.square(2); };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			// A call applying fewer values than the scope reads hands it zeros, and what the
+			// previous call left in the frame is not one of them.
+			name:     "a call applying fewer than the scope reads",
+			source:   `ident sum = defer { feed(0) + feed(1); }; ident f = defer { sum(feed(0), 5) + sum(1); };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			// A callee leaves nothing behind but its answer: an expression nobody takes and an
+			// "if" whose value is not used are dropped before it jumps back.
+			name:     "a callee with values nobody takes",
+			source:   `ident g = defer { 1; if feed(0) bigger 2 { 5; } else { 6; }; feed(0) + 1; }; ident f = defer { g(feed(0)) + g(1); };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			name:     "a callee with an if it answers with",
+			source:   `ident g = defer { if feed(0) bigger 2 { feed(0); } else { 6; }; }; ident f = defer { ident x = g(feed(0)); x * g(1); };`,
+			function: "f", args: []string{"3"},
+		},
+		{
+			name:     "a callee that wraps",
+			source:   `ident g = defer { feed(0) + feed(0); }; ident f = defer { g(feed(0)) + 1; };`,
+			function: "f", args: []string{"200"}, tapeSize: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			agreeBeside(t, tc.modules, tc.source, tc.function, tc.args, tc.tapeSize)
+		})
+	}
+}
+
+// A call the bytecode does not make is refused by the build, at every place it is made: the
+// evaluator makes it, so a contract written without it would answer something else than
+// "aurora run" does. A module's scope the file never reaches is not in the contract, and
+// what it calls is nothing the build has to refuse.
+func TestACallTheBytecodeDoesNotMakeIsRefused(t *testing.T) {
+	cases := []struct {
+		name    string
+		modules map[string]string
+		source  string
+		refused []string
+	}{
+		{
+			name:    "a scope calling itself, and a scope calling that one",
+			source:  "ident loop = defer { loop(feed(0)); };\nident g = defer { loop(1) + feed(0); };\n",
+			refused: []string{"line 1, column 22", "line 2, column 19"},
+		},
+		{
+			name:    "a call at the top of the program",
+			source:  "ident sq = defer { feed(0) * feed(0); };\nident k = sq(3);\n",
+			refused: []string{"line 2, column 11", "the constructor makes no calls"},
+		},
+		{
+			name:    "a module's scope that calls itself, which the file never calls",
+			modules: map[string]string{"loops.ar": "ident loop = defer { loop(feed(0)); };\nident one = defer { 1; };\n"},
+			source:  "use loops as l;\nident f = defer { l.one() + feed(0); };\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if len(tc.modules) > 0 {
+				dir = filepath.Join(project(t), "src")
+			}
+			for name, module := range tc.modules {
+				writeAt(t, dir, name, module)
+			}
+			source := writeAt(t, dir, "contract.ar", tc.source)
+			_, err := newSession(t, sessionOpts{}).Build(t.Context(), source, filepath.Join(dir, "contract.bin"))
+			if len(tc.refused) == 0 {
+				if err != nil {
+					t.Errorf("Build: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("the build wrote a contract that makes fewer calls than the program")
+			}
+			for _, want := range tc.refused {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("the build said %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// What a program reads of its call is the chain's on chain and the host's off it. Given the
+// same call, both answer the same — and at a tape narrower than an address, both keep the
+// same end of it.
+func TestTheCallIsReadTheSameOnChainAndOff(t *testing.T) {
+	chain, err := NewChainContext(ChainContextOptions{
+		Caller:      "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		CallValue:   "1000",
+		Timestamp:   "1700000000",
+		BlockNumber: "19000000",
+		ChainID:     "5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{name: "caller", source: `ident f = defer { caller + feed(0); };`},
+		{name: "callvalue", source: `ident f = defer { callvalue + feed(0); };`},
+		{name: "timestamp", source: `ident f = defer { timestamp - feed(0); };`},
+		{name: "blocknumber", source: `ident f = defer { blocknumber - feed(0); };`},
+		{name: "chainid", source: `ident f = defer { chainid * feed(0); };`},
+	}
+
+	args := []string{"1"}
+	for _, size := range []int{32, 8, 1} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("%s at %d bytes", tc.name, size), func(t *testing.T) {
+				build := sessionOpts{tapeSize: size, chain: chain}
+				path := writeAt(t, t.TempDir(), "contract.ar", tc.source)
+
+				off, err := newSession(t, build).CallLocal(t.Context(), path, "f", args)
+				if err != nil {
+					t.Fatalf("calling off chain: %v", err)
+				}
+				on, err := callOnChainAt(t, path, build, nil, func(functions []evm.ABIFunction) []byte {
+					calldata, err := EncodeCall(functions, "f", args)
+					if err != nil {
+						t.Fatalf("encoding the call: %v", err)
+					}
+					return calldata
+				})
+				if err != nil {
+					t.Fatalf("calling on chain: %v", err)
+				}
+				if got, want := decimalOf(on), decimalOf(off); got != want {
+					t.Errorf("the chain answered %s and the evaluator %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+// A call to another contract is answered by that contract on chain and by the registry off it.
+// The one on chain is deployed beside the caller from Aurora source, and the registry answers
+// the way that source would, so what is compared is the crossing: the calldata the caller
+// lays out, and the answer it reads back.
+func TestACallToAnotherContractAnswersTheSameOnChainAndOff(t *testing.T) {
+	const callee = `ident add = defer { feed(0) + feed(1); };`
+
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{name: "a call", source: `ident f = defer { call(feed(0), "add(uint256,uint256)", feed(1), 2) * 10; };`},
+		{name: "a static call", source: `ident f = defer { staticcall(feed(0), "add(uint256,uint256)", feed(1), 2) * 10; };`},
+		{name: "the answer on the right", source: `ident f = defer { 100 - call(feed(0), "add(uint256,uint256)", 2, feed(1)); };`},
+		{name: "bound to a name", source: `ident f = defer { ident sum = call(feed(0), "add(uint256,uint256)", feed(1), feed(1)); sum + 1; };`},
+	}
+
+	for _, size := range []int{32, 20} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("%s at %d bytes", tc.name, size), func(t *testing.T) {
+				dir := t.TempDir()
+				cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
+				at, _ := deployAt(t, writeAt(t, dir, "callee.ar", callee), sessionOpts{tapeSize: size}, nil, cfg)
+				address, functions := deployAt(t, writeAt(t, dir, "contract.ar", tc.source), sessionOpts{tapeSize: size}, nil, cfg)
+
+				args := []string{at.Hex(), "5"}
+				calldata, err := EncodeCall(functions, "f", args)
+				if err != nil {
+					t.Fatalf("encoding the call: %v", err)
+				}
+				on, _, err := runtime.Call(address, calldata, cfg)
+				if err != nil {
+					t.Fatalf("calling on chain: %v", err)
+				}
+
+				contracts := evaluator.NewRegistry().Answer(at.Bytes(), "add(uint256,uint256)", func(values [][]byte) ([]byte, error) {
+					sum := new(big.Int).Add(new(big.Int).SetBytes(values[0]), new(big.Int).SetBytes(values[1]))
+					return common.LeftPadBytes(sum.Bytes(), 32), nil
+				})
+				build := sessionOpts{tapeSize: size, contracts: contracts}
+				off, err := newSession(t, build).CallLocal(t.Context(), filepath.Join(dir, "contract.ar"), "f", args)
+				if err != nil {
+					t.Fatalf("calling off chain: %v", err)
+				}
+
+				if got, want := decimalOf(on), decimalOf(off); got != want {
+					t.Errorf("the chain answered %s and the evaluator %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+// Calls to another contract are made in the order they were written on both sides, even when
+// the operation taking them reads the right one first. The contract called here counts its
+// calls, so which one came first is in the answer: 1 * 10 - 2 in the order written, and
+// 2 * 10 - 1 the other way round.
+func TestCallsToAnotherContractAreMadeInTheOrderWritten(t *testing.T) {
+	// A counter, written by hand: every call adds one to slot zero and answers it.
+	counter := common.FromHex("0x601280600b6000396000f3" + "600054600101806000556000526020" + "6000f3")
+	const source = `ident f = defer { call(feed(0), "next()") * 10 - call(feed(0), "next()"); };`
+
+	for _, optimize := range []bool{false, true} {
+		t.Run(fmt.Sprintf("optimized: %v", optimize), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
+			_, at, _, err := runtime.Create(counter, cfg)
+			if err != nil {
+				t.Fatalf("deploying the counter: %v", err)
+			}
+			address, functions := deployAt(t, writeAt(t, dir, "contract.ar", source), sessionOpts{tapeSize: 32, optimize: optimize}, nil, cfg)
+
+			args := []string{at.Hex()}
+			calldata, err := EncodeCall(functions, "f", args)
+			if err != nil {
+				t.Fatalf("encoding the call: %v", err)
+			}
+			on, _, err := runtime.Call(address, calldata, cfg)
+			if err != nil {
+				t.Fatalf("calling on chain: %v", err)
+			}
+
+			calls := int64(0)
+			contracts := evaluator.NewRegistry().Answer(at.Bytes(), "next()", func([][]byte) ([]byte, error) {
+				calls++
+				return common.LeftPadBytes(big.NewInt(calls).Bytes(), 32), nil
+			})
+			build := sessionOpts{tapeSize: 32, contracts: contracts}
+			off, err := newSession(t, build).CallLocal(t.Context(), filepath.Join(dir, "contract.ar"), "f", args)
+			if err != nil {
+				t.Fatalf("calling off chain: %v", err)
+			}
+
+			if got := decimalOf(on); got != "8" {
+				t.Errorf("the chain answered %s, want the calls made in the order written", got)
+			}
+			if got := decimalOf(off); got != "8" {
+				t.Errorf("the evaluator answered %s, want the calls made in the order written", got)
+			}
+		})
+	}
+}
+
+// A contract answering more than a word is refused on both sides, with the same words: on chain
+// a value is a word on the stack and no shape is written to hold more, and the evaluator reads
+// no more than the chain can.
+func TestAnAnswerOfSeveralWordsIsRefusedOnChainAndOff(t *testing.T) {
+	// Written by hand: every call answers the two words 7 and 9.
+	pair := common.FromHex("0x600f80600b6000396000f3" + "600760005260096020526040" + "6000f3")
+	const source = `ident f = defer { call(feed(0), "pair()") + 1; };`
+
+	dir := t.TempDir()
+	cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
+	_, at, _, err := runtime.Create(pair, cfg)
+	if err != nil {
+		t.Fatalf("deploying the pair: %v", err)
+	}
+	address, functions := deployAt(t, writeAt(t, dir, "contract.ar", source), sessionOpts{tapeSize: 32}, nil, cfg)
+
+	args := []string{at.Hex()}
+	calldata, err := EncodeCall(functions, "f", args)
+	if err != nil {
+		t.Fatalf("encoding the call: %v", err)
+	}
+	returned, _, err := runtime.Call(address, calldata, cfg)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		t.Fatalf("the call did not revert: answered %x, %v", returned, err)
+	}
+	if reason, ok := evm.ReasonOf(returned); !ok || reason != ir.AnswerOfManyWords {
+		t.Errorf("reverted with %q, want %q", reason, ir.AnswerOfManyWords)
+	}
+
+	contracts := evaluator.NewRegistry().Answer(at.Bytes(), "pair()", evaluator.Returns(big.NewInt(7).Bytes(), big.NewInt(9).Bytes()))
+	build := sessionOpts{tapeSize: 32, contracts: contracts}
+	_, err = newSession(t, build).CallLocal(t.Context(), filepath.Join(dir, "contract.ar"), "f", args)
+	if err == nil || err.Error() != ir.AnswerOfManyWords {
+		t.Errorf("off chain: got %v, want %q", err, ir.AnswerOfManyWords)
+	}
+}
+
+// A contract that fails fails the call into it, with its reason: on chain the revert is passed
+// up whole, and off chain the registry's error stops the program.
+func TestACallToAContractThatFailsFailsTheSameOnChainAndOff(t *testing.T) {
+	const callee = `ident take = defer { require(feed(0) bigger 1, "too small"); feed(0); };`
+	const source = `ident f = defer { call(feed(0), "take(uint256)", feed(1)); };`
+
+	dir := t.TempDir()
+	cfg := &runtime.Config{GasLimit: 10_000_000, Value: big.NewInt(0)}
+	at, _ := deployAt(t, writeAt(t, dir, "callee.ar", callee), sessionOpts{tapeSize: 32}, nil, cfg)
+	address, functions := deployAt(t, writeAt(t, dir, "contract.ar", source), sessionOpts{tapeSize: 32}, nil, cfg)
+
+	args := []string{at.Hex(), "0"}
+	calldata, err := EncodeCall(functions, "f", args)
+	if err != nil {
+		t.Fatalf("encoding the call: %v", err)
+	}
+	returned, _, err := runtime.Call(address, calldata, cfg)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		t.Fatalf("the call did not revert: answered %x, %v", returned, err)
+	}
+	if reason, ok := evm.ReasonOf(returned); !ok || reason != "too small" {
+		t.Errorf("reverted with %q, want the callee's reason", reason)
+	}
+
+	contracts := evaluator.NewRegistry().Answer(at.Bytes(), "take(uint256)", func([][]byte) ([]byte, error) {
+		return nil, errors.New("too small")
+	})
+	build := sessionOpts{tapeSize: 32, contracts: contracts}
+	_, err = newSession(t, build).CallLocal(t.Context(), filepath.Join(dir, "contract.ar"), "f", args)
+	if err == nil || err.Error() != "too small" {
+		t.Errorf("off chain: got %v, want the callee's reason", err)
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/formatter"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// What "aurora fmt" does with a file: the layout is the formatter's, and this reads the file,
+// parses it the way compiling it would, and says whether the layout changed anything.
+
+// A Formatted file is one the formatter read, and what it made of it.
+type Formatted struct {
+	Path   string
+	Source []byte
+	Result []byte
+}
+
+// Changed says whether the file was not in the layout already.
+func (f Formatted) Changed() bool {
+	return string(f.Source) != string(f.Result)
+}
+
+// Format reads a file and answers it in the canonical layout. A file that does not parse is
+// not formatted: it answers why, the way compiling it would.
+//
+// It is parsed with whatever it imports, since a shape of another module is built with the
+// same braces as a block and only the parse can tell them apart.
+func (s *Session) Format(path string) (Formatted, error) {
+	if s.resolver == nil {
+		return Formatted{}, errors.New("no resolver was given to this session")
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return Formatted{}, err
+	}
+	tokens, err := s.lexer.GetTokens(source)
+	if err != nil {
+		return Formatted{}, err
+	}
+	modules, err := s.resolver.Resolve(path)
+	if err != nil {
+		return Formatted{}, err
+	}
+	entry := slices.IndexFunc(modules, func(each module.Module) bool { return each.IsEntry() })
+	if entry < 0 {
+		return Formatted{}, fmt.Errorf("%s was not read", path)
+	}
+	return Formatted{Path: path, Source: source, Result: formatter.Format(tokens, modules[entry].Tree)}, nil
+}
+
+// SourceFiles answers the source files a list of paths names: a file as it is, and a
+// directory as every source file at or below it. Nothing named is the directory the command
+// was run in.
+func SourceFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(each string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// A directory whose name starts with a dot is somebody else's: .git, an editor's.
+			if d.IsDir() && each != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), SourceExtension) {
+				files = append(files, each)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/fatih/color"
+
+	"github.com/guiferpa/aurora/builder/evm"
+)
+
+// What a call of each scope costs, said before anything is deployed.
+//
+// Two numbers, because neither is enough alone. The static one is read off the bytecode and
+// is the same on every run: a change that makes a scope dearer shows up in it whatever the
+// scope is handed. The measured one is a real call, in an EVM built in memory the way the
+// differential tests build one, so it carries what reading the bytecode cannot — memory
+// growing, a slot touched for the first time.
+
+// SampleFeed is what every position of a measured call is handed. One rather than zero: a
+// scope that divides by what it is fed would revert on zero, and that is not the call anybody
+// is budgeting for.
+const SampleFeed = "1"
+
+// gasLimit is what a measured call may spend, far more than any scope needs: running out is a
+// question for the static number, which says unbounded.
+const gasLimit = 30_000_000
+
+// ScopeGas is what calling one scope costs.
+type ScopeGas struct {
+	evm.ScopeGas
+	// Signature is what the scope answers to, read from the ABI; a scope the ABI does not list
+	// is named by its selector.
+	Signature string
+	// Measured is what the call spent in the EVM, past the cost every transaction pays just
+	// to be one.
+	Measured uint64
+	// Reverted is the reason the measured call reverted with, when it did.
+	Reverted string
+}
+
+// Static answers what the scope costs as read off the bytecode: the dispatcher reaching it and
+// the most expensive way through its body.
+func (s ScopeGas) Static() int {
+	return s.Dispatch + s.Body
+}
+
+// MeasureGas deploys a binary in an EVM of its own and calls every scope it dispatches to,
+// with every position fed SampleFeed — the constructor's too.
+func MeasureGas(bytecode []byte, functions []evm.ABIFunction, tapeSize int) ([]ScopeGas, error) {
+	deployed, err := EncodeDeploy(bytecode, functions, sampleArgs(constructorOf(functions)))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &runtime.Config{GasLimit: gasLimit, Value: big.NewInt(0)}
+	_, address, _, err := runtime.Create(deployed, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("deploying to measure gas: %w", err)
+	}
+
+	static := evm.Gas(bytecode, tapeSize)
+	scopes := make([]ScopeGas, 0, len(static))
+	for _, each := range static {
+		scope := ScopeGas{ScopeGas: each, Signature: fmt.Sprintf("0x%x", each.Selector)}
+		calldata := append([]byte{}, each.Selector...)
+		for _, fn := range functions {
+			if fn.Type == "function" && string(fn.Selector()) == string(each.Selector) {
+				scope.Signature = fn.Signature()
+				calldata = append(calldata, ParseArgs(sampleArgs(fn))...)
+			}
+		}
+
+		returned, left, err := runtime.Call(address, calldata, cfg)
+		scope.Measured = gasLimit - left
+		switch {
+		case errors.Is(err, vm.ErrExecutionReverted):
+			scope.Reverted = "reverted"
+			if reason, ok := evm.ReasonOf(returned); ok {
+				scope.Reverted = reason
+			}
+		case err != nil:
+			scope.Reverted = err.Error()
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// constructorOf answers the constructor of an ABI, which takes nothing when it is not listed.
+func constructorOf(functions []evm.ABIFunction) evm.ABIFunction {
+	for _, fn := range functions {
+		if fn.Type == "constructor" {
+			return fn
+		}
+	}
+	return evm.ABIFunction{Type: "constructor"}
+}
+
+// sampleArgs answers SampleFeed for every input of a function.
+func sampleArgs(fn evm.ABIFunction) []string {
+	args := make([]string, len(fn.Inputs))
+	for i := range args {
+		args[i] = SampleFeed
+	}
+	return args
+}
+
+// WriteGasReport writes what each scope costs, one line each, in the order the bodies are laid
+// out.
+func WriteGasReport(w io.Writer, scopes []ScopeGas) {
+	if w == nil {
+		return
+	}
+
+	bold := color.New(color.Bold).SprintFunc()
+	dim := color.New(color.Faint).SprintFunc()
+
+	_, _ = fmt.Fprintf(w, "⛽ %s %s\n", bold("gas per call"), dim("(every feed at "+SampleFeed+")"))
+	if len(scopes) == 0 {
+		_, _ = fmt.Fprintf(w, "   %s\n", dim("no scope to call"))
+		return
+	}
+
+	width := 0
+	for _, scope := range scopes {
+		width = max(width, len(scope.Signature))
+	}
+	for _, scope := range scopes {
+		static := fmt.Sprintf("%d static", scope.Static())
+		if scope.Unbounded {
+			static = "unbounded"
+		}
+		measured := fmt.Sprintf("%d measured", scope.Measured)
+		if scope.Reverted != "" {
+			measured += ", " + color.YellowString("reverted: %s", scope.Reverted)
+		}
+		_, _ = fmt.Fprintf(w, "   %s%s  %-14s %s\n",
+			scope.Signature, strings.Repeat(" ", width-len(scope.Signature)), static, measured)
+	}
+}
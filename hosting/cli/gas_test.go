@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gasAR = `ident rate = feed(0);
+
+ident scale = defer { feed(0) * rate; };
+ident half = defer { feed(0) / feed(1); };
+ident guard = defer { require(feed(0) bigger 5, "too small"); 1; };
+`
+
+// Every scope is called, and a straight one spends what reading it says, plus what memory
+// costs to grow — which reading it leaves out.
+func TestMeasureGasCallsEveryScope(t *testing.T) {
+	scopes, err := newSession(t, sessionOpts{}).Gas(writeAt(t, t.TempDir(), "main.ar", gasAR))
+	if err != nil {
+		t.Fatalf("Gas: %v", err)
+	}
+
+	signatures := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		signatures = append(signatures, scope.Signature)
+	}
+	if got := strings.Join(signatures, " "); got != "scale(uint256) half(uint256,uint256) guard(uint256)" {
+		t.Fatalf("measured %s", got)
+	}
+
+	for _, scope := range scopes[:2] {
+		if scope.Reverted != "" || scope.Unbounded {
+			t.Errorf("%s: %+v", scope.Signature, scope)
+		}
+		if scope.Measured < uint64(scope.Static()) {
+			t.Errorf("%s spent %d, less than the %d it cannot avoid", scope.Signature, scope.Measured, scope.Static())
+		}
+	}
+	// Fed 1, the guard does not hold, and the call says why it stopped.
+	if reason := scopes[2].Reverted; reason != "too small" {
+		t.Errorf("the guard reverted with %q", reason)
+	}
+}
+
+// The report follows the build, a line for every scope.
+func TestBuildReportsGas(t *testing.T) {
+	dir := t.TempDir()
+	source := writeAt(t, dir, "main.ar", gasAR)
+	out := &strings.Builder{}
+
+	if _, err := newSession(t, sessionOpts{stdout: out, gas: true}).Build(t.Context(), source, filepath.Join(dir, "main")); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	for _, want := range []string{"gas per call", "scale(uint256)", "static", "measured", "reverted: too small"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the report does not say %q:\n%s", want, out.String())
+		}
+	}
+}
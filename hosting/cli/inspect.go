@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// What each phase made of a program, for "aurora inspect".
+//
+// Nothing here is printed by a phase. The lexer answers with tokens, the parser with a tree and
+// the emitter with instructions, the way they always have; this asks each of them for what it
+// made and keeps it, and the host decides how it is written. That is the port the roadmap said
+// "-l" would have to come back as, if it came back.
+
+// InspectOptions says which products to keep. Asking for none is asking for all of them.
+type InspectOptions struct {
+	Tokens bool
+	AST    bool
+	IR     bool
+}
+
+func (o InspectOptions) all() bool {
+	return !o.Tokens && !o.AST && !o.IR
+}
+
+// Inspection is every module of a program, in the order they load.
+type Inspection struct {
+	Modules []InspectedModule `json:"modules"`
+}
+
+// InspectedModule is what the phases made of one file.
+type InspectedModule struct {
+	// Module is the name the module is imported by, and empty for the file that was named.
+	Module   string           `json:"module"`
+	Filename string           `json:"filename"`
+	Tokens   []InspectedToken `json:"tokens,omitempty"`
+	// AST is the tree as data, every node naming its kind.
+	AST any `json:"ast,omitempty"`
+	// IR is the module's range of the program's one stream.
+	IR *InspectedRange `json:"ir,omitempty"`
+
+	tree *ast.AST
+}
+
+// InspectedToken is a token where it was written: what kind it is and what it matched.
+type InspectedToken struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Tag    string `json:"tag"`
+	Match  string `json:"match"`
+}
+
+// InspectedRange is a module's instructions, at the positions they hold in the whole program —
+// which is what a deferred scope's range, a coverage count and a trace are all counted in.
+type InspectedRange struct {
+	From         uint64                 `json:"from"`
+	To           uint64                 `json:"to"`
+	Instructions []InspectedInstruction `json:"instructions"`
+}
+
+// InspectedInstruction is one instruction, the line and column it was compiled from when they
+// are known, and the way ir.Format writes it.
+type InspectedInstruction struct {
+	Position    uint64 `json:"position"`
+	Line        int    `json:"line,omitempty"`
+	Column      int    `json:"column,omitempty"`
+	Instruction string `json:"instruction"`
+}
+
+// Inspect compiles the source and everything it imports, and keeps what each phase made of
+// each file. Nothing runs.
+func (s *Session) Inspect(source string, opts InspectOptions) (Inspection, error) {
+	if s.resolver == nil {
+		return Inspection{}, fmt.Errorf("no resolver was given to this session")
+	}
+	modules, err := s.resolver.Resolve(source)
+	if err != nil {
+		return Inspection{}, err
+	}
+
+	wants := func(asked bool) bool { return asked || opts.all() }
+
+	var program loader.Program
+	if wants(opts.IR) {
+		if program, err = loader.Load(modules, s.emitter.EmitProgram); err != nil {
+			return Inspection{}, err
+		}
+	}
+
+	inspection := Inspection{Modules: make([]InspectedModule, 0, len(modules))}
+	for at, each := range modules {
+		inspected := InspectedModule{Module: string(each.ID), Filename: each.Tree.Filename}
+
+		if wants(opts.Tokens) {
+			tokens, err := s.tokens(each.Tree.Filename)
+			if err != nil {
+				return Inspection{}, err
+			}
+			inspected.Tokens = tokens
+		}
+		if wants(opts.AST) {
+			tree := each.Tree
+			inspected.tree = &tree
+			inspected.AST = ast.Describe(tree)
+		}
+		if wants(opts.IR) {
+			// The ranges are in the order the modules were handed to the loader.
+			inspected.IR = inspectRange(program, program.Ranges[at])
+		}
+		inspection.Modules = append(inspection.Modules, inspected)
+	}
+	return inspection, nil
+}
+
+// tokens reads a file again and answers what the lexer makes of it. The resolver read it too,
+// and kept the tree rather than the tokens, which is all a compile needs.
+func (s *Session) tokens(filename string) ([]InspectedToken, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.lexer.GetFilledTokens(source)
+	if err != nil {
+		return nil, err
+	}
+	inspected := make([]InspectedToken, 0, len(tokens))
+	for _, tok := range tokens {
+		inspected = append(inspected, InspectedToken{
+			Line:   tok.GetLine(),
+			Column: tok.GetColumn(),
+			Tag:    tok.GetTag().Id,
+			Match:  string(tok.GetMatch()),
+		})
+	}
+	return inspected, nil
+}
+
+func inspectRange(program loader.Program, each loader.Range) *InspectedRange {
+	inspected := &InspectedRange{From: each.From, To: each.To, Instructions: make([]InspectedInstruction, 0, each.To-each.From)}
+	for at := each.From; at < each.To; at++ {
+		inst := program.Instructions[at]
+		origin := inst.GetOrigin()
+		inspected.Instructions = append(inspected.Instructions, InspectedInstruction{
+			Position:    at,
+			Line:        origin.Line,
+			Column:      origin.Column,
+			Instruction: strings.TrimSuffix(ir.Format([]ir.Instruction{inst}), "\n"),
+		})
+	}
+	return inspected
+}
+
+// WriteInspection writes an inspection as text: a heading per module, then each product it
+// holds under a heading of its own.
+func WriteInspection(w io.Writer, inspection Inspection) error {
+	b := &strings.Builder{}
+	for at, each := range inspection.Modules {
+		if at > 0 {
+			b.WriteString("\n")
+		}
+		if each.Module == "" {
+			fmt.Fprintf(b, "== %s ==\n", displayPath(each.Filename))
+		} else {
+			fmt.Fprintf(b, "== %s (%s) ==\n", each.Module, displayPath(each.Filename))
+		}
+
+		if each.Tokens != nil {
+			b.WriteString("-- tokens\n")
+			for _, tok := range each.Tokens {
+				fmt.Fprintf(b, "%-8s %-12s %s\n", fmt.Sprintf("%d:%d", tok.Line, tok.Column), tok.Tag, tok.Match)
+			}
+		}
+		if each.tree != nil {
+			b.WriteString("-- ast\n")
+			b.WriteString(ast.Format(*each.tree))
+		}
+		if each.IR != nil {
+			fmt.Fprintf(b, "-- ir [%d, %d)\n", each.IR.From, each.IR.To)
+			for _, inst := range each.IR.Instructions {
+				at := ""
+				if inst.Line > 0 {
+					at = fmt.Sprintf("%d:%d", inst.Line, inst.Column)
+				}
+				fmt.Fprintf(b, "%6d  %-8s %s\n", inst.Position, at, inst.Instruction)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
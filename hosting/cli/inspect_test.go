@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// What "aurora inspect" answers: each phase's product, per module, asked for and handed over.
+
+func inspected(t *testing.T, entry string, opts InspectOptions) Inspection {
+	t.Helper()
+
+	inspection, err := newSession(t, sessionOpts{}).Inspect(filepath.FromSlash(entry), opts)
+	if err != nil {
+		t.Fatalf("inspecting: %v", err)
+	}
+	return inspection
+}
+
+// Every module is there, in the order they load, and the ranges are the program's: the
+// module's instructions are at the positions they hold in the one stream, one after the other.
+func TestInspectMarksEveryModulesRange(t *testing.T) {
+	projectOf(t, map[string]string{
+		"src/geo.ar":  "ident base = 10;",
+		"src/main.ar": "use geo as g;\nprintd g.base + 1;",
+	})
+
+	inspection := inspected(t, "src/main.ar", InspectOptions{IR: true})
+
+	if len(inspection.Modules) != 2 {
+		t.Fatalf("inspected %d modules, want 2", len(inspection.Modules))
+	}
+	geo, main := inspection.Modules[0], inspection.Modules[1]
+	if geo.Module != "geo" || main.Module != "" {
+		t.Errorf("modules are %q and %q, want geo and then the entry", geo.Module, main.Module)
+	}
+	if geo.IR.From != 0 || geo.IR.To != main.IR.From || main.IR.To <= main.IR.From {
+		t.Errorf("ranges are [%d, %d) and [%d, %d), want them back to back from zero",
+			geo.IR.From, geo.IR.To, main.IR.From, main.IR.To)
+	}
+	if first := main.IR.Instructions[0]; first.Position != main.IR.From {
+		t.Errorf("the entry's first instruction is at %d, want %d", first.Position, main.IR.From)
+	}
+	if geo.Tokens != nil || geo.AST != nil {
+		t.Error("asked for the instructions only, and got more")
+	}
+}
+
+// Asking for nothing is asking for everything, and the text has a heading for each.
+func TestInspectShowsEveryPhaseByDefault(t *testing.T) {
+	projectOf(t, map[string]string{"src/main.ar": "ident x = 1 + 2; #- a comment is not a token\n"})
+
+	var out bytes.Buffer
+	if err := WriteInspection(&out, inspected(t, "src/main.ar", InspectOptions{})); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"== " + filepath.FromSlash("src/main.ar") + " ==",
+		"-- tokens", "1:1      IDENT        ident",
+		"-- ast", "IdentLiteral\n  id: \"x\"",
+		"-- ir [0, ", "OpAdd",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("inspection wrote\n%s\nwant it to contain %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), "comment") {
+		t.Errorf("inspection wrote\n%s\nwant no comment among the tokens", out.String())
+	}
+}
+
+// As JSON, every node of the tree says what kind it is.
+func TestInspectAsJSON(t *testing.T) {
+	projectOf(t, map[string]string{"src/main.ar": "printd 7;"})
+
+	bs, err := json.Marshal(inspected(t, "src/main.ar", InspectOptions{AST: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"ast":{"filename":"` + filepath.FromSlash("src/main.ar") + `","kind":"AST","nodes":[{"format":"decimal","kind":"PrintStatement","parameter":{"kind":"NumberLiteral","value":7}}]}`
+	if !strings.Contains(string(bs), want) {
+		t.Errorf("inspection is %s, want it to contain %s", bs, want)
+	}
+}
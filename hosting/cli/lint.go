@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/fatih/color"
+
+	"github.com/guiferpa/aurora/linter"
+)
+
+// What "aurora lint" does with a file: the rules are the linter's, and this reads the file and
+// everything it imports the way compiling it would, with the tokens the trees do not keep.
+
+// Lintable reads a file and every module it imports, each with all of its tokens — comments
+// included, since a comment is where a rule is silenced. A file that does not parse is not
+// lintable: it answers why, the way compiling it would.
+//
+// Every file is named by its absolute path. The same module is reached from several programs
+// when a project is linted whole, and the linter reads it once only if it can tell.
+func (s *Session) Lintable(path string) ([]linter.File, error) {
+	if s.resolver == nil {
+		return nil, errors.New("no resolver was given to this session")
+	}
+	modules, err := s.resolver.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]linter.File, 0, len(modules))
+	for _, each := range modules {
+		tokens, err := s.lexer.GetTokens([]byte(each.Source))
+		if err != nil {
+			return nil, err
+		}
+		filename, err := filepath.Abs(each.Tree.Filename)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, linter.File{Filename: filename, Module: each, Tokens: tokens})
+	}
+	return files, nil
+}
+
+// ReportFindings writes what the linter found, one line each as file:line:column, which is
+// what an editor follows to jump there, with the rule that found it at the end: the name to
+// silence it by.
+func ReportFindings(w io.Writer, findings []linter.Finding) {
+	if w == nil {
+		return
+	}
+	paint := map[linter.Severity]*color.Color{
+		linter.Info:    color.New(color.FgCyan),
+		linter.Warning: color.New(color.FgHiYellow),
+		linter.Error:   color.New(color.FgRed),
+	}
+	for _, finding := range findings {
+		at := displayPath(finding.Filename)
+		if finding.Line > 0 {
+			at = fmt.Sprintf("%s:%d:%d", at, finding.Line, finding.Column)
+		}
+		_, _ = paint[finding.Severity].Fprintf(w, "%s: %s: %s (%s)\n", at, finding.Severity, finding.Message, finding.Rule)
+	}
+}
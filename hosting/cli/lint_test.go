@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/linter"
+)
+
+// A finding is written the way a warning is, file:line:column for an editor to follow, with the
+// rule that found it at the end: the name a comment or aurora.toml silences it by.
+func TestReportFindingsNamesTheRule(t *testing.T) {
+	out := &strings.Builder{}
+
+	ReportFindings(out, []linter.Finding{
+		{Rule: "unused-ident", Severity: linter.Warning, Filename: "main.ar", Line: 3, Column: 7, Message: "x is bound and never read"},
+	})
+
+	if got := out.String(); !strings.Contains(got, "main.ar:3:7: warning: x is bound and never read (unused-ident)") {
+		t.Errorf("wrote %q, want the place, the severity, the message and the rule", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 
+	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/emitter"
 	"github.com/guiferpa/aurora/evaluator"
 	"github.com/guiferpa/aurora/lexer"
@@ -32,6 +33,13 @@ type Session struct {
 	tapeSize int
 	stdout   io.Writer
 	warnings io.Writer
+	// trace is told which program a run is about to run. The evaluator is what tells it the
+	// steps, so it has to be handed to NewEvaluator as well.
+	trace *Trace
+	// gas says what each scope of a build costs to call.
+	gas bool
+	// optimize builds smaller and cheaper bytecode, and says how much was saved.
+	optimize bool
 }
 
 type NewSessionOptions struct {
@@ -47,6 +55,12 @@ type NewSessionOptions struct {
 	Stdout io.Writer
 	// Warnings receives compiler warnings. Nil discards them.
 	Warnings io.Writer
+	// Trace writes the steps of a run, for "aurora run --trace". Nil traces nothing.
+	Trace *Trace
+	// Gas has a build report what calling each scope costs, for "aurora build --gas".
+	Gas bool
+	// Optimize has a build write smaller and cheaper bytecode, for "aurora build -O".
+	Optimize bool
 }
 
 // evaluator answers with a fresh evaluator, or says that the session was built without a way
@@ -75,6 +89,21 @@ func (s *Session) compile(source string) (loader.Program, error) {
 	return loader.Load(modules, s.emitter.EmitProgram)
 }
 
+// Compile answers the program a source is, having said what compiling it had to say. It is
+// compile for a host that runs the program itself — "aurora debug" holds the evaluator and
+// stops it on a line, which is not something Run can be asked to do.
+func (s *Session) Compile(source string) (loader.Program, error) {
+	if err := byteutil.ValidateTapeSize(s.tapeSize); err != nil {
+		return loader.Program{}, err
+	}
+	program, err := s.compile(source)
+	if err != nil {
+		return loader.Program{}, err
+	}
+	s.report(program)
+	return program, nil
+}
+
 // report says what compiling each module had to say, naming the file it came from — a warning
 // about a module is not about the file somebody asked to run.
 func (s *Session) report(program loader.Program) {
@@ -93,5 +122,8 @@ func NewSession(opts NewSessionOptions) *Session {
 		tapeSize:     opts.TapeSize,
 		stdout:       opts.Stdout,
 		warnings:     opts.Warnings,
+		trace:        opts.Trace,
+		gas:          opts.Gas,
+		optimize:     opts.Optimize,
 	}
 }
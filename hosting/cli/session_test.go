@@ -30,6 +30,18 @@ type sessionOpts struct {
 	// asserts turns assertions on and sends what a program prints nowhere, which is what
 	// "aurora test" does: a test says what held, not what was printed on the way.
 	asserts bool
+	// cover counts the instructions that run, which is what "aurora test --cover" does.
+	cover bool
+	// trace writes the steps of a run, which is what "aurora run --trace" does.
+	trace *Trace
+	// gas has a build say what each scope costs, which is what "aurora build --gas" does.
+	gas bool
+	// optimize builds with the optimizer on, which is what "aurora build -O" does.
+	optimize bool
+	// chain is the call a program runs in, which is what "aurora run --caller" and the rest say.
+	chain evaluator.Context
+	// contracts answers for the other contracts a program calls.
+	contracts evaluator.Contracts
 }
 
 // newTestResolver puts the front of the pipeline together the way cmd/aurora does: a test
@@ -76,6 +88,10 @@ func newSession(t *testing.T, o sessionOpts) *Session {
 		printed = io.Discard
 	}
 	size := o.tapeSize
+	var tracer evaluator.Tracer
+	if o.trace != nil {
+		tracer = o.trace
+	}
 
 	return NewSession(NewSessionOptions{
 		Lexer:    lexer.New(),
@@ -90,11 +106,18 @@ func newSession(t *testing.T, o sessionOpts) *Session {
 				Args:         ParseArgs(o.args),
 				TapeSize:     size,
 				Asserts:      o.asserts,
+				Cover:        o.cover,
+				Tracer:       tracer,
+				Context:      o.chain,
+				Contracts:    o.contracts,
 			})
 		},
 		TapeSize: size,
 		Stdout:   stdout,
 		Warnings: o.warnings,
+		Trace:    o.trace,
+		Gas:      o.gas,
+		Optimize: o.optimize,
 	})
 }
 
@@ -40,12 +40,21 @@ func (t Target) FromProfile() bool {
 // Running a loose file is the common case while learning the language, and it should not
 // require a project to exist — so this path never looks for aurora.toml.
 func ResolveTarget(arg string) (Target, error) {
+	return ResolveTargetFrom("", arg)
+}
+
+// ResolveTargetFrom is ResolveTarget as if the command had been run in dir: a profile's project
+// is looked for from there, and a relative path — the file named, the source root — is read
+// against it. Empty is the working directory, which is what every command runs in; a host that
+// serves more than one project, like the debug adapter, cannot move the process to each one.
+func ResolveTargetFrom(dir, arg string) (Target, error) {
 	if strings.HasSuffix(arg, SourceExtension) {
-		tapeSize, err := ProjectTapeSize(arg)
+		source := within(dir, arg)
+		tapeSize, err := ProjectTapeSize(source)
 		if err != nil {
 			return Target{}, err
 		}
-		return Target{Source: arg, TapeSize: tapeSize, SourceRoot: ProjectSourceRoot(arg)}, nil
+		return Target{Source: source, TapeSize: tapeSize, SourceRoot: within(dir, ProjectSourceRoot(source))}, nil
 	}
 
 	if arg != "" && looksLikePath(arg) {
@@ -57,7 +66,11 @@ func ResolveTarget(arg string) (Target, error) {
 		name = DefaultProfile
 	}
 
-	env, err := LoadEnviron(name)
+	load := LoadEnviron
+	if dir != "" {
+		load = func(name string) (*Environ, error) { return LoadEnvironFrom(dir, name) }
+	}
+	env, err := load(name)
 	if err != nil {
 		return Target{}, err
 	}
@@ -67,10 +80,19 @@ func ResolveTarget(arg string) (Target, error) {
 		Binary:     env.AbsPath(env.Profile.Binary),
 		TapeSize:   env.Manifest.Project.TapeSize,
 		Profile:    name,
-		SourceRoot: env.Manifest.SourceRoot(),
+		SourceRoot: env.AbsPath(env.Manifest.SourceRoot()),
 	}, nil
 }
 
+// within answers a path as read from dir. Empty is the working directory, where a relative path
+// already reads the way it was written.
+func within(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
 // ProjectTapeSize answers the width of the project a file sits in, and zero when it sits in
 // none.
 //
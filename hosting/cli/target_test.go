@@ -71,6 +71,52 @@ func TestResolveTargetFromProfile(t *testing.T) {
 	}
 }
 
+// A target read from another directory is read the way it would be if the command ran there,
+// and the working directory is left where it was.
+func TestResolveTargetFromAnotherDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "aurora.toml"), []byte(manifestWithProfiles), 0o644); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+	t.Chdir(t.TempDir())
+
+	got, err := ResolveTargetFrom(dir, "tiny")
+	if err != nil {
+		t.Fatalf("ResolveTargetFrom: %v", err)
+	}
+	if want := filepath.Join(dir, "src/tiny.ar"); got.Source != want {
+		t.Errorf("source = %q, want %q", got.Source, want)
+	}
+	if want := filepath.Join(dir, "src"); got.SourceRoot != want {
+		t.Errorf("source root = %q, want %q", got.SourceRoot, want)
+	}
+
+	// Launched from inside the project, a profile still reads its source root from the
+	// project's own root, where the manifest was found.
+	inside := filepath.Join(dir, "src")
+	if err := os.MkdirAll(inside, 0o755); err != nil {
+		t.Fatalf("making the subdirectory: %v", err)
+	}
+	nested, err := ResolveTargetFrom(inside, "tiny")
+	if err != nil {
+		t.Fatalf("ResolveTargetFrom: %v", err)
+	}
+	if want := filepath.Join(dir, "src"); nested.SourceRoot != want {
+		t.Errorf("source root from a subdirectory = %q, want %q", nested.SourceRoot, want)
+	}
+
+	file, err := ResolveTargetFrom(dir, "src/main.ar")
+	if err != nil {
+		t.Fatalf("ResolveTargetFrom: %v", err)
+	}
+	if want := filepath.Join(dir, "src/main.ar"); file.Source != want {
+		t.Errorf("source = %q, want %q", file.Source, want)
+	}
+	if file.TapeSize != 1 {
+		t.Errorf("tape size = %d, want the project's", file.TapeSize)
+	}
+}
+
 // A path is taken as it is: it names no profile, so nothing is inherited from one. The
 // width is the exception, and it is not the profile's — a file inside a project is written
 // in that project's dialect however it was named.
@@ -32,6 +32,8 @@ type FileReport struct {
 	Path    string
 	Results []eval.AssertResult
 	Err     error // the file could not be compiled or run at all
+	// Coverage is which lines this file's run reached. It is nil unless the evaluator counted.
+	Coverage *Coverage
 }
 
 // Passed reports whether every assertion in the file held and nothing went wrong.
@@ -52,6 +54,9 @@ type TestReport struct {
 	Files  []FileReport
 	Passed int
 	Failed int
+	// Coverage is which lines the tests ran, across every file and the modules they named.
+	// It is nil unless the evaluators were built to count.
+	Coverage *Coverage
 }
 
 // brokenFiles counts the files that could not be compiled or run at all.
@@ -83,6 +88,23 @@ func (r TestReport) OK() bool {
 // Which files those are is settled before the session exists: a test file names its own
 // project, and the width it is compiled at comes from there — see TestFiles.
 func (s *Session) Test(ctx context.Context, files []string) (TestReport, error) {
+	return s.test(files, nil)
+}
+
+// TestWatched runs the tests a change affected, out of every test file of the project, which is
+// what "aurora test --watch" does after the first run. The coverage it reports — on the
+// terminal and in the report — is not this run's alone but every file's latest, once this run
+// is recorded in history: a summary of only the files that ran again would disagree with the
+// profile written from the same history.
+func (s *Session) TestWatched(ctx context.Context, affected, files []string, history *CoverageHistory) (TestReport, error) {
+	return s.test(affected, func(report TestReport) *Coverage {
+		return history.Record(report, files)
+	})
+}
+
+// test runs files and writes the report. Covered, when given, answers the coverage to report in
+// place of what these files ran.
+func (s *Session) test(files []string, covered func(TestReport) *Coverage) (TestReport, error) {
 	if err := byteutil.ValidateTapeSize(s.tapeSize); err != nil {
 		return TestReport{}, err
 	}
@@ -92,7 +114,7 @@ func (s *Session) Test(ctx context.Context, files []string) (TestReport, error)
 
 	report := TestReport{Files: make([]FileReport, 0, len(files))}
 	for _, path := range files {
-		file := s.runTestFile(path)
+		file := s.runTestFile(path, &report)
 		for _, result := range file.Results {
 			if result.Passed {
 				report.Passed++
@@ -102,6 +124,9 @@ func (s *Session) Test(ctx context.Context, files []string) (TestReport, error)
 		}
 		report.Files = append(report.Files, file)
 	}
+	if report.Coverage != nil && covered != nil {
+		report.Coverage = covered(report)
+	}
 
 	writeReport(s.stdout, report)
 	return report, nil
@@ -180,8 +205,10 @@ func runRanges(ev *evaluator.Evaluator, program loader.Program) error {
 //
 // It is compiled and run exactly as `aurora run` would compile and run it, because that is
 // what it is: a program that names what it needs, whose modules load once each and run before
-// it. The only thing this does that running does not is read the results afterwards.
-func (s *Session) runTestFile(path string) FileReport {
+// it. The only thing this does that running does not is read the results afterwards — and,
+// when the evaluator counted, which instructions ran, folded into the run's coverage. What ran
+// before a failure is counted too: it ran.
+func (s *Session) runTestFile(path string, run *TestReport) FileReport {
 	report := FileReport{Path: path}
 
 	program, err := s.compile(path)
@@ -196,7 +223,16 @@ func (s *Session) runTestFile(path string) FileReport {
 		return report
 	}
 
-	if err := runRanges(ev, program); err != nil {
+	err = runRanges(ev, program)
+	if hits := ev.Coverage(); hits != nil {
+		report.Coverage = NewCoverage()
+		report.Coverage.Add(program, hits)
+		if run.Coverage == nil {
+			run.Coverage = NewCoverage()
+		}
+		run.Coverage.Merge(report.Coverage)
+	}
+	if err != nil {
 		report.Err = err
 		return report
 	}
@@ -257,7 +293,9 @@ func writeReport(w io.Writer, report TestReport) {
 	}
 	if report.OK() {
 		_, _ = fmt.Fprintln(w, pass(summary))
-		return
+	} else {
+		_, _ = fmt.Fprintln(w, fail(summary))
 	}
-	_, _ = fmt.Fprintln(w, fail(summary))
+
+	writeCoverage(w, report.Coverage)
 }
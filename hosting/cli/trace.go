@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// DefaultTraceLimit is how many steps a trace writes when nobody said. A loop runs for as long
+// as it runs, and a trace with no end is one nobody reads to the end of.
+const DefaultTraceLimit = 1000
+
+// TraceOptions says which steps of a run are written, and how many.
+//
+// Each filter narrows what the others let through, and a filter left empty lets everything
+// through. A step is written when its module, its opcode and its line are all ones asked for.
+type TraceOptions struct {
+	// Limit is how many steps are written at most. Zero means DefaultTraceLimit; the steps
+	// past it are counted and not written.
+	Limit int
+	// Modules are the modules traced, each named the way it is imported or by the path of its
+	// file — which is how the file that was run, which is imported by nobody, is named.
+	Modules []string
+	// OpCodes are the instructions traced, named as the IR writes them ("OpAdd") or without
+	// the prefix, in any case ("add").
+	OpCodes []string
+	// Lines are the source lines traced. A line number is in every file, so it is mostly
+	// useful beside a module.
+	Lines []int
+}
+
+// A Trace is an evaluator.Tracer that writes the steps of a run, one per line, as they happen.
+//
+// The evaluator counts in positions, and knows nothing of files; the trace is told the program
+// before it runs, and its ranges say which module each position belongs to — the same way a
+// coverage count is read.
+type Trace struct {
+	w        io.Writer
+	limit    int
+	modules  []string
+	opcodes  map[byte]bool
+	lines    map[int]bool
+	program  loader.Program
+	tapeSize int
+	written  int
+	dropped  int
+}
+
+// NewTrace answers a trace writing to w, or says which opcode it was asked for that the IR
+// does not have.
+func NewTrace(w io.Writer, opts TraceOptions) (*Trace, error) {
+	t := &Trace{w: w, limit: opts.Limit, modules: opts.Modules}
+	if t.limit <= 0 {
+		t.limit = DefaultTraceLimit
+	}
+	if len(opts.OpCodes) > 0 {
+		t.opcodes = make(map[byte]bool, len(opts.OpCodes))
+		for _, name := range opts.OpCodes {
+			op, ok := opCodeNamed(name)
+			if !ok {
+				return nil, fmt.Errorf("trace: no instruction is called %q", name)
+			}
+			t.opcodes[op] = true
+		}
+	}
+	if len(opts.Lines) > 0 {
+		t.lines = make(map[int]bool, len(opts.Lines))
+		for _, line := range opts.Lines {
+			t.lines[line] = true
+		}
+	}
+	return t, nil
+}
+
+// opCodeNamed answers the opcode a name stands for. The IR only knows how to go the other
+// way, so every byte is asked.
+func opCodeNamed(name string) (byte, bool) {
+	for op := 0; op <= 0xff; op++ {
+		resolved := ir.ResolveOpCode(byte(op))
+		if resolved == "Unknown" {
+			continue
+		}
+		if strings.EqualFold(resolved, name) || strings.EqualFold(strings.TrimPrefix(resolved, "Op"), name) {
+			return byte(op), true
+		}
+	}
+	return 0, false
+}
+
+// Follow tells the trace which program is about to run, and how wide its values are.
+func (t *Trace) Follow(program loader.Program, tapeSize int) {
+	t.program = program
+	t.tapeSize = tapeSize
+}
+
+// Trace writes a step, when it is one that was asked for and there is room left for it.
+func (t *Trace) Trace(step evaluator.Step) {
+	each, ok := t.rangeOf(step.Position)
+	if !ok || !t.wants(step, each) {
+		return
+	}
+	if t.written >= t.limit {
+		t.dropped++
+		return
+	}
+	t.written++
+	_, _ = io.WriteString(t.w, t.format(step, each))
+}
+
+// Close writes how many steps were left out for the limit, when any were.
+func (t *Trace) Close() error {
+	if t.dropped == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(t.w, "... %d more steps not shown (limit %d)\n", t.dropped, t.limit)
+	return err
+}
+
+// rangeOf answers the range a position sits in.
+func (t *Trace) rangeOf(position uint64) (loader.Range, bool) {
+	for _, each := range t.program.Ranges {
+		if position >= each.From && position < each.To {
+			return each, true
+		}
+	}
+	return loader.Range{}, false
+}
+
+func (t *Trace) wants(step evaluator.Step, each loader.Range) bool {
+	if t.opcodes != nil && !t.opcodes[step.OpCode] {
+		return false
+	}
+	if t.lines != nil && !t.lines[step.Origin.Line] {
+		return false
+	}
+	if len(t.modules) > 0 {
+		names := []string{string(each.Module), each.Filename, displayPath(each.Filename)}
+		return slices.ContainsFunc(t.modules, func(asked string) bool {
+			return asked != "" && slices.Contains(names, asked)
+		})
+	}
+	return true
+}
+
+// format writes a step as its position, where it was written, the opcode indented by how many
+// calls deep it ran, what its operands were worth and what it answered.
+func (t *Trace) format(step evaluator.Step, each loader.Range) string {
+	at := displayPath(each.Filename)
+	if step.Origin.Known() {
+		at = fmt.Sprintf("%s:%d:%d", at, step.Origin.Line, step.Origin.Column)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%6d  %-20s %s%s", step.Position, at, strings.Repeat("  ", step.Depth), ir.ResolveOpCode(step.OpCode))
+	for i, operand := range step.Operands {
+		b.WriteString(" " + t.operand(operand, step.Values[i]))
+	}
+	switch {
+	case step.Err != nil:
+		fmt.Fprintf(b, " !! %v", step.Err)
+	case step.Result != nil:
+		fmt.Fprintf(b, " -> %s", byteutil.DecimalOf(step.Result, t.tapeSize))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// operand writes a value as the number it is, a name as it was spelled, and anything else as
+// the IR writes it.
+func (t *Trace) operand(operand ir.Operand, value []byte) string {
+	switch operand.Kind() {
+	case ir.KindRef, ir.KindImm:
+		if value == nil {
+			// A ref to something no instruction left: a scope's own label, say.
+			break
+		}
+		return byteutil.DecimalOf(value, t.tapeSize)
+	case ir.KindName:
+		return string(operand.Bytes())
+	}
+	return operand.String()
+}
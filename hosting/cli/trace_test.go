@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// traced runs the entry of a project under a trace, and answers with what the trace wrote.
+func traced(t *testing.T, entry string, opts TraceOptions) string {
+	t.Helper()
+
+	var written bytes.Buffer
+	trace, err := NewTrace(&written, opts)
+	if err != nil {
+		t.Fatalf("tracing: %v", err)
+	}
+	if err := newSession(t, sessionOpts{trace: trace}).Run(t.Context(), entry); err != nil {
+		t.Fatalf("running: %v", err)
+	}
+	return written.String()
+}
+
+// A step names the file and line it was written on, the values it was handed and what it
+// answered; a body is indented under the call that ran it.
+func TestTraceWritesEveryStep(t *testing.T) {
+	projectOf(t, map[string]string{
+		"src/main.ar": "ident twice = defer { feed(0) * 2; };\ntwice(3);",
+	})
+	got := traced(t, "src/main.ar", TraceOptions{})
+
+	for _, want := range []string{
+		"src/main.ar:1:31       OpMultiply 3 2 -> 6\n",
+		"src/main.ar:2:1      OpCall twice 3 -> 6\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace is missing %q:\n%s", want, got)
+		}
+	}
+}
+
+// Every filter narrows the trace, and the three narrow it together.
+func TestTraceFilters(t *testing.T) {
+	projectOf(t, map[string]string{
+		"src/geometry.ar": "ident side = 2 + 2;\nident area = defer { feed(0) * feed(0); };",
+		"src/main.ar":     "use geometry as g;\nident a = 1 + 1;\ng.area(g.side);",
+	})
+
+	cases := []struct {
+		name    string
+		opts    TraceOptions
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "by module",
+			opts:    TraceOptions{Modules: []string{"geometry"}},
+			want:    []string{"src/geometry.ar:1:"},
+			notWant: []string{"src/main.ar"},
+		},
+		{
+			name:    "by opcode, spelled either way",
+			opts:    TraceOptions{OpCodes: []string{"add", "OpMultiply"}},
+			want:    []string{"OpAdd 2 2 -> 4", "OpAdd 1 1 -> 2", "OpMultiply 4 4 -> 16"},
+			notWant: []string{"OpCall", "OpIdent"},
+		},
+		{
+			name:    "by line, in one module",
+			opts:    TraceOptions{Modules: []string{"src/main.ar"}, Lines: []int{2}, OpCodes: []string{"add"}},
+			want:    []string{"OpAdd 1 1 -> 2"},
+			notWant: []string{"OpAdd 2 2"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := traced(t, "src/main.ar", c.opts)
+			for _, want := range c.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("trace is missing %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range c.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("trace holds %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+// A trace stops writing at its limit, and says how much it left out.
+func TestTraceIsBounded(t *testing.T) {
+	projectOf(t, map[string]string{
+		"src/main.ar": "1 + 1;\n2 + 2;\n3 + 3;\n4 + 4;",
+	})
+	got := traced(t, "src/main.ar", TraceOptions{Limit: 2, OpCodes: []string{"add"}})
+
+	if lines := strings.Count(got, "OpAdd"); lines != 2 {
+		t.Errorf("wrote %d steps, want 2:\n%s", lines, got)
+	}
+	if !strings.HasSuffix(got, "... 2 more steps not shown (limit 2)\n") {
+		t.Errorf("trace does not say what it left out:\n%s", got)
+	}
+}
+
+func TestTraceRefusesAnInstructionThatIsNotThere(t *testing.T) {
+	if _, err := NewTrace(&bytes.Buffer{}, TraceOptions{OpCodes: []string{"teleport"}}); err == nil {
+		t.Error("tracing an instruction that does not exist was accepted")
+	}
+}
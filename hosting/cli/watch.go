@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/guiferpa/aurora/shared/manifest"
+)
+
+// The loop of "--watch": look at the files a project is made of, and when some of them change
+// and then stop changing, run again what they affect.
+//
+// It polls rather than subscribing to the operating system. A subscription is a dependency
+// per platform and an event per write, and an editor saving one file writes it several times;
+// a poll asks a question whose answer is already what is wanted — what is different now — and
+// the cost is a walk of a source tree, which is small. It is the same trade the language
+// server makes for a project's width: remember the modification time, and look again.
+
+// WatchInterval is how often the files are looked at.
+const WatchInterval = 200 * time.Millisecond
+
+// WatchQuiet is how long nothing may change before a run starts. Saving five files is five
+// changes a few milliseconds apart, and it is one edit: they are collected until the tree is
+// quiet, and run once.
+const WatchQuiet = 300 * time.Millisecond
+
+// stamp is what a file looked like: a write changes the time, and almost always the size —
+// the size is there for a file system whose clock is coarser than two saves.
+type stamp struct {
+	modified time.Time
+	size     int64
+}
+
+// watched answers whether a file is one whose change can change what a program answers: a
+// source file, or the manifest that says how wide a value is and where modules are.
+func watched(path string) bool {
+	return strings.HasSuffix(path, SourceExtension) || filepath.Base(path) == manifest.Filename
+}
+
+// snapshot answers what every watched file under the paths looks like now. A path that is not
+// there is not an error: a project's source root may not exist yet, and creating it is a
+// change like any other.
+func snapshot(paths []string) map[string]stamp {
+	seen := make(map[string]stamp)
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !watched(path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			seen[filepath.Clean(path)] = stamp{modified: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+	return seen
+}
+
+// watcher remembers what the files looked like and what has changed since the last run.
+//
+// It is told the time rather than reading a clock, so that whether five saves make one run is
+// a question a test can ask without sleeping.
+type watcher struct {
+	paths   []string
+	quiet   time.Duration
+	seen    map[string]stamp
+	pending map[string]bool
+	last    time.Time
+}
+
+func newWatcher(paths []string, quiet time.Duration) *watcher {
+	return &watcher{paths: paths, quiet: quiet, seen: snapshot(paths), pending: make(map[string]bool)}
+}
+
+// poll looks at the files, and answers what changed once they have stopped changing — nothing
+// while they still are, and nothing when nothing did. A file that appeared or went away is a
+// change the same as one that was written.
+func (w *watcher) poll(now time.Time) []string {
+	current := snapshot(w.paths)
+	changed := false
+	for path, was := range w.seen {
+		if is, ok := current[path]; !ok || is != was {
+			w.pending[path] = true
+			changed = true
+		}
+	}
+	for path := range current {
+		if _, ok := w.seen[path]; !ok {
+			w.pending[path] = true
+			changed = true
+		}
+	}
+	w.seen = current
+
+	if changed {
+		w.last = now
+		return nil
+	}
+	if len(w.pending) == 0 || now.Sub(w.last) < w.quiet {
+		return nil
+	}
+
+	batch := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		batch = append(batch, path)
+	}
+	slices.Sort(batch)
+	w.pending = make(map[string]bool)
+	return batch
+}
+
+// WatchOptions is what a watch is built with.
+type WatchOptions struct {
+	// Paths are the files and directories to look at: the source root, the manifest, and the
+	// file that was named when it sits outside both.
+	Paths []string
+	// Interval and Quiet default to WatchInterval and WatchQuiet.
+	Interval time.Duration
+	Quiet    time.Duration
+	// Screen is cleared before each run and told what changed. Nil says nothing.
+	Screen io.Writer
+	// Affects answers whether a change is one the run cares about. A change it does not care
+	// about leaves the screen as it is, since what is on it is still the answer. Nil means
+	// every change does.
+	Affects func(changed []string) bool
+}
+
+// Watch runs once, and then again every time the files change, until the context ends.
+//
+// What a run does is the caller's: it is handed what changed — nothing, the first time — and
+// decides what that affects. A run that fails is reported and waited on like one that passed,
+// since the next save is usually the fix.
+func Watch(ctx context.Context, opts WatchOptions, run func(changed []string) error) error {
+	interval, quiet := opts.Interval, opts.Quiet
+	if interval <= 0 {
+		interval = WatchInterval
+	}
+	if quiet <= 0 {
+		quiet = WatchQuiet
+	}
+
+	w := newWatcher(opts.Paths, quiet)
+	redraw(opts.Screen, nil, run(nil))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			changed := w.poll(now)
+			if len(changed) == 0 {
+				continue
+			}
+			if opts.Affects != nil && !opts.Affects(changed) {
+				continue
+			}
+			clearScreen(opts.Screen)
+			redraw(opts.Screen, changed, run(changed))
+		}
+	}
+}
+
+// clearScreen moves the cursor home and clears what is below it, so every run starts on an
+// empty terminal rather than scrolling under the last one.
+func clearScreen(w io.Writer) {
+	if w == nil {
+		return
+	}
+	_, _ = fmt.Fprint(w, "\x1b[H\x1b[2J")
+}
+
+// redraw says how the run went and that the watch carries on.
+func redraw(w io.Writer, changed []string, err error) {
+	if w == nil {
+		return
+	}
+	dim := color.New(color.Faint).SprintFunc()
+	fail := color.New(color.FgRed).SprintFunc()
+
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "%s %s\n", fail("error:"), err)
+	}
+	line := "watching for changes, Ctrl+C stops"
+	if len(changed) > 0 {
+		shown := make([]string, 0, len(changed))
+		for _, path := range changed {
+			shown = append(shown, displayPath(path))
+		}
+		line = fmt.Sprintf("changed %s; %s", strings.Join(shown, ", "), line)
+	}
+	_, _ = fmt.Fprintf(w, "\n%s\n", dim(line))
+}
+
+// WatchedPaths answers what a target's program can be changed by: the directory its modules
+// resolve from, the manifest when there is one, and the directory of the file itself — which
+// for a loose file may be neither, and which is where a test file appearing is seen.
+func WatchedPaths(target Target) []string {
+	paths := []string{target.SourceRoot, filepath.Dir(target.Source)}
+	dir, err := filepath.Abs(filepath.Dir(target.Source))
+	if err != nil {
+		return paths
+	}
+	if root, err := manifest.FindProjectRootFrom(dir); err == nil {
+		paths = append(paths, filepath.Join(root, manifest.Filename))
+	}
+	return paths
+}
+
+// Affected answers whether a change to any of the files touches the program a source is the
+// entry of: the source itself, or a module it reads, however far down.
+//
+// The module graph is the resolver's, asked again, because an edit may be exactly what added
+// or removed an import. A program that no longer resolves is affected — running it is how the
+// error is shown. A change to a manifest affects everything, since it can change what every
+// file means.
+func (s *Session) Affected(source string, changed []string) bool {
+	if len(changed) == 0 {
+		return true
+	}
+	touched := make(map[string]bool, len(changed))
+	for _, path := range changed {
+		if filepath.Base(path) == manifest.Filename {
+			return true
+		}
+		touched[absolute(path)] = true
+	}
+
+	if s.resolver == nil {
+		return true
+	}
+	modules, err := s.resolver.Resolve(source)
+	if err != nil {
+		return true
+	}
+	for _, each := range modules {
+		if touched[absolute(each.Tree.Filename)] {
+			return true
+		}
+	}
+	return false
+}
+
+// AffectedTests answers the test files a change touches, in the order they were given.
+func (s *Session) AffectedTests(files []string, changed []string) []string {
+	affected := make([]string, 0, len(files))
+	for _, file := range files {
+		if s.Affected(file, changed) {
+			affected = append(affected, file)
+		}
+	}
+	return affected
+}
+
+// absolute answers a path as the same string however it was named: a module is read from a
+// relative source root and a change is found under whatever path was watched.
+func absolute(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Saving five files is one edit. They are collected while they keep changing and handed over
+// once, when nothing has changed for the quiet period.
+func TestFiveSavesAreOneRun(t *testing.T) {
+	dir := t.TempDir()
+	w := newWatcher([]string{dir}, 100*time.Millisecond)
+	start := time.Now()
+
+	for i, name := range []string{"a.ar", "b.ar", "c.ar", "d.ar", "e.ar"} {
+		writeAt(t, dir, name, "printd 1;\n")
+		if got := w.poll(start.Add(time.Duration(i) * 10 * time.Millisecond)); got != nil {
+			t.Fatalf("a run was asked for while files were still changing: %v", got)
+		}
+	}
+	if got := w.poll(start.Add(90 * time.Millisecond)); got != nil {
+		t.Fatalf("a run was asked for before the tree was quiet: %v", got)
+	}
+
+	batch := w.poll(start.Add(200 * time.Millisecond))
+	if len(batch) != 5 {
+		t.Fatalf("handed over %v, want the five files at once", batch)
+	}
+	if again := w.poll(start.Add(time.Second)); again != nil {
+		t.Errorf("the same change was handed over twice: %v", again)
+	}
+}
+
+// Only source files and the manifest are watched: a binary being written by a build is not a
+// reason to build again.
+func TestOnlySourceAndTheManifestAreWatched(t *testing.T) {
+	dir := t.TempDir()
+	w := newWatcher([]string{dir}, 0)
+
+	writeAt(t, dir, "bin/main", "bytes")
+	writeAt(t, dir, "notes.txt", "words")
+	w.poll(time.Now())
+	if got := w.poll(time.Now().Add(time.Second)); got != nil {
+		t.Errorf("a change nobody compiles was handed over: %v", got)
+	}
+
+	writeAt(t, dir, "aurora.toml", "[project]\n")
+	w.poll(time.Now())
+	if got := w.poll(time.Now().Add(time.Second)); len(got) != 1 {
+		t.Errorf("the manifest changing was not seen: %v", got)
+	}
+}
+
+// A file going away is a change like any other: a program importing it no longer compiles.
+func TestARemovedFileIsAChange(t *testing.T) {
+	dir := t.TempDir()
+	path := writeAt(t, dir, "gone.ar", "printd 1;\n")
+	w := newWatcher([]string{dir}, 0)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	w.poll(time.Now())
+	if got := w.poll(time.Now().Add(time.Second)); !slices.Equal(got, []string{filepath.Clean(path)}) {
+		t.Errorf("handed over %v, want the file that went away", got)
+	}
+}
+
+// What a change affects is read from the module graph: a test is run again when the module it
+// names changes, and left alone when a module it never reads does.
+func TestAChangeAffectsTheTestsThatReadIt(t *testing.T) {
+	dir := project(t)
+	writeAt(t, dir, "src/main.ar", "printd 1;\n")
+	geometry := writeAt(t, dir, "src/geometry.ar", "ident area = defer { feed(0) * feed(1); };\n")
+	label := writeAt(t, dir, "src/label.ar", "ident name = 1;\n")
+	area := writeAt(t, dir, "src/area.test.ar", "use geometry as g;\nassert(g.area(2, 3) equals 6, \"area\");\n")
+	alone := writeAt(t, dir, "src/alone.test.ar", "assert(1 equals 1, \"alone\");\n")
+
+	s := newSession(t, sessionOpts{})
+	files := []string{alone, area}
+
+	if got := s.AffectedTests(files, []string{geometry}); !slices.Equal(got, []string{area}) {
+		t.Errorf("a change to geometry affects %v, want only the test naming it", got)
+	}
+	if got := s.AffectedTests(files, []string{label}); len(got) != 0 {
+		t.Errorf("a change to a module nobody reads affects %v", got)
+	}
+	if got := s.AffectedTests(files, []string{alone}); !slices.Equal(got, []string{alone}) {
+		t.Errorf("a change to a test affects %v, want that test", got)
+	}
+	if got := s.AffectedTests(files, []string{filepath.Join(dir, "aurora.toml")}); len(got) != 2 {
+		t.Errorf("a change to the manifest affects %v, want everything", got)
+	}
+}
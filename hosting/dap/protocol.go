@@ -0,0 +1,142 @@
+package dap
+
+import "encoding/json"
+
+// The part of the Debug Adapter Protocol the adapter speaks.
+// https://microsoft.github.io/debug-adapter-protocol/specification
+//
+// A message is framed the way a language server's is — a Content-Length header and a JSON
+// body — so the framing is the language server's own. What is inside differs: no JSON-RPC, a
+// sequence number on everything, and a type saying whether it is a request, a response or an
+// event.
+
+// A Message is what every message carries.
+type Message struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+}
+
+// A Request is a message from the client, asking for a command.
+type Request struct {
+	Message
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// A Response answers a request, by its sequence number.
+type Response struct {
+	Message
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	// ErrorMessage is shown to whoever asked, so it is written for them.
+	ErrorMessage string `json:"message,omitempty"`
+	Body         any    `json:"body,omitempty"`
+}
+
+// An Event is something the adapter has to say without being asked: the program stopped,
+// printed, ended.
+type Event struct {
+	Message
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// https://microsoft.github.io/debug-adapter-protocol/specification#Types_Capabilities
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+// LaunchArguments are what a launch configuration says, past what the protocol fixes.
+type LaunchArguments struct {
+	// Program is a profile of aurora.toml or a file, the way "aurora run" reads its target.
+	Program string `json:"program"`
+	// Args are what the program reads with feed(n).
+	Args []string `json:"args,omitempty"`
+	// Cwd is where the program is read from: a profile's project is looked for from it, and a
+	// relative path is read against it. Empty is wherever the adapter was started.
+	Cwd         string `json:"cwd,omitempty"`
+	StopOnEntry bool   `json:"stopOnEntry,omitempty"`
+	NoDebug     bool   `json:"noDebug,omitempty"`
+}
+
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+// https://microsoft.github.io/debug-adapter-protocol/specification#Types_Breakpoint
+type Breakpoint struct {
+	Verified bool   `json:"verified"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Source   Source `json:"source"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// https://microsoft.github.io/debug-adapter-protocol/specification#Types_StackFrame
+type StackFrame struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type StackTraceArguments struct {
+	ThreadID int `json:"threadId"`
+}
+
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+// https://microsoft.github.io/debug-adapter-protocol/specification#Types_Scope
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+// https://microsoft.github.io/debug-adapter-protocol/specification#Types_Variable
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId,omitempty"`
+}
+
+type StoppedEventBody struct {
+	Reason            string `json:"reason"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	Description       string `json:"description,omitempty"`
+}
+
+type OutputEventBody struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
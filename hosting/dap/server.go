@@ -0,0 +1,589 @@
+// Package dap is a debug adapter: "aurora debug" for an editor, spoken in the Debug Adapter
+// Protocol.
+//
+// What stops a program is the same as in the terminal — an evaluator.Monitor asking a
+// debug.Stepper before every instruction — and so is the length of a step. What differs is who
+// is asked what to do next. The terminal debugger reads a command inside the monitor; here the
+// program runs on a goroutine of its own, and a stop is that goroutine waiting on a channel
+// while the editor asks for the stack and the variables, until a request lets it go.
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/hosting/cli"
+	"github.com/guiferpa/aurora/hosting/debug"
+	"github.com/guiferpa/aurora/hosting/lsp/messenger"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/shared/printer"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// threadID is the one thread an Aurora program has.
+const threadID = 1
+
+// maxMessageBytes caps a single message, as the language server does.
+const maxMessageBytes = 16 << 20
+
+// errDisconnected stops a program whose client went away: the monitor answers with it, and
+// the evaluator stops the way it does for an instruction that fails.
+var errDisconnected = errors.New("the client disconnected")
+
+// errRunning answers a question only a stopped program can answer.
+var errRunning = errors.New("the program is running")
+
+// Options is what an adapter is built from.
+type Options struct {
+	// Compile answers the program a launch names, and how wide its values are. The target is
+	// read the way "aurora run" reads one — a profile of aurora.toml, or a file — as if it had
+	// been run in dir. The adapter never moves the process there: it outlives the launch.
+	Compile func(target, dir string) (loader.Program, int, error)
+	// Logger is told every message. Nil logs nothing.
+	Logger *log.Logger
+}
+
+// A Server is one debugging session: one client, one program.
+type Server struct {
+	compile func(target, dir string) (loader.Program, int, error)
+	log     *log.Logger
+
+	w       io.Writer
+	writing sync.Mutex
+	seq     int
+
+	// mu guards everything below, which both the goroutine reading requests and the one
+	// running the program reach.
+	mu       sync.Mutex
+	launch   LaunchArguments
+	stepper  *debug.Stepper
+	ev       *evaluator.Evaluator
+	tapeSize int
+	// configured says the client has sent its breakpoints; a program starts once it is
+	// launched and configured, in whichever order those arrive.
+	configured bool
+	started    bool
+	// reason is why the next stop is made, when it is not a breakpoint.
+	reason string
+	// release lets a stopped program go on. It is nil while the program runs.
+	release chan struct{}
+	// ended says the client wants the program gone.
+	ended bool
+	// pending are breakpoints asked for before there was a program to put them in, by the
+	// path of their file.
+	pending map[string][]int
+	done    chan struct{}
+}
+
+// New answers an adapter with no client yet.
+func New(opts Options) *Server {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	return &Server{
+		compile: opts.Compile,
+		log:     logger,
+		reason:  "entry",
+		pending: make(map[string][]int),
+		done:    make(chan struct{}),
+	}
+}
+
+// A handler answers a request with a body, or with why it could not. then, when there is one,
+// runs once the response is written: a program let go before its client heard it was let go
+// could stop again, and say so, before the answer to the request that let it go.
+type handler func(s *Server, args json.RawMessage) (body any, then func(), err error)
+
+var handlers = map[string]handler{
+	"initialize":        (*Server).initialize,
+	"launch":            (*Server).launchProgram,
+	"setBreakpoints":    (*Server).setBreakpoints,
+	"configurationDone": (*Server).configurationDone,
+	"threads":           (*Server).threads,
+	"stackTrace":        (*Server).stackTrace,
+	"scopes":            (*Server).scopes,
+	"variables":         (*Server).variables,
+	"evaluate":          (*Server).evaluate,
+	"continue":          resuming(debug.Continue),
+	"next":              resuming(debug.StepOver),
+	"stepIn":            resuming(debug.StepLine),
+	"stepOut":           resuming(debug.StepOut),
+	"pause":             (*Server).pause,
+	"terminate":         (*Server).terminate,
+	"disconnect":        (*Server).terminate,
+}
+
+// Serve reads requests and answers them until the client disconnects or stops writing. A
+// program still running then is stopped.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.end()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	scanner.Split(messenger.Split)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(body(scanner.Bytes()), &req); err != nil {
+			return err
+		}
+		s.log.Println(req.Command, string(req.Arguments))
+
+		h, ok := handlers[req.Command]
+		if !ok {
+			s.respond(req, nil, fmt.Errorf("%s is not supported", req.Command))
+			continue
+		}
+		answer, then, err := h(s, req.Arguments)
+		s.respond(req, answer, err)
+		if then != nil {
+			then()
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// body is a framed message without its header.
+func body(framed []byte) []byte {
+	_, content, _ := bytes.Cut(framed, []byte("\r\n\r\n"))
+	return content
+}
+
+// end stops the program, if it runs, and waits for it to be gone.
+func (s *Server) end() {
+	s.mu.Lock()
+	s.ended = true
+	started := s.started
+	s.letGo()
+	s.mu.Unlock()
+	if started {
+		<-s.done
+	}
+}
+
+// send writes a message, numbered as it is written: two goroutines write, and a client reads
+// the numbers in the order they arrive.
+func (s *Server) send(header *Message, msg any) {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+	s.seq++
+	header.Seq = s.seq
+	if _, err := messenger.Write(s.w, msg); err != nil {
+		s.log.Println(err)
+	}
+}
+
+func (s *Server) respond(req Request, body any, err error) {
+	res := Response{
+		Message:    Message{Type: "response"},
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		res.ErrorMessage = err.Error()
+	}
+	s.send(&res.Message, &res)
+}
+
+func (s *Server) event(name string, body any) {
+	ev := Event{Message: Message{Type: "event"}, Event: name, Body: body}
+	s.send(&ev.Message, &ev)
+}
+
+func (s *Server) initialize(json.RawMessage) (any, func(), error) {
+	return Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsEvaluateForHovers:        true,
+		SupportsTerminateRequest:         true,
+	}, nil, nil
+}
+
+// launchProgram compiles what the launch names. The client is told it can send its
+// breakpoints once there is a program to check them against, which is what "initialized"
+// means.
+func (s *Server) launchProgram(raw json.RawMessage) (any, func(), error) {
+	var args LaunchArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, nil, err
+	}
+	if args.Program == "" {
+		return nil, nil, errors.New("a launch names a program: a profile of aurora.toml or an .ar file")
+	}
+	program, tapeSize, err := s.compile(args.Program, args.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.launch, s.tapeSize = args, tapeSize
+	s.stepper = debug.NewStepper(program)
+	if !args.StopOnEntry {
+		s.stepper.Resume(debug.Continue)
+		s.reason = "step"
+	}
+	out := &output{server: s, category: "stdout"}
+	s.ev = evaluator.New(evaluator.NewEvaluatorOptions{
+		PrintBytes:   printer.Bytes(out, tapeSize),
+		PrintChars:   printer.Chars(out, tapeSize),
+		PrintDecimal: printer.Decimal(out, tapeSize),
+		Args:         cli.ParseArgs(args.Args),
+		TapeSize:     tapeSize,
+		Monitor:      s,
+	})
+	for path, lines := range s.pending {
+		s.breakAt(path, lines)
+	}
+	return nil, func() {
+		s.event("initialized", nil)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.start()
+	}, nil
+}
+
+// configurationDone says the client has sent everything it had to, and the program may start.
+func (s *Server) configurationDone(json.RawMessage) (any, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configured = true
+	return nil, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.start()
+	}, nil
+}
+
+// start runs the program once it is both launched and configured. It is called with mu held.
+func (s *Server) start() {
+	if s.started || s.stepper == nil || !s.configured {
+		return
+	}
+	s.started = true
+	go s.run(s.ev, s.stepper.Program())
+}
+
+// run runs every module of the program, and tells the client how it ended.
+func (s *Server) run(ev *evaluator.Evaluator, program loader.Program) {
+	defer close(s.done)
+
+	code := 0
+	for _, each := range program.Ranges {
+		_, err := ev.EvaluateModule(program.Instructions, each.From, each.To, string(each.Module))
+		if errors.Is(err, errDisconnected) {
+			return
+		}
+		if err != nil {
+			s.event("output", OutputEventBody{Category: "stderr", Output: err.Error() + "\n"})
+			code = 1
+			break
+		}
+	}
+	s.event("exited", map[string]int{"exitCode": code})
+	s.event("terminated", nil)
+}
+
+// Before is asked before every instruction runs, on the program's goroutine. A stop tells the
+// client, then waits for a request to let the program go.
+func (s *Server) Before(position uint64, inst ir.Instruction, depth int) error {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return errDisconnected
+	}
+	if s.launch.NoDebug || !s.stepper.Stops(position, inst, depth) {
+		s.mu.Unlock()
+		return nil
+	}
+	reason := s.reason
+	if s.stepper.AtBreak() && reason != "pause" && reason != "entry" {
+		reason = "breakpoint"
+	}
+	release := make(chan struct{})
+	s.release = release
+	s.mu.Unlock()
+
+	s.event("stopped", StoppedEventBody{Reason: reason, ThreadID: threadID, AllThreadsStopped: true})
+	<-release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return errDisconnected
+	}
+	return nil
+}
+
+// letGo lets a stopped program go on. It is called with mu held.
+func (s *Server) letGo() {
+	if s.release != nil {
+		close(s.release)
+		s.release = nil
+	}
+}
+
+// stopped answers the stepper of a program that is stopped, or says that it is not. It is
+// called with mu held.
+func (s *Server) stopped() (*debug.Stepper, error) {
+	if s.stepper == nil {
+		return nil, errors.New("no program was launched")
+	}
+	if s.release == nil {
+		return nil, errRunning
+	}
+	return s.stepper, nil
+}
+
+// resuming answers a request that lets a stopped program go on, as far as a mode takes it.
+func resuming(m debug.Mode) handler {
+	return func(s *Server, _ json.RawMessage) (any, func(), error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		stepper, err := s.stopped()
+		if err != nil {
+			return nil, nil, err
+		}
+		stepper.Resume(m)
+		s.reason = "step"
+		var body any
+		if m == debug.Continue {
+			body = map[string]bool{"allThreadsContinued": true}
+		}
+		return body, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.letGo()
+		}, nil
+	}
+}
+
+// pause stops a running program on the next instruction it runs.
+func (s *Server) pause(json.RawMessage) (any, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stepper == nil || s.release != nil {
+		return nil, nil, nil
+	}
+	s.stepper.Pause()
+	s.reason = "pause"
+	return nil, nil, nil
+}
+
+// terminate stops the program where it is. A program stopped is let go, and finds the client
+// gone at the next instruction.
+func (s *Server) terminate(json.RawMessage) (any, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+	return nil, s.letGo, nil
+}
+
+func (s *Server) threads(json.RawMessage) (any, func(), error) {
+	return map[string][]Thread{"threads": {{ID: threadID, Name: "main"}}}, nil, nil
+}
+
+// setBreakpoints puts the breakpoints of one file in place of those it had. A line nothing
+// runs on is refused, with why, rather than set and never reached.
+func (s *Server) setBreakpoints(raw json.RawMessage) (any, func(), error) {
+	var args SetBreakpointsArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, nil, err
+	}
+	lines := make([]int, 0, len(args.Breakpoints))
+	for _, each := range args.Breakpoints {
+		lines = append(lines, each.Line)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stepper == nil {
+		s.pending[args.Source.Path] = lines
+		breakpoints := make([]Breakpoint, 0, len(lines))
+		for _, line := range lines {
+			breakpoints = append(breakpoints, Breakpoint{Line: line, Source: args.Source, Message: "the program is not launched yet"})
+		}
+		return map[string][]Breakpoint{"breakpoints": breakpoints}, nil, nil
+	}
+	return map[string][]Breakpoint{"breakpoints": s.breakAt(args.Source.Path, lines)}, nil, nil
+}
+
+// breakAt puts a file's breakpoints in place. It is called with mu held.
+func (s *Server) breakAt(path string, lines []int) []Breakpoint {
+	s.stepper.ClearBreaks(path)
+	breakpoints := make([]Breakpoint, 0, len(lines))
+	for _, line := range lines {
+		at, err := s.stepper.Locate(path, line)
+		if err != nil {
+			breakpoints = append(breakpoints, Breakpoint{Line: line, Source: Source{Path: path}, Message: err.Error()})
+			continue
+		}
+		s.stepper.Break(at)
+		breakpoints = append(breakpoints, Breakpoint{Verified: true, Line: line, Source: sourceOf(at.File)})
+	}
+	return breakpoints
+}
+
+// stackTrace answers the calls the program is in, the one it is stopped in first: each is
+// where it has got to, and the outermost is the module the program is running at the top of.
+func (s *Server) stackTrace(json.RawMessage) (any, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stepper, err := s.stopped()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	at, position, inst := stepper.At()
+	calls := stepper.Calls()
+	top := "main"
+	if each, ok := stepper.RangeOf(position); ok && len(calls) == 0 && each.Module != "" {
+		top = string(each.Module)
+	}
+
+	frames := make([]StackFrame, 0, len(calls)+1)
+	column := max(inst.GetOrigin().Column, 1)
+	for open := len(calls); open >= 0; open-- {
+		name := top
+		if open > 0 {
+			name = calls[open-1].Name
+		}
+		frames = append(frames, StackFrame{ID: len(frames) + 1, Name: name, Source: sourceOf(at.File), Line: at.Line, Column: column})
+		if open > 0 {
+			at, column = calls[open-1].Site, 1
+		}
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil, nil
+}
+
+// scopes answers one scope per frame, holding what the frame sees. A frame's id is what its
+// variables are asked for by.
+func (s *Server) scopes(raw json.RawMessage) (any, func(), error) {
+	var args ScopesArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, nil, err
+	}
+	return map[string][]Scope{"scopes": {{Name: "Locals", VariablesReference: args.FrameID}}}, nil, nil
+}
+
+// variables answers what a frame of the stack holds: what it was fed, the names bound in it
+// and the scopes it deferred.
+//
+// The environ chain is a chain of scopes, and a call is one of them among the blocks it
+// opens. Each environ a call opened closes a frame: the ones inside it, up to the next call,
+// are that call's, and what is left past the last is the program's.
+func (s *Server) variables(raw json.RawMessage) (any, func(), error) {
+	var args VariablesArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.stopped(); err != nil {
+		return nil, nil, err
+	}
+
+	groups := make([][]evaluator.Frame, 1)
+	for _, frame := range s.ev.Frames() {
+		groups[len(groups)-1] = append(groups[len(groups)-1], frame)
+		if frame.Call {
+			groups = append(groups, nil)
+		}
+	}
+	at := args.VariablesReference - 1
+	if at < 0 || at >= len(groups) || len(groups[at]) == 0 {
+		return map[string][]Variable{"variables": {}}, nil, nil
+	}
+	return map[string][]Variable{"variables": s.variablesOf(groups[at])}, nil, nil
+}
+
+// variablesOf lists the environs of one frame, from the innermost: a name bound in a block
+// hides the same name bound around it, the way the program reads it.
+func (s *Server) variablesOf(frames []evaluator.Frame) []Variable {
+	variables := make([]Variable, 0)
+	for at, argument := range frames[0].Arguments {
+		variables = append(variables, Variable{Name: fmt.Sprintf("feed(%d)", at), Value: byteutil.DecimalOf(argument, s.tapeSize)})
+	}
+	seen := make(map[string]bool)
+	for _, frame := range frames {
+		for _, binding := range frame.Bindings {
+			if seen[binding.Name] {
+				continue
+			}
+			seen[binding.Name] = true
+			variables = append(variables, Variable{Name: binding.Name, Value: byteutil.DecimalOf(binding.Value, s.tapeSize)})
+		}
+	}
+	for _, frame := range frames {
+		for _, scope := range frame.Scopes {
+			variables = append(variables, Variable{
+				Name:  fmt.Sprintf("defer %d", scope.Index),
+				Value: fmt.Sprintf("instructions %d to %d", scope.From, scope.To),
+			})
+		}
+	}
+	return variables
+}
+
+// evaluate answers what a name holds, found the way the instruction about to run would find
+// it. It is what an editor shows on hover and in a watch.
+func (s *Server) evaluate(raw json.RawMessage) (any, func(), error) {
+	var args EvaluateArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stepper, err := s.stopped()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, ok := s.ev.Lookup(args.Expression)
+	if !ok {
+		_, position, _ := stepper.At()
+		if each, in := stepper.RangeOf(position); in && each.Module != "" {
+			value, ok = s.ev.Lookup(module.Qualify(each.Module, args.Expression))
+		}
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not bound here", args.Expression)
+	}
+	result := fmt.Sprintf("%s (bytes %v, text %q)", byteutil.DecimalOf(value, s.tapeSize), value, byteutil.TextOf(value, s.tapeSize))
+	return map[string]any{"result": result, "variablesReference": 0}, nil, nil
+}
+
+// sourceOf answers a file the way a client opens one: by its absolute path.
+func sourceOf(file string) Source {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	return Source{Name: filepath.Base(file), Path: path}
+}
+
+// output is a writer that sends what is written to the client, as an output event.
+type output struct {
+	server   *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.server.event("output", OutputEventBody{Category: o.category, Output: string(p)})
+	return len(p), nil
+}
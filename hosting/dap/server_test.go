@@ -0,0 +1,411 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/lsp/messenger"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/resolver"
+	"github.com/guiferpa/aurora/shared/manifest"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+const fib = `ident fib = defer {
+  ident n = feed(0);
+  if n smaller 2 { n; } else { fib(n - 1) + fib(n - 2); };
+};
+printd fib(4);
+`
+
+// compileIn writes a project's files, stands in it, and answers what compiles its
+// src/main.ar, whatever a launch names: the target is cmd/aurorada's to read.
+func compileIn(t *testing.T, files map[string]string) func(string, string) (loader.Program, int, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, source := range files {
+		at := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(at), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(at, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	lx, ps := lexer.New(), parser.New()
+	rs := resolver.New(resolver.Options{
+		SourceRoot: manifest.DefaultSourceRoot,
+		Read:       os.ReadFile,
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return ps.Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
+	return func(string, string) (loader.Program, int, error) {
+		modules, err := rs.Resolve(filepath.Join("src", "main.ar"))
+		if err != nil {
+			return loader.Program{}, 0, err
+		}
+		program, err := loader.Load(modules, emitter.New(emitter.NewEmitterOptions{}).EmitProgram)
+		return program, 8, err
+	}
+}
+
+// received is any message from the adapter, read loosely: a test looks at what it asked about.
+type received struct {
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// A client is an editor, as far as the adapter can tell: it writes requests and reads whatever
+// comes back.
+type client struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan received
+	seq      int
+	done     chan error
+}
+
+func connect(t *testing.T, compile func(string, string) (loader.Program, int, error)) *client {
+	t.Helper()
+
+	requests, in := io.Pipe()
+	out, responses := io.Pipe()
+	c := &client{t: t, in: in, messages: make(chan received, 64), done: make(chan error, 1)}
+	go func() {
+		c.done <- New(Options{Compile: compile}).Serve(requests, responses)
+		_ = responses.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		scanner := bufio.NewScanner(out)
+		scanner.Split(messenger.Split)
+		for scanner.Scan() {
+			var msg received
+			if err := json.Unmarshal(body(scanner.Bytes()), &msg); err != nil {
+				t.Error(err)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		_ = in.Close()
+		if err := <-c.done; err != nil {
+			t.Error(err)
+		}
+	})
+	return c
+}
+
+// next answers the next message, failing the test when none arrives.
+func (c *client) next() received {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the adapter stopped writing")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("the adapter said nothing")
+	}
+	return received{}
+}
+
+// request asks for a command and answers its response. Events before it are skipped: await
+// is for the ones a test wants.
+func (c *client) request(command string, args any) received {
+	c.t.Helper()
+	c.seq++
+	raw, err := json.Marshal(args)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := messenger.Write(c.in, Request{Message: Message{Seq: c.seq, Type: "request"}, Command: command, Arguments: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "response" && msg.RequestSeq == c.seq {
+			return msg
+		}
+	}
+}
+
+// await answers the next event of a kind, and every output event before it, joined.
+func (c *client) await(event string) (received, string) {
+	c.t.Helper()
+	output := &strings.Builder{}
+	for {
+		msg := c.next()
+		if msg.Type != "event" {
+			continue
+		}
+		if msg.Event == event {
+			return msg, output.String()
+		}
+		if msg.Event == "output" {
+			var body OutputEventBody
+			_ = json.Unmarshal(msg.Body, &body)
+			output.WriteString(body.Output)
+		}
+	}
+}
+
+func decode[T any](t *testing.T, raw json.RawMessage) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatalf("%v: %s", err, raw)
+	}
+	return v
+}
+
+// launched starts a session the way an editor does: initialize, launch, the breakpoints of
+// main.ar, and configurationDone.
+func launched(t *testing.T, c *client, stopOnEntry bool, lines ...int) []Breakpoint {
+	t.Helper()
+	if res := c.request("initialize", map[string]string{"adapterID": "aurora"}); !res.Success {
+		t.Fatalf("initialize: %s", res.Message)
+	}
+	if res := c.request("launch", LaunchArguments{Program: "src/main.ar", StopOnEntry: stopOnEntry}); !res.Success {
+		t.Fatalf("launch: %s", res.Message)
+	}
+	c.await("initialized")
+	path, _ := filepath.Abs(filepath.Join("src", "main.ar"))
+	breakpoints := make([]SourceBreakpoint, 0, len(lines))
+	for _, line := range lines {
+		breakpoints = append(breakpoints, SourceBreakpoint{Line: line})
+	}
+	res := c.request("setBreakpoints", SetBreakpointsArguments{Source: Source{Path: path}, Breakpoints: breakpoints})
+	if !res.Success {
+		t.Fatalf("setBreakpoints: %s", res.Message)
+	}
+	if res := c.request("configurationDone", nil); !res.Success {
+		t.Fatalf("configurationDone: %s", res.Message)
+	}
+	return decode[struct{ Breakpoints []Breakpoint }](t, res.Body).Breakpoints
+}
+
+func stopReason(t *testing.T, c *client) string {
+	t.Helper()
+	msg, _ := c.await("stopped")
+	return decode[StoppedEventBody](t, msg.Body).Reason
+}
+
+func stack(t *testing.T, c *client) []StackFrame {
+	t.Helper()
+	res := c.request("stackTrace", StackTraceArguments{ThreadID: threadID})
+	if !res.Success {
+		t.Fatalf("stackTrace: %s", res.Message)
+	}
+	return decode[struct{ StackFrames []StackFrame }](t, res.Body).StackFrames
+}
+
+func variables(t *testing.T, c *client, frame int) map[string]string {
+	t.Helper()
+	res := c.request("variables", VariablesArguments{VariablesReference: frame})
+	if !res.Success {
+		t.Fatalf("variables: %s", res.Message)
+	}
+	found := make(map[string]string)
+	for _, each := range decode[struct{ Variables []Variable }](t, res.Body).Variables {
+		found[each.Name] = each.Value
+	}
+	return found
+}
+
+func TestRunsToTheEndWithoutBreakpoints(t *testing.T) {
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": fib}))
+	launched(t, c, false)
+
+	exited, output := c.await("exited")
+	if output != "3\n" {
+		t.Errorf("printed %q, want %q", output, "3\n")
+	}
+	if code := decode[map[string]int](t, exited.Body)["exitCode"]; code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+	c.await("terminated")
+	c.request("disconnect", nil)
+}
+
+// The directory a launch names is where its program is read from, and only that: the adapter
+// outlives the launch, and the working directory is the whole process's.
+func TestLaunchReadsFromCwdWithoutMovingThere(t *testing.T) {
+	compile := compileIn(t, map[string]string{"src/main.ar": fib})
+	var asked string
+	c := connect(t, func(target, dir string) (loader.Program, int, error) {
+		asked = dir
+		return compile(target, dir)
+	})
+	before, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elsewhere := t.TempDir()
+	if res := c.request("initialize", map[string]string{"adapterID": "aurora"}); !res.Success {
+		t.Fatalf("initialize: %s", res.Message)
+	}
+	if res := c.request("launch", LaunchArguments{Program: "main", Cwd: elsewhere}); !res.Success {
+		t.Fatalf("launch: %s", res.Message)
+	}
+	if asked != elsewhere {
+		t.Errorf("the program was read from %q, want %q", asked, elsewhere)
+	}
+	if after, _ := os.Getwd(); after != before {
+		t.Errorf("a launch moved the adapter from %s to %s", before, after)
+	}
+	c.request("disconnect", nil)
+}
+
+func TestStopsOnEntry(t *testing.T) {
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": fib}))
+	launched(t, c, true)
+
+	if reason := stopReason(t, c); reason != "entry" {
+		t.Errorf("stopped for %q, want entry", reason)
+	}
+	frames := stack(t, c)
+	if len(frames) != 1 || frames[0].Line != 1 || frames[0].Name != "main" {
+		t.Errorf("stack %+v, want main at line 1", frames)
+	}
+	c.request("disconnect", nil)
+}
+
+// A breakpoint in a call stops with the calls it is in as frames, each with what it was fed.
+func TestStopsAtABreakpointWithTheStack(t *testing.T) {
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": fib}))
+	breakpoints := launched(t, c, false, 3, 4)
+
+	if !breakpoints[0].Verified {
+		t.Errorf("line 3 was refused: %s", breakpoints[0].Message)
+	}
+	if breakpoints[1].Verified || !strings.Contains(breakpoints[1].Message, "nothing on line 4") {
+		t.Errorf("line 4 was %+v, want refused", breakpoints[1])
+	}
+
+	if reason := stopReason(t, c); reason != "breakpoint" {
+		t.Errorf("stopped for %q, want breakpoint", reason)
+	}
+	frames := stack(t, c)
+	if len(frames) != 2 || frames[0].Name != "fib" || frames[0].Line != 3 || frames[1].Name != "main" || frames[1].Line != 5 {
+		t.Fatalf("stack %+v, want fib at 3 called from main at 5", frames)
+	}
+	locals := variables(t, c, frames[0].ID)
+	if locals["feed(0)"] != "4" || locals["n"] != "4" {
+		t.Errorf("locals %v, want feed(0) and n at 4", locals)
+	}
+	if _, ok := variables(t, c, frames[1].ID)["fib"]; !ok {
+		t.Errorf("the program's frame does not hold fib")
+	}
+
+	// The next stop on line 3 is a call deeper, fed one less.
+	c.request("continue", nil)
+	stopReason(t, c)
+	frames = stack(t, c)
+	if len(frames) != 3 {
+		t.Fatalf("stack %+v, want three frames", frames)
+	}
+	if n := variables(t, c, frames[0].ID)["n"]; n != "3" {
+		t.Errorf("n is %s, want 3", n)
+	}
+	res := c.request("evaluate", EvaluateArguments{Expression: "n"})
+	if !res.Success || !strings.HasPrefix(decode[map[string]any](t, res.Body)["result"].(string), "3 ") {
+		t.Errorf("evaluate n answered %s %s", res.Body, res.Message)
+	}
+	c.request("disconnect", nil)
+}
+
+func TestStepsOverAndIntoACall(t *testing.T) {
+	source := strings.Replace(fib, "printd fib(4);", "ident x = fib(4);\nprintd x;", 1)
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": source}))
+	launched(t, c, false, 5)
+	stopReason(t, c)
+
+	c.request("stepIn", nil)
+	if reason := stopReason(t, c); reason != "step" {
+		t.Errorf("stopped for %q, want step", reason)
+	}
+	if frames := stack(t, c); frames[0].Name != "fib" || frames[0].Line != 2 {
+		t.Errorf("stepping in stopped at %+v, want fib line 2", frames[0])
+	}
+
+	c.request("stepOut", nil)
+	stopReason(t, c)
+	if frames := stack(t, c); len(frames) != 1 || frames[0].Line != 5 {
+		t.Errorf("stepping out stopped at %+v, want main line 5", frames)
+	}
+
+	c.request("next", nil)
+	stopReason(t, c)
+	if frames := stack(t, c); len(frames) != 1 || frames[0].Line != 6 {
+		t.Errorf("stepping over stopped at %+v, want main line 6", frames)
+	}
+
+	c.request("next", nil)
+	_, output := c.await("exited")
+	if output != "3\n" {
+		t.Errorf("printed %q, want %q", output, "3\n")
+	}
+	c.request("disconnect", nil)
+}
+
+func TestAnswersOnlyWhileStopped(t *testing.T) {
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": fib}))
+	if res := c.request("stackTrace", StackTraceArguments{ThreadID: threadID}); res.Success {
+		t.Error("a stack was answered before a launch")
+	}
+	if res := c.request("launch", LaunchArguments{}); res.Success {
+		t.Error("a launch naming nothing succeeded")
+	}
+	if res := c.request("setExpression", nil); res.Success || !strings.Contains(res.Message, "not supported") {
+		t.Errorf("an unknown command answered %+v", res)
+	}
+	c.request("disconnect", nil)
+}
+
+func TestReportsAFailure(t *testing.T) {
+	c := connect(t, compileIn(t, map[string]string{"src/main.ar": "printd 1 / 0;\n"}))
+	launched(t, c, false)
+
+	exited, output := c.await("exited")
+	if output == "" {
+		t.Error("the failure was not told")
+	}
+	if code := decode[map[string]int](t, exited.Body)["exitCode"]; code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	c.request("disconnect", nil)
+}
@@ -0,0 +1,286 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// A command is a name, what it takes, and what it does with it. It answers whether the program
+// goes on, and an error to write when it could not do what it was asked.
+type command struct {
+	usage string
+	help  string
+	// takes says the command is nothing without an argument.
+	takes bool
+	run   func(d *Debugger, arg string) (bool, error)
+}
+
+// commands are the debugger's commands, by name. help is answered from this table rather than
+// living in it, for the same reason the REPL's :help is: a table holding a command that reads
+// the table is a cycle.
+var commands = map[string]command{
+	"break":    {usage: "break <line>", help: "stop on a line: 12, file.ar:12 or module:12", takes: true, run: (*Debugger).setBreak},
+	"delete":   {usage: "delete [line]", help: "forget a breakpoint, or every one", run: (*Debugger).deleteBreak},
+	"breaks":   {usage: "breaks", help: "list the breakpoints", run: (*Debugger).listBreaks},
+	"continue": {usage: "continue", help: "go on until a breakpoint, or the end", run: going(Continue)},
+	"step":     {usage: "step", help: "go on to the next line, into a call", run: going(StepLine)},
+	"next":     {usage: "next", help: "go on to the next line, over a call", run: going(StepOver)},
+	"stepi":    {usage: "stepi", help: "run one instruction", run: going(StepInstruction)},
+	"finish":   {usage: "finish", help: "go on until the call this is in answers", run: going(StepOut)},
+	"stack":    {usage: "stack", help: "list the calls the program is in", run: (*Debugger).showStack},
+	"where":    {usage: "where", help: "show where the program is stopped", run: (*Debugger).showWhere},
+	"list":     {usage: "list", help: "show the source around where the program is stopped", run: (*Debugger).list},
+	"env":      {usage: "env", help: "list the environ chain: names, deferred scopes, feed arguments", run: (*Debugger).showEnv},
+	"print":    {usage: "print <name>", help: "show what a name holds as bytes, a decimal and text", takes: true, run: (*Debugger).print},
+	"quit":     {usage: "quit", help: "stop the program and leave", run: func(*Debugger, string) (bool, error) { return false, errQuit }},
+}
+
+// aliases are the one-letter spellings of the commands typed most.
+var aliases = map[string]string{
+	"b": "break", "c": "continue", "s": "step", "n": "next", "si": "stepi",
+	"f": "finish", "w": "where", "l": "list", "e": "env", "p": "print", "q": "quit",
+}
+
+// going answers a command that lets the program go on, until the mode says to stop.
+func going(m Mode) func(d *Debugger, arg string) (bool, error) {
+	return func(d *Debugger, _ string) (bool, error) {
+		d.stepper.Resume(m)
+		return true, nil
+	}
+}
+
+// command runs a command line.
+func (d *Debugger) command(text string) (bool, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+	if full, ok := aliases[name]; ok {
+		name = full
+	}
+
+	if name == "help" || name == "h" {
+		d.help()
+		return false, nil
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return false, fmt.Errorf("unknown command %s; help lists them", name)
+	}
+	if cmd.takes && arg == "" {
+		return false, fmt.Errorf("usage: %s", cmd.usage)
+	}
+	d.repeat = text
+	return cmd.run(d, arg)
+}
+
+// help lists every command, in the order of their names.
+func (d *Debugger) help() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		d.say("  %-16s %s", commands[name].usage, commands[name].help)
+	}
+}
+
+// locate reads a line as a breakpoint names it. A bare number is a line of the file that was
+// run; before a colon is a module, named the way it is imported, or a file.
+func (d *Debugger) locate(arg string) (Location, error) {
+	named, number, qualified := strings.Cut(arg, ":")
+	if !qualified {
+		named, number = "", arg
+	}
+	line, err := strconv.Atoi(number)
+	if err != nil || line < 1 {
+		return Location{}, fmt.Errorf("%q is not a line", arg)
+	}
+	return d.stepper.Locate(named, line)
+}
+
+func (d *Debugger) setBreak(arg string) (bool, error) {
+	at, err := d.locate(arg)
+	if err != nil {
+		return false, err
+	}
+	d.stepper.Break(at)
+	d.say("breakpoint at %s:%d", displayPath(at.File), at.Line)
+	return false, nil
+}
+
+func (d *Debugger) deleteBreak(arg string) (bool, error) {
+	if arg == "" {
+		d.stepper.ClearAllBreaks()
+		d.say("every breakpoint deleted")
+		return false, nil
+	}
+	at, err := d.locate(arg)
+	if err != nil {
+		return false, err
+	}
+	if !d.stepper.Unbreak(at) {
+		return false, fmt.Errorf("there is no breakpoint at %s:%d", displayPath(at.File), at.Line)
+	}
+	d.say("deleted the breakpoint at %s:%d", displayPath(at.File), at.Line)
+	return false, nil
+}
+
+func (d *Debugger) listBreaks(string) (bool, error) {
+	breaks := d.stepper.Breaks()
+	if len(breaks) == 0 {
+		d.say("no breakpoints")
+	}
+	for _, at := range breaks {
+		d.say("  %s:%d", displayPath(at.File), at.Line)
+	}
+	return false, nil
+}
+
+func (d *Debugger) showWhere(string) (bool, error) {
+	d.where()
+	return false, nil
+}
+
+// showStack lists the calls the program is in, the one it is stopped in first, each with the
+// line it was called from.
+func (d *Debugger) showStack(string) (bool, error) {
+	at, _, _ := d.stepper.At()
+	d.say("  %s:%d", displayPath(at.File), at.Line)
+	calls := d.stepper.Calls()
+	for i := len(calls) - 1; i >= 0; i-- {
+		d.say("  in %s, called from %s:%d", calls[i].Name, displayPath(calls[i].Site.File), calls[i].Site.Line)
+	}
+	return false, nil
+}
+
+// where writes where the program is stopped: the line, the source written on it and the
+// instruction about to run.
+func (d *Debugger) where() {
+	here, position, inst := d.stepper.At()
+	at := displayPath(here.File)
+	if origin := inst.GetOrigin(); origin.Known() {
+		at = fmt.Sprintf("%s:%d:%d", at, origin.Line, origin.Column)
+	}
+	d.say("stopped at %s, depth %d", at, here.Depth)
+	if source, ok := d.sourceLine(here.Location); ok {
+		d.say("%5d | %s", here.Line, source)
+	}
+	d.say("   => %d %s", position, strings.TrimSuffix(ir.Format([]ir.Instruction{inst}), "\n"))
+}
+
+// list writes the lines around the one the program is stopped on, and marks it.
+func (d *Debugger) list(string) (bool, error) {
+	here, _, _ := d.stepper.At()
+	if here.Line == 0 {
+		return false, errors.New("the instruction about to run was not written on any line")
+	}
+	lines, err := d.source(here.File)
+	if err != nil {
+		return false, err
+	}
+	const around = 3
+	for line := max(1, here.Line-around); line <= min(len(lines), here.Line+around); line++ {
+		mark := " "
+		if line == here.Line {
+			mark = ">"
+		}
+		d.say("%s%4d | %s", mark, line, lines[line-1])
+	}
+	return false, nil
+}
+
+// sourceLine answers the text of one line.
+func (d *Debugger) sourceLine(at Location) (string, bool) {
+	lines, err := d.source(at.File)
+	if err != nil || at.Line < 1 || at.Line > len(lines) {
+		return "", false
+	}
+	return lines[at.Line-1], true
+}
+
+// source answers a file's lines, read once. A file that changes while it is being debugged is
+// still shown as it was compiled.
+func (d *Debugger) source(file string) ([]string, error) {
+	if lines, ok := d.sources[file]; ok {
+		return lines, nil
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+	d.sources[file] = lines
+	return lines, nil
+}
+
+// showEnv lists the environ chain from where the program stands outwards: in each, the names
+// bound, the scopes deferred and what feed answers with.
+func (d *Debugger) showEnv(string) (bool, error) {
+	frames := d.ev.Frames()
+	for at, frame := range frames {
+		switch {
+		case at == len(frames)-1:
+			d.say("frame %d, the program's", at)
+		case at == 0:
+			d.say("frame %d, where the program is stopped", at)
+		default:
+			d.say("frame %d", at)
+		}
+		d.writeFrame(frame)
+	}
+	return false, nil
+}
+
+func (d *Debugger) writeFrame(frame evaluator.Frame) {
+	for at, argument := range frame.Arguments {
+		d.say("  feed(%d) = %s", at, byteutil.DecimalOf(argument, d.tapeSize))
+	}
+	for _, binding := range frame.Bindings {
+		d.say("  ident %s = %s", binding.Name, byteutil.DecimalOf(binding.Value, d.tapeSize))
+	}
+	for _, scope := range frame.Scopes {
+		d.say("  defer %d = instructions %d to %d", scope.Index, scope.From, scope.To)
+	}
+}
+
+// print shows what a name holds, found the way the instruction about to run would find it.
+// Inside a module a name is bound qualified, so the module's own spelling is tried as well.
+func (d *Debugger) print(arg string) (bool, error) {
+	value, ok := d.ev.Lookup(arg)
+	if !ok {
+		_, position, _ := d.stepper.At()
+		if each, in := d.stepper.RangeOf(position); in && each.Module != "" {
+			value, ok = d.ev.Lookup(module.Qualify(each.Module, arg))
+		}
+	}
+	if !ok {
+		return false, fmt.Errorf("%s is not bound here", arg)
+	}
+	d.say("bytes    %v", value)
+	d.say("decimal  %s", byteutil.DecimalOf(value, d.tapeSize))
+	d.say("text     %q", byteutil.TextOf(value, d.tapeSize))
+	return false, nil
+}
+
+// displayPath answers a path relative to where the debugger was started, when it is inside it.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	relative, err := filepath.Rel(cwd, path)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return path
+	}
+	return relative
+}
@@ -0,0 +1,127 @@
+// Package debug is "aurora debug": a program run one step at a time, stopped on the lines
+// somebody asked to stop on, with the environ it is standing in there to be read.
+//
+// It is an evaluator.Monitor. The evaluator asks it before every instruction, and when it is
+// time to stop it answers by reading commands until one of them says to go on — so a stop is a
+// call that has not returned yet, and everything the program holds is exactly where it was.
+package debug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/wire/ir"
+)
+
+// prompt is what a command is asked for with.
+const prompt = "(debug) "
+
+// errQuit is how quitting stops the program: the monitor answers with it, and the evaluator
+// stops the way it does for any instruction that fails.
+var errQuit = errors.New("quit")
+
+// Options is what a debugger is built from.
+type Options struct {
+	// In is where commands are read from, one per line.
+	In io.Reader
+	// Out is where the debugger writes. The program prints wherever its printers do.
+	Out io.Writer
+	// TapeSize is how wide a value is, for reading one as a number.
+	TapeSize int
+}
+
+// A Debugger holds a program still where it was asked to, and answers questions about it.
+type Debugger struct {
+	in       *bufio.Scanner
+	out      io.Writer
+	tapeSize int
+
+	ev *evaluator.Evaluator
+	// stepper says where the program stops, and where it has.
+	stepper *Stepper
+	// repeat is the command an empty line runs again.
+	repeat string
+
+	sources map[string][]string
+}
+
+// New answers a debugger for a program that has not been handed to it yet.
+func New(opts Options) *Debugger {
+	return &Debugger{
+		in:       bufio.NewScanner(opts.In),
+		out:      opts.Out,
+		tapeSize: opts.TapeSize,
+		sources:  make(map[string][]string),
+	}
+}
+
+// Run runs a program under the debugger. The evaluator has to have been built with the
+// debugger as its Monitor; Run is handed it because the debugger reads what it holds.
+//
+// A program that fails answers with why, the way it would under "aurora run". Quitting is not
+// a failure.
+func (d *Debugger) Run(ev *evaluator.Evaluator, program loader.Program) error {
+	d.ev, d.stepper = ev, NewStepper(program)
+
+	for _, each := range program.Ranges {
+		_, err := ev.EvaluateModule(program.Instructions, each.From, each.To, string(each.Module))
+		if errors.Is(err, errQuit) {
+			d.say("program stopped")
+			return nil
+		}
+		if err != nil {
+			d.say("program failed: %v", err)
+			return err
+		}
+	}
+	d.say("program finished")
+	return nil
+}
+
+// Before is asked before every instruction runs. It stops the program there when the stepper
+// says to, and answers with errQuit when, stopped, somebody quits.
+func (d *Debugger) Before(position uint64, inst ir.Instruction, depth int) error {
+	if !d.stepper.Stops(position, inst, depth) {
+		return nil
+	}
+	d.where()
+	return d.prompt()
+}
+
+// prompt reads commands until one lets the program go on. The end of the input quits, since
+// nobody is left to say where to stop.
+func (d *Debugger) prompt() error {
+	for {
+		_, _ = fmt.Fprint(d.out, prompt)
+		if !d.in.Scan() {
+			d.say("")
+			return errQuit
+		}
+		text := d.in.Text()
+		if text == "" {
+			text = d.repeat
+		}
+		if text == "" {
+			continue
+		}
+		goes, err := d.command(text)
+		if errors.Is(err, errQuit) {
+			return err
+		}
+		if err != nil {
+			d.say("%v", err)
+			continue
+		}
+		if goes {
+			return nil
+		}
+	}
+}
+
+func (d *Debugger) say(format string, args ...any) {
+	_, _ = fmt.Fprintf(d.out, format+"\n", args...)
+}
@@ -0,0 +1,172 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/resolver"
+	"github.com/guiferpa/aurora/shared/manifest"
+	"github.com/guiferpa/aurora/shared/printer"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+const fib = `ident fib = defer {
+  ident n = feed(0);
+  if n smaller 2 { n; } else { fib(n - 1) + fib(n - 2); };
+};
+printd fib(4);
+`
+
+// compiled writes the files of a project, stands in it, and answers the program its
+// src/main.ar is, put together the way cmd/aurora puts it together.
+func compiled(t *testing.T, files map[string]string) loader.Program {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, source := range files {
+		at := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(at), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(at, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	lx, ps := lexer.New(), parser.New()
+	rs := resolver.New(resolver.Options{
+		SourceRoot: manifest.DefaultSourceRoot,
+		Read:       os.ReadFile,
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return ps.Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
+	modules, err := rs.Resolve(filepath.Join("src", "main.ar"))
+	if err != nil {
+		t.Fatalf("resolving: %v", err)
+	}
+	program, err := loader.Load(modules, emitter.New(emitter.NewEmitterOptions{}).EmitProgram)
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	return program
+}
+
+// debugged runs a program under the debugger, typing the commands given, and answers with
+// everything written: the debugger's and the program's, as they interleave on a terminal.
+func debugged(t *testing.T, program loader.Program, commands ...string) string {
+	t.Helper()
+
+	out := &strings.Builder{}
+	d := New(Options{In: strings.NewReader(strings.Join(commands, "\n") + "\n"), Out: out})
+	ev := evaluator.New(evaluator.NewEvaluatorOptions{PrintDecimal: printer.Decimal(out, 0), Monitor: d})
+	if err := d.Run(ev, program); err != nil {
+		t.Fatalf("running: %v\n%s", err, out)
+	}
+	return out.String()
+}
+
+func expect(t *testing.T, got string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+// The program stops on its first line, and goes to the end on continue.
+func TestDebuggerStopsOnTheFirstLine(t *testing.T) {
+	got := debugged(t, compiled(t, map[string]string{"src/main.ar": fib}), "continue")
+
+	expect(t, got, "stopped at src/main.ar:1:", "    1 | ident fib = defer {", "3\nprogram finished")
+}
+
+// A breakpoint on a line in a recursive scope stops once per call, and what the call was fed
+// is there to be read.
+func TestDebuggerStopsOnABreakpointInEveryCall(t *testing.T) {
+	got := debugged(t, compiled(t, map[string]string{"src/main.ar": fib}),
+		"break 3", "continue", "print n", "continue", "print n", "quit")
+
+	expect(t, got,
+		"breakpoint at src/main.ar:3",
+		"stopped at src/main.ar:3:6, depth 1",
+		"decimal  4",
+		"stopped at src/main.ar:3:6, depth 2",
+		"decimal  3",
+		"program stopped",
+	)
+}
+
+// next runs a call whole; step goes into it.
+func TestDebuggerStepsOverAndIntoACall(t *testing.T) {
+	program := compiled(t, map[string]string{"src/main.ar": "ident twice = defer { feed(0) * 2; };\nident a = twice(3);\nident b = a + 1;\n"})
+
+	over := debugged(t, program, "next", "next", "quit")
+	expect(t, over, "stopped at src/main.ar:2:", "stopped at src/main.ar:3:")
+	if strings.Contains(over, "depth 1") {
+		t.Errorf("next went into the call:\n%s", over)
+	}
+
+	into := debugged(t, program, "next", "step", "finish", "quit")
+	expect(t, into, "stopped at src/main.ar:1:31, depth 1", "stopped at src/main.ar:2:")
+}
+
+// env lists the chain from where the program is stopped outwards: feed's arguments, names, and
+// the scopes deferred.
+func TestDebuggerShowsTheEnvironChain(t *testing.T) {
+	got := debugged(t, compiled(t, map[string]string{"src/main.ar": fib}), "break 3", "continue", "env", "quit")
+
+	expect(t, got,
+		"frame 0, where the program is stopped\n  feed(0) = 4\n  ident n = 4\n",
+		"frame 1, the program's\n  ident fib = 0\n  defer 0 = instructions",
+	)
+}
+
+// A breakpoint can be put in a module, named the way it is imported, and a name bound there is
+// printed the way it is written in the module.
+func TestDebuggerStopsInAModule(t *testing.T) {
+	program := compiled(t, map[string]string{
+		"src/geometry.ar": "ident side = 4;\nident area = defer { feed(0) * side; };",
+		"src/main.ar":     "use geometry as g;\nprintd g.area(2);",
+	})
+	got := debugged(t, program, "break geometry:2", "continue", "print side", "continue", "continue")
+
+	// Line 2 is where the scope is bound, and where its body is: the call stops there again.
+	expect(t, got, "stopped at src/geometry.ar:2:7, depth 0", "decimal  4", "stopped at src/geometry.ar:2:32, depth 1", "8\nprogram finished")
+}
+
+// A line nothing runs on is refused, rather than set and never reached.
+func TestDebuggerRefusesABreakpointNothingReaches(t *testing.T) {
+	got := debugged(t, compiled(t, map[string]string{"src/main.ar": fib}), "break 40", "break nowhere:1", "quit")
+
+	expect(t, got, "nothing on line 40 of src/main.ar runs", `no module or file of this program is "nowhere"`)
+}
+
+// stack lists the calls the program is in, the innermost first, each with the line it was
+// called from.
+func TestDebuggerShowsTheCallsItIsIn(t *testing.T) {
+	got := debugged(t, compiled(t, map[string]string{"src/main.ar": fib}), "break 2", "continue", "continue", "stack", "quit")
+
+	expect(t, got, "  src/main.ar:2\n  in fib, called from src/main.ar:3\n  in fib, called from src/main.ar:5\n")
+}
@@ -0,0 +1,245 @@
+package debug
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// A Location is a line of a file.
+type Location struct {
+	File string
+	Line int
+}
+
+// A Place is a line and how many calls deep it was reached. Stepping over a call is going on
+// until the depth is back where it was.
+type Place struct {
+	Location
+	Depth int
+}
+
+// A Mode is how far a program goes before it stops again.
+type Mode int
+
+const (
+	// StepInstruction stops on the next instruction, whatever it is.
+	StepInstruction Mode = iota
+	// StepLine stops on the next line, into a call if that is where the next line is.
+	StepLine
+	// StepOver stops on the next line at this depth or above: a call runs whole.
+	StepOver
+	// StepOut stops once the call the program is in has answered.
+	StepOut
+	// Continue stops at a breakpoint, or not at all.
+	Continue
+)
+
+// A Call is one that has not answered yet: the name that was called, and where it was called
+// from. The calls a program is in are its stack.
+type Call struct {
+	Name string
+	Site Place
+}
+
+// A Stepper decides where a program stops. It is what a debugger is without a person: told
+// about every instruction before it runs, it answers whether to stop there, and keeps what
+// the answer was worked out from — the breakpoints, the way the program was last told to go
+// on, and the calls it is in.
+//
+// It is shared by the two hosts that stop a program: "aurora debug", which asks a terminal
+// what to do next, and the debug adapter, which asks an editor. Neither decides where a step
+// ends on its own, so a step is the same length in both.
+type Stepper struct {
+	program loader.Program
+	breaks  map[Location]bool
+	mode    Mode
+	// from is where the program was when it was last told to go on, which is what the
+	// stepping modes measure from.
+	from Place
+	// last is the last line an instruction ran on. A line is many instructions, and a
+	// breakpoint on it stops on the first of them rather than on every one.
+	last Place
+	// at is where the program is stopped, and inst what it is about to run there.
+	at       Place
+	position uint64
+	inst     ir.Instruction
+	calls    []Call
+}
+
+// NewStepper answers a stepper that stops on the first line of a program that runs: stepping
+// a line from nowhere, which every written line is away from.
+func NewStepper(program loader.Program) *Stepper {
+	return &Stepper{program: program, breaks: make(map[Location]bool), mode: StepLine}
+}
+
+// Program answers the program being stepped through.
+func (s *Stepper) Program() loader.Program {
+	return s.program
+}
+
+// Stops is told about an instruction before it runs, and answers whether the program stops
+// there. When it does, where it stopped is what At answers.
+func (s *Stepper) Stops(position uint64, inst ir.Instruction, depth int) bool {
+	here := Place{Depth: depth}
+	if each, ok := s.RangeOf(position); ok {
+		here.File = each.Filename
+	}
+	here.Line = inst.GetOrigin().Line
+
+	// A call at this depth is one level of the stack; whatever was deeper has answered.
+	s.calls = s.calls[:min(len(s.calls), depth)]
+	stop := s.stops(here)
+	if here.Line > 0 {
+		s.last = here
+	}
+	if stop {
+		s.at, s.position, s.inst = here, position, inst
+	}
+	if inst.GetOpCode() == ir.OpCall {
+		// Pushed after the question is answered: stopped on the call, the program is not in
+		// it yet.
+		s.calls = append(s.calls, Call{Name: string(inst.GetLeft().Bytes()), Site: here})
+	}
+	return stop
+}
+
+func (s *Stepper) stops(here Place) bool {
+	if s.mode == StepInstruction {
+		return true
+	}
+	if here.Line == 0 {
+		return false
+	}
+	// A breakpoint stops every mode, on the first instruction of its line: arriving from
+	// another line, or from another call of the same one.
+	if s.breaks[here.Location] && here != s.last {
+		return true
+	}
+	switch s.mode {
+	case StepLine:
+		return here != s.from
+	case StepOver:
+		return here.Depth < s.from.Depth || (here.Depth == s.from.Depth && here.Location != s.from.Location)
+	case StepOut:
+		return here.Depth < s.from.Depth
+	}
+	return false
+}
+
+// Resume lets the program go on from where it stopped, until the mode says to stop it again.
+func (s *Stepper) Resume(m Mode) {
+	s.mode = m
+	s.from = s.at
+}
+
+// Pause stops the program on the next instruction it runs, wherever the last step was going.
+// It is how a program running on its own is stopped from outside.
+func (s *Stepper) Pause() {
+	s.mode = StepInstruction
+}
+
+// AtBreak says whether the program is stopped on a breakpoint's line.
+func (s *Stepper) AtBreak() bool {
+	return s.breaks[s.at.Location]
+}
+
+// At answers where the program is stopped, the position of the instruction about to run there,
+// and the instruction.
+func (s *Stepper) At() (Place, uint64, ir.Instruction) {
+	return s.at, s.position, s.inst
+}
+
+// Calls answers the calls the program is in, the outermost first.
+func (s *Stepper) Calls() []Call {
+	return slices.Clone(s.calls)
+}
+
+// RangeOf answers the module a position sits in.
+func (s *Stepper) RangeOf(position uint64) (loader.Range, bool) {
+	return s.program.RangeOf(position)
+}
+
+// Locate answers a line of a module, and says so when nothing written on it runs — a
+// breakpoint there would be set and never reached.
+//
+// The module is named the way it is imported, by the path of its file, or by the file's name;
+// the empty name is the file that was run, since it is imported by nobody.
+func (s *Stepper) Locate(named string, line int) (Location, error) {
+	for _, each := range s.program.Ranges {
+		if !names(each.Module, each.Filename, named) {
+			continue
+		}
+		for at := each.From; at < each.To; at++ {
+			if s.program.Instructions[at].GetOrigin().Line == line {
+				return Location{File: each.Filename, Line: line}, nil
+			}
+		}
+		return Location{}, fmt.Errorf("nothing on line %d of %s runs", line, displayPath(each.Filename))
+	}
+	return Location{}, fmt.Errorf("no module or file of this program is %q", named)
+}
+
+// names says whether a module is the one named.
+func names(id module.ID, filename, named string) bool {
+	if named == "" {
+		return id == ""
+	}
+	if string(id) == named || filename == named || displayPath(filename) == named || filepath.Base(filename) == named {
+		return true
+	}
+	abs, err := filepath.Abs(filename)
+	return err == nil && abs == filepath.Clean(named)
+}
+
+// Break stops the program on a line from now on.
+func (s *Stepper) Break(at Location) {
+	s.breaks[at] = true
+}
+
+// Unbreak forgets a breakpoint, and says whether there was one.
+func (s *Stepper) Unbreak(at Location) bool {
+	was := s.breaks[at]
+	delete(s.breaks, at)
+	return was
+}
+
+// ClearBreaks forgets every breakpoint of a module, named the way Locate names one.
+func (s *Stepper) ClearBreaks(named string) {
+	for _, each := range s.program.Ranges {
+		if !names(each.Module, each.Filename, named) {
+			continue
+		}
+		for at := range s.breaks {
+			if at.File == each.Filename {
+				delete(s.breaks, at)
+			}
+		}
+	}
+}
+
+// ClearAllBreaks forgets every breakpoint.
+func (s *Stepper) ClearAllBreaks() {
+	clear(s.breaks)
+}
+
+// Breaks answers every breakpoint, by file and then by line.
+func (s *Stepper) Breaks() []Location {
+	breaks := make([]Location, 0, len(s.breaks))
+	for at := range s.breaks {
+		breaks = append(breaks, at)
+	}
+	slices.SortFunc(breaks, func(a, b Location) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return breaks
+}
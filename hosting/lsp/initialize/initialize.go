@@ -13,6 +13,16 @@ import (
 type InitializeRequestParams struct {
 	ClientInfo   *lsp.ClientInfo    `json:"clientInfo"`
 	Capabilities ClientCapabilities `json:"capabilities"`
+	// The folders the editor opened. RootURI is what a client older than workspace folders
+	// sends, and is kept for it.
+	RootURI          *lsp.URI          `json:"rootUri"`
+	WorkspaceFolders []WorkspaceFolder `json:"workspaceFolders"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspaceFolder
+type WorkspaceFolder struct {
+	URI  lsp.URI `json:"uri"`
+	Name string  `json:"name"`
 }
 
 // ClientCapabilities carries the one thing the server changes its answers for: whether the
@@ -32,6 +42,19 @@ func (r InitializeRequest) SnippetSupport() bool {
 	return r.Params.Capabilities.TextDocument.Completion.CompletionItem.SnippetSupport
 }
 
+// Roots answers the folders the editor opened, the workspace folders when the client sends
+// them and the root URI when it does not.
+func (r InitializeRequest) Roots() []lsp.URI {
+	roots := make([]lsp.URI, 0, len(r.Params.WorkspaceFolders))
+	for _, folder := range r.Params.WorkspaceFolders {
+		roots = append(roots, folder.URI)
+	}
+	if len(roots) == 0 && r.Params.RootURI != nil {
+		roots = append(roots, *r.Params.RootURI)
+	}
+	return roots
+}
+
 type InitializeRequest struct {
 	lsp.Request
 	Params InitializeRequestParams `json:"params"`
@@ -88,6 +111,27 @@ func NewResponse(id int) InitializeResponse {
 					},
 					Full: true,
 				},
+				// The layout "aurora fmt" writes, for a document or the lines of a
+				// selection: the same printer, so an editor and the command never disagree.
+				DocumentFormattingProvider:      true,
+				DocumentRangeFormattingProvider: true,
+				// Quick fixes for what the diagnostics say, and nothing else: the kinds are
+				// listed so a client does not ask for refactorings there are none of.
+				CodeActionProvider: &lsp.CodeActionOptions{CodeActionKinds: []string{textdoc.QuickFix}},
+				// A scope has no parameter list, so what a call reads is asked where the call
+				// opens and at each value after the first.
+				SignatureHelpProvider: &lsp.SignatureHelpOptions{TriggerCharacters: []string{"(", ","}},
+				// The position each value is applied at, and the shape a name bound from a
+				// call is read as: what the language leaves unwritten.
+				InlayHintProvider: true,
+				// An outline of what a file declares at the top, and a search for it across
+				// every module of the project.
+				DocumentSymbolProvider:  true,
+				WorkspaceSymbolProvider: true,
+				// Above a scope, a way to run it and what it reads; above a test, a way to run
+				// it. The lenses name commands, and the server is what carries them out.
+				CodeLensProvider:       &lsp.CodeLensOptions{},
+				ExecuteCommandProvider: &lsp.ExecuteCommandOptions{Commands: textdoc.Commands},
 			},
 			ServerInfo: lsp.ServerInfo{
 				Name:    "aurorals",
@@ -2,6 +2,7 @@ package initialize
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 
 	"github.com/guiferpa/aurora/version"
@@ -86,6 +87,25 @@ func TestNewResponse(t *testing.T) {
 	if !capabilities.RenameProvider.PrepareProvider {
 		t.Error("the server answers prepareRename, which is where a refusal is heard first")
 	}
+	if !capabilities.DocumentFormattingProvider || !capabilities.DocumentRangeFormattingProvider {
+		t.Error("formatting a document and a selection should be advertised")
+	}
+	if capabilities.CodeActionProvider == nil || !slices.Equal(capabilities.CodeActionProvider.CodeActionKinds, []string{"quickfix"}) {
+		t.Error("code actions should be advertised as quick fixes alone")
+	}
+	if capabilities.SignatureHelpProvider == nil || !slices.Equal(capabilities.SignatureHelpProvider.TriggerCharacters, []string{"(", ","}) {
+		t.Error("signature help should be asked for where a call opens and at each comma")
+	}
+	if !capabilities.InlayHintProvider {
+		t.Error("inlay hints should be advertised")
+	}
+	if !capabilities.DocumentSymbolProvider || !capabilities.WorkspaceSymbolProvider {
+		t.Error("an outline and a search across the project should be advertised")
+	}
+	if capabilities.CodeLensProvider == nil || capabilities.ExecuteCommandProvider == nil ||
+		!slices.Equal(capabilities.ExecuteCommandProvider.Commands, []string{"aurora.runScope", "aurora.runTests"}) {
+		t.Error("code lenses should be advertised with the commands they name")
+	}
 }
 
 // A client reads this as JSON, so the shape on the wire is what matters.
@@ -149,6 +169,36 @@ func TestSnippetSupportIsRead(t *testing.T) {
 	}
 }
 
+// The folders a search starts from: the workspace folders, and the root URI of a client that
+// sends nothing else.
+func TestRootsAreRead(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"workspace folders", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"file:///old","workspaceFolders":[{"uri":"file:///a","name":"a"},{"uri":"file:///b","name":"b"}]}}`, []string{"file:///a", "file:///b"}},
+		{"a root alone", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"file:///old"}}`, []string{"file:///old"}},
+		{"nothing", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":null,"workspaceFolders":null}}`, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := ParseRequest([]byte(tc.body))
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+			got := make([]string, 0)
+			for _, root := range req.Roots() {
+				got = append(got, string(root))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Roots() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 // The dot is declared as a trigger so the client asks for completion the moment someone
 // types it — which is when the fields of a shape are what they want.
 func TestDotTriggersCompletion(t *testing.T) {
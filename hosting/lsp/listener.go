@@ -16,6 +16,11 @@ const maxMessageBytes = 16 << 20
 
 type MethodHandler func(l *log.Logger, s *state.State, contents []byte) any
 
+// Messages is what a handler answers with when it has more than one thing to say: a command
+// the editor ran answers the request, and tells the person what happened in a notification
+// of its own. They are written in order.
+type Messages []any
+
 // Listen reads messages and hands each to the handler for its method.
 //
 // The state arrives rather than being made here: what a client has open is the server's, and
@@ -53,7 +58,14 @@ func Listen(l *log.Logger, r io.Reader, w io.Writer, s *state.State, handlers ma
 			continue
 		}
 
-		write(l, w, h(l, s, contents))
+		reply := h(l, s, contents)
+		if messages, several := reply.(Messages); several {
+			for _, msg := range messages {
+				write(l, w, msg)
+			}
+			continue
+		}
+		write(l, w, reply)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -127,3 +127,26 @@ func TestListenHandlesMessageLargerThan64KB(t *testing.T) {
 func intPtr(v int) *int {
 	return &v
 }
+
+// A handler with more than one thing to say has each of them written, in the order it said
+// them.
+func TestListenWritesEveryMessage(t *testing.T) {
+	in := strings.NewReader(frame(`{"jsonrpc":"2.0","id":3,"method":"workspace/executeCommand"}`) +
+		frame(`{"jsonrpc":"2.0","method":"exit"}`))
+	out := bytes.NewBuffer(nil)
+
+	Listen(discardLogger(), in, out, state.New(), map[Method]MethodHandler{
+		"workspace/executeCommand": func(l *log.Logger, s *state.State, contents []byte) any {
+			return Messages{NewNullResponse(intPtr(3)), NewShowMessageNotification(MessageInfo, "done")}
+		},
+	})
+
+	body := out.String()
+	answer, told := strings.Index(body, `"id":3`), strings.Index(body, `"window/showMessage"`)
+	if answer < 0 || told < 0 || told < answer {
+		t.Errorf("expected the answer and then the notification, got %q", body)
+	}
+	if strings.Count(body, "Content-Length") != 2 {
+		t.Errorf("expected two framed messages, got %q", body)
+	}
+}
@@ -71,6 +71,33 @@ func NewNullResponse(id *int) NullResponse {
 	return NullResponse{Response: Response{RPC: "2.0", ID: id}, Result: nil}
 }
 
+// Kinds of message shown to the person, as the protocol numbers them.
+const (
+	MessageError   = 1
+	MessageWarning = 2
+	MessageInfo    = 3
+)
+
+type ShowMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+// ShowMessageNotification is something said to the person rather than to the editor: a client
+// shows it where it shows anything it was told.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#window_showMessage
+type ShowMessageNotification struct {
+	Notification
+	Params ShowMessageParams `json:"params"`
+}
+
+func NewShowMessageNotification(kind int, message string) ShowMessageNotification {
+	return ShowMessageNotification{
+		Notification: Notification{RPC: "2.0", Method: "window/showMessage"},
+		Params:       ShowMessageParams{Type: kind, Message: message},
+	}
+}
+
 type ClientInfo struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
@@ -96,6 +123,47 @@ type ServerCapabilities struct {
 	RenameProvider         *RenameOptions         `json:"renameProvider,omitempty"`
 	CompletionProvider     map[string]any         `json:"completionProvider"`
 	SemanticTokensProvider *SemanticTokensOptions `json:"semanticTokensProvider,omitempty"`
+
+	DocumentFormattingProvider      bool `json:"documentFormattingProvider"`
+	DocumentRangeFormattingProvider bool `json:"documentRangeFormattingProvider"`
+
+	CodeActionProvider *CodeActionOptions `json:"codeActionProvider,omitempty"`
+
+	SignatureHelpProvider *SignatureHelpOptions `json:"signatureHelpProvider,omitempty"`
+	InlayHintProvider     bool                  `json:"inlayHintProvider"`
+
+	DocumentSymbolProvider  bool `json:"documentSymbolProvider"`
+	WorkspaceSymbolProvider bool `json:"workspaceSymbolProvider"`
+
+	CodeLensProvider       *CodeLensOptions       `json:"codeLensProvider,omitempty"`
+	ExecuteCommandProvider *ExecuteCommandOptions `json:"executeCommandProvider,omitempty"`
+}
+
+// CodeLensOptions says whether a lens is answered whole or resolved later. This server
+// answers every lens whole.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeLensOptions
+type CodeLensOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+// ExecuteCommandOptions names the commands the server carries out when a client asks.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#executeCommandOptions
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+// SignatureHelpOptions says which characters make a client ask for a signature without being
+// told to.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#signatureHelpOptions
+type SignatureHelpOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters"`
+}
+
+// CodeActionOptions says which kinds of code action the server answers with, so a client
+// asking for a refactoring does not ask a server that has none.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeActionOptions
+type CodeActionOptions struct {
+	CodeActionKinds []string `json:"codeActionKinds"`
 }
 
 // RenameOptions says the server answers textDocument/prepareRename as well, which is what
@@ -84,8 +84,7 @@ func (m *Mapper) Offset(pos Position) int {
 }
 
 // LineEndOffset returns the byte offset where the line holding offset ends, excluding the
-// line break. Used for comments: the lexer emits the "#-" token but drops everything after
-// it, so the comment range has to be recovered from the text.
+// line break.
 func (m *Mapper) LineEndOffset(offset int) int {
 	offset = m.clamp(offset)
 	end := strings.IndexByte(m.text[offset:], '\n')
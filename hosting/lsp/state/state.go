@@ -6,6 +6,10 @@ type State struct {
 	// expand snippets gets plain keywords: the placeholders would land in the buffer as
 	// the literal text they are.
 	snippets bool
+	// roots are the folders the editor opened, as the URIs it sent. A search across a
+	// project starts from them, since a project nobody has a file of open is still one
+	// somebody asked to work in.
+	roots []string
 }
 
 func New() *State {
@@ -20,6 +24,14 @@ func (s *State) SnippetSupport() bool {
 	return s.snippets
 }
 
+func (s *State) SetRoots(roots []string) {
+	s.roots = roots
+}
+
+func (s *State) Roots() []string {
+	return s.roots
+}
+
 func (s *State) UpdateDocument(key string, doc string) {
 	s.docs[key] = doc
 }
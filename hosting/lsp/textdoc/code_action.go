@@ -0,0 +1,426 @@
+package textdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/diag"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// Quick fixes: for some of what the diagnostics say, the edit that makes it go away.
+//
+// Only where the fix is the obvious one. A name nobody imported has one reading when exactly
+// one module offers it, a shape's name has one capital, and a call that applies fewer values
+// than its scope reads already runs as if it had applied zeros — writing them down changes
+// nothing but who can see it. Where the fix is a decision, like widening every value in the
+// project so one literal fits, it is offered and never preferred: an editor may apply a
+// preferred fix without asking.
+//
+// A fix is found by the diagnostic's code, and what it needs to know — how many positions a
+// call applied, how wide a tape a literal needs — by the facts the compiler wrote the message
+// from, never by the message: its words are for a person, and free to change.
+
+const (
+	// QuickFix is the one kind of code action the server answers with.
+	QuickFix = "quickfix"
+
+	unusedUse = "unused-use"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeActionContext
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// Only is the kinds the client wants, and every kind when it is empty.
+	Only []string `json:"only,omitempty"`
+}
+
+type CodeActionParams struct {
+	TextDocument Identifier        `json:"textDocument"`
+	Range        lsp.Range         `json:"range"`
+	Context      CodeActionContext `json:"context"`
+}
+
+type CodeActionRequest struct {
+	lsp.Request
+	Params CodeActionParams `json:"params"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeAction
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
+type CodeActionResponse struct {
+	lsp.Response
+	Result []CodeAction `json:"result"`
+}
+
+func ParseCodeActionRequest(contents []byte) (*CodeActionRequest, error) {
+	var req CodeActionRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewCodeActionResponse(id int, actions []CodeAction) CodeActionResponse {
+	return CodeActionResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: actions}
+}
+
+// Wants says whether a client asking with this context wants quick fixes at all.
+func (c CodeActionContext) Wants(kind string) bool {
+	return len(c.Only) == 0 || slices.ContainsFunc(c.Only, func(only string) bool {
+		return only == kind || strings.HasPrefix(kind, only+".")
+	})
+}
+
+// A Fix is one way to make a diagnostic go away.
+//
+// The edits are kept by path, like a rename's ranges: which URI a file has is the client's
+// vocabulary, and a fix may change a file the client never opened — aurora.toml.
+type Fix struct {
+	Title      string
+	Diagnostic Diagnostic
+	// Preferred is a fix an editor may apply on its own: the only sensible one.
+	Preferred bool
+	Edits     map[string][]TextEdit
+}
+
+// FixesFor answers the quick fixes for the diagnostics a range touches.
+//
+// The diagnostics are the document's own, found again rather than taken from the request: a
+// client sends back what it was told, which may be a version of the document behind the one
+// being asked about.
+func (s *Session) FixesFor(doc Document, selected lsp.Range) []Fix {
+	analysis := s.Analyze(doc)
+	fixes := make([]Fix, 0)
+	for _, diagnostic := range analysis.Diagnostics() {
+		if !touches(diagnostic.Range, selected) {
+			continue
+		}
+		for _, fix := range s.fixesOf(doc, analysis, diagnostic) {
+			fix.Diagnostic = diagnostic
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes
+}
+
+func (s *Session) fixesOf(doc Document, analysis *Analysis, diagnostic Diagnostic) []Fix {
+	switch diag.Code(diagnostic.Code) {
+	case unusedUse:
+		return removeUse(doc, analysis, diagnostic)
+	case diag.UnknownShape:
+		return s.importFixes(doc, analysis, diagnostic)
+	case diag.LowerCaseShape:
+		return capitalizeShape(doc, analysis, diagnostic)
+	case diag.ShortCall:
+		return padCall(doc, analysis, diagnostic, diagnostic.Data[diag.Reads], diagnostic.Data[diag.Applied])
+	case diag.WideLiteral:
+		if width := diagnostic.Data[diag.Width]; width > 0 {
+			return s.widenTape(doc, width)
+		}
+	}
+	return nil
+}
+
+// importFixes writes the use line a name reached through an alias nobody declared is missing:
+// one fix for each module under the source root that has the name, the one called what the
+// alias says first.
+func (s *Session) importFixes(doc Document, analysis *Analysis, diagnostic Diagnostic) []Fix {
+	if s.locate == nil || s.resolve == nil {
+		return nil
+	}
+	tokens := analysis.Tokens
+	i := indexOfStart(analysis, diagnostic.Range.Start)
+	if i < 2 || tokens[i-1].GetTag().Id != token.DOT || tokens[i-2].GetTag().Id != token.ID {
+		return nil
+	}
+	symbol, owner := string(tokens[i].GetMatch()), tokens[i-2]
+	alias := string(owner.GetMatch())
+	// A name the file binds is a value read without a shape, which is what the message says
+	// and no use line would fix.
+	table := scopesOf(tokens)
+	if _, bound := table.means[owner.GetCursor()]; bound {
+		return nil
+	}
+	if _, declared := aliasesOf(parser.ScanUses(tokens))[alias]; declared {
+		return nil
+	}
+
+	offering := make([]string, 0)
+	for _, specifier := range s.locate(doc).Modules {
+		if s.offers(doc, specifier, alias, symbol) {
+			offering = append(offering, specifier)
+		}
+	}
+	slices.SortStableFunc(offering, func(a, b string) int {
+		return boolOrder(lastSegment(b) == alias, lastSegment(a) == alias)
+	})
+
+	at, prefix, suffix := useLineAt(analysis)
+	fixes := make([]Fix, 0, len(offering))
+	for _, specifier := range offering {
+		line := fmt.Sprintf("use %s as %s;", specifier, alias)
+		fixes = append(fixes, Fix{
+			Title:     "Add " + line,
+			Preferred: len(offering) == 1,
+			Edits:     map[string][]TextEdit{doc.Filename: {{Range: lsp.Range{Start: at, End: at}, NewText: prefix + line + suffix}}},
+		})
+	}
+	return fixes
+}
+
+// offers says whether a module has a name — a binding or a shape — as the document would read
+// it through the alias. It is resolved the way the document would resolve it, so a module that
+// does not load is not offered.
+func (s *Session) offers(doc Document, specifier, alias, symbol string) bool {
+	header, err := s.lexer.GetFilledTokens([]byte("use " + specifier + " as " + alias + ";"))
+	if err != nil {
+		return false
+	}
+	modules, err := s.resolve(doc, parser.ScanUses(header))
+	if err != nil {
+		return false
+	}
+	for _, each := range modules {
+		if string(each.ID) != specifier {
+			continue
+		}
+		if slices.Contains(loader.Exports(each), symbol) {
+			return true
+		}
+		return slices.ContainsFunc(each.Tree.Shapes, func(shape ast.Shape) bool { return shape.Name == symbol })
+	}
+	return false
+}
+
+// useLineAt answers where a new use line goes — under the last one, or at the top of a file
+// with none — and what has to go around it there.
+func useLineAt(analysis *Analysis) (lsp.Position, string, string) {
+	uses := parser.ScanUses(analysis.Tokens)
+	if len(uses) == 0 {
+		return lsp.Position{}, "", "\n"
+	}
+	last := indexOf(analysis.Tokens, uses[len(uses)-1].Token)
+	for _, tk := range analysis.Tokens[last:] {
+		if tk.GetTag().Id == token.SEMICOLON {
+			end := analysis.Mapper.LineEndOffset(tk.GetCursor())
+			return analysis.Mapper.Position(end), "\n", ""
+		}
+	}
+	return lsp.Position{}, "", "\n"
+}
+
+// capitalizeShape renames a shape written in lower case, everywhere this file writes it: the
+// declaration, every value built of it, and every value read as it.
+func capitalizeShape(doc Document, analysis *Analysis, diagnostic Diagnostic) []Fix {
+	tokens := analysis.Tokens
+	i := indexOfStart(analysis, diagnostic.Range.Start)
+	if i < 1 || tokens[i-1].GetTag().Id != token.SHAPE {
+		return nil
+	}
+	name := string(tokens[i].GetMatch())
+	first, size := utf8.DecodeRuneInString(name)
+	capital := string(unicode.ToUpper(first)) + name[size:]
+	if capital == name {
+		return nil
+	}
+
+	declaration := tokens[i]
+	table := scopesOf(tokens)
+	edits := make([]TextEdit, 0)
+	for _, tk := range tokens {
+		if tk.GetCursor() == declaration.GetCursor() || means(table, tk, declaration) {
+			edits = append(edits, TextEdit{Range: rangeOf(analysis.Mapper, tk), NewText: capital})
+		}
+	}
+	return []Fix{{
+		Title:     fmt.Sprintf("Rename shape %s to %s", name, capital),
+		Preferred: true,
+		Edits:     map[string][]TextEdit{doc.Filename: edits},
+	}}
+}
+
+// padCall writes down the zeros a call applies without saying so, up to the last position its
+// scope reads.
+func padCall(doc Document, analysis *Analysis, diagnostic Diagnostic, reads, applied int) []Fix {
+	tokens := analysis.Tokens
+	i := indexOfStart(analysis, diagnostic.Range.Start)
+	if i < 0 || i+1 >= len(tokens) || tokens[i+1].GetTag().Id != token.O_PAREN || reads <= applied {
+		return nil
+	}
+	closing := matchingParen(tokens, i+1)
+	if closing < 0 {
+		return nil
+	}
+
+	zeros := strings.Repeat(", 0", reads-applied)
+	if applied == 0 {
+		zeros = strings.TrimPrefix(zeros, ", ")
+	}
+	at := analysis.Mapper.Position(tokens[closing].GetCursor())
+	return []Fix{{
+		Title:     fmt.Sprintf("Apply 0 to every position up to feed(%d)", reads-1),
+		Preferred: true,
+		Edits:     map[string][]TextEdit{doc.Filename: {{Range: lsp.Range{Start: at, End: at}, NewText: zeros}}},
+	}}
+}
+
+// matchingParen answers the index of the parenthesis closing the one at open, or -1.
+func matchingParen(tokens []token.Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].GetTag().Id {
+		case token.O_PAREN:
+			depth++
+		case token.C_PAREN:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// widenTape sets tape_size in the project's manifest to a width the literal fits. It changes
+// what every value of the project is, so it is offered and never preferred.
+func (s *Session) widenTape(doc Document, width int) []Fix {
+	if s.locate == nil {
+		return nil
+	}
+	project := s.locate(doc)
+	if project.Manifest == "" {
+		return nil
+	}
+	return []Fix{{
+		Title: fmt.Sprintf("Set tape_size to %d in aurora.toml", width),
+		Edits: map[string][]TextEdit{project.Manifest: {tapeSizeEdit(project.ManifestSource, width)}},
+	}}
+}
+
+// tapeSizeEdit writes a width into a manifest: over the value tape_size already has in
+// [project], under the [project] header when it has none, and as a [project] of its own at the
+// end when there is not even that. Everything else in the file is left as it was written,
+// comments included, which rewriting the whole table would not do.
+func tapeSizeEdit(source string, width int) TextEdit {
+	mapper := lsp.NewMapper(source)
+	insert := func(offset int, text string) TextEdit {
+		at := mapper.Position(offset)
+		return TextEdit{Range: lsp.Range{Start: at, End: at}, NewText: text}
+	}
+
+	section, offset := "", 0
+	header, indent := -1, ""
+	for _, line := range strings.SplitAfter(source, "\n") {
+		code, _, _ := strings.Cut(line, "#")
+		trimmed := strings.TrimSpace(code)
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			section = strings.TrimSpace(strings.Trim(trimmed, "[]"))
+			if section == "project" {
+				header = offset + len(line)
+				if !strings.HasSuffix(line, "\n") {
+					return insert(header, "\ntape_size = "+strconv.Itoa(width)+"\n")
+				}
+			}
+		case section == "project" && trimmed != "":
+			key, value, _ := strings.Cut(code, "=")
+			if header >= 0 && indent == "" {
+				indent = key[:len(key)-len(strings.TrimLeft(key, " \t"))]
+			}
+			if strings.TrimSpace(key) == "tape_size" {
+				start := offset + len(key) + 1
+				end := start + len(strings.TrimRight(value, " \t\r\n"))
+				return TextEdit{Range: mapper.Range(start, end-start), NewText: " " + strconv.Itoa(width)}
+			}
+		}
+		offset += len(line)
+	}
+
+	if header >= 0 {
+		return insert(header, indent+"tape_size = "+strconv.Itoa(width)+"\n")
+	}
+	prefix := ""
+	if source != "" && !strings.HasSuffix(source, "\n") {
+		prefix = "\n"
+	}
+	return insert(len(source), prefix+"\n[project]\ntape_size = "+strconv.Itoa(width)+"\n")
+}
+
+// removeUse deletes an import nothing reaches through, and the line it leaves empty.
+func removeUse(doc Document, analysis *Analysis, diagnostic Diagnostic) []Fix {
+	mapper := analysis.Mapper
+	start, end := mapper.Offset(diagnostic.Range.Start), mapper.Offset(diagnostic.Range.End)
+
+	alias := ""
+	for _, declaration := range parser.ScanUses(analysis.Tokens) {
+		if declaration.Token.GetCursor() == start {
+			alias = declaration.Alias
+		}
+	}
+	if alias == "" {
+		return nil
+	}
+
+	source := analysis.Source
+	lineStart := strings.LastIndexByte(source[:start], '\n') + 1
+	lineEnd := mapper.LineEndOffset(end)
+	if strings.TrimSpace(source[lineStart:start]) == "" && strings.TrimSpace(source[end:lineEnd]) == "" {
+		start, end = lineStart, lineEnd
+		end += len(source[end:]) - len(strings.TrimPrefix(strings.TrimPrefix(source[end:], "\r"), "\n"))
+	}
+	return []Fix{{
+		Title:     fmt.Sprintf("Remove the unused use line for %s", alias),
+		Preferred: true,
+		Edits:     map[string][]TextEdit{doc.Filename: {{Range: mapper.Range(start, end-start), NewText: ""}}},
+	}}
+}
+
+// indexOfStart answers the index of the token a diagnostic starts at, or -1.
+func indexOfStart(analysis *Analysis, at lsp.Position) int {
+	tk := analysis.TokenAt(at)
+	if tk == nil {
+		return -1
+	}
+	return indexOf(analysis.Tokens, tk)
+}
+
+// touches says whether two ranges share a place. A range with nothing in it — a cursor — is
+// in the one it sits inside or at the edge of.
+func touches(a, b lsp.Range) bool {
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+func before(a, b lsp.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+}
+
+func lastSegment(specifier string) string {
+	return specifier[strings.LastIndexByte(specifier, '/')+1:]
+}
+
+func boolOrder(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
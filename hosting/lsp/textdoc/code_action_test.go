@@ -0,0 +1,240 @@
+package textdoc
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+)
+
+// Every fix here is found by the code the parser, the emitter or the linter puts on what it
+// says, and each test makes that diagnostic the real way.
+
+// fixer is a session that reads a project in memory, compiles what it parses, and knows what
+// surrounds a document: the manifest when the files have one, and every module under src/.
+func fixer(files map[string]string) *Session {
+	return NewSession(NewSessionOptions{
+		Lexer:   lexer.New(),
+		Parser:  parser.New(),
+		Resolve: resolveFrom(files),
+		Emit:    emitter.New(emitter.NewEmitterOptions{}).EmitProgram,
+		Locate: func(doc Document) Project {
+			project := Project{}
+			if source, ok := files["aurora.toml"]; ok {
+				project.Manifest, project.ManifestSource = "aurora.toml", source
+			}
+			for name := range files {
+				if specifier, ok := strings.CutPrefix(name, "src/"); ok && path.Ext(name) == ".ar" {
+					project.Modules = append(project.Modules, strings.TrimSuffix(specifier, ".ar"))
+				}
+			}
+			slices.Sort(project.Modules)
+			return project
+		},
+	})
+}
+
+// everywhere is a range every diagnostic of a short document touches.
+var everywhere = lsp.Range{End: lsp.Position{Line: 100}}
+
+// fixed answers the titles of the fixes for a document, and the document as each would leave
+// it, or the file it changes when that is another one.
+func fixed(t *testing.T, session *Session, doc Document, files map[string]string) ([]string, []string) {
+	t.Helper()
+
+	titles, results := make([]string, 0), make([]string, 0)
+	for _, fix := range session.FixesFor(doc, everywhere) {
+		titles = append(titles, fix.Title)
+		for filename, edits := range fix.Edits {
+			source := doc.Source
+			if filename != doc.Filename {
+				source = files[filename]
+			}
+			results = append(results, edited(t, source, edits))
+		}
+	}
+	return titles, results
+}
+
+// A name reached through an alias nobody declared gets the use line, from the module that has
+// the name, under the use lines already there.
+func TestFixAddsTheMissingUse(t *testing.T) {
+	files := map[string]string{
+		"src/geometry.ar": "ident side = 4;\n",
+		"src/numbers.ar":  "ident one = 1;\n",
+		"src/main.ar":     "",
+	}
+	session := fixer(files)
+
+	source := "use numbers as n;\nprintd n.one + g.side;\n"
+	titles, results := fixed(t, session, Document{Filename: "src/main.ar", Source: source}, files)
+	if !slices.Equal(titles, []string{"Add use geometry as g;"}) {
+		t.Fatalf("offered %q, want the one module that has side", titles)
+	}
+	if want := "use numbers as n;\nuse geometry as g;\nprintd n.one + g.side;\n"; results[0] != want {
+		t.Errorf("the fix gives %q, want %q", results[0], want)
+	}
+
+	// A file with none has it written at the top.
+	_, results = fixed(t, session, Document{Filename: "src/main.ar", Source: "printd g.side;\n"}, files)
+	if want := "use geometry as g;\nprintd g.side;\n"; len(results) != 1 || results[0] != want {
+		t.Errorf("the fix gives %q, want %q", results, want)
+	}
+}
+
+// A value the file binds is read without a shape, and no use line fixes that.
+func TestNoUseIsOfferedForABoundName(t *testing.T) {
+	files := map[string]string{"src/geometry.ar": "ident side = 4;\n"}
+	titles, _ := fixed(t, fixer(files), Document{Filename: "src/main.ar", Source: "ident g = 1;\nprintd g.side;\n"}, files)
+	if len(titles) != 0 {
+		t.Errorf("offered %q for a name the file binds", titles)
+	}
+}
+
+// A shape written in lower case is renamed where it is declared, built and read as.
+func TestFixCapitalizesAShape(t *testing.T) {
+	source := "shape point { x, y };\nident p = point{1, 2};\nprintd p.x + (3 as point).y;\n"
+	titles, results := fixed(t, fixer(nil), Document{Filename: "main.ar", Source: source}, nil)
+
+	if !slices.Equal(titles, []string{"Rename shape point to Point"}) {
+		t.Fatalf("offered %q", titles)
+	}
+	if want := "shape Point { x, y };\nident p = Point{1, 2};\nprintd p.x + (3 as Point).y;\n"; results[0] != want {
+		t.Errorf("the fix gives %q, want %q", results[0], want)
+	}
+}
+
+// A short call has the zeros it already applies written down, up to the last position read.
+func TestFixPadsAShortCall(t *testing.T) {
+	source := "ident add = defer { feed(0) + feed(1) + feed(2); };\nprintd add(1);\nprintd add();\n"
+	titles, results := fixed(t, fixer(nil), Document{Filename: "main.ar", Source: source}, nil)
+
+	if !slices.Equal(titles, []string{"Apply 0 to every position up to feed(2)", "Apply 0 to every position up to feed(2)"}) {
+		t.Fatalf("offered %q", titles)
+	}
+	for at, want := range []string{
+		"ident add = defer { feed(0) + feed(1) + feed(2); };\nprintd add(1, 0, 0);\nprintd add();\n",
+		"ident add = defer { feed(0) + feed(1) + feed(2); };\nprintd add(1);\nprintd add(0, 0, 0);\n",
+	} {
+		if results[at] != want {
+			t.Errorf("fix %d gives %q, want %q", at, results[at], want)
+		}
+	}
+}
+
+// A literal that does not fit widens the project to the narrowest tape it fits, in the
+// manifest — and only when there is one to change.
+func TestFixWidensTheTape(t *testing.T) {
+	files := map[string]string{"aurora.toml": "[project]\n  name = \"wide\"\n  tape_size = 1 # narrow\n"}
+	for _, tc := range []struct {
+		source string
+		title  string
+		want   string
+	}{
+		{"printd 300;\n", "Set tape_size to 2 in aurora.toml", "[project]\n  name = \"wide\"\n  tape_size = 2 # narrow\n"},
+		{"printc \"abc\";\n", "Set tape_size to 3 in aurora.toml", "[project]\n  name = \"wide\"\n  tape_size = 3 # narrow\n"},
+	} {
+		titles, results := fixed(t, fixer(files), Document{Filename: "src/main.ar", Source: tc.source, TapeSize: 1}, files)
+		if !slices.Equal(titles, []string{tc.title}) {
+			t.Errorf("%q: offered %q, want %q", tc.source, titles, tc.title)
+			continue
+		}
+		if results[0] != tc.want {
+			t.Errorf("%q: the manifest becomes %q, want %q", tc.source, results[0], tc.want)
+		}
+	}
+
+	if titles, _ := fixed(t, fixer(nil), Document{Filename: "main.ar", Source: "printd 300;\n", TapeSize: 1}, nil); len(titles) != 0 {
+		t.Errorf("offered %q with no manifest to change", titles)
+	}
+}
+
+// A fix reads the diagnostic's code and facts, and never its words: a message reworded, or
+// gone, offers the same fix.
+func TestFixesDoNotReadTheMessage(t *testing.T) {
+	source := "ident add = defer { feed(0) + feed(1); };\nprintd add(1);\n"
+	doc := Document{Filename: "main.ar", Source: source}
+	session := fixer(nil)
+	analysis := session.Analyze(doc)
+
+	diagnostics := analysis.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != "short-call" {
+		t.Fatalf("diagnostics %+v, want the short call, coded", diagnostics)
+	}
+	reworded := diagnostics[0]
+	reworded.Message = "this call is short"
+
+	fixes := session.fixesOf(doc, analysis, reworded)
+	if len(fixes) != 1 {
+		t.Fatalf("offered %d fixes for a reworded message, want the one", len(fixes))
+	}
+	if got, want := edited(t, source, fixes[0].Edits[doc.Filename]), "ident add = defer { feed(0) + feed(1); };\nprintd add(1, 0);\n"; got != want {
+		t.Errorf("the fix gives %q, want %q", got, want)
+	}
+}
+
+// The width is written where the manifest keeps it, and everything else is left as written.
+func TestTapeSizeEdit(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"over the width it has", "[project]\nname = \"a\"\ntape_size = 8\n", "[project]\nname = \"a\"\ntape_size = 16\n"},
+		{"under the header, indented like its keys", "[project]\n  name = \"a\"\n\n[lint]\n", "[project]\n  tape_size = 16\n  name = \"a\"\n\n[lint]\n"},
+		{"not in another table", "[project]\nname = \"a\"\n[deploy.local]\ntape_size = 8\n", "[project]\ntape_size = 16\nname = \"a\"\n[deploy.local]\ntape_size = 8\n"},
+		{"in a table of its own", "[lint]\ndead-code = \"off\"", "[lint]\ndead-code = \"off\"\n\n[project]\ntape_size = 16\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := edited(t, tc.manifest, []TextEdit{tapeSizeEdit(tc.manifest, 16)}); got != tc.want {
+				t.Errorf("gives %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// An import nothing reaches through is said, faded rather than underlined, and the fix takes
+// its line away.
+func TestFixRemovesAnUnusedUse(t *testing.T) {
+	files := map[string]string{"src/geometry.ar": "ident side = 4;\n", "src/numbers.ar": "ident one = 1;\n"}
+	session := fixer(files)
+	doc := Document{Filename: "src/main.ar", Source: "use geometry as g;\nuse numbers as n;\nprintd n.one;\n"}
+
+	diagnostics := session.ValidateCode(doc)
+	if len(diagnostics) != 1 || diagnostics[0].Code != "unused-use" || !slices.Equal(diagnostics[0].Tags, []int{Unnecessary}) {
+		t.Fatalf("reported %+v, want the unused import, faded", diagnostics)
+	}
+	if got := diagnostics[0].Range; got != lsp.LineRange(0, 0, 18) {
+		t.Errorf("drawn over %+v, want the whole use line", got)
+	}
+
+	titles, results := fixed(t, session, doc, files)
+	if !slices.Equal(titles, []string{"Remove the unused use line for g"}) {
+		t.Fatalf("offered %q", titles)
+	}
+	if want := "use numbers as n;\nprintd n.one;\n"; results[0] != want {
+		t.Errorf("the fix gives %q, want %q", results[0], want)
+	}
+
+	// A comment saying it is meant is heard here as it is by aurora lint.
+	doc.Source = "use geometry as g; #- lint:ignore unused-use loaded for what it prints\nprintd 1;\n"
+	if diagnostics := session.ValidateCode(doc); len(diagnostics) != 0 {
+		t.Errorf("reported %+v for a silenced import", diagnostics)
+	}
+}
+
+// Only the diagnostics the range touches are fixed: a client asks for the line the cursor is
+// on.
+func TestFixesAreForTheRangeAskedAbout(t *testing.T) {
+	source := "ident add = defer { feed(0) + feed(1); };\nprintd add(1);\nprintd add(2);\n"
+	fixes := fixer(nil).FixesFor(Document{Filename: "main.ar", Source: source}, lsp.LineRange(2, 9, 9))
+
+	if len(fixes) != 1 || fixes[0].Diagnostic.Range.Start.Line != 2 {
+		t.Errorf("fixed %+v, want the call on line 2 alone", fixes)
+	}
+}
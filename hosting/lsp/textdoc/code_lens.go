@@ -0,0 +1,248 @@
+package textdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/wire/diag"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// What is written above a scope, and above a test: a way to run it without leaving the file,
+// and what there is to know about it before it runs.
+//
+// A deferred scope at the top of a file is what another file calls and what a contract
+// answers with, so above one goes how to run it, how many positions it reads — which the
+// language leaves unwritten, like signature help says — and whether what it is made of
+// reaches a chain. A test file gets a way to run it, and each assertion a way to run the file
+// and hear what that one said.
+//
+// The lenses only name commands. Running is the host's: it reads files, builds an evaluator
+// and says what happened, none of which this package does.
+
+// The commands a lens names, and what workspace/executeCommand answers to.
+const (
+	// RunScope runs the file a scope is written in and then calls it. Its arguments are the
+	// file, the name, and the values to apply — which a client that asks for them appends, and
+	// which are zeros when none are given, since that is what a position nobody applied reads.
+	RunScope = "aurora.runScope"
+	// RunTests runs a test file. Its arguments are the file and, from a lens above one
+	// assertion, the message that assertion is known by.
+	RunTests = "aurora.runTests"
+)
+
+// Commands is every command the server carries out, for the capability that says so.
+var Commands = []string{RunScope, RunTests}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeLens
+type CodeLens struct {
+	Range lsp.Range `json:"range"`
+	// A lens that only says something still carries a command, with no name: a lens without
+	// one is unresolved, and a client would ask for it again through a request this server
+	// does not answer.
+	Command Command `json:"command"`
+}
+
+type CodeLensParams struct {
+	TextDocument Identifier `json:"textDocument"`
+}
+
+type CodeLensRequest struct {
+	lsp.Request
+	Params CodeLensParams `json:"params"`
+}
+
+type CodeLensResponse struct {
+	lsp.Response
+	Result []CodeLens `json:"result"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#executeCommandParams
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments"`
+}
+
+type ExecuteCommandRequest struct {
+	lsp.Request
+	Params ExecuteCommandParams `json:"params"`
+}
+
+type ExecuteCommandResponse struct {
+	lsp.Response
+	Result any `json:"result"`
+}
+
+func ParseCodeLensRequest(contents []byte) (*CodeLensRequest, error) {
+	var req CodeLensRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func ParseExecuteCommandRequest(contents []byte) (*ExecuteCommandRequest, error) {
+	var req ExecuteCommandRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewCodeLensResponse(id int, lenses []CodeLens) CodeLensResponse {
+	return CodeLensResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: lenses}
+}
+
+func NewExecuteCommandResponse(id int, result any) ExecuteCommandResponse {
+	return ExecuteCommandResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: result}
+}
+
+// CodeLensesFor answers the lenses of a document, in the order they are written.
+//
+// Only a document that parses has any: a lens offers to run something, and what does not
+// parse does not run. The diagnostic is already saying why.
+func (s *Session) CodeLensesFor(doc Document) []CodeLens {
+	analysis := s.Analyze(doc)
+	lenses := make([]CodeLens, 0)
+	if analysis.AST == nil {
+		return lenses
+	}
+
+	if strings.HasSuffix(doc.Filename, ".test.ar") {
+		return s.testLenses(doc, analysis)
+	}
+
+	reads := emitter.ScopeReads(analysis.AST.Nodes)
+	tokens := analysis.Tokens
+	for i := 0; i < len(tokens); i++ {
+		end := statementEnd(tokens, i)
+		name, _, _, _ := readBinding(tokens, i)
+		if tokens[i].GetTag().Id != token.IDENT || name == "" || i+3 >= len(tokens) || tokens[i+3].GetTag().Id != token.DEFER {
+			i = end
+			continue
+		}
+		at := rangeOf(analysis.Mapper, tokens[i])
+
+		positions, known := reads[name]
+		title := "▶ run"
+		if positions > 0 {
+			title = "▶ run with…"
+		}
+		lenses = append(lenses, CodeLens{Range: at, Command: Command{
+			Title: title, Command: RunScope, Arguments: []any{doc.Filename, name},
+		}})
+		if known {
+			lenses = append(lenses, CodeLens{Range: at, Command: Command{Title: readsTitle(positions)}})
+		}
+		if compiled, ok := compiledFrom(analysis.Program, tokens[i], tokens[end]); ok && s.target != nil {
+			lenses = append(lenses, CodeLens{Range: at, Command: Command{Title: chainTitle(s.target(compiled))}})
+		}
+		i = end
+	}
+	return lenses
+}
+
+// testLenses answers a lens running the whole file at its top, and one above each assertion.
+func (s *Session) testLenses(doc Document, analysis *Analysis) []CodeLens {
+	lenses := []CodeLens{{Range: lsp.LineRange(0, 0, 0), Command: Command{
+		Title: "▶ run tests", Command: RunTests, Arguments: []any{doc.Filename},
+	}}}
+	tokens := analysis.Tokens
+	for i, tk := range tokens {
+		if tk.GetTag().Id != token.ASSERT {
+			continue
+		}
+		message, ok := assertMessage(tokens, i)
+		if !ok {
+			continue
+		}
+		lenses = append(lenses, CodeLens{Range: rangeOf(analysis.Mapper, tk), Command: Command{
+			Title: "▶ run test", Command: RunTests, Arguments: []any{doc.Filename, message},
+		}})
+	}
+	return lenses
+}
+
+// assertMessage answers the text of the assertion whose keyword is at i: the last text of its
+// parentheses, which is where the parser takes it from.
+func assertMessage(tokens []token.Token, i int) (string, bool) {
+	message, found := "", false
+	depth := 0
+	for j := i + 1; j < len(tokens); j++ {
+		switch tokens[j].GetTag().Id {
+		case token.O_PAREN:
+			depth++
+		case token.C_PAREN:
+			depth--
+			if depth == 0 {
+				return message, found
+			}
+		case token.STRING:
+			if depth == 1 {
+				quoted := tokens[j].GetMatch()
+				message, found = string(quoted[1:len(quoted)-1]), true
+			}
+		}
+	}
+	return "", false
+}
+
+func readsTitle(positions int) string {
+	switch positions {
+	case 0:
+		return "reads no positions"
+	case 1:
+		return "reads 1 position"
+	}
+	return fmt.Sprintf("reads %d positions", positions)
+}
+
+// chainTitle says what the backend said about a scope, by the names it gave what it does not
+// write — "if", "printd" — since a lens is one line and the whole of each reason is what
+// "aurora build" says.
+//
+// The name is read off the message, the way the quick fixes read theirs: what comes before
+// "does not reach" for what is not written yet, and the keyword a message opens with for what
+// never will be.
+func chainTitle(warnings []diag.Warning) string {
+	if len(warnings) == 0 {
+		return "EVM: supported"
+	}
+	names := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		name, _, pending := strings.Cut(warning.Message, " does not reach")
+		if !pending {
+			name, _, _ = strings.Cut(warning.Message, " ")
+		}
+		names = append(names, name)
+	}
+	return "EVM: not supported (" + strings.Join(names, ", ") + ")"
+}
+
+// compiledFrom answers the instructions a top-level statement compiled to: the expression of
+// the program holding an instruction the emitter says was written between two tokens. Not
+// every instruction says where it came from — a body's mostly do not — but the binding does,
+// and the expression it belongs to is the whole statement.
+func compiledFrom(program *ir.Program, from, to token.Token) ([]ir.Instruction, bool) {
+	if program == nil {
+		return nil, false
+	}
+	for _, expression := range program.Expressions {
+		for _, inst := range program.Instructions[expression.From:expression.To] {
+			origin := inst.GetOrigin()
+			if !origin.Known() {
+				continue
+			}
+			after := origin.Line > from.GetLine() || origin.Line == from.GetLine() && origin.Column >= from.GetColumn()
+			before := origin.Line < to.GetLine() || origin.Line == to.GetLine() && origin.Column <= to.GetColumn()
+			if after && before {
+				return program.Instructions[expression.From:expression.To], true
+			}
+		}
+	}
+	return nil, false
+}
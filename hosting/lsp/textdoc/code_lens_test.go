@@ -0,0 +1,85 @@
+package textdoc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/builder/evm"
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+)
+
+// withTarget is a session wired the way the server wires it: compiled, with the EVM builder
+// saying what it does not write.
+func withTarget() *Session {
+	return NewSession(NewSessionOptions{
+		Lexer:  lexer.New(),
+		Parser: parser.New(),
+		Emit:   emitter.New(emitter.NewEmitterOptions{}).EmitProgram,
+		Target: evm.Warnings,
+	})
+}
+
+// lensLines is a lens per line: where it is, what it says, and what it runs.
+func lensLines(lenses []CodeLens) []string {
+	lines := make([]string, 0, len(lenses))
+	for _, lens := range lenses {
+		line := fmt.Sprintf("%d %s", lens.Range.Start.Line, lens.Command.Title)
+		if lens.Command.Command != "" {
+			line += fmt.Sprintf(" -> %s %v", lens.Command.Command, lens.Command.Arguments)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestCodeLensesAboveScopes(t *testing.T) {
+	source := "ident area = defer { feed(0) * feed(1); };\n" +
+		"ident n = 1;\n" +
+		"shape P { x, y };\nident pick = defer {\n  P{feed(0), 1};\n};\n" +
+		"ident nothing = defer { printd 3; };\n"
+	got := lensLines(withTarget().CodeLensesFor(Document{Filename: "main.ar", Source: source}))
+	want := []string{
+		"0 ▶ run with… -> aurora.runScope [main.ar area]",
+		"0 reads 2 positions",
+		"0 EVM: supported",
+		"3 ▶ run with… -> aurora.runScope [main.ar pick]",
+		"3 reads 1 position",
+		"3 EVM: not supported (shape)",
+		"6 ▶ run -> aurora.runScope [main.ar nothing]",
+		"6 reads no positions",
+		"6 EVM: not supported (printd)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lenses are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// Without a backend nothing is said about a chain, and a document that does not parse offers
+// nothing to run.
+func TestCodeLensesSayOnlyWhatTheyKnow(t *testing.T) {
+	got := lensLines(session().CodeLensesFor(Document{Filename: "main.ar", Source: "ident f = defer { feed(0); };\n"}))
+	if strings.Join(got, "\n") != "0 ▶ run with… -> aurora.runScope [main.ar f]\n0 reads 1 position" {
+		t.Errorf("lenses are %v", got)
+	}
+	if got := withTarget().CodeLensesFor(Document{Filename: "main.ar", Source: "ident f = defer { feed(0); ;\n"}); len(got) != 0 {
+		t.Errorf("a broken document has lenses: %v", lensLines(got))
+	}
+}
+
+func TestCodeLensesAboveTests(t *testing.T) {
+	source := "ident a = 2;\n" +
+		"assert(a equals 2, \"a is two\");\n" +
+		"assert((a + 1) equals 3, \"a grows\");\n"
+	got := lensLines(withTarget().CodeLensesFor(Document{Filename: "a.test.ar", Source: source}))
+	want := []string{
+		"0 ▶ run tests -> aurora.runTests [a.test.ar]",
+		"1 ▶ run test -> aurora.runTests [a.test.ar a is two]",
+		"2 ▶ run test -> aurora.runTests [a.test.ar a grows]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lenses are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -9,11 +9,23 @@ type Diagnostic struct {
 	// The range at which the message applies.
 	Range    lsp.Range `json:"range"`
 	Severity int       `json:"severity"`
-	Source   string    `json:"source"`
+	// Code names what said it, when that has a name of its own: a lint rule, which is also
+	// what a comment silences it by, or what the compiler coded the ones a quick fix acts on as.
+	Code   string `json:"code,omitempty"`
+	Source string `json:"source"`
 	// displayed to the user
 	Message string `json:"message"`
+	// Data is the numbers a coded diagnostic was written from, by name. A client hands it back
+	// untouched, and a quick fix reads it rather than the message.
+	Data map[string]int `json:"data,omitempty"`
+	// Tags say how a client may draw it beyond the severity: Unnecessary is faded out
+	// rather than underlined.
+	Tags []int `json:"tags,omitempty"`
 }
 
+// Unnecessary marks code that can go, which clients draw faded instead of underlined.
+const Unnecessary = 1
+
 type Diagnostics []Diagnostic
 
 type DiagnosticsParams struct {
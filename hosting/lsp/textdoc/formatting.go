@@ -0,0 +1,114 @@
+package textdoc
+
+import (
+	"encoding/json"
+
+	"github.com/guiferpa/aurora/formatter"
+	"github.com/guiferpa/aurora/hosting/lsp"
+)
+
+// Formatting a document, or the lines of a selection, in the layout "aurora fmt" writes. It is
+// the same printer, so saving a file in an editor and running the command over it agree.
+
+// FormattingOptions are what a client says about its own settings. A tab is two spaces in
+// every Aurora file whatever the editor prefers, so they are read and not obeyed.
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+type FormattingParams struct {
+	TextDocument Identifier        `json:"textDocument"`
+	Options      FormattingOptions `json:"options"`
+}
+
+type FormattingRequest struct {
+	lsp.Request
+	Params FormattingParams `json:"params"`
+}
+
+type RangeFormattingParams struct {
+	TextDocument Identifier        `json:"textDocument"`
+	Range        lsp.Range         `json:"range"`
+	Options      FormattingOptions `json:"options"`
+}
+
+type RangeFormattingRequest struct {
+	lsp.Request
+	Params RangeFormattingParams `json:"params"`
+}
+
+type FormattingResponse struct {
+	lsp.Response
+	Result []TextEdit `json:"result"`
+}
+
+func ParseFormattingRequest(contents []byte) (*FormattingRequest, error) {
+	var req FormattingRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func ParseRangeFormattingRequest(contents []byte) (*RangeFormattingRequest, error) {
+	var req RangeFormattingRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewFormattingResponse(id int, edits []TextEdit) FormattingResponse {
+	return FormattingResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: edits}
+}
+
+// FormattingFor answers the edit that puts a whole document in the layout: one, replacing all
+// of it, or none when it already is. A document that does not parse is left alone — the
+// diagnostics already say why, and a save should not fail over it.
+func (s *Session) FormattingFor(doc Document) []TextEdit {
+	analysis := s.Analyze(doc)
+	if analysis.AST == nil {
+		return []TextEdit{}
+	}
+	tokens, err := s.lexer.GetTokens([]byte(doc.Source))
+	if err != nil {
+		return []TextEdit{}
+	}
+	formatted := string(formatter.Format(tokens, *analysis.AST))
+	if formatted == doc.Source {
+		return []TextEdit{}
+	}
+	whole := lsp.Range{End: analysis.Mapper.Position(len(doc.Source))}
+	return []TextEdit{{Range: whole, NewText: formatted}}
+}
+
+// RangeFormattingFor answers the edit that puts the lines a selection touches in the layout,
+// whole lines from the first to the last. A selection ending at the start of a line does not
+// touch that line: it is what selecting whole lines in most editors sends.
+func (s *Session) RangeFormattingFor(doc Document, selected lsp.Range) []TextEdit {
+	analysis := s.Analyze(doc)
+	if analysis.AST == nil {
+		return []TextEdit{}
+	}
+	tokens, err := s.lexer.GetTokens([]byte(doc.Source))
+	if err != nil {
+		return []TextEdit{}
+	}
+
+	first, last := selected.Start.Line, selected.End.Line
+	if last > first && selected.End.Character == 0 {
+		last--
+	}
+	// The layout may move a brace across the edge of the selection, and then the lines it
+	// replaces are more than were selected.
+	formatted, from, to := formatter.FormatLines(tokens, *analysis.AST, first+1, last+1)
+	mapper := analysis.Mapper
+	start := mapper.Offset(lsp.Position{Line: from - 1})
+	end := mapper.LineEndOffset(mapper.Offset(lsp.Position{Line: to - 1}))
+
+	if formatted == doc.Source[start:end] {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: lsp.Range{Start: mapper.Position(start), End: mapper.Position(end)}, NewText: formatted}}
+}
@@ -0,0 +1,73 @@
+package textdoc
+
+import (
+	"testing"
+
+	"github.com/guiferpa/aurora/hosting/lsp"
+)
+
+// edited is the document with the edits made, the way the editor makes them.
+func edited(t *testing.T, source string, edits []TextEdit) string {
+	t.Helper()
+
+	mapper := lsp.NewMapper(source)
+	out := source
+	for i := len(edits) - 1; i >= 0; i-- {
+		start := mapper.Offset(edits[i].Range.Start)
+		end := mapper.Offset(edits[i].Range.End)
+		if start > end || end > len(out) {
+			t.Fatalf("range %+v is not inside the document", edits[i].Range)
+		}
+		out = out[:start] + edits[i].NewText + out[end:]
+	}
+	return out
+}
+
+// A document is put in the layout whole, comments and all, and one already in it is not
+// touched: an edit that changes nothing still marks a file as modified in most editors.
+func TestFormattingAWholeDocument(t *testing.T) {
+	const source = "ident  a=1;   #- one\n\n\nif a  bigger 0 {\nprintd a;\n}  ;\n"
+	const want = "ident a = 1; #- one\n\nif a bigger 0 {\n  printd a;\n};\n"
+
+	edits := session().FormattingFor(Document{Filename: "main.ar", Source: source})
+	if got := edited(t, source, edits); got != want {
+		t.Errorf("formatting gives:\n%q\nwant:\n%q", got, want)
+	}
+	if again := session().FormattingFor(Document{Filename: "main.ar", Source: want}); len(again) != 0 {
+		t.Errorf("a formatted document was edited again: %+v", again)
+	}
+}
+
+// A selection formats the lines it touches and no others, at the depth the whole document
+// puts them.
+func TestFormattingASelection(t *testing.T) {
+	const source = "ident  a=1;\nif a bigger 0 {\nprintd   a;\nprintd a  ;\n};\n"
+	const want = "ident  a=1;\nif a bigger 0 {\n  printd a;\nprintd a  ;\n};\n"
+
+	// From the start of line 3 to the start of line 4 is line 3 alone.
+	selected := lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 3}}
+	edits := session().RangeFormattingFor(Document{Filename: "main.ar", Source: source}, selected)
+	if got := edited(t, source, edits); got != want {
+		t.Errorf("formatting the selection gives:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+// A brace the layout moves onto the selected line is taken off the line it was on, so the
+// selection grows to both rather than leaving the brace twice.
+func TestFormattingASelectionTakesInAMovedBrace(t *testing.T) {
+	const source = "ident f = defer\n{\nfeed(0);\n};\n"
+	const want = "ident f = defer {\nfeed(0);\n};\n"
+
+	selected := lsp.Range{Start: lsp.Position{Line: 0}, End: lsp.Position{Line: 0, Character: 5}}
+	edits := session().RangeFormattingFor(Document{Filename: "main.ar", Source: source}, selected)
+	if got := edited(t, source, edits); got != want {
+		t.Errorf("formatting the selection gives:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+// A document that does not parse is left as it is: the diagnostics say why.
+func TestFormattingLeavesABrokenDocument(t *testing.T) {
+	if edits := session().FormattingFor(Document{Filename: "main.ar", Source: "ident  a = ;\n"}); len(edits) != 0 {
+		t.Errorf("a document that does not parse was edited: %+v", edits)
+	}
+}
@@ -0,0 +1,132 @@
+package textdoc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// What the editor writes into a line without it being there: the position each value of a
+// call is applied at, and the shape a name bound from a call is read as.
+//
+// Both are things the language leaves unwritten on purpose — a scope has no parameter list,
+// and a name bound from a scope that promised a shape is that shape without anybody claiming
+// it — and both are what a reader has to go and look up. Neither needs the document to parse:
+// they are read from the tokens, like the shapes completion offers.
+
+// Kinds of inlay hint.
+const (
+	TypeHint      = 1
+	ParameterHint = 2
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHint
+type InlayHint struct {
+	Position     lsp.Position `json:"position"`
+	Label        string       `json:"label"`
+	Kind         int          `json:"kind"`
+	PaddingLeft  bool         `json:"paddingLeft,omitempty"`
+	PaddingRight bool         `json:"paddingRight,omitempty"`
+}
+
+type InlayHintParams struct {
+	TextDocument Identifier `json:"textDocument"`
+	Range        lsp.Range  `json:"range"`
+}
+
+type InlayHintRequest struct {
+	lsp.Request
+	Params InlayHintParams `json:"params"`
+}
+
+type InlayHintResponse struct {
+	lsp.Response
+	Result []InlayHint `json:"result"`
+}
+
+func ParseInlayHintRequest(contents []byte) (*InlayHintRequest, error) {
+	var req InlayHintRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewInlayHintResponse(id int, hints []InlayHint) InlayHintResponse {
+	return InlayHintResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: hints}
+}
+
+// InlayHintsFor answers the hints inside a range, in the order they are written.
+func (s *Session) InlayHintsFor(doc Document, within lsp.Range) []InlayHint {
+	analysis := s.Analyze(doc)
+	tokens := analysis.Tokens
+	shapes := shapesOf(analysis, aliasesOf(parser.ScanUses(tokens)))
+
+	hints := make([]InlayHint, 0)
+	for i, tk := range tokens {
+		switch tk.GetTag().Id {
+		case token.IDENT:
+			// `ident s = g.new_square(1, 2);` is read as g.Square because that scope promised
+			// it; a name claimed with as or built in place says so already.
+			name, shape, _, called := readBinding(tokens, i)
+			if promised := shapes.promises[called]; name != "" && shape == "" && promised != "" {
+				hints = append(hints, InlayHint{
+					Position: analysis.Mapper.Position(tokens[i+1].GetCursor() + len(tokens[i+1].GetMatch())),
+					Label:    ": " + promised,
+					Kind:     TypeHint,
+				})
+			}
+		case token.ID:
+			if i+1 < len(tokens) && tokens[i+1].GetTag().Id == token.O_PAREN {
+				for n, at := range valuesOf(tokens, i+1) {
+					hints = append(hints, InlayHint{
+						Position:     analysis.Mapper.Position(tokens[at].GetCursor()),
+						Label:        fmt.Sprintf("feed(%d):", n),
+						Kind:         ParameterHint,
+						PaddingRight: true,
+					})
+				}
+			}
+		}
+	}
+
+	kept := hints[:0]
+	for _, hint := range hints {
+		if touches(lsp.Range{Start: hint.Position, End: hint.Position}, within) {
+			kept = append(kept, hint)
+		}
+	}
+	return kept
+}
+
+// valuesOf answers where each value a call applies starts, as token indexes, given the
+// parenthesis that opens it. A call still being written has the values written so far.
+func valuesOf(tokens []token.Token, open int) []int {
+	starts := make([]int, 0)
+	depth, expecting := 0, true
+	for i := open + 1; i < len(tokens); i++ {
+		id := tokens[i].GetTag().Id
+		if depth == 0 {
+			switch id {
+			case token.C_PAREN, token.SEMICOLON, token.EOF:
+				return starts
+			case token.COMMA:
+				expecting = true
+				continue
+			}
+		}
+		if expecting {
+			starts, expecting = append(starts, i), false
+		}
+		switch id {
+		case token.O_PAREN, token.O_CUR_BRK, token.O_BRK:
+			depth++
+		case token.C_PAREN, token.C_CUR_BRK, token.C_BRK:
+			depth--
+		}
+	}
+	return starts
+}
@@ -139,39 +139,44 @@ func TestCompletionOffersTheModules(t *testing.T) {
 // hand it a project in memory: what is on a disk is the server's business, and this package
 // answers with values whatever the world it is put in.
 func withModuleFiles(files map[string]string) *Session {
+	return NewSession(NewSessionOptions{
+		Lexer:   lexer.New(),
+		Parser:  parser.New(),
+		Resolve: resolveFrom(files),
+	})
+}
+
+// resolveFrom is the port reading a map instead of a disk, with src/ as the source root.
+func resolveFrom(files map[string]string) Resolve {
 	lx := lexer.New()
 	ps := parser.New()
 
-	return NewSession(NewSessionOptions{
-		Lexer:  lx,
-		Parser: ps,
-		Resolve: func(doc Document, uses []ast.UseDeclaration) ([]module.Module, error) {
-			return resolver.New(resolver.Options{
-				SourceRoot: "src",
-				Read: func(path string) ([]byte, error) {
-					source, ok := files[path]
-					if !ok {
-						return nil, fmt.Errorf("no such file")
-					}
-					return []byte(source), nil
-				},
-				Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
-					tokens, err := lx.GetFilledTokens(source)
-					if err != nil {
-						return ast.AST{}, err
-					}
-					return ps.Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
-				},
-				Header: func(source []byte) ([]ast.UseDeclaration, error) {
-					tokens, err := lx.GetFilledTokens(source)
-					if err != nil {
-						return nil, err
-					}
-					return parser.ScanUses(tokens), nil
-				},
-			}).DependenciesOf(doc.Filename, uses)
-		},
-	})
+	return func(doc Document, uses []ast.UseDeclaration) ([]module.Module, error) {
+		return resolver.New(resolver.Options{
+			SourceRoot: "src",
+			Read: func(path string) ([]byte, error) {
+				source, ok := files[path]
+				if !ok {
+					return nil, fmt.Errorf("no such file")
+				}
+				return []byte(source), nil
+			},
+			Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+				tokens, err := lx.GetFilledTokens(source)
+				if err != nil {
+					return ast.AST{}, err
+				}
+				return ps.Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
+			},
+			Header: func(source []byte) ([]ast.UseDeclaration, error) {
+				tokens, err := lx.GetFilledTokens(source)
+				if err != nil {
+					return nil, err
+				}
+				return parser.ScanUses(tokens), nil
+			},
+		}).DependenciesOf(doc.Filename, uses)
+	}
 }
 
 const geometry = "ident area = defer { feed(0) * feed(1); };\nident base = 10;"
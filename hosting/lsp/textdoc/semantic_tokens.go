@@ -106,9 +106,8 @@ func collectSemanticTokens(mapper *lsp.Mapper, tokens []token.Token) []semanticT
 		offset := tk.GetCursor()
 
 		if tag == token.COMMENT_LINE {
-			// The lexer drops everything after "#-", so the comment body comes from the text.
-			end := mapper.LineEndOffset(offset)
-			out = append(out, semanticToken{offset: offset, length: end - offset, tokenType: SemanticComment})
+			// A comment's token is the whole of it, mark and text, to the end of its line.
+			out = append(out, semanticToken{offset: offset, length: length, tokenType: SemanticComment})
 			continue
 		}
 
@@ -139,7 +138,9 @@ func collectSemanticTokens(mapper *lsp.Mapper, tokens []token.Token) []semanticT
 func semanticTypeOf(tag string) (int, bool) {
 	switch tag {
 	case token.IDENT, token.IF, token.ELSE, token.BRANCH, token.DEFER,
-		token.PRINTB, token.PRINTC, token.PRINTD, token.ASSERT, token.FEED,
+		token.PRINTB, token.PRINTC, token.PRINTD, token.ASSERT, token.REQUIRE, token.FEED,
+		token.CALLER, token.CALLVALUE, token.TIMESTAMP, token.BLOCKNUMBER, token.CHAINID,
+		token.CALL, token.STATICCALL,
 		token.HEAD, token.TAIL, token.PUSH, token.PULL, token.TRUE, token.FALSE,
 		token.SHAPE, token.AS, token.USE, token.RETURNS:
 		return SemanticKeyword, true
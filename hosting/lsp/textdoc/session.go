@@ -4,6 +4,7 @@ import (
 	"github.com/guiferpa/aurora/lexer"
 	"github.com/guiferpa/aurora/parser"
 	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/diag"
 	"github.com/guiferpa/aurora/wire/ir"
 	"github.com/guiferpa/aurora/wire/module"
 )
@@ -20,6 +21,8 @@ type Session struct {
 	parser  parser.Parser
 	resolve Resolve
 	emit    Emit
+	locate  Locate
+	target  Target
 }
 
 // Emit compiles a tree, which is how the editor hears what the compiler has to say about a
@@ -45,6 +48,34 @@ type Emit func(ast.AST) (ir.Program, error)
 // nothing, which is the truth about a page with one editor in it.
 type Resolve func(doc Document, uses []ast.UseDeclaration) ([]module.Module, error)
 
+// Locate answers what surrounds a document that a quick fix may read or change: the project's
+// manifest, and the modules the document could import.
+//
+// A quick fix is the one answer here that reaches past the document. Making a literal fit is
+// a change to aurora.toml, and a use line for a module nobody imported yet needs to know which
+// modules there are — and both are files, which are the host's business. Without the port the
+// fixes that need them are not offered, which is the truth about a page with one editor in it.
+type Locate func(doc Document) Project
+
+// Target answers what a backend does not write of some instructions, which is what a code
+// lens says above a scope: whether it reaches a chain.
+//
+// The list lives with the backend, and the host is the one that knows there is a backend at
+// all: the server hands in the EVM builder's, and the playground, which builds nothing, hands
+// in none and is never told about a chain.
+type Target func(insts []ir.Instruction) []diag.Warning
+
+// A Project is what Locate found around a document.
+type Project struct {
+	// Manifest is the path of the project's aurora.toml and ManifestSource what it says now;
+	// both are empty when the document belongs to no project.
+	Manifest       string
+	ManifestSource string
+	// Modules is every module under the source root, by the specifier a use line names it
+	// with.
+	Modules []string
+}
+
 type NewSessionOptions struct {
 	Lexer  *lexer.Lexer
 	Parser parser.Parser
@@ -53,8 +84,12 @@ type NewSessionOptions struct {
 	Resolve Resolve
 	// Emit is optional. Without it a document is only checked as far as it parses.
 	Emit Emit
+	// Locate is optional. Without it no quick fix reaches past the document.
+	Locate Locate
+	// Target is optional. Without it no lens says anything about a chain.
+	Target Target
 }
 
 func NewSession(opts NewSessionOptions) *Session {
-	return &Session{lexer: opts.Lexer, parser: opts.Parser, resolve: opts.Resolve, emit: opts.Emit}
+	return &Session{lexer: opts.Lexer, parser: opts.Parser, resolve: opts.Resolve, emit: opts.Emit, locate: opts.Locate, target: opts.Target}
 }
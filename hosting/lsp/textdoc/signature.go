@@ -0,0 +1,257 @@
+package textdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/guiferpa/aurora/emitter"
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/resolver"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// What a call site is told while it is being written.
+//
+// A scope has no parameter list: applying is handing a vector to a block, and the block reads
+// positions of it with feed(n). So at `area(` nobody can see what position 0 is for, or how many
+// there are — both are written somewhere inside a body, which may be in another file. The
+// signature says the second: every position the body reads, and the shape calling it answers
+// with when it promised one. What each is for is the body's to say.
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#parameterInformation
+type ParameterInformation struct {
+	Label string `json:"label"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#signatureInformation
+type SignatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation string                 `json:"documentation,omitempty"`
+	Parameters    []ParameterInformation `json:"parameters"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#signatureHelp
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+type SignatureHelpRequest struct {
+	lsp.Request
+	Params PositionParams `json:"params"`
+}
+
+type SignatureHelpResponse struct {
+	lsp.Response
+	Result SignatureHelp `json:"result"`
+}
+
+func ParseSignatureHelpRequest(contents []byte) (*SignatureHelpRequest, error) {
+	var req SignatureHelpRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewSignatureHelpResponse(id int, help SignatureHelp) SignatureHelpResponse {
+	return SignatureHelpResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: help}
+}
+
+// SignatureFor answers what the call the cursor is inside reads, and which of its values the
+// cursor is on. Nothing comes back when the cursor is in no call, or in a call to a name that
+// is not bound to a scope exactly once: which body that reaches is a question for run time.
+func (s *Session) SignatureFor(doc Document, pos lsp.Position) (SignatureHelp, bool) {
+	analysis := s.Analyze(doc)
+	offset := analysis.Mapper.Offset(pos)
+	callee, active, ok := callAround(analysis.Tokens, offset)
+	if !ok {
+		return SignatureHelp{}, false
+	}
+
+	name := nameAt(analysis.Tokens, callee)
+	reads, known := s.positionsOf(doc, analysis, offset, name)
+	if !known {
+		return SignatureHelp{}, false
+	}
+
+	parameters := make([]ParameterInformation, 0, reads)
+	for n := range reads {
+		parameters = append(parameters, ParameterInformation{Label: fmt.Sprintf("feed(%d)", n)})
+	}
+	labels := make([]string, 0, reads)
+	for _, parameter := range parameters {
+		labels = append(labels, parameter.Label)
+	}
+
+	label := name + "(" + strings.Join(labels, ", ") + ")"
+	documentation := fmt.Sprintf("reads %d positions: whatever is not applied answers with a tape of zeros", reads)
+	if reads == 0 {
+		documentation = "reads no positions: whatever is applied is not read"
+	}
+	aliases := aliasesOf(parser.ScanUses(analysis.Tokens))
+	if shape := shapesOf(analysis, aliases).promises[name]; shape != "" {
+		label += " returns " + shape
+		documentation += "\nanswers with " + shape
+	}
+
+	return SignatureHelp{
+		Signatures:      []SignatureInformation{{Label: label, Documentation: documentation, Parameters: parameters}},
+		ActiveParameter: active,
+	}, true
+}
+
+// callAround answers the name of the call the offset is inside, as a token index, and how many
+// values come before the cursor in it.
+//
+// It reads the tokens backwards, because the call being written never parses: the parenthesis
+// that opens it is the first one left open, and the values before the cursor are the commas
+// between them that no inner parenthesis or brace holds.
+func callAround(tokens []token.Token, offset int) (int, int, bool) {
+	parens, braces, commas := 0, 0, 0
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].GetCursor() >= offset {
+			continue
+		}
+		switch tokens[i].GetTag().Id {
+		case token.C_PAREN:
+			parens++
+		case token.O_PAREN:
+			if parens > 0 {
+				parens--
+				continue
+			}
+			if i > 0 && tokens[i-1].GetTag().Id == token.ID {
+				return i - 1, commas, true
+			}
+			// Parentheses that group rather than apply: the call, if any, is further out, and
+			// the commas read so far were inside none of its values.
+			commas = 0
+		case token.C_CUR_BRK:
+			braces++
+		case token.O_CUR_BRK:
+			if braces == 0 {
+				return 0, 0, false
+			}
+			braces--
+		case token.SEMICOLON:
+			if braces == 0 {
+				return 0, 0, false
+			}
+		case token.COMMA:
+			if parens == 0 && braces == 0 {
+				commas++
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// positionsOf answers how many positions the scope a name is bound to reads, the way the
+// emitter counts them when it warns about a short call: a name of this document from its
+// tree, and a name reached through an alias from the module's.
+func (s *Session) positionsOf(doc Document, analysis *Analysis, offset int, name string) (int, bool) {
+	if alias, symbol, reached := strings.Cut(name, "."); reached {
+		specifier, imported := aliasesOf(parser.ScanUses(analysis.Tokens))[alias]
+		if !imported {
+			return 0, false
+		}
+		found, loaded := analysis.module(specifier)
+		if !loaded {
+			return 0, false
+		}
+		reads, known := emitter.ScopeReads(found.Tree.Nodes)[module.Qualify(found.ID, symbol)]
+		return reads, known
+	}
+
+	tree := s.treeAround(doc, analysis, offset)
+	if tree == nil {
+		return 0, false
+	}
+	reads, known := emitter.ScopeReads(tree.Nodes)[name]
+	return reads, known
+}
+
+// treeAround answers a tree to read scopes out of while the document is being written: the
+// document's own when it parses, and otherwise the document without the statement the offset
+// is in.
+//
+// That statement is the one being typed, which is why the document does not parse, and it is
+// never the one that binds the scope being called — a name is bound by a statement of its own.
+// Everything around it usually parses. When it does not, there is no tree, and the signature
+// says nothing rather than something guessed.
+func (s *Session) treeAround(doc Document, analysis *Analysis, offset int) *ast.AST {
+	if analysis.AST != nil {
+		return analysis.AST
+	}
+	tokens := analysis.Tokens
+	from, to := statementAround(tokens, offset)
+	if from > to {
+		return nil
+	}
+	rest := append(append(make([]token.Token, 0, len(tokens)), tokens[:from]...), tokens[to+1:]...)
+	tree, err := s.parser.Parse(parser.ParseInput{
+		Filename: doc.Filename,
+		Tokens:   rest,
+		TapeSize: doc.TapeSize,
+		Imports:  resolver.OffersOf(analysis.Modules),
+	})
+	if err != nil {
+		return nil
+	}
+	return &tree
+}
+
+// statementAround answers the first and last token index of the innermost statement holding
+// an offset: from the semicolon or brace that ends the statement before it, to its own
+// semicolon, or to the brace closing the block it is in.
+func statementAround(tokens []token.Token, offset int) (int, int) {
+	at := 0
+	for i, tk := range tokens {
+		if tk.GetCursor() < offset {
+			at = i
+		}
+	}
+
+	from, depth := 0, 0
+	for i := at; i >= 0; i-- {
+		id := tokens[i].GetTag().Id
+		if id == token.C_CUR_BRK || id == token.C_PAREN {
+			depth++
+		}
+		if (id == token.O_CUR_BRK || id == token.O_PAREN) && depth > 0 {
+			depth--
+			continue
+		}
+		if i < at && depth == 0 && (id == token.SEMICOLON || id == token.O_CUR_BRK) {
+			from = i + 1
+			break
+		}
+	}
+
+	to, depth := len(tokens)-2, 0 // the last token is EOF, which the parser wants kept
+	for i := at + 1; i < len(tokens)-1; i++ {
+		id := tokens[i].GetTag().Id
+		if id == token.O_CUR_BRK || id == token.O_PAREN {
+			depth++
+		}
+		if (id == token.C_CUR_BRK || id == token.C_PAREN) && depth > 0 {
+			depth--
+			continue
+		}
+		if depth == 0 && id == token.SEMICOLON {
+			to = i
+			break
+		}
+		if depth == 0 && id == token.C_CUR_BRK {
+			to = i - 1
+			break
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,107 @@
+package textdoc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/hosting/lsp"
+)
+
+// The signature is asked while the call is being written, so every document here is one that
+// does not parse yet — except where the point is that it does.
+
+func TestSignatureWhileTheCallIsWritten(t *testing.T) {
+	const scope = "shape Square { w, h };\nident square = defer { Square{feed(0), feed(1)}; } returns Square;\n"
+	for _, tc := range []struct {
+		name   string
+		source string
+		at     lsp.Position
+		label  string
+		active int
+	}{
+		{"at the parenthesis", scope + "printd square(", lsp.Position{Line: 2, Character: 14}, "square(feed(0), feed(1)) returns Square", 0},
+		{"after a comma", scope + "printd square(2, ", lsp.Position{Line: 2, Character: 17}, "square(feed(0), feed(1)) returns Square", 1},
+		{"past a group and a block", scope + "printd square((1 + 2), { 3; }, ", lsp.Position{Line: 2, Character: 32}, "square(feed(0), feed(1)) returns Square", 2},
+		{"in a document that parses", scope + "printd square(2, 3);\n", lsp.Position{Line: 2, Character: 16}, "square(feed(0), feed(1)) returns Square", 1},
+		{"of a scope that promised nothing", "ident add = defer { feed(0) + feed(2); };\nprintd add(", lsp.Position{Line: 1, Character: 11}, "add(feed(0), feed(1), feed(2))", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			help, ok := session().SignatureFor(Document{Filename: "main.ar", Source: tc.source}, tc.at)
+			if !ok {
+				t.Fatal("said nothing")
+			}
+			if got := help.Signatures[0].Label; got != tc.label {
+				t.Errorf("label is %q, want %q", got, tc.label)
+			}
+			if help.ActiveParameter != tc.active {
+				t.Errorf("the cursor is on value %d, want %d", help.ActiveParameter, tc.active)
+			}
+		})
+	}
+}
+
+// The positions are the emitter's count, so the editor says what the warning about a short
+// call says: a nested scope's feeds are its own.
+func TestSignatureCountsWhatTheEmitterCounts(t *testing.T) {
+	source := "ident outer = defer { ident inner = defer { feed(5); }; feed(0); };\nprintd outer("
+	help, ok := session().SignatureFor(Document{Filename: "main.ar", Source: source}, lsp.Position{Line: 1, Character: 13})
+	if !ok {
+		t.Fatal("said nothing")
+	}
+	if got := len(help.Signatures[0].Parameters); got != 1 {
+		t.Errorf("offered %d positions, want the one outer reads itself", got)
+	}
+}
+
+// A scope of another file is read from its module.
+func TestSignatureOfAModuleScope(t *testing.T) {
+	session := withModuleFiles(map[string]string{"src/geometry.ar": geometry})
+	help, ok := session.SignatureFor(Document{Filename: "src/main.ar", Source: "use geometry as g;\nprintd g.area(2, "}, lsp.Position{Line: 1, Character: 17})
+	if !ok {
+		t.Fatal("said nothing")
+	}
+	if got := help.Signatures[0].Label; got != "g.area(feed(0), feed(1))" {
+		t.Errorf("label is %q", got)
+	}
+}
+
+// Outside a call, or in a call to something that is not one scope, there is nothing to say.
+func TestNoSignature(t *testing.T) {
+	for _, tc := range []struct {
+		source string
+		at     lsp.Position
+	}{
+		{"ident a = 1;\nprintd a;\n", lsp.Position{Line: 1, Character: 8}},
+		{"ident f = defer { feed(0); };\nprintd f(1);\nprintd 2 + ", lsp.Position{Line: 2, Character: 11}},
+		{"ident f = defer { 1; };\nident f = defer { feed(0); };\nprintd f(", lsp.Position{Line: 2, Character: 9}},
+	} {
+		if help, ok := session().SignatureFor(Document{Filename: "main.ar", Source: tc.source}, tc.at); ok {
+			t.Errorf("%q: said %+v", tc.source, help)
+		}
+	}
+}
+
+// Every value of a call is told its position, and a name bound from a scope that promised a
+// shape is told the shape — unless it was claimed in the source already.
+func TestInlayHints(t *testing.T) {
+	source := "shape Square { w, h };\n" +
+		"ident square = defer { Square{feed(0), feed(1)}; } returns Square;\n" +
+		"ident s = square(2, (1 + 2));\n" +
+		"ident c = square(4, 5) as Square;\n"
+	hints := session().InlayHintsFor(Document{Filename: "main.ar", Source: source}, lsp.Range{End: lsp.Position{Line: 10}})
+
+	got := make([]string, 0)
+	for _, hint := range hints {
+		got = append(got, fmt.Sprintf("%d:%d %s", hint.Position.Line, hint.Position.Character, hint.Label))
+	}
+	want := []string{"2:7 : Square", "2:17 feed(0):", "2:20 feed(1):", "3:17 feed(0):", "3:20 feed(1):"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hints are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Only what the range asked about.
+	if hints := session().InlayHintsFor(Document{Filename: "main.ar", Source: source}, lsp.LineRange(3, 0, 30)); len(hints) != 2 {
+		t.Errorf("answered %+v for line 3 alone", hints)
+	}
+}
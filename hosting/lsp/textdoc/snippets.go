@@ -25,6 +25,7 @@ var keywordSnippets = map[string]string{
 	token.RETURNS: "returns ${0:Shape}",
 	token.USE:     "use ${1:a/b/c} as ${0:alias};",
 	token.ASSERT:  "assert(${1:condition}, \"${0:message}\");",
+	token.REQUIRE: "require(${1:condition}, \"${0:message}\");",
 	token.FEED:    "feed(${0:0})",
 	token.PRINTB:  "printb ${0:value};",
 	token.PRINTC:  "printc ${0:value};",
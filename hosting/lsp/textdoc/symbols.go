@@ -0,0 +1,250 @@
+package textdoc
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// An outline of a file, and a search over every file of a project.
+//
+// What a file declares at the top is what another file can reach, which makes it the outline:
+// the modules it brings in, its shapes and their fields, and the names it binds — a name bound
+// to a deferred scope apart from one bound to a value, since one is called and the other read.
+// It is read from the tokens like the shapes completion offers, because an outline is most
+// wanted in a file somebody is in the middle of breaking.
+
+// Kinds of symbol, as the protocol numbers them.
+const (
+	ModuleSymbol   = 2
+	FieldSymbol    = 8
+	FunctionSymbol = 12
+	VariableSymbol = 13
+	StructSymbol   = 23
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#documentSymbol
+type DocumentSymbol struct {
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+	Kind   int    `json:"kind"`
+	// Range is the whole statement, and SelectionRange the name inside it.
+	Range          lsp.Range        `json:"range"`
+	SelectionRange lsp.Range        `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument Identifier `json:"textDocument"`
+}
+
+type DocumentSymbolRequest struct {
+	lsp.Request
+	Params DocumentSymbolParams `json:"params"`
+}
+
+type DocumentSymbolResponse struct {
+	lsp.Response
+	Result []DocumentSymbol `json:"result"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#symbolInformation
+type SymbolInformation struct {
+	Name          string       `json:"name"`
+	Kind          int          `json:"kind"`
+	Location      lsp.Location `json:"location"`
+	ContainerName string       `json:"containerName,omitempty"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type WorkspaceSymbolRequest struct {
+	lsp.Request
+	Params WorkspaceSymbolParams `json:"params"`
+}
+
+type WorkspaceSymbolResponse struct {
+	lsp.Response
+	Result []SymbolInformation `json:"result"`
+}
+
+func ParseDocumentSymbolRequest(contents []byte) (*DocumentSymbolRequest, error) {
+	var req DocumentSymbolRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func ParseWorkspaceSymbolRequest(contents []byte) (*WorkspaceSymbolRequest, error) {
+	var req WorkspaceSymbolRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func NewDocumentSymbolResponse(id int, symbols []DocumentSymbol) DocumentSymbolResponse {
+	return DocumentSymbolResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: symbols}
+}
+
+func NewWorkspaceSymbolResponse(id int, symbols []SymbolInformation) WorkspaceSymbolResponse {
+	return WorkspaceSymbolResponse{Response: lsp.Response{RPC: "2.0", ID: &id}, Result: symbols}
+}
+
+// DocumentSymbolsFor answers the outline of a document, in the order it is written.
+//
+// A document that does not lex is outlined as far as it does: everything above the character
+// the lexer stopped at is still what it was.
+func (s *Session) DocumentSymbolsFor(doc Document) []DocumentSymbol {
+	tokens, _ := s.lexer.GetFilledTokens([]byte(doc.Source))
+	mapper := lsp.NewMapper(doc.Source)
+	span := func(from, to int) lsp.Range {
+		start := tokens[from].GetCursor()
+		return mapper.Range(start, tokens[to].GetCursor()+len(tokens[to].GetMatch())-start)
+	}
+
+	symbols := make([]DocumentSymbol, 0)
+	for i := 0; i < len(tokens); i++ {
+		end := statementEnd(tokens, i)
+		switch tokens[i].GetTag().Id {
+		case token.USE:
+			alias, specifier, at := readUse(tokens, i)
+			if alias == "" {
+				break
+			}
+			symbols = append(symbols, DocumentSymbol{
+				Name: alias, Detail: "module " + specifier, Kind: ModuleSymbol,
+				Range: span(i, end), SelectionRange: rangeOf(mapper, tokens[at]),
+			})
+		case token.SHAPE:
+			if i+2 >= len(tokens) || tokens[i+1].GetTag().Id != token.ID || tokens[i+2].GetTag().Id != token.O_CUR_BRK {
+				break
+			}
+			shape := DocumentSymbol{
+				Name: string(tokens[i+1].GetMatch()), Kind: StructSymbol,
+				Range: span(i, end), SelectionRange: rangeOf(mapper, tokens[i+1]),
+			}
+			fields := make([]string, 0)
+			for j := i + 3; j <= end && tokens[j].GetTag().Id != token.C_CUR_BRK; j++ {
+				if tokens[j].GetTag().Id != token.ID {
+					continue
+				}
+				fields = append(fields, string(tokens[j].GetMatch()))
+				shape.Children = append(shape.Children, DocumentSymbol{
+					Name: string(tokens[j].GetMatch()), Detail: "field of " + shape.Name, Kind: FieldSymbol,
+					Range: rangeOf(mapper, tokens[j]), SelectionRange: rangeOf(mapper, tokens[j]),
+				})
+			}
+			shape.Detail = "shape { " + strings.Join(fields, ", ") + " }"
+			symbols = append(symbols, shape)
+		case token.IDENT:
+			name, shape, promised, _ := readBinding(tokens, i)
+			if name == "" {
+				break
+			}
+			symbol := DocumentSymbol{
+				Name: name, Detail: shape, Kind: VariableSymbol,
+				Range: span(i, end), SelectionRange: rangeOf(mapper, tokens[i+1]),
+			}
+			if i+3 < len(tokens) && tokens[i+3].GetTag().Id == token.DEFER {
+				symbol.Kind, symbol.Detail = FunctionSymbol, "deferred scope"
+				if promised != "" {
+					symbol.Detail += " returns " + promised
+				}
+			}
+			symbols = append(symbols, symbol)
+		}
+		i = end
+	}
+	return symbols
+}
+
+// readUse reads a use line from its keyword: the alias, the path, and where the alias is.
+func readUse(tokens []token.Token, i int) (string, string, int) {
+	found := parser.ScanUses(tokens[i:min(statementEnd(tokens, i)+1, len(tokens))])
+	if len(found) == 0 {
+		return "", "", i
+	}
+	for j := statementEnd(tokens, i); j > i; j-- {
+		if tokens[j].GetTag().Id == token.ID && string(tokens[j].GetMatch()) == found[0].Alias {
+			return found[0].Alias, found[0].Specifier, j
+		}
+	}
+	return "", "", i
+}
+
+// statementEnd answers the index of the token a statement starting at i ends with: its
+// semicolon, or the last token before the end when it has none yet. Braces and parentheses are
+// stepped over, since the semicolons inside them are a body's.
+func statementEnd(tokens []token.Token, i int) int {
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		switch tokens[j].GetTag().Id {
+		case token.O_CUR_BRK, token.O_PAREN, token.O_BRK:
+			depth++
+		case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+			depth--
+		case token.SEMICOLON:
+			if depth <= 0 {
+				return j
+			}
+		case token.EOF:
+			return max(j-1, i)
+		}
+	}
+	return len(tokens) - 1
+}
+
+// MatchesQuery says whether a name is what a workspace search asks for: the letters of the
+// query in the order typed, with anything between them, in either case. `nsq` finds
+// new_square, which is how searching for a symbol is typed.
+func MatchesQuery(name, query string) bool {
+	rest := []rune(strings.ToLower(name))
+	for _, want := range strings.ToLower(query) {
+		if unicode.IsSpace(want) {
+			continue
+		}
+		at := -1
+		for i, have := range rest {
+			if have == want {
+				at = i
+				break
+			}
+		}
+		if at < 0 {
+			return false
+		}
+		rest = rest[at+1:]
+	}
+	return true
+}
+
+// WorkspaceSymbolsIn answers the symbols of one file of a project that a search matches, with
+// the module they belong to as their container — and a field with its shape too, since a field
+// alone is a word every other shape may use.
+func (s *Session) WorkspaceSymbolsIn(doc Document, uri lsp.URI, container, query string) []SymbolInformation {
+	found := make([]SymbolInformation, 0)
+	var visit func(symbols []DocumentSymbol, container string)
+	visit = func(symbols []DocumentSymbol, container string) {
+		for _, symbol := range symbols {
+			if MatchesQuery(symbol.Name, query) {
+				found = append(found, SymbolInformation{
+					Name:          symbol.Name,
+					Kind:          symbol.Kind,
+					Location:      lsp.Location{URI: uri, Range: symbol.SelectionRange},
+					ContainerName: container,
+				})
+			}
+			visit(symbol.Children, container+"."+symbol.Name)
+		}
+	}
+	visit(s.DocumentSymbolsFor(doc), container)
+	return found
+}
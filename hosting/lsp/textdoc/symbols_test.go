@@ -0,0 +1,93 @@
+package textdoc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// The outline, one line per symbol: its kind, name, detail, and the line and column of the
+// name it selects. Children are indented under their parent.
+func outline(symbols []DocumentSymbol, indent string) []string {
+	lines := make([]string, 0)
+	for _, symbol := range symbols {
+		lines = append(lines, fmt.Sprintf("%s%d %s %q %d:%d", indent, symbol.Kind, symbol.Name, symbol.Detail,
+			symbol.SelectionRange.Start.Line, symbol.SelectionRange.Start.Character))
+		lines = append(lines, outline(symbol.Children, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	source := "use geometry/shapes as g;\n" +
+		"shape Square { w, h };\n" +
+		"ident square = defer { ident inner = 1; Square{feed(0), feed(1)}; } returns Square;\n" +
+		"ident s = square(2, 3) as Square;\n" +
+		"ident n = 1;\n" +
+		"printd n;\n"
+	got := outline(session().DocumentSymbolsFor(Document{Filename: "main.ar", Source: source}), "")
+	want := []string{
+		`2 g "module geometry/shapes" 0:23`,
+		`23 Square "shape { w, h }" 1:6`,
+		`  8 w "field of Square" 1:15`,
+		`  8 h "field of Square" 1:18`,
+		`12 square "deferred scope returns Square" 2:6`,
+		`13 s "Square" 3:6`,
+		`13 n "" 4:6`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("outline is\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// The range is the whole statement, so an editor can tell which symbol the cursor is in.
+func TestDocumentSymbolRangeIsTheStatement(t *testing.T) {
+	source := "ident f = defer {\n  feed(0);\n};\nprintd f(1);\n"
+	symbols := session().DocumentSymbolsFor(Document{Filename: "main.ar", Source: source})
+	if len(symbols) != 1 {
+		t.Fatalf("outline is %+v", symbols)
+	}
+	if r := symbols[0].Range; r.Start.Line != 0 || r.Start.Character != 0 || r.End.Line != 2 || r.End.Character != 2 {
+		t.Errorf("range is %+v, want 0:0 to 2:2", r)
+	}
+}
+
+// A document being written is outlined as far as it goes.
+func TestDocumentSymbolsOfABrokenDocument(t *testing.T) {
+	source := "ident a = 1;\nident b = (;\nshape P { x"
+	got := outline(session().DocumentSymbolsFor(Document{Filename: "main.ar", Source: source}), "")
+	if len(got) < 2 || got[0] != `13 a "" 0:6` || got[1] != `13 b "" 1:6` {
+		t.Errorf("outline is %v", got)
+	}
+}
+
+func TestWorkspaceSymbolsMatchTheQuery(t *testing.T) {
+	source := "shape Square { w, h };\nident new_square = defer { Square{feed(0), feed(1)}; };\nident n = 1;\n"
+	found := session().WorkspaceSymbolsIn(Document{Filename: "src/geometry.ar", Source: source}, "file:///src/geometry.ar", "geometry", "nsq")
+	if len(found) != 1 || found[0].Name != "new_square" || found[0].ContainerName != "geometry" || found[0].Location.URI != "file:///src/geometry.ar" {
+		t.Errorf("found %+v", found)
+	}
+
+	// A field is contained by its shape too, and an empty query is everything.
+	all := session().WorkspaceSymbolsIn(Document{Filename: "src/geometry.ar", Source: source}, "file:///src/geometry.ar", "geometry", "")
+	if len(all) != 5 || all[1].Name != "w" || all[1].ContainerName != "geometry.Square" {
+		t.Errorf("found %+v", all)
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name, query string
+		want        bool
+	}{
+		{"new_square", "nsq", true},
+		{"new_square", "SQUARE", true},
+		{"new_square", "", true},
+		{"new_square", "qs", false},
+		{"area", "areas", false},
+	} {
+		if got := MatchesQuery(tc.name, tc.query); got != tc.want {
+			t.Errorf("MatchesQuery(%q, %q) = %v, want %v", tc.name, tc.query, got, tc.want)
+		}
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"errors"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/guiferpa/aurora/hosting/lsp"
+	"github.com/guiferpa/aurora/linter"
 	"github.com/guiferpa/aurora/loader"
 	"github.com/guiferpa/aurora/parser"
 	"github.com/guiferpa/aurora/resolver"
 	"github.com/guiferpa/aurora/wire/ast"
 	"github.com/guiferpa/aurora/wire/diag"
+	"github.com/guiferpa/aurora/wire/ir"
 	"github.com/guiferpa/aurora/wire/module"
 	"github.com/guiferpa/aurora/wire/token"
 )
@@ -47,6 +50,13 @@ type Analysis struct {
 	// a word that a parse cannot see, because they are about the whole of a tree rather
 	// than about a token.
 	Warnings []diag.Warning
+	// Program is what the document compiled to, for what is said about a scope by what it
+	// compiles to. Nil unless it parsed and the session was handed an emitter.
+	Program *ir.Program
+	// Unused is every use line nothing in the document reaches through, as the linter's
+	// unused-use rule finds it. It is the one rule the editor runs on its own: an import that
+	// can go is what a quick fix removes, and a finding nobody is told about cannot be fixed.
+	Unused []linter.Finding
 }
 
 // module answers the module of a specifier among the ones this document imports.
@@ -120,13 +130,40 @@ func (s *Session) Analyze(doc Document) *Analysis {
 	// where that word is cheapest to hear.
 	if s.emit != nil {
 		if program, err := s.emit(tree); err == nil {
-			analysis.Warnings = program.Warnings
+			analysis.Warnings, analysis.Program = program.Warnings, &program
 		}
 	}
 
+	// Only where modules are resolved at all: in a page with one editor nothing can be
+	// imported, and calling a use line there unused would be true and beside the point.
+	if s.resolve != nil {
+		analysis.Unused = s.unusedUses(doc, tree)
+	}
+
 	return analysis
 }
 
+// unusedUses runs the unused-use rule over the document alone. The rule reads one file at a
+// time anyway — an alias belongs to the file that wrote it — so nothing else has to be read.
+func (s *Session) unusedUses(doc Document, tree ast.AST) []linter.Finding {
+	// The tokens the analysis keeps have no comments, and a comment is where the rule is
+	// silenced.
+	tokens, err := s.lexer.GetTokens([]byte(doc.Source))
+	if err != nil {
+		return nil
+	}
+	rules := slices.DeleteFunc(linter.Rules(), func(rule linter.Rule) bool { return rule.ID != unusedUse })
+	l, err := linter.New(linter.Options{Rules: rules})
+	if err != nil {
+		return nil
+	}
+	return l.Lint([]linter.File{{
+		Filename: doc.Filename,
+		Module:   module.Module{Tree: tree, Source: doc.Source},
+		Tokens:   tokens,
+	}})
+}
+
 // Diagnostics reports the failure of this pass, if any.
 //
 // The parser stops at the first error, so at most one diagnostic comes out of a pass;
@@ -144,7 +181,8 @@ func (a *Analysis) Diagnostics() Diagnostics {
 	if failure == nil {
 		// Nothing is wrong enough to stop it, which is when what the compiler merely
 		// wanted to say is worth saying.
-		return append(diagnostics, a.warnings()...)
+		diagnostics = append(diagnostics, a.warnings()...)
+		return append(diagnostics, a.unused()...)
 	}
 
 	source := "aurora"
@@ -152,16 +190,21 @@ func (a *Analysis) Diagnostics() Diagnostics {
 
 	// Position comes from the structured error carried by the lexer and the parser,
 	// so the underline covers the offending tk instead of guessing from the message.
+	// What it is about comes from there too, for a quick fix to act on.
 	var perr *token.Error
+	code, data := "", map[string]int(nil)
 	if errors.As(failure, &perr) {
 		rng = a.rangeFor(perr.Offset, perr.Length)
+		code, data = string(perr.Code), perr.Facts
 	}
 
 	return append(diagnostics, Diagnostic{
 		Range:    rng,
 		Severity: SeverityError,
+		Code:     code,
 		Source:   source,
 		Message:  failure.Error(),
+		Data:     data,
 	})
 }
 
@@ -183,13 +226,53 @@ func (a *Analysis) warnings() Diagnostics {
 		diagnostics = append(diagnostics, Diagnostic{
 			Range:    a.Mapper.RangeAt(warning.Line, warning.Column),
 			Severity: SeverityWarning,
+			Code:     string(warning.Code),
 			Source:   "aurora",
 			Message:  warning.Message,
+			Data:     warning.Facts,
 		})
 	}
 	return diagnostics
 }
 
+// unused answers the imports nothing reaches through, each drawn over its whole use line and
+// faded rather than underlined: it is not wrong, and it can go.
+func (a *Analysis) unused() Diagnostics {
+	diagnostics := Diagnostics{}
+	for _, finding := range a.Unused {
+		declaration, ok := a.useAt(finding.Line, finding.Column)
+		if !ok {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    declaration,
+			Severity: SeverityHint,
+			Code:     finding.Rule,
+			Source:   "aurora lint",
+			Message:  finding.Message,
+			Tags:     []int{Unnecessary},
+		})
+	}
+	return diagnostics
+}
+
+// useAt answers the range of the use declaration whose keyword is at a place, from the
+// keyword to its semicolon.
+func (a *Analysis) useAt(line, column int) (lsp.Range, bool) {
+	for i, tk := range a.Tokens {
+		if tk.GetTag().Id != token.USE || tk.GetLine() != line || tk.GetColumn() != column {
+			continue
+		}
+		for _, end := range a.Tokens[i:] {
+			if end.GetTag().Id == token.SEMICOLON {
+				start := tk.GetCursor()
+				return a.Mapper.Range(start, end.GetCursor()+1-start), true
+			}
+		}
+	}
+	return lsp.Range{}, false
+}
+
 // rangeFor converts a byte span into a range, backing up to the last meaningful character
 // when the span would be empty. Errors reported against the EOF tk — a missing
 // semicolon, an unclosed block — otherwise produce a zero-width marker past the end of the
@@ -406,6 +489,8 @@ func describeNode(node ast.Node) string {
 		return "deferred scope"
 	case ast.CalleeLiteral:
 		return "call"
+	case ast.ContractCall:
+		return "call to a contract"
 	default:
 		return "expression"
 	}
@@ -0,0 +1,251 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/evaluator"
+	"github.com/guiferpa/aurora/wire/eval"
+	"github.com/guiferpa/aurora/wire/ir"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// A line starting with a colon is a question about the session rather than a line of the
+// program: what a line compiles to, what is bound, what a value reads as. No program can
+// start with one, so the two never meet.
+
+// commandPrefix is what marks a line as a command.
+const commandPrefix = ":"
+
+// isCommand says whether a line is a command.
+func isCommand(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), commandPrefix)
+}
+
+// A command is a name, what it takes, and what it does with it.
+type command struct {
+	usage string
+	help  string
+	// takes says the command is nothing without an argument.
+	takes bool
+	run   func(s *Session, arg string) error
+}
+
+// commands are the session's commands, by name. :help is answered from this table rather than
+// living in it, since a table holding a command that reads the table is a cycle.
+var commands = map[string]command{
+	"ir":     {usage: ":ir <expr>", help: "show the instructions a line compiles to, without running it", takes: true, run: (*Session).showIR},
+	"env":    {usage: ":env", help: "list the names bound and the scopes deferred", run: (*Session).showEnv},
+	"tape":   {usage: ":tape <n>", help: "start over with values n bytes wide", takes: true, run: (*Session).setTape},
+	"load":   {usage: ":load <file.ar>", help: "run a file into the session", takes: true, run: (*Session).loadFile},
+	"save":   {usage: ":save <file.ar>", help: "write what ran as a source file", takes: true, run: (*Session).save},
+	"reset":  {usage: ":reset", help: "start over, forgetting everything", run: (*Session).reset},
+	"shapes": {usage: ":shapes", help: "list the shapes declared", run: (*Session).showShapes},
+	"show":   {usage: ":show <expr>", help: "show a value as bytes, as a decimal and as text", takes: true, run: (*Session).show},
+}
+
+// command runs a command line. Like a line of the program, an error is written and the session
+// carries on.
+func (s *Session) command(text string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(text), commandPrefix), " ")
+	arg = strings.TrimSpace(arg)
+
+	if name == "help" {
+		s.help()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		_, _ = fmt.Fprintf(s.out, "unknown command :%s; :help lists them\n", name)
+		return
+	}
+	if cmd.takes && arg == "" {
+		_, _ = fmt.Fprintf(s.out, "usage: %s\n", cmd.usage)
+		return
+	}
+	if err := cmd.run(s, arg); err != nil {
+		_, _ = fmt.Fprintln(s.out, err)
+	}
+}
+
+// help lists every command, in the order of their names.
+func (s *Session) help() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(s.out, "  %-16s %s\n", commands[name].usage, commands[name].help)
+	}
+}
+
+// showIR compiles a line and writes what it compiled to. It is not run, so nothing it would
+// bind is bound — the use lines in it still load, since a line is checked against them.
+func (s *Session) showIR(arg string) error {
+	program, err := s.compile(terminated(arg))
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(s.out, ir.Format(program.Instructions))
+	return nil
+}
+
+// showEnv lists what the session has bound, then what each module it loaded has.
+//
+// A deferred scope is listed apart from the names: the name holds an index, which is a value
+// like any other, and the scope is what the index reaches when it is called.
+func (s *Session) showEnv(string) error {
+	frames := s.ev.Frames()
+	empty := true
+	for _, frame := range frames {
+		empty = s.writeFrame(frame, "") && empty
+	}
+
+	ids := make([]string, 0, len(s.loaded))
+	for id := range s.loaded {
+		ids = append(ids, string(id))
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		frame, ok := s.ev.ModuleFrame(id)
+		if !ok {
+			continue
+		}
+		// A module binds its names qualified, so two modules can bind the same word; under
+		// the module's own heading they are written the way its file wrote them.
+		for at, binding := range frame.Bindings {
+			frame.Bindings[at].Name = s.loaded[module.ID(id)].Symbol(binding.Name)
+		}
+		_, _ = fmt.Fprintf(s.out, "module %s\n", id)
+		s.writeFrame(frame, "  ")
+		empty = false
+	}
+
+	if empty {
+		_, _ = fmt.Fprintln(s.out, "nothing is bound")
+	}
+	return nil
+}
+
+// writeFrame writes one environ's names and scopes, and answers whether there were none.
+func (s *Session) writeFrame(frame evaluator.Frame, indent string) bool {
+	for _, binding := range frame.Bindings {
+		_, _ = fmt.Fprintf(s.out, "%sident %s = %v\n", indent, binding.Name, binding.Value)
+	}
+	for _, scope := range frame.Scopes {
+		_, _ = fmt.Fprintf(s.out, "%sdefer %d = instructions %d to %d\n", indent, scope.Index, scope.From, scope.To)
+	}
+	return len(frame.Bindings) == 0 && len(frame.Scopes) == 0
+}
+
+// setTape starts the session over at another width. Nothing carries across: a name bound to
+// eight bytes is not a name bound to two.
+func (s *Session) setTape(arg string) error {
+	size, err := strconv.Atoi(arg)
+	if err != nil || size == 0 {
+		return fmt.Errorf("tape size must be a number from 1 to 32, not %q", arg)
+	}
+	if err := byteutil.ValidateTapeSize(size); err != nil {
+		return err
+	}
+	s.rebuild(size)
+	_, _ = fmt.Fprintf(s.out, "values are %d bytes wide; the session starts over\n", size)
+	return nil
+}
+
+// loadFile runs a file as if it had been typed, without answering for every line of it: a file
+// is mostly declarations, and what it prints is what it wants shown.
+func (s *Session) loadFile(arg string) error {
+	source, err := os.ReadFile(strings.Trim(arg, `"`))
+	if err != nil {
+		return err
+	}
+	program, err := s.compile(string(source))
+	if err != nil {
+		return err
+	}
+	var failed error
+	ran := 0
+	s.execute(program, func(_ eval.Returns, _ string, err error) {
+		if failed = err; err == nil {
+			ran++
+		}
+	})
+	s.acceptRan(string(source), ran)
+	return failed
+}
+
+// reset starts the session over at the width it has.
+func (s *Session) reset(string) error {
+	s.rebuild(s.tapeSize)
+	_, _ = fmt.Fprintln(s.out, "the session starts over")
+	return nil
+}
+
+// showShapes lists every shape the session knows, those a module answered with included, under
+// the names they are built by.
+func (s *Session) showShapes(string) error {
+	names := make([]string, 0, len(s.declarations.Shapes))
+	for name := range s.declarations.Shapes {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(s.out, "no shapes are declared")
+		return nil
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(s.out, "shape %s { %s }\n", name, strings.Join(s.declarations.Shapes[name], ", "))
+	}
+	return nil
+}
+
+// show writes a value in the three readings a tape has. It takes any expression, since a name
+// is one, and `g.base` or `p.x` is what somebody asking about a name often means.
+//
+// The expression runs in the session like a line typed, and what it binds stays bound, so it is
+// recorded like one: a :save after it has to bind the same names.
+func (s *Session) show(arg string) error {
+	expr := terminated(arg)
+	program, err := s.compile(expr)
+	if err != nil {
+		return err
+	}
+
+	var value []byte
+	var failed error
+	ran := 0
+	s.execute(program, func(temps eval.Returns, result string, err error) {
+		value, failed = temps[result], err
+		if err == nil {
+			ran++
+		}
+	})
+	s.acceptRan(expr, ran)
+	if failed != nil {
+		return failed
+	}
+	if value == nil {
+		return errors.New("that has no value to show")
+	}
+
+	_, _ = fmt.Fprintf(s.out, "bytes    %v\n", value)
+	_, _ = fmt.Fprintf(s.out, "decimal  %s\n", byteutil.DecimalOf(value, s.tapeSize))
+	_, _ = fmt.Fprintf(s.out, "text     %q\n", byteutil.TextOf(value, s.tapeSize))
+	return nil
+}
+
+// terminated ends an expression with the semicolon a statement needs, so a command can be
+// asked about `x` as well as `x;`.
+func terminated(expr string) string {
+	if strings.HasSuffix(expr, ";") {
+		return expr
+	}
+	return expr + ";"
+}
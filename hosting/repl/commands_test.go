@@ -0,0 +1,106 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A colon starts a question about the session rather than a line of the program. These are
+// typed the way the lines are, and what is pinned is what each command answers.
+
+func TestCommandsAnswer(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines string
+		want  []string
+	}{
+		{
+			// What a line compiles to, without it running: the name is not bound afterwards.
+			name:  "ir",
+			lines: ":ir ident x = 1 + 2\nx;\n",
+			want:  []string{"OpAdd", "OpIdent", "identifier x not found"},
+		},
+		{
+			name:  "env lists names and deferred scopes",
+			lines: "ident x = 7;\nident double = defer { feed(0) * 2; };\n:env\n",
+			want:  []string{"ident x = [0 0 0 0 0 0 0 7]", "ident double = [0 0 0 0 0 0 0 0]", "defer 0 = instructions"},
+		},
+		{name: "env with nothing bound", lines: ":env\n", want: []string{"nothing is bound"}},
+		{
+			name:  "shapes",
+			lines: "shape Point { x, y };\n:shapes\n",
+			want:  []string{"shape Point { x, y }"},
+		},
+		{name: "no shapes", lines: ":shapes\n", want: []string{"no shapes are declared"}},
+		{
+			// The three readings of one tape, side by side.
+			name:  "show",
+			lines: "ident letter = 65;\n:show letter\n",
+			want:  []string{"bytes    [0 0 0 0 0 0 0 65]", "decimal  65", `text     "A"`},
+		},
+		{
+			name:  "show takes an expression",
+			lines: "shape Point { x, y };\nident p = Point{10, 20};\n:show p.y\n",
+			want:  []string{"decimal  20"},
+		},
+		{
+			name:  "reset forgets",
+			lines: "ident x = 7;\n:reset\nx;\n",
+			want:  []string{"the session starts over", "identifier x not found"},
+		},
+		{
+			// The width changes and the session starts over at it.
+			name:  "tape",
+			lines: "ident x = 7;\n:tape 1\n255 + 1;\nx;\n",
+			want:  []string{"values are 1 bytes wide", "= [0]", "identifier x not found"},
+		},
+		{name: "tape out of range", lines: ":tape 40\n1;\n", want: []string{"tape", "= [0 0 0 0 0 0 0 1]"}},
+		{name: "tape that is not a number", lines: ":tape wide\n", want: []string{`not "wide"`}},
+		{name: "a command missing its argument", lines: ":show\n", want: []string{"usage: :show <expr>"}},
+		{name: "an unknown command", lines: ":nope\n", want: []string{"unknown command :nope"}},
+		{name: "help", lines: ":help\n", want: []string{":ir <expr>", ":load <file.ar>", ":show <expr>"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := typed(t, tc.lines, 0)
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("session wrote %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+// A loaded file runs as if it had been typed, so what it binds is there on the next line; it
+// is not answered for line by line, since a file is mostly declarations.
+func TestLoadRunsAFileIntoTheSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shapes.ar")
+	source := "shape Point { x, y };\nident origin = Point{3, 4};\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := typed(t, ":load "+path+"\norigin.x + origin.y;\n", 0)
+
+	if strings.Count(got, "=") != 1 {
+		t.Errorf("session wrote %q, want only the typed line answered", got)
+	}
+	if !strings.Contains(got, "= [0 0 0 0 0 0 0 7]") {
+		t.Errorf("session wrote %q, want what the file bound to be there", got)
+	}
+}
+
+// A module a session loaded keeps its names in its own environ, and :env says whose they are.
+func TestEnvListsModules(t *testing.T) {
+	got := typedIn(t, withModule(t, geometry), "use geometry as g;\n:env\n", 0)
+
+	for _, want := range []string{"module geometry", "  ident base = [0 0 0 0 0 0 0 10]", "  defer 0"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("session wrote %q, want it to contain %q", got, want)
+		}
+	}
+}
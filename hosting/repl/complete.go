@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/loader"
+	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// complete answers every word the session knows that starts with the one given, sorted.
+//
+// A word with a dot in it reaches into a module: the alias before the dot says which, and what
+// can follow is what that module binds at its top, the same answer the language server gives.
+// Anything else is a keyword, a name this session bound, a shape it declared, or an alias.
+func (s *Session) complete(word string) []string {
+	if alias, member, qualified := strings.Cut(word, module.Separator); qualified {
+		return s.completeMember(alias, member)
+	}
+
+	known := make([]string, 0)
+	for _, tag := range token.GetProcessableTags() {
+		known = append(known, tag.Keyword)
+	}
+	for _, frame := range s.ev.Frames() {
+		for _, binding := range frame.Bindings {
+			known = append(known, binding.Name)
+		}
+	}
+	for name := range s.declarations.Shapes {
+		// A shape a module answered with is written with the module in front, and reached
+		// through its alias rather than typed whole.
+		if !strings.Contains(name, module.Separator) {
+			known = append(known, name)
+		}
+	}
+	for alias := range s.declarations.Modules {
+		known = append(known, alias)
+	}
+	return matching(known, word)
+}
+
+// completeMember answers the names a module offers under an alias, written the way they are
+// typed: the alias, the dot, the name.
+func (s *Session) completeMember(alias, member string) []string {
+	specifier, ok := s.declarations.Modules[alias]
+	if !ok {
+		return nil
+	}
+	loaded, ok := s.loaded[module.ID(specifier)]
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0)
+	for _, name := range matching(loader.Exports(loaded), member) {
+		names = append(names, alias+module.Separator+name)
+	}
+	return names
+}
+
+// matching answers the words that start with the prefix, once each, in order.
+func matching(words []string, prefix string) []string {
+	found := make([]string, 0)
+	for _, word := range words {
+		if word != "" && strings.HasPrefix(word, prefix) {
+			found = append(found, word)
+		}
+	}
+	slices.Sort(found)
+	return slices.Compact(found)
+}
@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// What Tab offers is what the session knows, asked after the lines were typed.
+
+func TestCompletionOffersWhatTheSessionKnows(t *testing.T) {
+	s := newTypedSession("ident total = 1;\nident tally = 2;\nshape Tuple { a, b };\n", 0, &strings.Builder{}, false)
+	s.Start()
+
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{word: "t", want: []string{"tail", "tally", "timestamp", "total"}},
+		{word: "to", want: []string{"total"}},
+		{word: "de", want: []string{"defer"}},
+		{word: "Tu", want: []string{"Tuple"}},
+		{word: "zz", want: []string{}},
+	}
+	for _, tc := range cases {
+		if got := s.complete(tc.word); !slices.Equal(got, tc.want) {
+			t.Errorf("complete(%q) = %v, want %v", tc.word, got, tc.want)
+		}
+	}
+}
+
+// After an alias and a dot, what follows is what the module binds at its top.
+func TestCompletionReachesIntoAModule(t *testing.T) {
+	t.Chdir(withModule(t, geometry))
+	s := newTypedSession("use geometry as g;\n", 0, &strings.Builder{}, true)
+	s.Start()
+
+	if got, want := s.complete("g"), []string{"g"}; !slices.Equal(got, want) {
+		t.Errorf("complete(%q) = %v, want %v", "g", got, want)
+	}
+	if got, want := s.complete("g."), []string{"g.area", "g.base"}; !slices.Equal(got, want) {
+		t.Errorf("complete(%q) = %v, want %v", "g.", got, want)
+	}
+	if got, want := s.complete("g.b"), []string{"g.base"}; !slices.Equal(got, want) {
+		t.Errorf("complete(%q) = %v, want %v", "g.b", got, want)
+	}
+	if got := s.complete("nope."); len(got) != 0 {
+		t.Errorf("complete(%q) = %v, want nothing", "nope.", got)
+	}
+}
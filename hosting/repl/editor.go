@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 )
 
@@ -19,6 +20,7 @@ const (
 	keyCtrlD     = 0x04
 	keyCtrlE     = 0x05
 	keyCtrlH     = 0x08
+	keyTab       = 0x09
 	keyLineFeed  = 0x0a
 	keyEnter     = 0x0d
 	keyEscape    = 0x1b
@@ -36,6 +38,9 @@ type editor struct {
 	prompt string
 	hist   *History
 	raw    func() (func(), error)
+	// complete answers what the word before the cursor could be finished as. Nil makes Tab
+	// a key like any other control character, which is to say nothing.
+	complete func(word string) []string
 
 	mu      sync.Mutex
 	restore func()
@@ -51,6 +56,12 @@ func newEditor(in io.Reader, out io.Writer, prompt string, hist *History, raw fu
 	}
 }
 
+// SetPrompt changes what the next line is prompted with: the session asks for another one
+// while what was typed is not finished.
+func (e *editor) SetPrompt(prompt string) {
+	e.prompt = prompt
+}
+
 // Restore leaves raw mode if the editor is currently in it. It is safe to call from a
 // signal handler, which is the one path that can end the process while a line is being read.
 func (e *editor) Restore() {
@@ -127,6 +138,23 @@ func (l *line) toStart() { l.pos = 0 }
 
 func (l *line) toEnd() { l.pos = len(l.buf) }
 
+// wordBefore answers the word that ends at the cursor: the characters a name is made of, and
+// the dot that reaches into a module.
+func (l *line) wordBefore() string {
+	start := l.pos
+	for start > 0 && isWordRune(l.buf[start-1]) {
+		start--
+	}
+	return string(l.buf[start:l.pos])
+}
+
+// isWordRune says whether a rune can be part of a name, the way the lexer reads one, or the
+// dot between an alias and what it names.
+func isWordRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		strings.ContainsRune("_-?!<>.", r)
+}
+
 // historyBack moves one entry towards the past, stashing the line being typed on the first
 // move. At the oldest entry it changes nothing and still takes the cursor to the end.
 func (l *line) historyBack() {
@@ -208,6 +236,8 @@ func (e *editor) ReadLine() (string, error) {
 			return e.end("", errInterrupt)
 		case r == keyCtrlD && len(l.buf) == 0:
 			return e.end("", io.EOF)
+		case r == keyTab && e.complete != nil:
+			e.completeWord(l)
 		case r == keyEscape:
 			// A lone ESC blocks until the next key arrives; acceptable for a REPL.
 			apply(escapeKeys[e.readEscape()], l)
@@ -219,6 +249,40 @@ func (e *editor) ReadLine() (string, error) {
 	}
 }
 
+// completeWord finishes the word before the cursor as far as every answer agrees. When they
+// agree no further than what is typed, they are listed under the line, and the line is drawn
+// again below them.
+func (e *editor) completeWord(l *line) {
+	word := l.wordBefore()
+	candidates := e.complete(word)
+	if len(candidates) == 0 {
+		return
+	}
+
+	common := candidates[0]
+	for _, each := range candidates[1:] {
+		common = commonPrefix(common, each)
+	}
+	if len(common) > len(word) {
+		for _, r := range strings.TrimPrefix(common, word) {
+			l.insert(r)
+		}
+		return
+	}
+	if len(candidates) > 1 {
+		_, _ = fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// commonPrefix answers what two words start with alike.
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
 // press applies a key that does not end the line: one of the editing keys, the rune itself,
 // or nothing at all.
 func (e *editor) press(r rune, l *line) {
@@ -189,3 +189,51 @@ func TestEditorRawModeFailurePropagates(t *testing.T) {
 		t.Fatalf("err = %v, want %v", err, want)
 	}
 }
+
+// Tab finishes the word before the cursor as far as every answer agrees, and lists them when
+// they agree no further than what is typed.
+func TestEditorTabCompletes(t *testing.T) {
+	words := []string{"defer", "double", "printb", "printc", "printd"}
+	complete := func(word string) []string { return matching(words, word) }
+
+	for _, tt := range []struct {
+		name   string
+		input  string
+		want   string
+		listed bool
+	}{
+		{name: "one answer", input: "def\t" + keyEnterS, want: "defer"},
+		{name: "as far as the answers agree", input: "pr\t" + keyEnterS, want: "print"},
+		{name: "a word in the middle of the line", input: "ident x = dou\t(1);" + keyEnterS, want: "ident x = double(1);"},
+		{name: "no answer", input: "zz\t" + keyEnterS, want: "zz"},
+		{name: "the answers are listed", input: "print\t" + keyEnterS, want: "print", listed: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			e := newEditor(strings.NewReader(tt.input), out, prompt, LoadHistory(""), nil)
+			e.complete = complete
+
+			got, err := e.ReadLine()
+			if err != nil {
+				t.Fatalf("ReadLine: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("line = %q, want %q", got, tt.want)
+			}
+			if listed := strings.Contains(out.String(), "printb  printc  printd"); listed != tt.listed {
+				t.Errorf("listed = %v, want %v, in %q", listed, tt.listed, out.String())
+			}
+		})
+	}
+}
+
+// Without a completer, Tab is a control character like the others, and is dropped.
+func TestEditorTabWithoutCompletion(t *testing.T) {
+	got, err := readLine(t, LoadHistory(""), "a\tb"+keyEnterS)
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if got != "ab" {
+		t.Errorf("line = %q, want %q", got, "ab")
+	}
+}
@@ -19,8 +19,10 @@ import (
 	"github.com/guiferpa/aurora/parser"
 	"github.com/guiferpa/aurora/resolver"
 	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/eval"
 	"github.com/guiferpa/aurora/wire/ir"
 	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
 )
 
 // render prints the value of the line that was typed — the temp left by its last
@@ -52,20 +54,30 @@ func render(w io.Writer, temps map[string][]byte, result string, eerr error) {
 
 const prompt = ">> "
 
+// continuation is the prompt of a line that carries on the one before it: a brace was opened
+// and has not been closed yet.
+const continuation = ".. "
+
 // lineReader is where the REPL gets the next line from: the editor when stdin is a
 // terminal (arrow keys, history), a plain scanner otherwise (pipes, CI, tests).
 type lineReader interface {
 	ReadLine() (string, error)
+	SetPrompt(prompt string)
 }
 
 // scannerReader is the non-interactive path: same behavior the REPL had before the editor.
 type scannerReader struct {
 	scanner *bufio.Scanner
 	out     io.Writer
+	prompt  string
+}
+
+func (s *scannerReader) SetPrompt(prompt string) {
+	s.prompt = prompt
 }
 
 func (s *scannerReader) ReadLine() (string, error) {
-	_, _ = fmt.Fprint(s.out, prompt)
+	_, _ = fmt.Fprint(s.out, s.prompt)
 	if !s.scanner.Scan() {
 		if err := s.scanner.Err(); err != nil {
 			return "", err
@@ -80,7 +92,7 @@ func (s *scannerReader) ReadLine() (string, error) {
 func newLineReader(in io.Reader, out io.Writer) (lineReader, *History) {
 	f, ok := in.(*os.File)
 	if !ok || !isTTY(f) {
-		return &scannerReader{scanner: bufio.NewScanner(in), out: out}, LoadHistory("")
+		return &scannerReader{scanner: bufio.NewScanner(in), out: out, prompt: prompt}, LoadHistory("")
 	}
 
 	// Without a home directory the history stays in memory; that is no reason to break the REPL.
@@ -107,6 +119,7 @@ type Session struct {
 	emitter emitter.Emitter
 
 	ev       *evaluator.Evaluator
+	build    func(tapeSize int) Phases
 	reader   lineReader
 	out      io.Writer
 	tapeSize int
@@ -127,6 +140,10 @@ type Session struct {
 	// that imports.
 	loaded map[module.ID]module.Module
 
+	// ran is every piece of the session that compiled and ran to the end, in order: what
+	// :save writes down.
+	ran []entry
+
 	hist       *History
 	histWarned bool
 }
@@ -139,7 +156,24 @@ func (s *Session) run(text string) {
 		_, _ = fmt.Fprintln(s.out, err)
 		return
 	}
-	s.evaluate(program)
+	// A line that stopped partway keeps what it bound before it stopped, so that much of it is
+	// part of the session as well.
+	s.acceptRan(text, s.evaluate(program))
+}
+
+// rebuild starts the session over at a width: every phase that depends on it is made again,
+// and everything the old ones left behind — names, shapes, modules, the buffer — goes with them,
+// since none of it means the same thing at another width.
+func (s *Session) rebuild(tapeSize int) {
+	phases := s.build(tapeSize)
+	s.emitter = phases.Emitter
+	s.ev = phases.Evaluator
+	s.resolver = phases.Resolver
+	s.tapeSize = tapeSize
+	s.declarations = parser.NewDeclarations()
+	s.insts = nil
+	s.loaded = make(map[module.ID]module.Module)
+	s.ran = nil
 }
 
 // compile takes the line through the three phases, showing what each one produced when -l
@@ -246,14 +280,28 @@ func (s *Session) load(tree ast.AST) error {
 }
 
 // evaluate runs the line's expressions one at a time, so a line holding several of them
-// answers where each one happens rather than all of them at the end.
-func (s *Session) evaluate(program ir.Program) {
+// answers where each one happens rather than all of them at the end. It answers how many of
+// them ran to the end; the error the line stopped at has already been written.
+func (s *Session) evaluate(program ir.Program) int {
+	ran := 0
+	s.execute(program, func(temps eval.Returns, result string, err error) {
+		render(s.out, temps, result, err)
+		if err == nil {
+			ran++
+		}
+	})
+	return ran
+}
+
+// execute runs a program's expressions one at a time, handing each one's temps to answer as
+// it happens, and stops at the first that fails.
+func (s *Session) execute(program ir.Program, answer func(temps eval.Returns, result string, err error)) {
 	offset := len(s.insts)
 	s.insts = append(s.insts, program.Instructions...)
 
 	for _, expr := range program.Expressions {
 		temps, err := s.ev.EvaluateRange(s.insts, uint64(offset+expr.From), uint64(offset+expr.To))
-		render(s.out, temps, byteutil.ToHex(expr.Label), err)
+		answer(temps, byteutil.ToHex(expr.Label), err)
 		if err != nil {
 			return
 		}
@@ -270,21 +318,29 @@ func (s *Session) remember(text string) {
 	}
 }
 
-// NewSessionOptions is what a session is made of. The phases and the evaluator arrive built,
-// from cmd/aurora, which is where the flags that decide how to build them are read.
-type NewSessionOptions struct {
-	Lexer     *lexer.Lexer
-	Parser    parser.Parser
+// Phases is what a session builds at a width: everything in it reads or writes values of
+// that width, so changing the width is building them again.
+type Phases struct {
 	Emitter   emitter.Emitter
 	Evaluator *evaluator.Evaluator
+	// Resolver finds the files a use line names. Without one a session takes no imports.
+	Resolver *resolver.Resolver
+}
+
+// NewSessionOptions is what a session is made of. The phases arrive as a way to build them,
+// from cmd/aurora, which is where the flags that decide how to build them are read.
+type NewSessionOptions struct {
+	Lexer  *lexer.Lexer
+	Parser parser.Parser
+	// Build makes the phases at a width. It is called once to start, and again by :tape and
+	// :reset, which are a session starting over.
+	Build func(tapeSize int) Phases
 	// In is where lines are typed; Out receives everything the session has to say — the
 	// prompt, the value of each line, and the errors that did not stop it.
 	In  io.Reader
 	Out io.Writer
-	// TapeSize is the width in bytes of every value, the same one the phases were built with.
+	// TapeSize is the width in bytes of every value the session starts with.
 	TapeSize int
-	// Resolver finds the files a use line names. Without one a session takes no imports.
-	Resolver *resolver.Resolver
 }
 
 // NewSession builds a session from what it was handed.
@@ -292,22 +348,21 @@ type NewSessionOptions struct {
 // One evaluator lasts the whole session, unlike a command that runs one program: what a line
 // binds has to still be there on the next one, and so does the scope a line deferred.
 func NewSession(opts NewSessionOptions) *Session {
-	tapeSize := byteutil.TapeSize(opts.TapeSize)
 	reader, hist := newLineReader(opts.In, opts.Out)
 
-	return &Session{
-		lexer:        opts.Lexer,
-		parser:       opts.Parser,
-		emitter:      opts.Emitter,
-		ev:           opts.Evaluator,
-		reader:       reader,
-		out:          opts.Out,
-		tapeSize:     tapeSize,
-		declarations: parser.NewDeclarations(),
-		resolver:     opts.Resolver,
-		loaded:       make(map[module.ID]module.Module),
-		hist:         hist,
+	s := &Session{
+		lexer:  opts.Lexer,
+		parser: opts.Parser,
+		build:  opts.Build,
+		reader: reader,
+		out:    opts.Out,
+		hist:   hist,
+	}
+	s.rebuild(byteutil.TapeSize(opts.TapeSize))
+	if editing, ok := reader.(*editor); ok {
+		editing.complete = s.complete
 	}
+	return s
 }
 
 // Start reads line after line until there are no more, and is where a session spends its life.
@@ -327,7 +382,7 @@ func (s *Session) Start() {
 	})
 
 	for {
-		text, err := reader.ReadLine()
+		text, err := s.read()
 		if errors.Is(err, errInterrupt) { // Ctrl+C: drop the line, prompt again
 			continue
 		}
@@ -341,11 +396,69 @@ func (s *Session) Start() {
 			continue
 		}
 
-		s.remember(text)
+		if isCommand(text) {
+			s.command(text)
+			continue
+		}
 		s.run(text)
 	}
 }
 
+// read answers what was typed up to where it can be run: one line, or as many as it takes to
+// close every brace, bracket and parenthesis the first one opened, so a defer can be written
+// the way it is in a file.
+//
+// Every line goes into the history as it was typed, which is also how it is walked back
+// through. Ctrl+C drops all of them; the end of the input runs what there is, which is either
+// finished or an error worth seeing.
+func (s *Session) read() (string, error) {
+	text, err := s.reader.ReadLine()
+	if err != nil || strings.TrimSpace(text) == "" {
+		return text, err
+	}
+	s.remember(text)
+	if isCommand(text) {
+		return text, nil
+	}
+
+	defer s.reader.SetPrompt(prompt)
+	for s.unfinished(text) {
+		s.reader.SetPrompt(continuation)
+		more, err := s.reader.ReadLine()
+		if errors.Is(err, errInterrupt) {
+			return "", err
+		}
+		if err != nil {
+			return text, nil
+		}
+		if strings.TrimSpace(more) != "" {
+			s.remember(more)
+		}
+		text += "\n" + more
+	}
+	return text, nil
+}
+
+// unfinished says whether something opened in the text has not been closed. It is counted on
+// the lexer's tokens rather than on characters, so a brace inside text or after a comment mark
+// is not one; a text the lexer refuses is finished, and compiling it is how the error is said.
+func (s *Session) unfinished(text string) bool {
+	tokens, err := s.lexer.GetTokens([]byte(text))
+	if err != nil {
+		return false
+	}
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.GetTag().Id {
+		case token.O_CUR_BRK, token.O_BRK, token.O_PAREN:
+			depth++
+		case token.C_CUR_BRK, token.C_BRK, token.C_PAREN:
+			depth--
+		}
+	}
+	return depth > 0
+}
+
 // leaveOnInterrupt says goodbye on Ctrl+C, after giving the terminal back.
 func leaveOnInterrupt(out io.Writer, restore func()) {
 	csig := make(chan os.Signal, 1)
@@ -0,0 +1,128 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// A session is a file typed slowly, and :save is it written down: every piece that ran, in the
+// order it ran, as a source file that runs to the same place. What failed is left out — a file
+// holding it would not compile, or would stop where the session did — but what ran before it
+// on the same line stays in, since it bound names the session still has.
+
+// An entry is one piece that ran: the use lines it opened with, and everything after them.
+//
+// They are kept apart because a file is stricter than a session. A use line is allowed on any
+// line typed, since each one is the top of a program of its own, but a file has one top, and
+// every use line has to be above everything else in it.
+type entry struct {
+	uses string
+	body string
+}
+
+// accept records a piece that ran.
+func (s *Session) accept(text string) {
+	s.ran = append(s.ran, s.split(text))
+}
+
+// acceptRan records the statements of a piece that ran to the end, which is all of them when
+// nothing failed. A piece that stopped partway kept what its first statements bound, and a later
+// line may read those names, so the file :save writes has to bind them too; the statement that
+// failed, and those after it, never happened.
+//
+// A top-level statement is one expression of the program, so the piece is cut after as many
+// semicolons as expressions ran, counting only those outside every brace and parenthesis.
+func (s *Session) acceptRan(text string, ran int) {
+	if ran == 0 {
+		return
+	}
+	tokens, err := s.lexer.GetFilledTokens([]byte(text))
+	if err != nil {
+		return
+	}
+
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.GetTag().Id {
+		case token.O_CUR_BRK, token.O_PAREN, token.O_BRK:
+			depth++
+		case token.C_CUR_BRK, token.C_PAREN, token.C_BRK:
+			depth--
+		case token.SEMICOLON:
+			if depth > 0 {
+				continue
+			}
+			if ran--; ran == 0 {
+				s.accept(text[:tok.GetCursor()+len(tok.GetMatch())])
+				return
+			}
+		}
+	}
+	// The last statement of a piece may go without its semicolon.
+	s.accept(text)
+}
+
+// split cuts a piece at the end of its last use line, found on the lexer's tokens so a
+// semicolon in text or in a comment is not mistaken for one.
+func (s *Session) split(text string) entry {
+	tokens, err := s.lexer.GetFilledTokens([]byte(text))
+	if err != nil {
+		return entry{body: text}
+	}
+
+	end := 0
+	for at := 0; at < len(tokens) && tokens[at].GetTag().Id == token.USE; at++ {
+		for at < len(tokens) && tokens[at].GetTag().Id != token.SEMICOLON {
+			at++
+		}
+		if at == len(tokens) {
+			break
+		}
+		end = tokens[at].GetCursor() + len(tokens[at].GetMatch())
+	}
+	return entry{uses: strings.TrimSpace(text[:end]), body: strings.TrimSpace(text[end:])}
+}
+
+// source is the session as a file: the use lines first, a blank line, then the rest.
+func (s *Session) source() string {
+	var uses, body []string
+	for _, each := range s.ran {
+		if each.uses != "" {
+			uses = append(uses, each.uses)
+		}
+		if each.body != "" {
+			body = append(body, each.body)
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range uses {
+		b.WriteString(line + "\n")
+	}
+	if len(uses) > 0 && len(body) > 0 {
+		b.WriteString("\n")
+	}
+	for _, line := range body {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// save writes the session to a file, over whatever was there.
+func (s *Session) save(arg string) error {
+	path := strings.Trim(arg, `"`)
+	if err := os.WriteFile(path, []byte(s.source()), 0o644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(s.out, "saved the session to %s\n", path)
+	return nil
+}
+
+// Replay runs a file into the session before anything is typed, the way :load does: what it
+// binds is there on the first line, and what it ran is part of what :save writes.
+func (s *Session) Replay(path string) error {
+	return s.loadFile(path)
+}
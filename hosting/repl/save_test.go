@@ -0,0 +1,128 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// What :save writes is what ran, as a file; what Replay reads back is a file, into a session.
+
+// saved types the lines, then saves, and answers the file.
+func saved(t *testing.T, lines string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.ar")
+	typed(t, lines+":save "+path+"\n", 0)
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(source)
+}
+
+// Lines that failed bound nothing, and a command is not a line of the program: neither is in
+// the file. A piece typed over several lines is kept as it was typed.
+func TestSaveWritesWhatRan(t *testing.T) {
+	got := saved(t, "ident x = 7;\nnope;\n:env\n1 +;\nident double = defer {\n  feed(0) * 2;\n};\ndouble(x);\n")
+
+	want := "ident x = 7;\nident double = defer {\n  feed(0) * 2;\n};\ndouble(x);\n"
+	if got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+}
+
+// A line that stops partway keeps what it bound before it stopped, and a later line may read
+// it: the statements that ran are in the file, and the one that failed is not.
+func TestSaveKeepsWhatALineBoundBeforeItFailed(t *testing.T) {
+	got := saved(t, "ident a = 3; ident b = nope; ident c = 4;\na * 2;\n")
+
+	want := "ident a = 3;\na * 2;\n"
+	if got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "replayed.ar")
+	if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTypedSession("", 0, &strings.Builder{}, false)
+	if err := s.Replay(path); err != nil {
+		t.Errorf("the saved file does not replay: %v", err)
+	}
+}
+
+// What :show is asked about runs in the session, and what it binds stays bound, so it is in the
+// file like a line typed: otherwise a later line reading the name would not replay.
+func TestSaveKeepsWhatShowBound(t *testing.T) {
+	got := saved(t, ":show ident y = 2\ny * 3;\n")
+
+	want := "ident y = 2;\ny * 3;\n"
+	if got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+}
+
+// A use line may be typed anywhere in a session, and has to be at the top of a file.
+func TestSaveMovesUseLinesToTheTop(t *testing.T) {
+	dir := withModule(t, geometry)
+	path := filepath.Join(dir, "session.ar")
+	typedIn(t, dir, "ident x = 1;\nuse geometry as g; g.base + x;\n:save "+path+"\n", 0)
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "use geometry as g;\n\nident x = 1;\ng.base + x;\n"
+	if string(source) != want {
+		t.Errorf("saved %q, want %q", source, want)
+	}
+}
+
+// Starting over is starting the file over too.
+func TestSaveAfterResetStartsFromTheReset(t *testing.T) {
+	if got, want := saved(t, "ident x = 7;\n:reset\nident y = 8;\n"), "ident y = 8;\n"; got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+}
+
+// A saved session replayed into a new one carries on where it stopped: what it bound is there
+// on the first line typed, and saving again keeps it.
+func TestReplayCarriesOn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.ar")
+	if err := os.WriteFile(path, []byte(saved(t, "ident x = 7;\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &strings.Builder{}
+	s := newTypedSession("x * 2;\n:save "+path+"\n", 0, out, false)
+	if err := s.Replay(path); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	s.Start()
+
+	if !strings.Contains(out.String(), "= [0 0 0 0 0 0 0 14]") {
+		t.Errorf("session wrote %q, want what the file bound to be there", out.String())
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ident x = 7;\nx * 2;\n"; string(source) != want {
+		t.Errorf("saved %q, want %q", source, want)
+	}
+}
+
+// A file that does not run is said, rather than half of it being taken for a session.
+func TestReplayOfAFileThatFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.ar")
+	if err := os.WriteFile(path, []byte("ident x = 1;\nnope;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTypedSession("", 0, &strings.Builder{}, false)
+	if err := s.Replay(path); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("Replay = %v, want the name that was never set", err)
+	}
+}
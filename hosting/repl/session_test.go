@@ -26,25 +26,34 @@ import (
 func typed(t *testing.T, lines string, tapeSize int) string {
 	t.Helper()
 
-	size := byteutil.TapeSize(tapeSize)
 	out := &strings.Builder{}
+	newTypedSession(lines, tapeSize, out, false).Start()
+	return out.String()
+}
 
-	NewSession(NewSessionOptions{
-		Lexer:   lexer.New(),
-		Parser:  parser.New(),
-		Emitter: emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
-		Evaluator: evaluator.New(evaluator.NewEvaluatorOptions{
-			PrintBytes:   printer.Bytes(out, size),
-			PrintChars:   printer.Chars(out, size),
-			PrintDecimal: printer.Decimal(out, size),
-			TapeSize:     size,
-		}),
+// newTypedSession builds a session that will read the lines given and write to out, taking
+// use lines when it is told there are modules to read.
+func newTypedSession(lines string, tapeSize int, out *strings.Builder, modules bool) *Session {
+	lx := lexer.New()
+	ps := parser.New()
+
+	return NewSession(NewSessionOptions{
+		Lexer:  lx,
+		Parser: ps,
+		Build: func(size int) Phases {
+			phases := Phases{
+				Emitter:   emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
+				Evaluator: newEvaluator(out, size),
+			}
+			if modules {
+				phases.Resolver = newResolver(lx, ps, size)
+			}
+			return phases
+		},
 		In:       strings.NewReader(lines),
 		Out:      out,
-		TapeSize: size,
-	}).Start()
-
-	return out.String()
+		TapeSize: byteutil.TapeSize(tapeSize),
+	})
 }
 
 func TestSessionAnswersWithTheTape(t *testing.T) {
@@ -155,59 +164,55 @@ func TestSessionSaysNothingForABlankLine(t *testing.T) {
 	}
 }
 
+// newEvaluator is the evaluator a session is built with, printing where the session writes.
+func newEvaluator(out *strings.Builder, size int) *evaluator.Evaluator {
+	return evaluator.New(evaluator.NewEvaluatorOptions{
+		PrintBytes:   printer.Bytes(out, size),
+		PrintChars:   printer.Chars(out, size),
+		PrintDecimal: printer.Decimal(out, size),
+		TapeSize:     size,
+	})
+}
+
 // typedIn is typed, in a project on disk: a use line reads a file, and where it reads from is
 // where the session was started.
 func typedIn(t *testing.T, dir, lines string, tapeSize int) string {
 	t.Helper()
 	t.Chdir(dir)
 
-	size := byteutil.TapeSize(tapeSize)
 	out := &strings.Builder{}
-	lx := lexer.New()
-	ps := parser.New()
-
-	NewSession(NewSessionOptions{
-		Lexer:   lx,
-		Parser:  ps,
-		Emitter: emitter.New(emitter.NewEmitterOptions{TapeSize: size}),
-		Evaluator: evaluator.New(evaluator.NewEvaluatorOptions{
-			PrintBytes:   printer.Bytes(out, size),
-			PrintChars:   printer.Chars(out, size),
-			PrintDecimal: printer.Decimal(out, size),
-			TapeSize:     size,
-		}),
-		In:       strings.NewReader(lines),
-		Out:      out,
-		TapeSize: size,
-		Resolver: resolver.New(resolver.Options{
-			SourceRoot: "src",
-			Read:       os.ReadFile,
-			Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
-				tokens, err := lx.GetFilledTokens(source)
-				if err != nil {
-					return ast.AST{}, err
-				}
-				return ps.Parse(parser.ParseInput{
-					Filename: filename,
-					Tokens:   tokens,
-					TapeSize: size,
-					Module:   string(id),
-					Imports:  imports,
-				})
-			},
-			Header: func(source []byte) ([]ast.UseDeclaration, error) {
-				tokens, err := lx.GetFilledTokens(source)
-				if err != nil {
-					return nil, err
-				}
-				return parser.ScanUses(tokens), nil
-			},
-		}),
-	}).Start()
-
+	newTypedSession(lines, tapeSize, out, true).Start()
 	return out.String()
 }
 
+// newResolver reads modules from the source directory of the project the test stands in.
+func newResolver(lx *lexer.Lexer, ps parser.Parser, size int) *resolver.Resolver {
+	return resolver.New(resolver.Options{
+		SourceRoot: "src",
+		Read:       os.ReadFile,
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return ps.Parse(parser.ParseInput{
+				Filename: filename,
+				Tokens:   tokens,
+				TapeSize: size,
+				Module:   string(id),
+				Imports:  imports,
+			})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
+}
+
 // withModule writes a project with one module in it and answers where it is.
 func withModule(t *testing.T, source string) string {
 	t.Helper()
@@ -294,3 +299,50 @@ func TestASessionReadsAPromisedShape(t *testing.T) {
 		t.Errorf("the session said %q, want it to contain 42", got)
 	}
 }
+
+// A line that opens a brace goes on until it is closed, under a prompt that says so, and runs
+// as one: a defer is written the way it is in a file.
+func TestSessionReadsUntilTheBracesClose(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines string
+		want  []string
+	}{
+		{
+			name:  "a defer over several lines",
+			lines: "ident double = defer {\n  feed(0) * 2;\n};\ndouble(21);\n",
+			want:  []string{continuation, "= [0 0 0 0 0 0 0 42]"},
+		},
+		{
+			name:  "a call split over lines",
+			lines: "printd (1 +\n2);\n",
+			want:  []string{continuation, "3"},
+		},
+		{
+			// A brace inside text or behind a comment mark opens nothing.
+			name:  "a brace in text",
+			lines: "\"{\";\n",
+			want:  []string{"= [0 0 0 0 0 0 0 123]"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := typed(t, tc.lines, 0)
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("session wrote %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+// A comment mark hides what follows it on the line, braces included.
+func TestSessionIgnoresABraceInAComment(t *testing.T) {
+	got := typed(t, "1; #- {\n2;\n", 0)
+
+	if strings.Contains(got, continuation) {
+		t.Errorf("session wrote %q, want no line carried on", got)
+	}
+}
@@ -25,6 +25,14 @@ var keywordTags = []token.Tag{
 	token.TagPull,
 	token.TagFeed,
 	token.TagAssert,
+	token.TagRequire,
+	token.TagCaller,
+	token.TagCallValue,
+	token.TagTimestamp,
+	token.TagBlockNumber,
+	token.TagChainID,
+	token.TagCall,
+	token.TagStaticCall,
 	token.TagShape,
 	token.TagAs,
 	token.TagUse,
@@ -6,16 +6,21 @@ import (
 	"github.com/guiferpa/aurora/wire/token"
 )
 
+// GetTokens reads every token of a source, the ones that mean nothing to a parser included:
+// whitespace, line breaks and comments are what a formatter and an editor put back where they
+// were.
+//
+// A comment is one token, from "#-" to the end of its line, and what it says is its match.
+// Whatever is written after the mark is not read as code, so nothing in it can be refused.
 func (l *Lexer) GetTokens(bs []byte) ([]token.Token, error) {
 	cursor := 0
 	col := cursor + 1
 	line := 1
 	length := len(bs)
 	tokens := make([]token.Token, 0)
-	isComment := false
 	for cursor < length {
 		matched, tag, match := MatchToken(bs[cursor:])
-		if !matched && !isComment {
+		if !matched {
 			return tokens, &token.Error{
 				Message: fmt.Sprintf("unexpected character at line %d, column %d", line, col),
 				Line:    line,
@@ -24,20 +29,16 @@ func (l *Lexer) GetTokens(bs []byte) ([]token.Token, error) {
 				Length:  1,
 			}
 		}
-		if !isComment {
-			tokens = append(tokens, token.New(match, tag, line, col, cursor))
+		if tag.Id == token.COMMENT_LINE {
+			match = bs[cursor : cursor+commentLength(bs[cursor:])]
 		}
+		tokens = append(tokens, token.New(match, tag, line, col, cursor))
 		if len(match) == 0 {
 			cursor++
 		}
 		cursor = cursor + len(match)
 
-		if tag.Id == token.COMMENT_LINE {
-			isComment = true
-		}
-
 		if tag.Id == token.BREAK_LINE {
-			isComment = false
 			line++
 			col = 1
 		} else {
@@ -47,6 +48,17 @@ func (l *Lexer) GetTokens(bs []byte) ([]token.Token, error) {
 	return append(tokens, token.New([]byte{}, token.TagEOF, line, col, cursor)), nil
 }
 
+// commentLength answers how long the comment a source starts with is: up to the line break
+// that ends it, which is not its own.
+func commentLength(bs []byte) int {
+	for at, c := range bs {
+		if isNewline(c) {
+			return at
+		}
+	}
+	return len(bs)
+}
+
 func (l *Lexer) GetFilledTokens(bs []byte) ([]token.Token, error) {
 	toks, err := l.GetTokens(bs)
 	if err != nil {
@@ -0,0 +1,200 @@
+// Package linter reads a program for what is legal and probably not meant: a name nothing reads,
+// an import nothing reaches through, a condition that can only go one way.
+//
+// The emitter already says some of this while it compiles — a call applying fewer values than
+// its scope reads, an assert outside a test — and those stay where they are: they are about
+// what the instructions will do, and a program compiled without asking should still hear them.
+// What is here is about how the source was written. Nothing in it changes what runs, so none of
+// it belongs in a compile, and every one of it is a matter of taste somebody may not share —
+// which is why each rule has a name, a severity a project can change, and a comment that
+// silences it on one line.
+//
+// A rule reads trees, never instructions, and reads all of a project's files at once. A name at
+// the top of a module is used when another file reads it, and a shape is built when any file
+// builds it, so a rule handed one file at a time would be wrong about both.
+package linter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// Severity is how much a finding matters, and what a project decides per rule.
+type Severity int
+
+const (
+	// Off is a rule nobody asked for: it does not run.
+	Off Severity = iota
+	// Info is said and does not fail anything.
+	Info
+	// Warning is said, and fails "aurora lint".
+	Warning
+	// Error is said, and fails it too; it is what a project marks as never acceptable.
+	Error
+)
+
+var severities = []string{"off", "info", "warning", "error"}
+
+func (s Severity) String() string {
+	if int(s) < 0 || int(s) >= len(severities) {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return severities[s]
+}
+
+// ParseSeverity reads a severity as aurora.toml writes it.
+func ParseSeverity(text string) (Severity, error) {
+	at := slices.Index(severities, text)
+	if at < 0 {
+		return Off, fmt.Errorf("%q is not a severity: it is one of %s", text, strings.Join(severities, ", "))
+	}
+	return Severity(at), nil
+}
+
+// A Finding is one thing a rule has to say, and where.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// A File is one file of the program, as the resolver read it, and every token of it. The tokens
+// are the lexer's GetTokens, comments included: a comment is where a finding is silenced, and
+// the tree has none.
+type File struct {
+	Filename string
+	Module   module.Module
+	Tokens   []token.Token
+}
+
+// A Rule is one thing worth checking. Check answers what it found without a severity; the
+// linter puts the one the project chose.
+type Rule struct {
+	// ID is the name aurora.toml and a suppression comment call the rule by.
+	ID       string
+	Summary  string
+	Severity Severity
+	Check    func(*Program) []Finding
+}
+
+// Rules is every rule there is, in the order they are listed.
+func Rules() []Rule {
+	return []Rule{
+		{ID: "unused-ident", Summary: "a name bound with ident that nothing reads", Severity: Warning, Check: unusedIdents},
+		{ID: "unused-use", Summary: "a module alias nothing in the file reaches through", Severity: Warning, Check: unusedUses},
+		{ID: "shadowed-name", Summary: "a name bound again inside a scope that can already read it", Severity: Info, Check: shadowedNames},
+		{ID: "unbuilt-shape", Summary: "a shape declared and never built, read with as, or promised", Severity: Warning, Check: unbuiltShapes},
+		{ID: "unfed-position", Summary: "a feed(n) past every value any call to its scope applies", Severity: Warning, Check: unfedPositions},
+		{ID: "constant-condition", Summary: "an if or branch arm whose test is written in literals only", Severity: Warning, Check: constantConditions},
+		{ID: "dead-code", Summary: "the arm of an if or branch its literal test never takes", Severity: Warning, Check: deadCode},
+	}
+}
+
+// Options says which rules run and how much each one matters. No rules is every rule, and a
+// rule missing from Severities keeps its own.
+type Options struct {
+	Rules      []Rule
+	Severities map[string]string
+}
+
+// A Linter is a set of rules, each at the severity a project chose.
+type Linter struct {
+	rules []Rule
+}
+
+// New checks the severities against the rules before anything is read: a rule misspelled in
+// aurora.toml is a setting that silently does nothing, which is the one kind of mistake a lint
+// configuration cannot afford.
+func New(options Options) (*Linter, error) {
+	rules := options.Rules
+	if rules == nil {
+		rules = Rules()
+	}
+	rules = slices.Clone(rules)
+
+	for id, text := range options.Severities {
+		at := slices.IndexFunc(rules, func(rule Rule) bool { return rule.ID == id })
+		if at < 0 {
+			return nil, fmt.Errorf("no lint rule is called %q: it is one of %s", id, strings.Join(ids(rules), ", "))
+		}
+		severity, err := ParseSeverity(text)
+		if err != nil {
+			return nil, fmt.Errorf("lint rule %s: %w", id, err)
+		}
+		rules[at].Severity = severity
+	}
+	return &Linter{rules: rules}, nil
+}
+
+// Rules is the rules this linter runs, each at the severity it runs at.
+func (l *Linter) Rules() []Rule {
+	return slices.Clone(l.rules)
+}
+
+// Lint answers what every rule found in a program, in the order of the files and of the places
+// in them. A finding a comment silences is not in the answer; a comment silencing a rule there
+// is not is, the way New refuses one in aurora.toml.
+//
+// The same file may be handed over twice — once as what was asked for, once as a module another
+// file imports — and it is read once, as the module: that is the reading in which its names
+// are the ones the rest of the program uses.
+func (l *Linter) Lint(files []File) []Finding {
+	program := newProgram(dedupe(files))
+
+	found := make([]Finding, 0)
+	for _, rule := range l.rules {
+		if rule.Severity == Off {
+			continue
+		}
+		for _, finding := range rule.Check(program) {
+			finding.Rule, finding.Severity = rule.ID, rule.Severity
+			if !program.suppressed(finding) {
+				found = append(found, finding)
+			}
+		}
+	}
+	found = append(found, program.unknownSuppressions(ids(l.rules))...)
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return cmp.Or(
+			cmp.Compare(found[i].Filename, found[j].Filename),
+			cmp.Compare(found[i].Line, found[j].Line),
+			cmp.Compare(found[i].Column, found[j].Column),
+		) < 0
+	})
+	return found
+}
+
+// dedupe keeps one reading of each file, the module's when there is one.
+func dedupe(files []File) []File {
+	kept := make([]File, 0, len(files))
+	at := make(map[string]int)
+	for _, file := range files {
+		i, seen := at[file.Filename]
+		switch {
+		case !seen:
+			at[file.Filename] = len(kept)
+			kept = append(kept, file)
+		case kept[i].Module.IsEntry() && !file.Module.IsEntry():
+			kept[i] = file
+		}
+	}
+	return kept
+}
+
+func ids(rules []Rule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.ID)
+	}
+	return names
+}
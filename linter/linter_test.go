@@ -0,0 +1,247 @@
+package linter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/guiferpa/aurora/lexer"
+	"github.com/guiferpa/aurora/parser"
+	"github.com/guiferpa/aurora/resolver"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+)
+
+// read resolves src/main.ar out of a set of files the way a command line would out of a disk,
+// and answers it as the linter takes it.
+func read(t *testing.T, files map[string]string, entries ...string) []File {
+	t.Helper()
+
+	lx := lexer.New()
+	rs := resolver.New(resolver.Options{
+		SourceRoot: "src",
+		Read: func(name string) ([]byte, error) {
+			source, ok := files[name]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(source), nil
+		},
+		Parse: func(filename string, id module.ID, source []byte, imports map[string]ast.Offer) (ast.AST, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return ast.AST{}, err
+			}
+			return parser.New().Parse(parser.ParseInput{Filename: filename, Tokens: tokens, Module: string(id), Imports: imports})
+		},
+		Header: func(source []byte) ([]ast.UseDeclaration, error) {
+			tokens, err := lx.GetFilledTokens(source)
+			if err != nil {
+				return nil, err
+			}
+			return parser.ScanUses(tokens), nil
+		},
+	})
+
+	if len(entries) == 0 {
+		entries = []string{path.Join("src", "main.ar")}
+	}
+	read := make([]File, 0)
+	for _, entry := range entries {
+		modules, err := rs.Resolve(entry)
+		if err != nil {
+			t.Fatalf("resolving %s: %v", entry, err)
+		}
+		for _, each := range modules {
+			tokens, err := lx.GetTokens([]byte(each.Source))
+			if err != nil {
+				t.Fatalf("lexing %s: %v", each.Tree.Filename, err)
+			}
+			read = append(read, File{Filename: each.Tree.Filename, Module: each, Tokens: tokens})
+		}
+	}
+	return read
+}
+
+// linted answers what every rule finds in a program, each finding written the way a person
+// reads it: where, what, and which rule.
+func linted(t *testing.T, severities map[string]string, files map[string]string, entries ...string) []string {
+	t.Helper()
+
+	l, err := New(Options{Severities: severities})
+	if err != nil {
+		t.Fatalf("configuring: %v", err)
+	}
+	found := make([]string, 0)
+	for _, finding := range l.Lint(read(t, files, entries...)) {
+		found = append(found, fmt.Sprintf("%s:%d:%d %s: %s", finding.Filename, finding.Line, finding.Column, finding.Rule, finding.Message))
+	}
+	return found
+}
+
+func expect(t *testing.T, got []string, wants ...string) {
+	t.Helper()
+	if len(got) != len(wants) {
+		t.Fatalf("found %d, want %d:\n%s", len(got), len(wants), strings.Join(got, "\n"))
+	}
+	for at, want := range wants {
+		if !strings.Contains(got[at], want) {
+			t.Errorf("finding %d is %q, want it to say %q", at, got[at], want)
+		}
+	}
+}
+
+// Only what nobody reads: a name read inside a scope and one read by the file importing its
+// module are fine — and a scope calling itself is not somebody using it.
+func TestUnusedIdents(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/geometry.ar": "ident side = 4;\nident spare = 5;\n",
+		"src/main.ar": "use geometry as g;\n" +
+			"ident area = defer { ident n = feed(0); n * g.side; };\n" +
+			"ident loop = defer { loop(feed(0)); };\n" +
+			"printd area(2);\n",
+	})
+
+	expect(t, got,
+		"src/geometry.ar:2:7 unused-ident: spare is bound and never read, here or by any file that imports geometry",
+		"src/main.ar:3:7 unused-ident: loop is bound and never read",
+	)
+}
+
+// An alias is used when a name or a shape is reached through it.
+func TestUnusedUses(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/shapes.ar":  "shape Point { x, y };\n",
+		"src/numbers.ar": "ident one = 1;\n",
+		"src/unused.ar":  "ident two = 2;\n",
+		"src/main.ar":    "use shapes as s;\nuse numbers as n;\nuse unused as u;\nprintd s.Point{n.one, 2}.x;\n",
+	})
+
+	expect(t, got, "src/main.ar:3:1 unused-use: u is never used: nothing in this file reaches unused through it",
+		"src/unused.ar:1:7 unused-ident")
+}
+
+func TestShadowedNames(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/main.ar": "ident x = 1;\nident y = { ident x = 2; x; };\nident z = { ident w = 3; w; };\nprintd x + y + z;\n",
+	})
+
+	expect(t, got, "src/main.ar:2:19 shadowed-name: x is bound again here: inside this scope the x bound at line 1 cannot be read")
+}
+
+// A shape is used when any file builds it, reads a value as it or promises it — and a shape of
+// a module is built under its qualified name, by whoever imports it.
+func TestUnbuiltShapes(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/shapes.ar": "shape Point { x, y };\nshape Size { w, h };\nshape Spare { a };\n",
+		"src/main.ar": "use shapes as s;\nshape Local { v };\nshape Read { v };\n" +
+			"printd s.Point{1, 2}.x;\nprintd (3 as Read).v;\nprintd (4 as s.Size).w;\n",
+	})
+
+	expect(t, got,
+		"src/main.ar:2:1 unbuilt-shape: shape Local is declared and nothing builds it",
+		"src/shapes.ar:3:1 unbuilt-shape: shape Spare is declared and nothing builds it",
+	)
+}
+
+// A feed past every call is reported at the feed; a scope that escapes as a value is not
+// reported at all, since a call to it may be anywhere.
+func TestUnfedPositions(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/main.ar": "ident add = defer { feed(0) + feed(1) + feed(2); };\n" +
+			"ident kept = defer { feed(3); };\n" +
+			"ident alias = kept;\n" +
+			"printd add(1, 2) + add(3);\nprintd alias(1);\n",
+	})
+
+	expect(t, got, "src/main.ar:1:46 unfed-position: feed(2) reads a position no call to add applies: the most any call applies is 2")
+}
+
+// A test written in literals is reported once as constant, and what it can never reach as dead
+// — in a branch, the arms after it.
+func TestConstantConditionsAndDeadCode(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/main.ar": "ident a = feed(0);\n" +
+			"printd if true { 1; } else { 2; };\n" +
+			"printd if 2 bigger 1 { 3; };\n" +
+			"printd if a bigger 1 { 4; };\n" +
+			"printd branch { a equals 0: 5, true: 6, 7; };\n",
+	})
+
+	expect(t, got,
+		"src/main.ar:2:11 constant-condition: the test of this if is always true",
+		"src/main.ar:2:30 dead-code: this never runs: the test before it is always true",
+		"src/main.ar:3:11 constant-condition: the test of this if is written in literals only",
+		"src/main.ar:5:32 constant-condition: the test of this arm of the branch is always true",
+		"src/main.ar:5:41 dead-code: this never runs",
+	)
+}
+
+// A comment silences its own line, the next line holding code when it is alone on its own,
+// or the whole file; anything after the rules is the reason.
+func TestSuppression(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/main.ar": "ident a = 1; #- lint:ignore unused-ident\n" +
+			"#- lint:ignore shadowed-name, unused-ident kept for later\n" +
+			"\n" +
+			"ident b = 2;\n" +
+			"ident c = 3; #- lint:ignore shadowed-name\n" +
+			"#- lint:ignore-file constant-condition\n" +
+			"printd if false { 1; };\n",
+	})
+
+	expect(t, got, "src/main.ar:5:7 unused-ident: c is bound and never read", "src/main.ar:7:19 dead-code")
+}
+
+// A comment naming a rule there is not silences nothing, and says so at the comment, the way
+// aurora.toml naming one is refused.
+func TestSuppressingAnUnknownRule(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/main.ar": "ident a = 1; #- lint:ignore unused-idnet\n" +
+			"printd a;\n",
+	})
+
+	expect(t, got, `src/main.ar:1:14 unknown-rule: no lint rule is called "unused-idnet"`)
+}
+
+// A project decides how much each rule matters, and a rule it turns off does not run.
+func TestSeverities(t *testing.T) {
+	files := map[string]string{"src/main.ar": "ident a = 1;\nprintd if false { 1; };\n"}
+
+	l, err := New(Options{Severities: map[string]string{"unused-ident": "error", "constant-condition": "off"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := l.Lint(read(t, files))
+	rules := make([]string, 0)
+	for _, finding := range got {
+		rules = append(rules, fmt.Sprintf("%s %s", finding.Rule, finding.Severity))
+	}
+	if !slices.Equal(rules, []string{"unused-ident error", "dead-code warning"}) {
+		t.Errorf("found %v, want unused-ident as an error and no constant-condition", rules)
+	}
+}
+
+// A setting that names no rule, or no severity, is refused rather than ignored.
+func TestSeveritiesAreChecked(t *testing.T) {
+	if _, err := New(Options{Severities: map[string]string{"unused-idents": "off"}}); err == nil || !strings.Contains(err.Error(), `no lint rule is called "unused-idents"`) {
+		t.Errorf("a misspelled rule gave %v", err)
+	}
+	if _, err := New(Options{Severities: map[string]string{"dead-code": "loud"}}); err == nil || !strings.Contains(err.Error(), `"loud" is not a severity`) {
+		t.Errorf("a misspelled severity gave %v", err)
+	}
+}
+
+// A module linted as a program of its own and as a module of another is read once, as the
+// module: its names are used by whoever imports it.
+func TestAFileReadTwiceIsReadOnce(t *testing.T) {
+	got := linted(t, nil, map[string]string{
+		"src/geometry.ar": "ident side = 4;\n",
+		"src/main.ar":     "use geometry as g;\nprintd g.side;\n",
+	}, "src/geometry.ar", "src/main.ar")
+
+	expect(t, got)
+}
@@ -0,0 +1,271 @@
+package linter
+
+import (
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// What the rules share: which declaration every name written in the program means, read once
+// for all of them.
+//
+// A name means the nearest declaration of it in the scopes around where it is written, and a
+// scope is a block — a deferred scope's body, either arm of an if, braces written on their own.
+// Where in the block the declaration is does not matter: a deferred scope runs when it is
+// called, so its body may read a name written below it.
+//
+// A name no scope of its file declares is another module's, written qualified — x.add is
+// a/b.add by the time it is in a tree — and the module declares it under that same name. So a
+// name left over is counted for the whole program, and a declaration at the top of a module
+// is read by every file that reads its name.
+
+// A Program is the files being linted and what their names mean.
+type Program struct {
+	Files []*Unit
+
+	// reads is how often each name no file resolved was read, calls what every call to one
+	// applied, and escapes which were read as values.
+	reads   map[string]int
+	calls   map[string][]int
+	escapes map[string]bool
+}
+
+// A Unit is one file, and the declarations it makes.
+type Unit struct {
+	File
+	Bindings []*Binding
+
+	suppressions suppressions
+}
+
+// A Binding is a name declared with ident.
+type Binding struct {
+	Name  string
+	Token token.Token
+	Value ast.Node
+	Unit  *Unit
+	// Top says it is declared at the top of its file, where another file may read it.
+	Top bool
+	// Shadows is the declaration of the same name in a scope around this one, if there is one.
+	Shadows *Binding
+	// Reads is how often something other than its own value reads it, and Calls what each
+	// call to it applied. Escapes says it was read as a value rather than called, so it may be
+	// called somewhere nothing here can see.
+	Reads   int
+	Calls   []int
+	Escapes bool
+	// Twice says its scope declares the name more than once, so which one a reading means is
+	// not something to be sure of.
+	Twice bool
+}
+
+// Typed is the name as the file wrote it, without the module in front.
+func (b *Binding) Typed() string {
+	if b.Token == nil {
+		return b.Name
+	}
+	return string(b.Token.GetMatch())
+}
+
+type scope struct {
+	parent *scope
+	names  map[string]*Binding
+}
+
+func (s *scope) lookup(name string) *Binding {
+	for at := s; at != nil; at = at.parent {
+		if found, ok := at.names[name]; ok {
+			return found
+		}
+	}
+	return nil
+}
+
+func newProgram(files []File) *Program {
+	p := &Program{reads: make(map[string]int), calls: make(map[string][]int), escapes: make(map[string]bool)}
+	for _, file := range files {
+		unit := &Unit{File: file, suppressions: suppressionsOf(file.Tokens)}
+		p.Files = append(p.Files, unit)
+		r := &reader{program: p, unit: unit}
+		r.enter(nil, file.Module.Tree.Nodes)
+	}
+
+	// What a file left for the rest of the program reaches a top-level declaration of the file
+	// that declares it.
+	for _, unit := range p.Files {
+		for _, binding := range unit.Bindings {
+			if !binding.Top {
+				continue
+			}
+			binding.Reads += p.reads[binding.Name]
+			binding.Calls = append(binding.Calls, p.calls[binding.Name]...)
+			binding.Escapes = binding.Escapes || p.escapes[binding.Name]
+		}
+	}
+	return p
+}
+
+// A reader walks one file, keeping the scopes it is inside.
+type reader struct {
+	program *Program
+	unit    *Unit
+	// defining is the bindings whose values are being read: a scope calling itself is not
+	// somebody else using it.
+	defining []*Binding
+}
+
+// enter reads one scope: what it declares first, then what it reads.
+func (r *reader) enter(parent *scope, body []ast.Node) {
+	here := &scope{parent: parent, names: make(map[string]*Binding)}
+	for _, node := range body {
+		r.declare(here, node)
+	}
+	for _, node := range body {
+		r.read(here, node)
+	}
+}
+
+// declare finds the bindings a node makes in the scope it is written in, stopping where a
+// scope of its own begins.
+func (r *reader) declare(here *scope, node ast.Node) {
+	ast.Walk(node, func(each ast.Node) bool {
+		switch n := each.(type) {
+		case ast.BlockExpression, ast.IfExpression:
+			return false
+		case ast.IdentLiteral:
+			binding := &Binding{Name: n.Id, Token: n.Token, Value: n.Value, Unit: r.unit, Top: here.parent == nil}
+			if earlier, ok := here.names[n.Id]; ok {
+				earlier.Twice, binding.Twice = true, true
+			} else if here.parent != nil {
+				binding.Shadows = here.parent.lookup(n.Id)
+			}
+			here.names[n.Id] = binding
+			r.unit.Bindings = append(r.unit.Bindings, binding)
+		}
+		return true
+	})
+}
+
+// read resolves every name a node reads, and enters every scope it opens.
+func (r *reader) read(here *scope, node ast.Node) {
+	ast.Walk(node, func(each ast.Node) bool {
+		switch n := each.(type) {
+		case ast.BlockExpression:
+			r.enter(here, n.Body)
+			return false
+		case ast.IfExpression:
+			r.read(here, n.Test)
+			r.enter(here, n.Body)
+			if n.Else != nil {
+				r.enter(here, n.Else.Body)
+			}
+			return false
+		case ast.IdentLiteral:
+			binding := here.names[n.Id]
+			r.defining = append(r.defining, binding)
+			r.read(here, n.Value)
+			r.defining = r.defining[:len(r.defining)-1]
+			return false
+		case ast.CalleeLiteral:
+			r.call(here, n.Id.Value, len(n.Params))
+			for _, param := range n.Params {
+				r.read(here, param.Expression)
+			}
+			return false
+		case ast.IdentifierLiteral:
+			r.value(here, n.Value)
+		}
+		return true
+	})
+}
+
+func (r *reader) call(here *scope, name string, applied int) {
+	binding := here.lookup(name)
+	if binding == nil {
+		r.program.reads[name]++
+		r.program.calls[name] = append(r.program.calls[name], applied)
+		return
+	}
+	binding.Calls = append(binding.Calls, applied)
+	if !r.isDefining(binding) {
+		binding.Reads++
+	}
+}
+
+func (r *reader) value(here *scope, name string) {
+	binding := here.lookup(name)
+	if binding == nil {
+		r.program.reads[name]++
+		r.program.escapes[name] = true
+		return
+	}
+	binding.Escapes = true
+	if !r.isDefining(binding) {
+		binding.Reads++
+	}
+}
+
+func (r *reader) isDefining(binding *Binding) bool {
+	for _, each := range r.defining {
+		if each == binding {
+			return true
+		}
+	}
+	return false
+}
+
+// at is the place a token is written, in the file it is in.
+func (u *Unit) at(tk token.Token, message string) Finding {
+	finding := Finding{Filename: u.Filename, Message: message}
+	if tk != nil {
+		finding.Line, finding.Column = tk.GetLine(), tk.GetColumn()
+	}
+	return finding
+}
+
+// firstToken is where a node starts, as far as a token in it says.
+func firstToken(node ast.Node) token.Token {
+	var found token.Token
+	ast.Walk(node, func(each ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch n := each.(type) {
+		case ast.IdentifierLiteral:
+			found = n.Token
+		case ast.BooleanLiteral:
+			found = n.Token
+		case ast.NumberLiteral:
+			found = n.Token
+		case ast.TextLiteral:
+			found = n.Token
+		case ast.OperationLiteral:
+			found = n.Token
+		case ast.TapeBracketExpression:
+			found = n.Token
+		case ast.IdentLiteral:
+			found = n.Token
+		case ast.IfExpression:
+			found = n.Token
+		case ast.AssertStatement:
+			found = n.Token
+		case ast.RequireStatement:
+			found = n.Token
+		case ast.ContextExpression:
+			found = n.Token
+		case ast.ContractCall:
+			found = n.Token
+		case ast.ShapeLiteral:
+			found = n.Token
+		case ast.PullExpression:
+			found = n.Token
+		case ast.PushExpression:
+			found = n.Token
+		case ast.HeadExpression:
+			found = n.Token
+		case ast.TailExpression:
+			found = n.Token
+		}
+		return found == nil
+	})
+	return found
+}
@@ -0,0 +1,290 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/guiferpa/aurora/byteutil"
+	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/module"
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// unusedIdents reports a name bound and never read.
+//
+// A name its scope binds twice is not reported: which reading means which is the kind of
+// question this stays out of rather than guesses at.
+func unusedIdents(p *Program) []Finding {
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		for _, binding := range unit.Bindings {
+			if binding.Reads > 0 || binding.Twice {
+				continue
+			}
+			message := fmt.Sprintf("%s is bound and never read", binding.Typed())
+			if binding.Top && !unit.Module.IsEntry() {
+				message = fmt.Sprintf("%s is bound and never read, here or by any file that imports %s", binding.Typed(), unit.Module.ID)
+			}
+			found = append(found, unit.at(binding.Token, message))
+		}
+	}
+	return found
+}
+
+// unusedUses reports an alias nothing in its file reaches through. The module still loads and
+// its body still runs, which may be why it is there; the alias is what is not needed.
+func unusedUses(p *Program) []Finding {
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		tree := unit.Module.Tree
+		reached := make(map[string]bool)
+		for _, reference := range tree.References {
+			reached[reference.Module] = true
+		}
+		// A shape of another module is built, read as or promised under its qualified name, and
+		// is not a reference: nothing has to be found at run time for it.
+		for _, shape := range shapesNamed(tree) {
+			if id, _, qualified := module.Split(shape); qualified {
+				reached[string(id)] = true
+			}
+		}
+
+		for _, node := range tree.Nodes {
+			use, ok := node.(ast.UseDeclaration)
+			if !ok || reached[use.Specifier] {
+				continue
+			}
+			found = append(found, unit.at(use.Token, fmt.Sprintf("%s is never used: nothing in this file reaches %s through it", use.Alias, use.Specifier)))
+		}
+	}
+	return found
+}
+
+// shadowedNames reports a name bound inside a scope that could already read another of the
+// same name. Inside it, the other one cannot be read at all.
+func shadowedNames(p *Program) []Finding {
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		for _, binding := range unit.Bindings {
+			if binding.Shadows == nil {
+				continue
+			}
+			message := fmt.Sprintf("%s is bound again here: inside this scope the %s bound outside it cannot be read", binding.Typed(), binding.Typed())
+			if outer := binding.Shadows.Token; outer != nil {
+				message = fmt.Sprintf("%s is bound again here: inside this scope the %s bound at line %d cannot be read", binding.Typed(), binding.Typed(), outer.GetLine())
+			}
+			found = append(found, unit.at(binding.Token, message))
+		}
+	}
+	return found
+}
+
+// unbuiltShapes reports a shape nothing builds, reads a value as, or promises to answer with.
+//
+// Reading with `as` counts: a shape laid over a tape that came from somewhere else is what that
+// form is for, and the value was built all the same.
+func unbuiltShapes(p *Program) []Finding {
+	type key struct {
+		module module.ID
+		name   string
+	}
+	used := make(map[key]bool)
+	for _, unit := range p.Files {
+		for _, shape := range shapesNamed(unit.Module.Tree) {
+			if id, symbol, qualified := module.Split(shape); qualified {
+				used[key{id, symbol}] = true
+				continue
+			}
+			used[key{unit.Module.ID, shape}] = true
+		}
+	}
+
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		ast.Walk(unit.Module.Tree, func(node ast.Node) bool {
+			declaration, ok := node.(ast.ShapeDeclaration)
+			if ok && !used[key{unit.Module.ID, declaration.Name}] {
+				found = append(found, unit.at(declaration.Token, fmt.Sprintf("shape %s is declared and nothing builds it", declaration.Name)))
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// shapesNamed is every shape a tree names other than where it declares one.
+func shapesNamed(tree ast.AST) []string {
+	named := make([]string, 0)
+	ast.Walk(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case ast.ShapeLiteral:
+			named = append(named, n.Name)
+		case ast.ShapedExpression:
+			named = append(named, n.Shape)
+		case ast.BlockExpression:
+			if n.Returns != "" {
+				named = append(named, n.Returns)
+			}
+		}
+		return true
+	})
+	return named
+}
+
+// unfedPositions reports a feed(n) no call reaches: every call to its scope applies n values or
+// fewer, so position n always answers with a tape of zeros.
+//
+// It is the other side of what the emitter says about a short call. That one points at a call
+// when the scope reads more; this points at the feed when no call applies enough, which is
+// where the mistake is when every caller agrees.
+//
+// It stays quiet unless it sees every call. A scope read as a value — passed, kept, answered by
+// an if — may be called where nothing here can follow, and one never called at all is what
+// unused-ident is for.
+func unfedPositions(p *Program) []Finding {
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		for _, binding := range unit.Bindings {
+			scope, deferred := binding.Value.(ast.DeferExpression)
+			if !deferred || binding.Escapes || binding.Twice || len(binding.Calls) == 0 {
+				continue
+			}
+			most := 0
+			for _, applied := range binding.Calls {
+				most = max(most, applied)
+			}
+			for _, feed := range feedsOf(scope.Block.Body) {
+				if int(feed.Nth.Value) < most {
+					continue
+				}
+				message := fmt.Sprintf("feed(%d) reads a position no call to %s applies: the most any call applies is %d, so it answers with a tape of zeros",
+					feed.Nth.Value, binding.Typed(), most)
+				found = append(found, unit.at(feed.Nth.Token, message))
+			}
+		}
+	}
+	return found
+}
+
+// feedsOf is every feed a body reads, leaving out the deferred scopes inside it: their feeds
+// read what is applied to them.
+func feedsOf(body []ast.Node) []ast.FeedExpression {
+	feeds := make([]ast.FeedExpression, 0)
+	for _, node := range body {
+		ast.Walk(node, func(each ast.Node) bool {
+			switch n := each.(type) {
+			case ast.DeferExpression:
+				return false
+			case ast.FeedExpression:
+				feeds = append(feeds, n)
+			}
+			return true
+		})
+	}
+	return feeds
+}
+
+// constantConditions reports an if, or an arm of a branch, whose test is written in literals
+// only: it goes the same way every time it runs.
+func constantConditions(p *Program) []Finding {
+	found := make([]Finding, 0)
+	eachIf(p, func(unit *Unit, n ast.IfExpression) {
+		if !constant(n.Test) {
+			return
+		}
+		what := "this if"
+		if n.Token == nil {
+			// A branch is nested ifs by the time it is a tree, and only the ones it made have
+			// no token of their own.
+			what = "this arm of the branch"
+		}
+		message := fmt.Sprintf("the test of %s is written in literals only, so it goes the same way every time", what)
+		if truth, known := truthOf(n.Test); known {
+			message = fmt.Sprintf("the test of %s is always %t", what, truth)
+		}
+		found = append(found, unit.at(firstToken(n.Test), message))
+	})
+	return found
+}
+
+// deadCode reports what an if or a branch can never reach: the body behind a test that is
+// always false, and the else — the rest of a branch — behind one that is always true.
+//
+// Aurora has no statement that leaves a scope, so a test written as a literal is the only
+// thing that makes code unreachable.
+func deadCode(p *Program) []Finding {
+	found := make([]Finding, 0)
+	eachIf(p, func(unit *Unit, n ast.IfExpression) {
+		truth, known := truthOf(n.Test)
+		if !known {
+			return
+		}
+		dead := n.Body
+		if truth {
+			dead = nil
+			if n.Else != nil {
+				dead = n.Else.Body
+			}
+		}
+		if len(dead) == 0 {
+			return
+		}
+		message := fmt.Sprintf("this never runs: the test before it is always %t", truth)
+		found = append(found, unit.at(firstToken(dead[0]), message))
+	})
+	return found
+}
+
+func eachIf(p *Program, visit func(*Unit, ast.IfExpression)) {
+	for _, unit := range p.Files {
+		ast.Walk(unit.Module.Tree, func(node ast.Node) bool {
+			if n, ok := node.(ast.IfExpression); ok {
+				visit(unit, n)
+			}
+			return true
+		})
+	}
+}
+
+// constant says whether a value is written in literals only.
+func constant(node ast.Node) bool {
+	switch n := node.(type) {
+	case ast.BooleanLiteral, ast.NumberLiteral, ast.TextLiteral:
+		return true
+	case ast.PrimaryExpression:
+		return constant(n.Expression)
+	case ast.UnaryExpression:
+		return constant(n.Expression)
+	case ast.BinaryExpression:
+		return constant(n.Left) && constant(n.Right)
+	case ast.RelativeExpression:
+		return constant(n.Left) && constant(n.Right)
+	case ast.BooleanExpression:
+		return constant(n.Left) && constant(n.Right)
+	}
+	return false
+}
+
+// truthOf answers which way a test goes, when that is plain from how it is written: true,
+// false, and `and` and `or` of those. A comparison of numbers is constant as well, but which way
+// it goes depends on the width of a tape, and this is not going to do arithmetic to find out.
+func truthOf(node ast.Node) (bool, bool) {
+	switch n := node.(type) {
+	case ast.BooleanLiteral:
+		return byteutil.ToBoolean(n.Value), true
+	case ast.PrimaryExpression:
+		return truthOf(n.Expression)
+	case ast.BooleanExpression:
+		left, leftKnown := truthOf(n.Left)
+		right, rightKnown := truthOf(n.Right)
+		if !leftKnown || !rightKnown {
+			return false, false
+		}
+		switch n.Operation.Value {
+		case token.TagAnd.Keyword:
+			return left && right, true
+		case token.TagOr.Keyword:
+			return left || right, true
+		}
+	}
+	return false, false
+}
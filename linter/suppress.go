@@ -0,0 +1,141 @@
+package linter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// Silencing a rule where its finding is not wanted, in a comment, where whoever reads the code
+// sees that it was silenced:
+//
+//	ident unused = 1; #- lint:ignore unused-ident kept for the next release
+//	#- lint:ignore unused-ident, shadowed-name
+//	ident n = feed(0);
+//	#- lint:ignore-file dead-code
+//
+// A comment after code silences its own line; a comment alone on a line silences the next line
+// that holds code; ignore-file silences the whole file. The rules are named one by one, with
+// commas between them, and whatever follows the last is the reason, for the reader.
+
+const (
+	ignoreLine = "lint:ignore"
+	ignoreFile = "lint:ignore-file"
+)
+
+// suppressions is what a file's comments silence: rule -> the lines, and the rules silenced in
+// the whole file. Named is every rule a comment names, where the comment is, so a name that is
+// no rule can be said rather than silence nothing.
+type suppressions struct {
+	lines map[string]map[int]bool
+	file  map[string]bool
+	named []Finding
+}
+
+func (p *Program) suppressed(finding Finding) bool {
+	for _, unit := range p.Files {
+		if unit.Filename != finding.Filename {
+			continue
+		}
+		s := unit.suppressions
+		return s.file[finding.Rule] || s.lines[finding.Rule][finding.Line]
+	}
+	return false
+}
+
+// unknownRule is what a comment silencing a rule there is not is said under. A misspelled name
+// silences nothing, and without this nobody would hear about it until the finding came back.
+const unknownRule = "unknown-rule"
+
+// unknownSuppressions answers a finding for every rule a comment names that is not one of known,
+// at the comment.
+func (p *Program) unknownSuppressions(known []string) []Finding {
+	found := make([]Finding, 0)
+	for _, unit := range p.Files {
+		for _, named := range unit.suppressions.named {
+			if slices.Contains(known, named.Rule) {
+				continue
+			}
+			found = append(found, Finding{
+				Rule: unknownRule, Severity: Warning, Filename: unit.Filename, Line: named.Line, Column: named.Column,
+				Message: fmt.Sprintf("no lint rule is called %q: it is one of %s", named.Rule, strings.Join(known, ", ")),
+			})
+		}
+	}
+	return found
+}
+
+func suppressionsOf(tokens []token.Token) suppressions {
+	s := suppressions{lines: make(map[string]map[int]bool), file: make(map[string]bool)}
+
+	// A comment alone on its line waits for the next line holding code.
+	pending := make([]string, 0)
+	codeOn := 0 // the last line code was seen on
+	for _, tk := range tokens {
+		switch tk.GetTag().Id {
+		case token.WHITESPACE, token.BREAK_LINE, token.EOF:
+			continue
+		case token.COMMENT_LINE:
+			whole, rules := directive(string(tk.GetMatch()))
+			for _, rule := range rules {
+				s.named = append(s.named, Finding{Rule: rule, Line: tk.GetLine(), Column: tk.GetColumn()})
+			}
+			switch {
+			case whole:
+				for _, rule := range rules {
+					s.file[rule] = true
+				}
+			case codeOn == tk.GetLine():
+				s.silence(rules, tk.GetLine())
+			default:
+				pending = append(pending, rules...)
+			}
+			continue
+		}
+		codeOn = tk.GetLine()
+		s.silence(pending, codeOn)
+		pending = pending[:0]
+	}
+	return s
+}
+
+func (s suppressions) silence(rules []string, line int) {
+	for _, rule := range rules {
+		if s.lines[rule] == nil {
+			s.lines[rule] = make(map[int]bool)
+		}
+		s.lines[rule][line] = true
+	}
+}
+
+// directive reads a comment: whether it silences the whole file, and which rules. A comment
+// that is not a directive silences nothing.
+func directive(comment string) (bool, []string) {
+	text := strings.TrimSpace(strings.TrimPrefix(comment, "#-"))
+	whole := false
+	switch {
+	case strings.HasPrefix(text, ignoreFile+" "):
+		whole, text = true, strings.TrimPrefix(text, ignoreFile)
+	case strings.HasPrefix(text, ignoreLine+" "):
+		text = strings.TrimPrefix(text, ignoreLine)
+	default:
+		return false, nil
+	}
+
+	rules := make([]string, 0)
+	words := strings.Fields(text)
+	for at, word := range words {
+		for _, rule := range strings.Split(word, ",") {
+			if rule != "" {
+				rules = append(rules, rule)
+			}
+		}
+		// Without a comma after it, the rule was the last one and the rest is the reason.
+		if !strings.HasSuffix(word, ",") && (at+1 == len(words) || !strings.HasPrefix(words[at+1], ",")) {
+			break
+		}
+	}
+	return whole, rules
+}
@@ -107,6 +107,31 @@ type Range struct {
 	Filename string
 	From, To uint64
 	Warnings []diag.Warning
+	// Promises is what the module's scopes said they answer with, as its own file typed them.
+	Promises []ast.Promise
+}
+
+// Promises answers what every scope of the program promised, by the name it is bound to in
+// the instructions. A backend describing what a program answers reads it there, and a
+// module's scope is bound under its qualified name.
+func (p Program) Promises() map[string]ast.Promise {
+	promises := make(map[string]ast.Promise)
+	for _, each := range p.Ranges {
+		for _, promise := range each.Promises {
+			promises[module.Qualify(each.Module, promise.Scope)] = promise
+		}
+	}
+	return promises
+}
+
+// RangeOf answers the module a position of the stream sits in.
+func (p Program) RangeOf(position uint64) (Range, bool) {
+	for _, each := range p.Ranges {
+		if position >= each.From && position < each.To {
+			return each, true
+		}
+	}
+	return Range{}, false
 }
 
 // Emit compiles one tree. It is a port because the loader is a phase like any other and does
@@ -141,6 +166,7 @@ func Load(modules []module.Module, emit Emit) (Program, error) {
 			From:     from,
 			To:       uint64(len(program.Instructions)),
 			Warnings: compiled.Warnings,
+			Promises: each.Tree.Promises,
 		})
 	}
 	return program, nil
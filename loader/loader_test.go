@@ -229,6 +229,38 @@ func TestLoadLaysEveryModuleEndToEnd(t *testing.T) {
 	}
 }
 
+// What a scope promised is found under the name it is bound to in the instructions: the
+// module's qualified, the entry's bare.
+func TestPromisesAreKeyedByTheBoundName(t *testing.T) {
+	modules, err := load(t, map[string]string{
+		"src/main.ar": "use a/b as x;\nshape Pair { left, right };\nident pair = defer { Pair{1, 2}; } returns Pair;",
+		"src/a/b.ar":  "shape Square { width, height };\nident square = defer { Square{feed(0), feed(0)}; } returns Square;",
+	})
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	program, err := Load(modules, emitter.New(emitter.NewEmitterOptions{}).EmitProgram)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	bound := make(map[string]bool)
+	for _, inst := range program.Instructions {
+		if inst.GetOpCode() == ir.OpIdent {
+			bound[string(inst.GetLeft().Bytes())] = true
+		}
+	}
+	promises := program.Promises()
+	for name, shape := range map[string]string{"a/b.square": "Square", "pair": "Pair"} {
+		if promises[name].Shape != shape {
+			t.Errorf("%s promised %q, want %s", name, promises[name].Shape, shape)
+		}
+		if !bound[name] {
+			t.Errorf("no instruction binds %s", name)
+		}
+	}
+}
+
 // A name that is not there stops the load, rather than being compiled into a program that
 // would look for it while running.
 func TestLoadRefusesBeforeItEmits(t *testing.T) {
@@ -262,3 +294,27 @@ func header(source []byte) ([]ast.UseDeclaration, error) {
 	}
 	return parser.ScanUses(tokens), nil
 }
+
+// A position is in the module whose range holds it, and one past the program is in none.
+func TestRangeOf(t *testing.T) {
+	modules, err := load(t, map[string]string{
+		"src/main.ar": "use a/b as x;\nident y = x.base;",
+		"src/a/b.ar":  "ident base = 10;",
+	})
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	program, err := Load(modules, emitter.New(emitter.NewEmitterOptions{}).EmitProgram)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for _, each := range program.Ranges {
+		if got, ok := program.RangeOf(each.From); !ok || got.Module != each.Module {
+			t.Errorf("position %d is in %q, want %q", each.From, got.Module, each.Module)
+		}
+	}
+	if _, ok := program.RangeOf(uint64(len(program.Instructions))); ok {
+		t.Error("a position past the program is in a module")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/guiferpa/aurora/byteutil"
 	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/diag"
 	"github.com/guiferpa/aurora/wire/token"
 )
 
@@ -179,11 +180,30 @@ func (p *pr) ParseNumber() (ast.NumberLiteral, error) {
 // be truncated silently at emission.
 func (p *pr) fitInTape(v uint64, tok token.Token) (ast.NumberLiteral, error) {
 	if !byteutil.FitsInTape(v, p.tapeSize) {
-		return ast.NumberLiteral{}, token.NewError(tok, "value %d does not fit in a %d-byte tape (max %d)", v, p.tapeSize, byteutil.MaxTapeValue(p.tapeSize))
+		return ast.NumberLiteral{}, token.NewError(tok, "value %d does not fit in a %d-byte tape (max %d)", v, p.tapeSize, byteutil.MaxTapeValue(p.tapeSize)).
+			Coded(diag.WideLiteral, map[string]int{diag.Width: narrowestFor(v)})
 	}
 	return ast.NumberLiteral{Value: v, Token: tok}, nil
 }
 
+// narrowestFor answers the narrowest tape a number fits in, and zero when none does.
+func narrowestFor(v uint64) int {
+	for width := byteutil.MinTapeSize; width <= byteutil.MaxTapeSize; width++ {
+		if byteutil.FitsInTape(v, width) {
+			return width
+		}
+	}
+	return 0
+}
+
+// widthHolding answers the tape that holds n bytes, and zero when none is that wide.
+func widthHolding(n int) int {
+	if n > byteutil.MaxTapeSize {
+		return 0
+	}
+	return n
+}
+
 // ParseText reads `"text"` into one tape holding its bytes.
 //
 // The bytes are the text as written, in UTF-8, right aligned like every other value — so
@@ -204,7 +224,8 @@ func (p *pr) ParseText() (ast.TextLiteral, error) {
 	// where it was written, the same way a number that does not fit is.
 	if len(content) > p.tapeSize {
 		return ast.TextLiteral{}, token.NewError(tok, "text is %d bytes but a tape holds %d at line %d and column %d",
-			len(content), p.tapeSize, tok.GetLine(), tok.GetColumn())
+			len(content), p.tapeSize, tok.GetLine(), tok.GetColumn()).
+			Coded(diag.WideLiteral, map[string]int{diag.Width: widthHolding(len(content))})
 	}
 
 	return ast.TextLiteral{Value: byteutil.PaddingTape(content, p.tapeSize), Token: tok}, nil
@@ -224,6 +245,12 @@ func (p *pr) parsePrimaryExpr() (ast.Node, error) {
 	if lookahead.GetTag().Id == token.FEED {
 		return p.ParseFeed()
 	}
+	if _, ok := contextReadings[lookahead.GetTag().Id]; ok {
+		return p.ParseContext()
+	}
+	if id := lookahead.GetTag().Id; id == token.CALL || id == token.STATICCALL {
+		return p.ParseContractCall()
+	}
 	if lookahead.GetTag().Id == token.O_PAREN {
 		if _, err := p.EatToken(token.O_PAREN); err != nil {
 			return nil, err
@@ -328,7 +355,8 @@ func (p *pr) ParseTapeBrk() (ast.Node, error) {
 		return nil, err
 	}
 	if len(items) > p.tapeSize {
-		return nil, token.NewError(closing, "tape literal has %d values but a tape holds %d bytes", len(items), p.tapeSize)
+		return nil, token.NewError(closing, "tape literal has %d values but a tape holds %d bytes", len(items), p.tapeSize).
+			Coded(diag.WideLiteral, map[string]int{diag.Width: widthHolding(len(items))})
 	}
 	return ast.TapeBracketExpression{Items: items, Token: at}, nil
 }
@@ -840,6 +868,9 @@ func (p *pr) ParseExpr() (ast.Node, error) {
 	if lookahead.GetTag().Id == token.ASSERT {
 		return p.ParseAssert()
 	}
+	if lookahead.GetTag().Id == token.REQUIRE {
+		return p.ParseRequire()
+	}
 	if lookahead.GetTag().Id == token.USE {
 		return p.ParseUse()
 	}
@@ -901,41 +932,56 @@ func (p *pr) ParseAssert() (ast.Node, error) {
 		return nil, token.NewError(lookahead, "assert can only be used in .test.ar files (at line %d, column %d)", lookahead.GetLine(), lookahead.GetColumn())
 	}
 
-	t, err := p.EatToken(token.ASSERT)
+	t, condition, message, err := p.parseCheck(token.ASSERT)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := p.EatToken(token.O_PAREN); err != nil {
+	return ast.AssertStatement{Condition: condition, Message: message, Token: t}, nil
+}
+
+// ParseRequire parses a guard, require(condition, "message"). Unlike an assertion it is
+// written in any file: it is part of what a program does, not of how it is tested.
+func (p *pr) ParseRequire() (ast.Node, error) {
+	t, condition, message, err := p.parseCheck(token.REQUIRE)
+	if err != nil {
 		return nil, err
 	}
+	return ast.RequireStatement{Condition: condition, Message: message, Token: t}, nil
+}
+
+// parseCheck parses what an assertion and a guard have in common: the keyword, then a
+// condition and a message between parentheses.
+func (p *pr) parseCheck(keyword string) (token.Token, ast.Node, string, error) {
+	t, err := p.EatToken(keyword)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if _, err := p.EatToken(token.O_PAREN); err != nil {
+		return nil, nil, "", err
+	}
 	condition, err := p.ParseExpr()
 	if err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
 	if _, err := p.EatToken(token.COMMA); err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
 	// The message is a literal rather than an expression: it is text for whoever reads the
 	// result, and nothing in the language builds text anyway.
 	message := p.GetLookahead()
 	if message == nil || message.GetTag().Id != token.STRING {
-		return nil, token.NewError(message, "assert needs a message written as text at line %d and column %d",
-			t.GetLine(), t.GetColumn())
+		return nil, nil, "", token.NewError(message, "%s needs a message written as text at line %d and column %d",
+			string(t.GetMatch()), t.GetLine(), t.GetColumn())
 	}
 	if _, err := p.EatToken(token.STRING); err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
 	quoted := message.GetMatch()
 
 	if _, err := p.EatToken(token.C_PAREN); err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
-
-	return ast.AssertStatement{
-		Condition: condition,
-		Message:   string(quoted[1 : len(quoted)-1]),
-		Token:     t,
-	}, nil
+	return t, condition, string(quoted[1 : len(quoted)-1]), nil
 }
 
 // ParseFeed parses the builtin "feed": feed(index), or feed index without parentheses.
@@ -963,6 +1009,143 @@ func (p *pr) ParseFeed() (ast.Node, error) {
 	return ast.FeedExpression{Nth: nth}, nil
 }
 
+// contextReadings is what each context builtin asks, by the keyword it is written with.
+var contextReadings = map[string]ast.ContextReading{
+	token.CALLER:      ast.Caller,
+	token.CALLVALUE:   ast.CallValue,
+	token.TIMESTAMP:   ast.Timestamp,
+	token.BLOCKNUMBER: ast.BlockNumber,
+	token.CHAINID:     ast.ChainID,
+}
+
+// ParseContext parses a context builtin. It is a word and nothing else: there is nothing to
+// apply to it, since what it reads is the call's rather than the program's.
+func (p *pr) ParseContext() (ast.Node, error) {
+	t, err := p.EatToken(p.GetLookahead().GetTag().Id)
+	if err != nil {
+		return nil, err
+	}
+	return ast.ContextExpression{Reading: contextReadings[t.GetTag().Id], Token: t}, nil
+}
+
+// ParseContractCall parses a call to another contract: call(address, "signature", values...),
+// or staticcall with the same parts.
+//
+// The signature is checked here rather than where the call is made, because here is where it
+// was written: a value too many or too few would be encoded into a call no contract answers,
+// and on chain that is not an error — it is whatever the other side makes of it.
+func (p *pr) ParseContractCall() (ast.Node, error) {
+	t, err := p.EatToken(p.GetLookahead().GetTag().Id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.EatToken(token.O_PAREN); err != nil {
+		return nil, err
+	}
+	address, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.EatToken(token.COMMA); err != nil {
+		return nil, err
+	}
+	signature := p.GetLookahead()
+	if signature == nil || signature.GetTag().Id != token.STRING {
+		return nil, token.NewError(signature, "%s needs the signature it calls written as text at line %d and column %d",
+			string(t.GetMatch()), t.GetLine(), t.GetColumn())
+	}
+	if _, err := p.EatToken(token.STRING); err != nil {
+		return nil, err
+	}
+	quoted := signature.GetMatch()
+	text := string(quoted[1 : len(quoted)-1])
+	types, ok := signatureTypes(text)
+	if !ok {
+		return nil, token.NewError(signature, "%q is not a signature, which is a name and its types: transfer(address,uint256) (at line %d, column %d)",
+			text, signature.GetLine(), signature.GetColumn())
+	}
+	for _, kind := range types {
+		if !isWordType(kind) {
+			return nil, token.NewError(signature, "%s takes a %s, which is not a word: only uintN, intN, address, bool and bytesN can be applied (at line %d, column %d)",
+				text, kind, signature.GetLine(), signature.GetColumn())
+		}
+	}
+	words := len(types)
+
+	values := make([]ast.Node, 0, words)
+	for p.GetLookahead().GetTag().Id == token.COMMA {
+		if _, err := p.EatToken(token.COMMA); err != nil {
+			return nil, err
+		}
+		value, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if _, err := p.EatToken(token.C_PAREN); err != nil {
+		return nil, err
+	}
+	if len(values) != words {
+		return nil, token.NewError(t, "%s takes %d values, %d were applied (at line %d, column %d)",
+			text, words, len(values), t.GetLine(), t.GetColumn())
+	}
+	return ast.ContractCall{Static: t.GetTag().Id == token.STATICCALL, Address: address, Signature: text, Values: values, Token: t}, nil
+}
+
+// signatureTypes answers the types a signature takes, and whether it is one: a name, then the
+// types of what it takes between parentheses, with nothing in between.
+func signatureTypes(signature string) ([]string, bool) {
+	open := strings.IndexByte(signature, '(')
+	if open < 1 || !strings.HasSuffix(signature, ")") {
+		return nil, false
+	}
+	for at, c := range signature[:open] {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (at == 0 || c < '0' || c > '9') {
+			return nil, false
+		}
+	}
+	types := signature[open+1 : len(signature)-1]
+	if types == "" {
+		return nil, true
+	}
+	each := strings.Split(types, ",")
+	for _, kind := range each {
+		if kind == "" || strings.ContainsAny(kind, " ()") {
+			return nil, false
+		}
+	}
+	return each, true
+}
+
+// isWordType answers whether a value of an ABI type is one word of the call, laid where it is
+// written. Every value Aurora has is a word, so that is all it can apply. The rest — a string,
+// bytes, an array — is written as a pointer to data laid out further along, and a word put in
+// its place would be read as the pointer. A type spelled without its width, as uint is, is
+// not it either: the selector is worked out from the signature as written, and no contract
+// answers to that one.
+func isWordType(kind string) bool {
+	switch kind {
+	case "address", "bool":
+		return true
+	}
+	for _, each := range []struct {
+		prefix        string
+		step, largest int
+	}{{"uint", 8, 256}, {"int", 8, 256}, {"bytes", 1, 32}} {
+		width, ok := strings.CutPrefix(kind, each.prefix)
+		if !ok || width == "" || width[0] == '0' {
+			continue
+		}
+		n, err := strconv.Atoi(width)
+		if err == nil && n >= each.step && n <= each.largest && n%each.step == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *pr) ParseExprs(t token.Tag) ([]ast.Node, error) {
 	// Anything read until a token other than the end of the file is a body, and a body is
 	// not the top of a file.
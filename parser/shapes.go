@@ -7,6 +7,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/guiferpa/aurora/wire/ast"
+	"github.com/guiferpa/aurora/wire/diag"
 	"github.com/guiferpa/aurora/wire/module"
 	"github.com/guiferpa/aurora/wire/token"
 )
@@ -94,7 +95,7 @@ func (p *pr) ParseShape() (ast.Node, error) {
 	shapeName := string(name.GetMatch())
 	if !capitalized(shapeName) {
 		return nil, token.NewError(name, "shape %s must start with a capital letter at line %d and column %d",
-			shapeName, name.GetLine(), name.GetColumn())
+			shapeName, name.GetLine(), name.GetColumn()).Coded(diag.LowerCaseShape, nil)
 	}
 	if _, declared := p.declarations.Shapes[shapeName]; declared {
 		return nil, token.NewError(name, "shape %s is already declared at line %d and column %d",
@@ -236,7 +237,7 @@ func (p *pr) parseField(expr ast.Node) (ast.Node, error) {
 	shape := p.shapeOf(expr)
 	if shape == "" {
 		return nil, token.NewError(name, "cannot read field %s at line %d and column %d: nothing says which shape this value is, name it with 'as'",
-			field, name.GetLine(), name.GetColumn())
+			field, name.GetLine(), name.GetColumn()).Coded(diag.UnknownShape, nil)
 	}
 
 	fields := p.declarations.Shapes[shape]
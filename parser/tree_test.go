@@ -296,6 +296,69 @@ func TestParsePrintShapes(t *testing.T) {
 	}
 }
 
+// The readings of the call are words with nothing applied, like a literal, and they parse into
+// one node carrying which reading it is. In an operation they are an operand like any other.
+func TestParseContextShapes(t *testing.T) {
+	cases := []struct {
+		source string
+		want   ast.ContextReading
+	}{
+		{source: "caller;", want: ast.Caller},
+		{source: "callvalue;", want: ast.CallValue},
+		{source: "timestamp;", want: ast.Timestamp},
+		{source: "blocknumber;", want: ast.BlockNumber},
+		{source: "chainid;", want: ast.ChainID},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.source, func(t *testing.T) {
+			read := first[ast.ContextExpression](t, tc.source)
+			if read.Reading != tc.want {
+				t.Errorf("reading = %q, want %q", read.Reading, tc.want)
+			}
+		})
+	}
+
+	sum := first[ast.BinaryExpression](t, "callvalue + 1;")
+	if _, ok := sum.Left.(ast.ContextExpression); !ok {
+		t.Errorf("the left operand is %T, want the reading", sum.Left)
+	}
+}
+
+// A call to another contract keeps its signature as written, since that is what the selector is
+// worked out from, and the values after it in the order they were applied.
+func TestParseContractCallShape(t *testing.T) {
+	cases := []struct {
+		source string
+		static bool
+		values int
+	}{
+		{source: `call(1, "transfer(address,uint256)", 2, 3);`, static: false, values: 2},
+		{source: `staticcall(1, "totalSupply()");`, static: true, values: 0},
+		{source: `call(1, "set(bool,int8,bytes32)", 2, 3, 4);`, static: false, values: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.source, func(t *testing.T) {
+			call := first[ast.ContractCall](t, tc.source)
+			if call.Static != tc.static {
+				t.Errorf("static = %v, want %v", call.Static, tc.static)
+			}
+			if len(call.Values) != tc.values {
+				t.Errorf("%d values, want %d", len(call.Values), tc.values)
+			}
+		})
+	}
+
+	call := first[ast.ContractCall](t, `call(1, "transfer(address,uint256)", 2, 3);`)
+	if call.Signature != "transfer(address,uint256)" {
+		t.Errorf("signature = %q", call.Signature)
+	}
+	if _, ok := call.Address.(ast.NumberLiteral); !ok {
+		t.Errorf("the address is %T, want the number written", call.Address)
+	}
+}
+
 func TestParseAssertShape(t *testing.T) {
 	tree, err := parseSource(t, `assert(1 equals 1, "ok");`, "checks.test.ar")
 	if err != nil {
@@ -313,6 +376,17 @@ func TestParseAssertShape(t *testing.T) {
 	}
 }
 
+// A guard is written in any file, not only a test: it is part of what the program does.
+func TestParseRequireShape(t *testing.T) {
+	guard := first[ast.RequireStatement](t, `require(1 equals 1, "must hold");`)
+	if _, ok := guard.Condition.(ast.RelativeExpression); !ok {
+		t.Errorf("condition is %T, want a comparison", guard.Condition)
+	}
+	if guard.Message != "must hold" {
+		t.Errorf("the message is %q, want the text that was written", guard.Message)
+	}
+}
+
 func TestParseUnaryShape(t *testing.T) {
 	unary := first[ast.UnaryExpression](t, "-5;")
 	if unary.Operation.Value != "-" {
@@ -491,6 +565,14 @@ func TestParseErrors(t *testing.T) {
 		{name: "branch without a condition", source: "branch { 1: 2, 3; };", wantErr: "boolean expression"},
 		{name: "pull with an invalid target", source: "pull true 1;", wantErr: "not a valid append target"},
 		{name: "push with an invalid item", source: "push [1] true;", wantErr: "not a valid push item"},
+		{name: "call without a signature", source: "call(1, 2);", wantErr: "signature it calls written as text"},
+		{name: "call by something not a signature", source: `call(1, "transfer");`, wantErr: "is not a signature"},
+		{name: "call with too few values", source: `call(1, "transfer(address,uint256)", 2);`, wantErr: "takes 2 values, 1 were applied"},
+		{name: "call taking a string", source: `call(1, "setName(string)", 2);`, wantErr: "takes a string, which is not a word"},
+		{name: "call taking an array", source: `call(1, "sum(uint256[])", 2);`, wantErr: "takes a uint256[], which is not a word"},
+		{name: "call taking bytes", source: `call(1, "store(bytes)", 2);`, wantErr: "takes a bytes, which is not a word"},
+		{name: "call taking a width nobody has", source: `call(1, "f(uint7)", 2);`, wantErr: "takes a uint7, which is not a word"},
+		{name: "call taking a type without its width", source: `call(1, "f(uint)", 2);`, wantErr: "takes a uint, which is not a word"},
 	}
 
 	for _, tc := range cases {
@@ -27,6 +27,10 @@ type Manifest struct {
 	Project  Project                `toml:"project"`
 	Profiles map[string]Profile     `toml:"profiles"`
 	Deploys  map[string]DeployState `toml:"deploys"`
+	// Lint is how much each lint rule matters to this project, by the rule's name: "off",
+	// "info", "warning" or "error". A rule left out keeps its own. It is read as text here and
+	// checked by the linter, which is what knows the rules.
+	Lint map[string]string `toml:"lint"`
 }
 
 // DeployState holds the last deploy result for a profile. Written by the CLI on each deploy; do not edit by hand.
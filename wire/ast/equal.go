@@ -66,6 +66,8 @@ func nodeEqual(a, b Node) bool {
 		return sameKind(b, va, printEqual)
 	case AssertStatement:
 		return sameKind(b, va, assertEqual)
+	case RequireStatement:
+		return sameKind(b, va, requireEqual)
 	case UnaryExpression:
 		return sameKind(b, va, unaryEqual)
 	case ShapeDeclaration:
@@ -90,6 +92,10 @@ func nodeEqual(a, b Node) bool {
 		return sameKind(b, va, tapeEqual)
 	case FeedExpression:
 		return sameKind(b, va, feedEqual)
+	case ContextExpression:
+		return sameKind(b, va, contextEqual)
+	case ContractCall:
+		return sameKind(b, va, contractCallEqual)
 	case RelativeExpression:
 		return sameKind(b, va, relativeEqual)
 	case BooleanExpression:
@@ -169,6 +175,10 @@ func assertEqual(a, b AssertStatement) bool {
 	return token.Equal(a.Token, b.Token) && nodeEqual(a.Condition, b.Condition) && a.Message == b.Message
 }
 
+func requireEqual(a, b RequireStatement) bool {
+	return token.Equal(a.Token, b.Token) && nodeEqual(a.Condition, b.Condition) && a.Message == b.Message
+}
+
 // A shape's fields are positional, so their order is part of the shape and not a detail of
 // how the declaration was written.
 func shapeDeclarationEqual(a, b ShapeDeclaration) bool {
@@ -213,6 +223,15 @@ func feedEqual(a, b FeedExpression) bool {
 	return numberEqual(a.Nth, b.Nth)
 }
 
+func contextEqual(a, b ContextExpression) bool {
+	return token.Equal(a.Token, b.Token) && a.Reading == b.Reading
+}
+
+func contractCallEqual(a, b ContractCall) bool {
+	return token.Equal(a.Token, b.Token) && a.Static == b.Static && a.Signature == b.Signature &&
+		nodeEqual(a.Address, b.Address) && nodesEqual(a.Values, b.Values)
+}
+
 func nodesEqual(a, b []Node) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,168 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Format renders a tree one field per line, indented by how deep it sits, which is how a tree
+// is read by a person. It is ir.Format's counterpart, and lives here for the same reason:
+// writing the vocabulary down is part of the vocabulary.
+//
+// It is written from the node types themselves rather than from a switch over them, so a node
+// added tomorrow is shown the day it is added. What the JSON tags hide — the tokens a node
+// was read from — is hidden here too: a node is shown as what it says, not where it was.
+func Format(tree AST) string {
+	b := &strings.Builder{}
+	for _, node := range tree.Nodes {
+		writeValue(b, 0, reflect.ValueOf(node))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Describe answers a node as plain data, for encoding/json: every node is an object naming its
+// kind next to its fields, which is what an interface field loses when a tree is marshalled
+// as it is.
+func Describe(node any) any {
+	return describe(reflect.ValueOf(node))
+}
+
+// A field is one shown field of a node: the name it goes by in JSON, and what it holds.
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+// fieldsOf answers the fields of a node worth showing, in the order they are declared.
+func fieldsOf(v reflect.Value) []field {
+	fields := make([]field, 0, v.NumField())
+	for at := 0; at < v.NumField(); at++ {
+		declared := v.Type().Field(at)
+		if !declared.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(declared.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(declared.Name)
+		}
+		if strings.Contains(options, "omitempty") && empty(v.Field(at)) {
+			continue
+		}
+		fields = append(fields, field{name: name, value: v.Field(at)})
+	}
+	return fields
+}
+
+// empty is what omitempty means to encoding/json: the zero value, or a list with nothing in it.
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// writeValue writes a value where the cursor is. A node goes on to write its fields on the
+// lines below, one level deeper; anything else fits where it is.
+func writeValue(b *strings.Builder, depth int, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		writeValue(b, depth, v.Elem())
+	case reflect.Struct:
+		b.WriteString(v.Type().Name())
+		for _, each := range fieldsOf(v) {
+			fmt.Fprintf(b, "\n%s%s:", indent(depth+1), each.name)
+			if !listed(each.value) {
+				b.WriteString(" ")
+			}
+			writeValue(b, depth+1, each.value)
+		}
+	case reflect.Slice:
+		writeSlice(b, depth, v)
+	case reflect.String:
+		fmt.Fprintf(b, "%q", v.String())
+	default:
+		fmt.Fprintf(b, "%v", v.Interface())
+	}
+}
+
+// writeSlice writes bytes and words on one line, and a list of nodes one item per line.
+func writeSlice(b *strings.Builder, depth int, v reflect.Value) {
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		fmt.Fprintf(b, "%v", v.Bytes())
+		return
+	}
+	if v.Type().Elem().Kind() == reflect.String {
+		words := make([]string, v.Len())
+		for at := range words {
+			words[at] = fmt.Sprintf("%q", v.Index(at).String())
+		}
+		fmt.Fprintf(b, "[%s]", strings.Join(words, ", "))
+		return
+	}
+	if v.Len() == 0 {
+		b.WriteString("[]")
+		return
+	}
+	for at := 0; at < v.Len(); at++ {
+		fmt.Fprintf(b, "\n%s- ", indent(depth+1))
+		writeValue(b, depth+1, v.Index(at))
+	}
+}
+
+// listed says whether a value is written as a list under its name rather than beside it.
+func listed(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return false
+	}
+	kind := v.Type().Elem().Kind()
+	return kind != reflect.Uint8 && kind != reflect.String
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// describe is Describe over a reflected value.
+func describe(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return describe(v.Elem())
+	case reflect.Struct:
+		object := map[string]any{"kind": v.Type().Name()}
+		for _, each := range fieldsOf(v) {
+			object[each.name] = describe(each.value)
+		}
+		return object
+	case reflect.Slice:
+		// Bytes are a value's bytes, and read better as numbers than as base64.
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			numbers := make([]int, v.Len())
+			for at := range numbers {
+				numbers[at] = int(v.Index(at).Uint())
+			}
+			return numbers
+		}
+		items := make([]any, v.Len())
+		for at := range items {
+			items[at] = describe(v.Index(at))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,66 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/guiferpa/aurora/wire/token"
+)
+
+// A tree written down reads as its nodes, one field to a line and a level deeper per child,
+// and never as the tokens it was read from.
+func TestFormatWritesEveryNodeAndNoToken(t *testing.T) {
+	tree := AST{Nodes: []Node{
+		IdentLiteral{
+			Id:    "x",
+			Token: one,
+			Value: BinaryExpression{Left: number(1), Right: TextLiteral{Value: []byte("hi")}, Operation: operation("+")},
+		},
+		ShapeDeclaration{Name: "Point", Fields: []string{"x", "y"}, Token: two},
+		DeferExpression{Block: BlockExpression{Body: []Node{FeedExpression{Nth: number(0)}}}},
+		IfExpression{Test: BooleanLiteral{Value: []byte{1}}, Body: []Node{}},
+	}}
+
+	want := `IdentLiteral
+  id: "x"
+  value: BinaryExpression
+    left: NumberLiteral
+      value: 1
+    right: TextLiteral
+      value: [104 105]
+    operation: OperationLiteral
+      value: "+"
+ShapeDeclaration
+  name: "Point"
+  fields: ["x", "y"]
+DeferExpression
+  block: BlockExpression
+    body:
+      - FeedExpression
+        nth: NumberLiteral
+          value: 0
+IfExpression
+  test: BooleanLiteral
+    value: [1]
+  body: []
+  else: nil
+`
+	if got := Format(tree); got != want {
+		t.Errorf("Format =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// As data, a node says what kind it is: that is what a tree marshalled as it is loses, since
+// every child is behind the Node interface.
+func TestDescribeNamesTheKindOfEveryNode(t *testing.T) {
+	node := PrintStatement{Format: PrintDecimal, Param: IdentifierLiteral{Value: "x", Token: token.New([]byte("x"), token.TagId, 1, 8, 7)}}
+
+	bs, err := json.Marshal(Describe(node))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"format":"decimal","kind":"PrintStatement","parameter":{"kind":"IdentifierLiteral","value":"x"}}`
+	if string(bs) != want {
+		t.Errorf("Describe = %s, want %s", bs, want)
+	}
+}
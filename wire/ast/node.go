@@ -207,6 +207,47 @@ type FeedExpression struct {
 	Nth NumberLiteral `json:"nth"`
 }
 
+// ContextReading is what a context builtin asks about the call a program is running in.
+type ContextReading string
+
+const (
+	Caller      ContextReading = "caller"      // who made the call
+	CallValue   ContextReading = "callvalue"   // how much value came with it
+	Timestamp   ContextReading = "timestamp"   // when the block it is in was made
+	BlockNumber ContextReading = "blocknumber" // the number of that block
+	ChainID     ContextReading = "chainid"     // the chain it is on
+)
+
+// ContextExpression is one of the context builtins: "caller", "callvalue" and the rest. They
+// differ only in what they ask, so they are one node carrying which, the way the prints are.
+//
+// What they answer is not the program's: on chain it is the call's, and off chain it is
+// whatever the host running the program says it is.
+type ContextExpression struct {
+	mark
+	Reading ContextReading `json:"reading"`
+	Token   token.Token    `json:"-"`
+}
+
+// ContractCall is `call(address, "signature", values...)`, or `staticcall` with the same
+// parts: a call out of the program, to another contract.
+//
+// The signature is a literal, like a guard's message, and for the same reason: it is written
+// for whoever answers the call rather than computed by the program. It is also what the
+// selector is hashed from, which is why it is held whole — the four bytes alone would tell
+// nobody reading the tree what is being called.
+//
+// Static is what the two keywords differ in. A static call promises the contract it reaches
+// will write nothing, and the chain holds it to that.
+type ContractCall struct {
+	mark
+	Static    bool        `json:"static"`
+	Address   Node        `json:"address"`
+	Signature string      `json:"signature"`
+	Values    []Node      `json:"values"`
+	Token     token.Token `json:"-"`
+}
+
 type IdentLiteral struct {
 	mark
 	Id    string      `json:"id"`
@@ -227,6 +268,19 @@ type AssertStatement struct {
 	Token     token.Token `json:"-"`
 }
 
+// RequireStatement is `require(condition, "message")`: a guard that stops the program with
+// its message when the condition does not hold.
+//
+// It is an assertion's sibling rather than a kind of one. An assertion reports to "aurora
+// test" and a plain run steps over it; a guard holds everywhere — off chain it is an error,
+// and on chain the call reverts with the message as its reason.
+type RequireStatement struct {
+	mark
+	Condition Node        `json:"condition"`
+	Message   string      `json:"message"`
+	Token     token.Token `json:"-"`
+}
+
 // AST is the top-level node: Aurora is expression-only, so a parsed file is the sequence
 // of expressions it holds. The unit of compilation is the file.
 type AST struct {
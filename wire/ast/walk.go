@@ -0,0 +1,39 @@
+package ast
+
+import "reflect"
+
+// Walk calls visit on a node and on every node under it, a parent before its children and
+// children in the order their fields are declared. When visit answers false, what is under
+// that node is skipped.
+//
+// It reads the node types the way Format does, from their fields rather than from a switch
+// over them, so a question that only wants to find one kind of node — where the shapes are
+// built, which names are read — does not have to know every other kind to get past it.
+func Walk(node Node, visit func(Node) bool) {
+	walk(reflect.ValueOf(node), visit)
+}
+
+func walk(v reflect.Value, visit func(Node) bool) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if !v.IsNil() {
+			walk(v.Elem(), visit)
+		}
+	case reflect.Struct:
+		if node, ok := v.Interface().(Node); ok && !visit(node) {
+			return
+		}
+		for at := 0; at < v.NumField(); at++ {
+			if v.Type().Field(at).IsExported() {
+				walk(v.Field(at), visit)
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for at := 0; at < v.Len(); at++ {
+			walk(v.Index(at), visit)
+		}
+	}
+}
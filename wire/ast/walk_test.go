@@ -0,0 +1,54 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Every node is reached, a parent before its children, whatever field it hangs from: an
+// interface, a list, a struct held by value or a pointer.
+func TestWalkReachesEveryNodeInOrder(t *testing.T) {
+	tree := AST{Nodes: []Node{
+		IdentLiteral{Id: "p", Value: ShapeLiteral{Name: "Point", Values: []Node{number(1), number(2)}}},
+		IfExpression{
+			Test: BooleanLiteral{Value: []byte{1}},
+			Body: []Node{PrintStatement{Param: number(3)}},
+			Else: &ElseExpression{Body: []Node{DeferExpression{Block: BlockExpression{Body: []Node{FeedExpression{Nth: number(0)}}}}}},
+		},
+	}}
+
+	var kinds []string
+	Walk(tree, func(node Node) bool {
+		kinds = append(kinds, reflect.TypeOf(node).Name())
+		return true
+	})
+	want := []string{
+		"AST",
+		"IdentLiteral", "ShapeLiteral", "NumberLiteral", "NumberLiteral",
+		"IfExpression", "BooleanLiteral", "PrintStatement", "NumberLiteral",
+		"ElseExpression", "DeferExpression", "BlockExpression", "FeedExpression", "NumberLiteral",
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("walked\n  %v\nwant\n  %v", kinds, want)
+	}
+}
+
+// Answering false skips what is under a node, and only that.
+func TestWalkSkipsWhatIsUnderARefusedNode(t *testing.T) {
+	tree := AST{Nodes: []Node{
+		DeferExpression{Block: BlockExpression{Body: []Node{number(1)}}},
+		number(2),
+	}}
+
+	numbers := 0
+	Walk(tree, func(node Node) bool {
+		if _, ok := node.(NumberLiteral); ok {
+			numbers++
+		}
+		_, isDefer := node.(DeferExpression)
+		return !isDefer
+	})
+	if numbers != 1 {
+		t.Errorf("reached %d numbers, want the 1 outside the scope", numbers)
+	}
+}
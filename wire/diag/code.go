@@ -0,0 +1,28 @@
+package diag
+
+// A Code names what a diagnostic is about, for a tool that acts on it rather than reads it. A
+// quick fix is found by its code, so the words of a message stay free to change: they are for
+// a person, and a person is the only one reading them now.
+//
+// Most of what a phase says has no code, because nothing acts on it.
+type Code string
+
+const (
+	// UnknownShape is a field read from a value nothing says the shape of.
+	UnknownShape Code = "unknown-shape"
+	// LowerCaseShape is a shape declared with a name that does not start with a capital.
+	LowerCaseShape Code = "lower-case-shape"
+	// ShortCall is a call applying fewer values than its scope reads. Its facts are Reads and
+	// Applied.
+	ShortCall Code = "short-call"
+	// WideLiteral is a literal the tape is too narrow for. Its fact is Width, the narrowest tape
+	// it fits, and zero when no tape is wide enough.
+	WideLiteral Code = "wide-literal"
+)
+
+// The names of the numbers a coded diagnostic was written from.
+const (
+	Reads   = "reads"
+	Applied = "applied"
+	Width   = "width"
+)
@@ -17,6 +17,10 @@ type Warning struct {
 	Message string
 	Line    int
 	Column  int
+	// Code and Facts are what a tool acting on the warning reads instead of the message: what it
+	// is about, and the numbers the message was written from. Both are empty for most.
+	Code  Code
+	Facts map[string]int
 }
 
 func (w Warning) String() string {
@@ -0,0 +1,12 @@
+package ir
+
+// A fault is an instruction that cannot answer, said the same way by every backend that runs
+// it. The evaluator fails with the words, and a chain reverts with them as the reason, so a
+// differential test compares one with the other and a person reads the same thing in both.
+//
+// Division has one, and so does a call to another contract that answers more than a value can
+// hold. An exponent, like every other operation, wraps at the tape width and always answers.
+const (
+	DivideByZero      = "integer divide by zero"               // OpDivide by a zero tape
+	AnswerOfManyWords = "a contract answered more than a word" // OpContractCall or OpStaticCall answering past 32 bytes
+)
@@ -61,7 +61,7 @@ func TestAnInstructionAnswersWithWhatItWasGiven(t *testing.T) {
 // looking for why an instruction is where it is. An opcode added without one shows up as
 // "Unknown" in all three, which reads like a bug in the program rather than a gap here.
 func TestEveryOpcodeAnswersToAName(t *testing.T) {
-	for op := OpMultiply; op <= OpField; op++ {
+	for op := OpMultiply; op <= OpStaticCall; op++ {
 		name := ResolveOpCode(op)
 
 		if name == "Unknown" {
@@ -77,7 +77,7 @@ func TestEveryOpcodeAnswersToAName(t *testing.T) {
 func TestNoTwoOpcodesShareAName(t *testing.T) {
 	seen := make(map[string]byte)
 
-	for op := OpMultiply; op <= OpField; op++ {
+	for op := OpMultiply; op <= OpStaticCall; op++ {
 		name := ResolveOpCode(op)
 		if first, taken := seen[name]; taken {
 			t.Errorf("%s names both %d and %d", name, first, op)
@@ -74,4 +74,22 @@ const (
 	// Reading past the end gives the neutral value rather than failing.
 	OpJoin  // Ref, Ref -> the run with one more tape at its end
 	OpField // Ref, Imm -> the tape at that index of the run
+
+	// A guard holds on every backend: the evaluator fails with the message, and a chain
+	// reverts with it as the reason.
+	OpRequire // Ref, Text -> stops the program with the message when the condition is false
+
+	// The call a program is running in. None takes anything: what they answer is the call's,
+	// which on chain the chain says and off chain whoever runs the program does.
+	OpCaller      // -> who made the call
+	OpCallValue   // -> how much value came with it
+	OpTimestamp   // -> when the block it is in was made
+	OpBlockNumber // -> the number of that block
+	OpChainID     // -> the chain it is on
+
+	// A call out of the program, to another contract. The signature rides as text, like a
+	// guard's message: the selector is the chain's business, and a host answering off chain
+	// is better told what was called than four bytes of a hash of it.
+	OpContractCall // Ref, Text, Ref... -> what the contract at the address answered
+	OpStaticCall   // Ref, Text, Ref... -> the same, of a contract that may write nothing
 )
@@ -66,6 +66,22 @@ func ResolveOpCode(op byte) string {
 		return "OpGetFeed"
 	case OpAssert:
 		return "OpAssert"
+	case OpRequire:
+		return "OpRequire"
+	case OpCaller:
+		return "OpCaller"
+	case OpCallValue:
+		return "OpCallValue"
+	case OpTimestamp:
+		return "OpTimestamp"
+	case OpBlockNumber:
+		return "OpBlockNumber"
+	case OpChainID:
+		return "OpChainID"
+	case OpContractCall:
+		return "OpContractCall"
+	case OpStaticCall:
+		return "OpStaticCall"
 	}
 	return "Unknown"
 }
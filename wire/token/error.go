@@ -1,6 +1,10 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/guiferpa/aurora/wire/diag"
+)
 
 // Error is a compiler error that carries the position of the offending input, so callers
 // can point at it without parsing the message. The CLI prints Message as before; the
@@ -15,6 +19,10 @@ type Error struct {
 	Column  int
 	Offset  int
 	Length  int
+	// Code and Facts are what a tool acting on the error reads instead of the message: what it
+	// is about, and the numbers the message was written from. Both are empty for most.
+	Code  diag.Code
+	Facts map[string]int
 }
 
 func (e *Error) Error() string {
@@ -36,3 +44,9 @@ func NewError(tok Token, format string, args ...any) *Error {
 	}
 	return err
 }
+
+// Coded answers the error with what it is about, for whoever acts on it.
+func (e *Error) Coded(code diag.Code, facts map[string]int) *Error {
+	e.Code, e.Facts = code, facts
+	return e
+}